@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pedrobarco/nomuz/internal/backup"
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/fsutil"
+	"github.com/pedrobarco/nomuz/internal/render"
+	"github.com/urfave/cli/v3"
+)
+
+func newExportCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "export",
+		Usage:     "Back up playlists and their tracks to a JSON or CSV file",
+		UsageText: `nomuz export --from <connector> (--all | --include <pattern>...) [--exclude <pattern>]... [--owned-only] [-o <file>] [--format json|csv]`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "from",
+				Usage:    "Source connector",
+				Required: true,
+			},
+			&cli.BoolFlag{
+				Name:  "all",
+				Usage: "Export every playlist",
+			},
+			&cli.StringSliceFlag{
+				Name:  "include",
+				Usage: "Only export playlists whose name matches the glob (prefix with re: for a regexp)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "exclude",
+				Usage: "Skip playlists whose name matches the glob (prefix with re: for a regexp)",
+			},
+			&cli.BoolFlag{
+				Name:  "owned-only",
+				Usage: "Only export playlists owned by the user",
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Backup file, or - for stdout",
+				Value:   "-",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Backup format: " + strings.Join(backup.Formats(), ", ") + " (default: from the file extension)",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			output := cmd.String("output")
+			format, err := getBackupFormat(cmd, output)
+			if err != nil {
+				return err
+			}
+
+			profile := profileConfig{
+				Include:   cmd.StringSlice("include"),
+				Exclude:   cmd.StringSlice("exclude"),
+				OwnedOnly: cmd.Bool("owned-only"),
+			}
+			if !cmd.Bool("all") && len(profile.Include) == 0 {
+				return fmt.Errorf("no playlists selected: use --all or --include")
+			}
+
+			selector, err := profile.selector()
+			if err != nil {
+				return err
+			}
+
+			cfg, err := LoadConfig(cmd.String("config"))
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			from := cmd.String("from")
+			connector, err := NewConnector(ctx, cfg, from)
+			if err != nil {
+				return fmt.Errorf("failed to create connector: %w", err)
+			}
+			defer closeConnector(connector)
+
+			res, err := connector.GetPlaylists(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get playlists: %w", err)
+			}
+
+			var pls []*domain.Playlist
+			for _, p := range selector.Select(res) {
+				full, err := connector.GetPlaylist(ctx, p.ID)
+				if err != nil {
+					return fmt.Errorf("failed to get tracks for playlist %s: %w", p.Name, err)
+				}
+				if full != nil {
+					pls = append(pls, full)
+				}
+			}
+
+			var b bytes.Buffer
+			if err := backup.Write(&b, format, backup.New(from, pls)); err != nil {
+				return err
+			}
+
+			if output == "-" {
+				_, err := cmd.Root().Writer.Write(b.Bytes())
+				return err
+			}
+
+			if err := fsutil.WriteFileAtomic(output, b.Bytes()); err != nil {
+				return fmt.Errorf("failed to write backup: %w", err)
+			}
+
+			fmt.Fprintf(cmd.Root().ErrWriter, "Exported %d playlists to %s\n", len(pls), output)
+			return nil
+		},
+	}
+}
+
+func newImportCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "import",
+		Usage:     "Recreate the playlists of a backup file on a connector",
+		UsageText: `nomuz import <file> --to <connector> [--format json|csv] [--include <pattern>]... [--exclude <pattern>]... [--dedupe] [--added-order] [--output <format>] [--dry-run] [--journal <path>]`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "to",
+				Usage:    "Destination connector",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Backup format: " + strings.Join(backup.Formats(), ", ") + " (default: from the file extension)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "include",
+				Usage: "Only import playlists whose name matches the glob (prefix with re: for a regexp)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "exclude",
+				Usage: "Skip playlists whose name matches the glob (prefix with re: for a regexp)",
+			},
+			&cli.BoolFlag{
+				Name:  "dedupe",
+				Usage: "Keep destination playlists free of duplicate tracks",
+			},
+			&cli.BoolFlag{
+				Name:  "added-order",
+				Usage: "Fill new playlists in the order their tracks were added to the source",
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "Changelog format: " + strings.Join(render.ChangelogFormats(), ", "),
+				Value: string(render.FormatTable),
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Only print the changelog without applying it",
+			},
+			&cli.StringFlag{
+				Name:  "journal",
+				Usage: "Path to the sync journal file",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			input := cmd.Args().First()
+			if input == "" {
+				return fmt.Errorf("backup file is required")
+			}
+
+			format, err := getBackupFormat(cmd, input)
+			if err != nil {
+				return err
+			}
+
+			changelogFormat, err := render.ParseChangelogFormat(cmd.String("output"))
+			if err != nil {
+				return err
+			}
+
+			profile := profileConfig{
+				Include: cmd.StringSlice("include"),
+				Exclude: cmd.StringSlice("exclude"),
+			}
+			selector, err := profile.selector()
+			if err != nil {
+				return err
+			}
+
+			f, err := os.Open(input)
+			if err != nil {
+				return fmt.Errorf("failed to open backup: %w", err)
+			}
+			defer f.Close()
+
+			name := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+			b, err := backup.Read(f, format, name)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := LoadConfig(cmd.String("config"))
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			to, err := NewConnector(ctx, cfg, cmd.String("to"))
+			if err != nil {
+				return fmt.Errorf("failed to create destination connector: %w", err)
+			}
+			defer closeConnector(to)
+
+			opts := []domain.PlanOption{domain.WithPlaylistSelector(selector)}
+			if cmd.Bool("dedupe") {
+				opts = append(opts, domain.WithDedupe())
+			}
+			if cmd.Bool("added-order") {
+				opts = append(opts, domain.WithAddedOrder())
+			}
+
+			return runSync(ctx, cmd, changelogFormat, input, cmd.String("to"), backup.NewConnector(b), to, opts...)
+		},
+	}
+}
+
+func getBackupFormat(cmd *cli.Command, p string) (backup.Format, error) {
+	if f := cmd.String("format"); f != "" {
+		return backup.ParseFormat(f)
+	}
+	return backup.FormatFromPath(p), nil
+}
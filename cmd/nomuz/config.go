@@ -5,40 +5,59 @@ import (
 	"os"
 	"path"
 
+	"github.com/pedrobarco/nomuz/internal/plugin"
+	"github.com/pedrobarco/nomuz/internal/smart"
 	"gopkg.in/yaml.v3"
 )
 
 type config struct {
-	Connectors connectorsConfig `yaml:"connectors"`
+	Connectors     connectorsConfig            `yaml:"connectors"`
+	Plugins        map[string]plugin.Config    `yaml:"plugins,omitempty"`
+	Profiles       map[string]profileConfig    `yaml:"profiles,omitempty"`
+	SmartPlaylists map[string]smart.Definition `yaml:"smart_playlists,omitempty"`
 }
 
-type connectorsConfig struct {
-	Spotify spotifyConfig `yaml:"spotify"`
-	Tidal   tidalConfig   `yaml:"tidal"`
-}
+// connectorsConfig holds the config section of each connector, which is
+// decoded into the connector's own config type when it is created.
+type connectorsConfig map[string]yaml.Node
 
-type spotifyConfig struct {
-	ClientID     string `yaml:"client_id"`
-	ClientSecret string `yaml:"client_secret"`
+func (c connectorsConfig) decode(name string, v any) error {
+	node, found := c[name]
+	if !found {
+		return nil
+	}
+	return node.Decode(v)
 }
 
-type tidalConfig struct {
-	ClientID     string `yaml:"client_id"`
-	ClientSecret string `yaml:"client_secret"`
-	CountryCode  string `yaml:"country_code"`
+type profileConfig struct {
+	From          string   `yaml:"from"`
+	To            string   `yaml:"to"`
+	Include       []string `yaml:"include,omitempty"`
+	Exclude       []string `yaml:"exclude,omitempty"`
+	PlaylistIDs   []string `yaml:"playlist_ids,omitempty"`
+	OwnedOnly     bool     `yaml:"owned_only,omitempty"`
+	Collaborative *bool    `yaml:"collaborative,omitempty"`
+	MinTracks     int      `yaml:"min_tracks,omitempty"`
+	MaxTracks     int      `yaml:"max_tracks,omitempty"`
+	Dedupe        bool     `yaml:"dedupe,omitempty"`
+	AddedOrder    bool     `yaml:"added_order,omitempty"`
 }
 
 var defaultConfig = config{}
 
-func LoadConfig() (*config, error) {
+// LoadConfig reads the config file at cfgFile, or at
+// ~/.config/nomuz/config.yaml when empty, creating it if it does not exist.
+func LoadConfig(cfgFile string) (*config, error) {
 	config := defaultConfig
 
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user config dir: %v", err)
+	if cfgFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user config dir: %v", err)
+		}
+		cfgFile = path.Join(home, ".config", "nomuz", "config.yaml")
 	}
 
-	cfgFile := path.Join(home, ".config", "nomuz", "config.yaml")
 	if _, err := os.Stat(cfgFile); err != nil && os.IsNotExist(err) {
 		if err := os.MkdirAll(path.Dir(cfgFile), 00755); err != nil {
 			return nil, fmt.Errorf("failed to create config dir: %v", err)
@@ -65,5 +84,11 @@ func LoadConfig() (*config, error) {
 		}
 	}
 
+	for name, p := range config.Plugins {
+		if err := plugin.Register(name, p); err != nil {
+			return nil, fmt.Errorf("failed to register plugin: %v", err)
+		}
+	}
+
 	return &config, nil
 }
@@ -1,34 +1,35 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"io"
 
 	"github.com/pedrobarco/nomuz/internal/domain"
-	"github.com/pedrobarco/nomuz/internal/spotify"
-	"github.com/pedrobarco/nomuz/internal/tidal"
-)
-
-type ConnectorName string
+	"github.com/pedrobarco/nomuz/internal/registry"
 
-const (
-	ConnectorSpotify ConnectorName = "spotify"
-	ConnectorTidal   ConnectorName = "tidal"
+	// Built-in connectors register themselves.
+	_ "github.com/pedrobarco/nomuz/internal/applemusic"
+	_ "github.com/pedrobarco/nomuz/internal/deezer"
+	_ "github.com/pedrobarco/nomuz/internal/jellyfin"
+	_ "github.com/pedrobarco/nomuz/internal/m3u"
+	_ "github.com/pedrobarco/nomuz/internal/plex"
+	_ "github.com/pedrobarco/nomuz/internal/spotify"
+	_ "github.com/pedrobarco/nomuz/internal/subsonic"
+	_ "github.com/pedrobarco/nomuz/internal/tidal"
+	_ "github.com/pedrobarco/nomuz/internal/xspf"
+	_ "github.com/pedrobarco/nomuz/internal/ytmusic"
 )
 
-func NewConnector(cfg *config, name string) (domain.Connector, error) {
-	switch ConnectorName(name) {
-	case ConnectorSpotify:
-		return spotify.NewConnector(
-			cfg.Connectors.Spotify.ClientID,
-			cfg.Connectors.Spotify.ClientSecret,
-		)
-	case ConnectorTidal:
-		return tidal.NewConnector(
-			cfg.Connectors.Tidal.ClientID,
-			cfg.Connectors.Tidal.ClientSecret,
-			cfg.Connectors.Tidal.CountryCode,
-		)
-	default:
-		return nil, fmt.Errorf("unknown connector: %s", name)
+// NewConnector builds the connector for name, which may carry an argument
+// after a colon, e.g. `m3u:./playlists`.
+func NewConnector(ctx context.Context, cfg *config, name string) (domain.Connector, error) {
+	return registry.New(ctx, name, cfg.Connectors.decode)
+}
+
+// closeConnector stops connectors that hold resources, such as the process of
+// a plugin.
+func closeConnector(c domain.Connector) {
+	if closer, ok := c.(io.Closer); ok {
+		closer.Close()
 	}
 }
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pedrobarco/nomuz/internal/registry"
+	"github.com/pedrobarco/nomuz/internal/render"
+	"github.com/urfave/cli/v3"
+)
+
+func newConnectorsCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "connectors",
+		Usage:     "List the available connectors and what they support",
+		UsageText: `nomuz connectors [--output <format>]`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "Output format: " + strings.Join(render.ConnectorsFormats(), ", "),
+				Value: string(render.FormatTable),
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			format, err := render.ParseConnectorsFormat(cmd.String("output"))
+			if err != nil {
+				return err
+			}
+
+			// Plugins are registered when the config is loaded.
+			if _, err := LoadConfig(cmd.String("config")); err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			return render.Connectors(cmd.Root().Writer, format, render.ConnectorInfos(registry.All()))
+		},
+	}
+}
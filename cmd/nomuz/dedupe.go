@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/urfave/cli/v3"
+)
+
+func newDedupeCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "dedupe",
+		Usage:     "Find and remove duplicate tracks in a playlist",
+		UsageText: `nomuz dedupe --from <connector> --playlist <playlist name> [--remove]`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "from",
+				Usage:    "Connector holding the playlist",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "playlist",
+				Usage:    "Playlist name",
+				Required: true,
+			},
+			&cli.BoolFlag{
+				Name:  "remove",
+				Usage: "Remove the duplicates, keeping one copy of each track; repeated tracks are added back once at the end",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			cfg, err := LoadConfig(cmd.String("config"))
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			connector, err := NewConnector(ctx, cfg, cmd.String("from"))
+			if err != nil {
+				return fmt.Errorf("failed to create connector: %w", err)
+			}
+			defer closeConnector(connector)
+
+			name := cmd.String("playlist")
+			pl, err := connector.GetPlaylistByName(ctx, name)
+			if err != nil {
+				return fmt.Errorf("failed to get playlist: %w", err)
+			}
+			if pl == nil {
+				return fmt.Errorf("playlist not found: %s", name)
+			}
+
+			groups := domain.FindDuplicates(pl.Tracks)
+			if len(groups) == 0 {
+				fmt.Fprintln(cmd.Root().Writer, "No duplicates found")
+				return nil
+			}
+
+			t := table.New().
+				Border(lipgloss.NormalBorder()).
+				StyleFunc(func(row, col int) lipgloss.Style {
+					return cellStyle
+				})
+
+			t.Headers("Kind", "Kept", "Duplicates", "Positions")
+			for _, g := range groups {
+				var extra []string
+				for _, tr := range g.Extra() {
+					extra = append(extra, formatTrack(tr))
+				}
+
+				var positions []string
+				for _, pos := range g.Positions {
+					positions = append(positions, strconv.Itoa(pos+1))
+				}
+
+				t.Row(
+					string(g.Kind),
+					formatTrack(g.Kept()),
+					strings.Join(extra, "\n"),
+					strings.Join(positions, ", "),
+				)
+			}
+
+			fmt.Fprintln(cmd.Root().Writer, t.Render())
+
+			if !cmd.Bool("remove") {
+				return nil
+			}
+
+			caps := connector.Capabilities()
+			if !caps.DeleteTracks {
+				return fmt.Errorf("failed to remove duplicates: %s cannot remove tracks: %w", cmd.String("from"), domain.ErrUnsupported)
+			}
+
+			ref := domain.PlaylistRef{
+				ID:   pl.ID,
+				Name: pl.Name,
+			}
+			cl := domain.Changelog{
+				TracksByPlaylist: map[domain.PlaylistRef]domain.PlaylistTracksChangelog{
+					ref: domain.DuplicateChanges(pl.Tracks),
+				},
+			}
+
+			res, err := domain.Apply(ctx, connector, cl.Operations(caps.BatchSize(domain.DefaultBatchSize)))
+			if err != nil {
+				return fmt.Errorf("failed to remove duplicates: %w", err)
+			}
+			if res.HasFailures() {
+				return fmt.Errorf("failed to remove duplicates: %w", res.Err())
+			}
+
+			fmt.Fprintf(cmd.Root().Writer, "Removed duplicates from %s\n", pl.Name)
+			return nil
+		},
+	}
+}
+
+func formatTrack(tr domain.Track) string {
+	return fmt.Sprintf("%s - %s (%s)", tr.Artist, tr.Title, tr.ID)
+}
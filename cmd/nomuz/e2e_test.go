@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/fakeservice"
+	"github.com/pedrobarco/nomuz/internal/spotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// e2e runs the CLI against fake Spotify and TIDAL servers, with the config,
+// tokens and journal in a temporary home directory.
+type e2e struct {
+	t       *testing.T
+	spotify *fakeservice.Spotify
+	tidal   *fakeservice.Tidal
+	config  string
+	// smart holds the smart playlists written to the config, if any.
+	smart map[string]any
+}
+
+func newE2E(t *testing.T, spotifyLib, tidalLib fakeservice.Library) *e2e {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	e := &e2e{
+		t:       t,
+		spotify: fakeservice.NewSpotify(t, spotifyLib),
+		tidal:   fakeservice.NewTidal(t, tidalLib),
+		config:  filepath.Join(home, "nomuz.yaml"),
+	}
+
+	// As left by `nomuz login spotify`.
+	require.NoError(t, spotify.SaveAuthToken(e.spotify.Token()))
+
+	e.writeConfig(e.tidal.ClientSecret)
+	return e
+}
+
+func (e *e2e) writeConfig(tidalSecret string) {
+	cfg := map[string]any{
+		"connectors": map[string]any{
+			"spotify": map[string]string{
+				"client_id":     e.spotify.ClientID,
+				"client_secret": e.spotify.ClientSecret,
+				"base_url":      e.spotify.BaseURL(),
+				"token_url":     e.spotify.TokenURL(),
+			},
+			"tidal": map[string]string{
+				"client_id":     e.tidal.ClientID,
+				"client_secret": tidalSecret,
+				"country_code":  "US",
+				"base_url":      e.tidal.BaseURL(),
+				"token_url":     e.tidal.TokenURL(),
+			},
+		},
+	}
+	if e.smart != nil {
+		cfg["smart_playlists"] = e.smart
+	}
+
+	b, err := yaml.Marshal(cfg)
+	require.NoError(e.t, err)
+	require.NoError(e.t, os.WriteFile(e.config, b, 0o600))
+}
+
+// run runs nomuz with the given arguments and returns what it printed to
+// stdout.
+func (e *e2e) run(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	app := newApp()
+	app.Writer = &stdout
+	app.ErrWriter = &stderr
+
+	err := app.Run(context.Background(), append([]string{"nomuz", "--config", e.config}, args...))
+	return stdout.String(), err
+}
+
+var (
+	spotifyTracks = []fakeservice.Track{
+		{ID: "sp1", ISRC: "USRC17607839", Title: "Song", Artist: "Band", Album: "Album", Duration: 201 * time.Second},
+		{ID: "sp2", Title: "Other Song", Artist: "Other Band", Album: "Other Album", Duration: 242 * time.Second},
+		{ID: "sp3", ISRC: "GBAYE0601498", Title: "Rare Song", Artist: "Obscure Band", Album: "Demo", Duration: 180 * time.Second},
+	}
+	tidalTracks = []fakeservice.Track{
+		{ID: "td1", ISRC: "USRC17607839", Title: "Song", Artist: "Band", Album: "Album", Duration: 201 * time.Second},
+		{ID: "td2", ISRC: "GBUM71029604", Title: "Other Song", Artist: "Other Band", Album: "Other Album", Duration: 242 * time.Second},
+	}
+)
+
+func TestPlaylistsCommand(t *testing.T) {
+	var playlists []fakeservice.Playlist
+	for i := range 55 {
+		playlists = append(playlists, fakeservice.Playlist{Name: fmt.Sprintf("Playlist %02d", i)})
+	}
+	playlists[0].Tracks = []string{"sp1", "sp2"}
+	playlists[1].Owner = "bob"
+
+	e := newE2E(t,
+		fakeservice.Library{User: "alice", Tracks: spotifyTracks, Playlists: playlists},
+		fakeservice.Library{},
+	)
+
+	out, err := e.run("playlists", "--from", "spotify", "--output", "json")
+	require.NoError(t, err)
+
+	var res []struct {
+		Name       string `json:"name"`
+		Owner      string `json:"owner"`
+		Owned      bool   `json:"owned"`
+		TrackCount int    `json:"track_count"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &res))
+
+	// Listed across pages of 50, with a single token refresh.
+	require.Len(t, res, 55)
+	assert.Equal(t, "Playlist 00", res[0].Name)
+	assert.Equal(t, 2, res[0].TrackCount)
+	assert.True(t, res[0].Owned)
+	assert.Equal(t, "bob", res[1].Owner)
+	assert.False(t, res[1].Owned)
+	assert.Equal(t, "Playlist 54", res[54].Name)
+	assert.Equal(t, 1, e.spotify.Refreshes())
+
+	out, err = e.run("playlists", "--from", "spotify", "--owned-only", "--name", "Playlist 00", "--with-tracks", "--output", "json")
+	require.NoError(t, err)
+	assert.Contains(t, out, `"title": "Other Song"`)
+}
+
+func TestSyncCommand(t *testing.T) {
+	e := newE2E(t,
+		fakeservice.Library{
+			Tracks: spotifyTracks,
+			Playlists: []fakeservice.Playlist{
+				{Name: "Road Trip", Tracks: []string{"sp1", "sp2", "sp3"}},
+				{Name: "Draft", Tracks: []string{"sp1"}},
+			},
+		},
+		fakeservice.Library{Tracks: tidalTracks},
+	)
+	e.tidal.PageSize = 1
+
+	out, err := e.run("sync", "--from", "spotify", "--to", "tidal", "--include", "Road*", "--output", "json", "--dry-run")
+	require.NoError(t, err)
+	assert.Empty(t, e.tidal.Playlists(), "dry runs leave the destination untouched")
+
+	var cl struct {
+		Summary struct {
+			Created int `json:"created"`
+			Added   int `json:"added"`
+			Missing int `json:"missing"`
+		} `json:"summary"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &cl))
+	assert.Equal(t, 1, cl.Summary.Created)
+	assert.Equal(t, 2, cl.Summary.Added)
+	assert.Equal(t, 1, cl.Summary.Missing)
+
+	_, err = e.run("sync", "--from", "spotify", "--to", "tidal", "--include", "Road*")
+	require.NoError(t, err)
+
+	require.Len(t, e.tidal.Playlists(), 1)
+	pl, found := e.tidal.Playlist("Road Trip")
+	require.True(t, found)
+	assert.Equal(t, []string{"td1", "td2"}, pl.Tracks)
+
+	journal := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "nomuz", "journal.jsonl")
+	assert.NoFileExists(t, journal, "the journal is removed once applied")
+
+	// Syncing again finds nothing to change.
+	_, err = e.run("sync", "--from", "spotify", "--to", "tidal", "--include", "Road*")
+	require.NoError(t, err)
+
+	pl, _ = e.tidal.Playlist("Road Trip")
+	assert.Equal(t, []string{"td1", "td2"}, pl.Tracks)
+}
+
+func TestSyncCommandRemovesTracks(t *testing.T) {
+	e := newE2E(t,
+		fakeservice.Library{
+			Tracks: spotifyTracks,
+			Playlists: []fakeservice.Playlist{
+				{Name: "Road Trip", Tracks: []string{"sp1", "sp2"}},
+			},
+		},
+		fakeservice.Library{
+			Tracks: tidalTracks,
+			Playlists: []fakeservice.Playlist{
+				{Name: "Road Trip", Tracks: []string{"td2"}},
+			},
+		},
+	)
+
+	_, err := e.run("sync", "--from", "tidal", "--to", "spotify")
+	require.NoError(t, err)
+
+	pl, found := e.spotify.Playlist("Road Trip")
+	require.True(t, found)
+	assert.Equal(t, []string{"sp2"}, pl.Tracks)
+	assert.Len(t, e.spotify.Playlists(), 1)
+}
+
+func TestTidalCredentials(t *testing.T) {
+	e := newE2E(t, fakeservice.Library{}, fakeservice.Library{})
+
+	_, err := e.run("playlists", "--from", "tidal")
+	require.NoError(t, err)
+	assert.Equal(t, 1, e.tidal.Tokens())
+
+	e.writeConfig("wrong-secret")
+	_, err = e.run("playlists", "--from", "tidal")
+	assert.ErrorContains(t, err, "invalid_client")
+}
+
+func TestSmartCommand(t *testing.T) {
+	e := newE2E(t,
+		fakeservice.Library{
+			Tracks: spotifyTracks,
+			Playlists: []fakeservice.Playlist{
+				{Name: "Road Trip", Tracks: []string{"sp1", "sp3"}},
+				{Name: "Draft", Tracks: []string{"sp2", "sp1"}},
+			},
+		},
+		fakeservice.Library{Tracks: tidalTracks},
+	)
+
+	e.smart = map[string]any{
+		"bands": map[string]any{
+			"name":  "Bands",
+			"from":  []string{"spotify"},
+			"to":    "tidal",
+			"rules": map[string]any{"artists": []string{"*band"}, "exclude_artists": []string{"obscure*"}},
+			"sort":  "title",
+		},
+	}
+	e.writeConfig(e.tidal.ClientSecret)
+
+	_, err := e.run("smart", "bands")
+	require.NoError(t, err)
+
+	pl, found := e.tidal.Playlist("Bands")
+	require.True(t, found)
+	assert.Equal(t, []string{"td2", "td1"}, pl.Tracks)
+
+	// Each run evaluates the rules again and only applies the difference.
+	e.smart["bands"].(map[string]any)["limit"] = 1
+	e.writeConfig(e.tidal.ClientSecret)
+
+	out, err := e.run("smart", "bands", "--output", "json")
+	require.NoError(t, err)
+	assert.Contains(t, out, `"removed": 1`)
+
+	pl, _ = e.tidal.Playlist("Bands")
+	assert.Equal(t, []string{"td2"}, pl.Tracks)
+
+	_, err = e.run("smart", "unknown")
+	assert.ErrorContains(t, err, `smart playlist "unknown" not found`)
+}
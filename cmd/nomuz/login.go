@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pedrobarco/nomuz/internal/registry"
+	"github.com/urfave/cli/v3"
+)
+
+func newLoginCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "login",
+		Usage:     "Sign in to a connector and store its credentials",
+		UsageText: `nomuz login <connector>`,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if cmd.Args().Len() != 1 {
+				return fmt.Errorf("expected a single connector name")
+			}
+
+			cfg, err := LoadConfig(cmd.String("config"))
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			name, _ := registry.ParseSpec(cmd.Args().First())
+			e, found := registry.Lookup(name)
+			if !found {
+				return fmt.Errorf("unknown connector: %s", name)
+			}
+
+			err = e.Login(ctx, func(v any) error {
+				return cfg.Connectors.decode(name, v)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to log in to %s: %w", name, err)
+			}
+
+			fmt.Fprintf(cmd.Root().ErrWriter, "Logged in to %s\n", name)
+			return nil
+		},
+	}
+}
@@ -8,12 +8,20 @@ import (
 	"github.com/urfave/cli/v3"
 )
 
-func main() {
-	cmd := &cli.Command{
+func newApp() *cli.Command {
+	return &cli.Command{
 		Name:  "nomuz",
 		Usage: "A music playlist synchronization tool",
 		Commands: []*cli.Command{
-			playlistsCmd,
+			newPlaylistsCmd(),
+			newSyncCmd(),
+			newApplyCmd(),
+			newDedupeCmd(),
+			newSmartCmd(),
+			newConnectorsCmd(),
+			newLoginCmd(),
+			newExportCmd(),
+			newImportCmd(),
 		},
 		Flags: []cli.Flag{
 			&cli.StringFlag{
@@ -23,8 +31,10 @@ func main() {
 			},
 		},
 	}
+}
 
-	if err := cmd.Run(context.Background(), os.Args); err != nil {
+func main() {
+	if err := newApp().Run(context.Background(), os.Args); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -1,68 +1,174 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"fmt"
-	"strconv"
+	"slices"
+	"strings"
 
-	"github.com/charmbracelet/lipgloss"
-	"github.com/charmbracelet/lipgloss/table"
 	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/render"
 	"github.com/urfave/cli/v3"
 )
 
-var playlistsCmd = &cli.Command{
-	Name:      "playlists",
-	Usage:     "List all available playlists",
-	UsageText: `nomuz playlists --from <connector> [--name <playlist name>]`,
-	Flags: []cli.Flag{
-		&cli.StringFlag{
-			Name:     "from",
-			Usage:    "Source connector",
-			Required: true,
+func newPlaylistsCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "playlists",
+		Usage: "List all available playlists",
+		UsageText: `nomuz playlists --from <connector> [--name <playlist name>] [--contains <text>] [--owner <owner>]
+	[--min-tracks <n>] [--max-tracks <n>] [--sort name|owner|size|id] [--reverse] [--with-tracks] [--output <format>]`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "from",
+				Usage:    "Source connector",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "name",
+				Usage: "Playlist name",
+			},
+			&cli.StringFlag{
+				Name:  "contains",
+				Usage: "Only list playlists whose name contains the text (case-insensitive)",
+			},
+			&cli.StringFlag{
+				Name:  "owner",
+				Usage: "Only list playlists owned by the given user",
+			},
+			&cli.BoolFlag{
+				Name:  "owned-only",
+				Usage: "Only list playlists owned by the user",
+			},
+			&cli.IntFlag{
+				Name:  "min-tracks",
+				Usage: "Only list playlists with at least this many tracks",
+			},
+			&cli.IntFlag{
+				Name:  "max-tracks",
+				Usage: "Only list playlists with at most this many tracks",
+			},
+			&cli.StringFlag{
+				Name:  "sort",
+				Usage: "Sort playlists by name, owner, size or id",
+			},
+			&cli.BoolFlag{
+				Name:  "reverse",
+				Usage: "Reverse the sort order",
+			},
+			&cli.BoolFlag{
+				Name:  "with-tracks",
+				Usage: "Include the full track listing of each playlist",
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "Output format: " + strings.Join(render.PlaylistsFormats(), ", "),
+				Value: string(render.FormatTable),
+			},
 		},
-		&cli.StringFlag{
-			Name:  "name",
-			Usage: "Playlist name",
-		},
-	},
-	Action: func(ctx context.Context, cmd *cli.Command) error {
-		cfg, err := LoadConfig()
-		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
-		}
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			format, err := render.ParsePlaylistsFormat(cmd.String("output"))
+			if err != nil {
+				return err
+			}
 
-		connector, err := NewConnector(cfg, cmd.String("from"))
-		if err != nil {
-			return fmt.Errorf("failed to create connector: %w", err)
-		}
+			cfg, err := LoadConfig(cmd.String("config"))
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
 
-		res, err := connector.GetPlaylists(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to get playlists: %w", err)
-		}
+			connector, err := NewConnector(ctx, cfg, cmd.String("from"))
+			if err != nil {
+				return fmt.Errorf("failed to create connector: %w", err)
+			}
+			defer closeConnector(connector)
+
+			res, err := connector.GetPlaylists(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get playlists: %w", err)
+			}
 
-		var pls []*domain.Playlist
-		name := cmd.String("name")
-		for _, p := range res {
-			if name == "" || p.Name == name {
+			selector := domain.PlaylistSelector{
+				OwnedOnly: cmd.Bool("owned-only"),
+				MinTracks: cmd.Int("min-tracks"),
+				MaxTracks: cmd.Int("max-tracks"),
+			}
+
+			var pls []*domain.Playlist
+			name := cmd.String("name")
+			contains := strings.ToLower(cmd.String("contains"))
+			owner := cmd.String("owner")
+			for _, p := range selector.Select(res) {
+				if name != "" && p.Name != name {
+					continue
+				}
+				if contains != "" && !strings.Contains(strings.ToLower(p.Name), contains) {
+					continue
+				}
+				if owner != "" && !strings.EqualFold(p.Owner, owner) {
+					continue
+				}
 				pls = append(pls, p)
 			}
-		}
 
-		t := table.New().
-			Border(lipgloss.NormalBorder()).
-			StyleFunc(func(row, col int) lipgloss.Style {
-				return cellStyle
-			})
+			if err := sortPlaylists(pls, cmd.String("sort"), cmd.Bool("reverse")); err != nil {
+				return err
+			}
 
-		t.Headers("ID", "Name", "# Tracks")
-		for _, p := range pls {
-			t.Row(p.ID, p.Name, strconv.Itoa(len(p.Tracks)))
-		}
+			withTracks := cmd.Bool("with-tracks")
+			if withTracks {
+				for i, p := range pls {
+					full, err := connector.GetPlaylist(ctx, p.ID)
+					if err != nil {
+						return fmt.Errorf("failed to get tracks for playlist %s: %w", p.Name, err)
+					}
+					if full != nil {
+						pls[i] = full
+					}
+				}
+			}
 
-		fmt.Println(t.Render())
+			return render.Playlists(cmd.Root().Writer, format, pls, withTracks)
+		},
+	}
+}
 
+func sortPlaylists(pls []*domain.Playlist, by string, reverse bool) error {
+	var compare func(a, b *domain.Playlist) int
+	switch by {
+	case "":
+		if reverse {
+			slices.Reverse(pls)
+		}
 		return nil
-	},
+	case "name":
+		compare = func(a, b *domain.Playlist) int {
+			return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		}
+	case "owner":
+		compare = func(a, b *domain.Playlist) int {
+			return cmp.Or(
+				cmp.Compare(strings.ToLower(a.Owner), strings.ToLower(b.Owner)),
+				cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)),
+			)
+		}
+	case "size":
+		compare = func(a, b *domain.Playlist) int {
+			return cmp.Compare(a.Size(), b.Size())
+		}
+	case "id":
+		compare = func(a, b *domain.Playlist) int {
+			return cmp.Compare(a.ID, b.ID)
+		}
+	default:
+		return fmt.Errorf("unknown sort key %q: expected name, owner, size or id", by)
+	}
+
+	slices.SortStableFunc(pls, func(a, b *domain.Playlist) int {
+		if reverse {
+			return compare(b, a)
+		}
+		return compare(a, b)
+	})
+	return nil
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/render"
+	"github.com/pedrobarco/nomuz/internal/smart"
+	"github.com/urfave/cli/v3"
+)
+
+func newSmartCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "smart",
+		Usage:     "Generate a smart playlist from the config file and sync it to its destination",
+		UsageText: `nomuz smart <name> [--dedupe] [--output <format>] [--dry-run] [--journal <path>]`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "dedupe",
+				Usage: "Keep the destination playlist free of duplicate tracks",
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "Changelog format: " + strings.Join(render.ChangelogFormats(), ", "),
+				Value: string(render.FormatTable),
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Only print the changelog without applying it",
+			},
+			&cli.StringFlag{
+				Name:  "journal",
+				Usage: "Path to the sync journal file",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			name := cmd.Args().First()
+			if name == "" {
+				return fmt.Errorf("smart playlist name is required")
+			}
+
+			cfg, err := LoadConfig(cmd.String("config"))
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			def, found := cfg.SmartPlaylists[name]
+			if !found {
+				return fmt.Errorf("smart playlist %q not found in config", name)
+			}
+			if def.Name == "" {
+				def.Name = name
+			}
+			if len(def.From) == 0 || def.To == "" {
+				return fmt.Errorf("smart playlist %q needs both source and destination connectors", name)
+			}
+
+			format, err := render.ParseChangelogFormat(cmd.String("output"))
+			if err != nil {
+				return err
+			}
+
+			var libraries []domain.Connector
+			for _, from := range def.From {
+				c, err := NewConnector(ctx, cfg, from)
+				if err != nil {
+					return fmt.Errorf("failed to create source connector %s: %w", from, err)
+				}
+				defer closeConnector(c)
+				libraries = append(libraries, c)
+			}
+
+			to, err := NewConnector(ctx, cfg, def.To)
+			if err != nil {
+				return fmt.Errorf("failed to create destination connector: %w", err)
+			}
+			defer closeConnector(to)
+
+			pl, err := def.Evaluate(ctx, time.Now(), libraries...)
+			if err != nil {
+				return fmt.Errorf("failed to evaluate smart playlist %s: %w", name, err)
+			}
+
+			var opts []domain.PlanOption
+			if cmd.Bool("dedupe") {
+				opts = append(opts, domain.WithDedupe())
+			}
+
+			return runSync(ctx, cmd, format, "smart:"+name, def.To, smart.NewConnector(pl), to, opts...)
+		},
+	}
+}
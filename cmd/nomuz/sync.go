@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/journal"
+	"github.com/pedrobarco/nomuz/internal/render"
+	"github.com/urfave/cli/v3"
+)
+
+func newSyncCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "sync",
+		Usage: "Synchronize playlists from one connector to another",
+		UsageText: `nomuz sync --from <connector> --to <connector> [--include <pattern>]... [--exclude <pattern>]... [--owned-only] [--dedupe] [--added-order] [--output <format>] [--dry-run] [--journal <path>]
+nomuz sync --profile <name> [--dry-run]`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "from",
+				Usage: "Source connector",
+			},
+			&cli.StringFlag{
+				Name:  "to",
+				Usage: "Destination connector",
+			},
+			&cli.StringFlag{
+				Name:  "profile",
+				Usage: "Sync profile from the config file",
+			},
+			&cli.StringSliceFlag{
+				Name:  "include",
+				Usage: "Only sync playlists whose name matches the glob (prefix with re: for a regexp)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "exclude",
+				Usage: "Skip playlists whose name matches the glob (prefix with re: for a regexp)",
+			},
+			&cli.BoolFlag{
+				Name:  "owned-only",
+				Usage: "Only sync playlists owned by the user",
+			},
+			&cli.BoolFlag{
+				Name:  "dedupe",
+				Usage: "Keep destination playlists free of duplicate tracks",
+			},
+			&cli.BoolFlag{
+				Name:  "added-order",
+				Usage: "Fill new playlists in the order their tracks were added to the source",
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "Changelog format: " + strings.Join(render.ChangelogFormats(), ", "),
+				Value: string(render.FormatTable),
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Only print the changelog without applying it",
+			},
+			&cli.StringFlag{
+				Name:  "journal",
+				Usage: "Path to the sync journal file",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			cfg, err := LoadConfig(cmd.String("config"))
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			format, err := render.ParseChangelogFormat(cmd.String("output"))
+			if err != nil {
+				return err
+			}
+
+			profile, err := resolveSyncProfile(cfg, cmd)
+			if err != nil {
+				return err
+			}
+
+			selector, err := profile.selector()
+			if err != nil {
+				return err
+			}
+
+			from, err := NewConnector(ctx, cfg, profile.From)
+			if err != nil {
+				return fmt.Errorf("failed to create source connector: %w", err)
+			}
+			defer closeConnector(from)
+
+			to, err := NewConnector(ctx, cfg, profile.To)
+			if err != nil {
+				return fmt.Errorf("failed to create destination connector: %w", err)
+			}
+			defer closeConnector(to)
+
+			opts := []domain.PlanOption{domain.WithPlaylistSelector(selector)}
+			if profile.Dedupe {
+				opts = append(opts, domain.WithDedupe())
+			}
+			if profile.AddedOrder {
+				opts = append(opts, domain.WithAddedOrder())
+			}
+
+			return runSync(ctx, cmd, format, profile.From, profile.To, from, to, opts...)
+		},
+	}
+}
+
+func newApplyCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "apply",
+		Usage:     "Apply the sync plan stored in a journal",
+		UsageText: `nomuz apply --resume [--journal <path>]`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "resume",
+				Usage: "Resume an interrupted sync, skipping operations already applied",
+			},
+			&cli.StringFlag{
+				Name:  "journal",
+				Usage: "Path to the sync journal file",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if !cmd.Bool("resume") {
+				return fmt.Errorf("nothing to apply: pass --resume to continue an interrupted sync")
+			}
+
+			journalPath, err := getJournalPath(cmd)
+			if err != nil {
+				return err
+			}
+
+			j, err := journal.Open(journalPath)
+			if err != nil {
+				return fmt.Errorf("failed to open sync journal: %w", err)
+			}
+
+			cfg, err := LoadConfig(cmd.String("config"))
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			to, err := NewConnector(ctx, cfg, j.Plan().To)
+			if err != nil {
+				return fmt.Errorf("failed to create destination connector: %w", err)
+			}
+			defer closeConnector(to)
+
+			return applyJournal(ctx, cmd, to, j)
+		},
+	}
+}
+
+// runSync plans the sync, prints the changelog and, unless --dry-run is set,
+// applies it through a journal.
+func runSync(ctx context.Context, cmd *cli.Command, format render.Format, fromName, toName string, from, to domain.Connector, opts ...domain.PlanOption) error {
+	journalPath, err := getJournalPath(cmd)
+	if err != nil {
+		return err
+	}
+
+	exists, err := journal.Exists(journalPath)
+	if err != nil {
+		return err
+	}
+	if exists && !cmd.Bool("dry-run") {
+		return fmt.Errorf("found unfinished sync journal at %s: run `nomuz apply --resume` to finish it or remove the file", journalPath)
+	}
+
+	cl, err := domain.PlanSync(ctx, from, to, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to plan sync: %w", err)
+	}
+
+	err = render.Changelog(cmd.Root().Writer, format, render.ChangelogReport{
+		From:      fromName,
+		To:        toName,
+		Changelog: cl,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render changelog: %w", err)
+	}
+
+	if cmd.Bool("dry-run") {
+		return nil
+	}
+
+	j, err := journal.Create(journalPath, journal.Plan{
+		From:       fromName,
+		To:         toName,
+		Operations: cl.Operations(to.Capabilities().BatchSize(domain.DefaultBatchSize)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create sync journal: %w", err)
+	}
+
+	return applyJournal(ctx, cmd, to, j)
+}
+
+func resolveSyncProfile(cfg *config, cmd *cli.Command) (profileConfig, error) {
+	var profile profileConfig
+	if name := cmd.String("profile"); name != "" {
+		p, found := cfg.Profiles[name]
+		if !found {
+			return profile, fmt.Errorf("unknown sync profile: %s", name)
+		}
+		profile = p
+	}
+
+	if from := cmd.String("from"); from != "" {
+		profile.From = from
+	}
+	if to := cmd.String("to"); to != "" {
+		profile.To = to
+	}
+	if profile.From == "" || profile.To == "" {
+		return profile, fmt.Errorf("both source and destination connectors are required: use --from/--to or --profile")
+	}
+
+	profile.Include = append(profile.Include, cmd.StringSlice("include")...)
+	profile.Exclude = append(profile.Exclude, cmd.StringSlice("exclude")...)
+	profile.OwnedOnly = profile.OwnedOnly || cmd.Bool("owned-only")
+	profile.Dedupe = profile.Dedupe || cmd.Bool("dedupe")
+	profile.AddedOrder = profile.AddedOrder || cmd.Bool("added-order")
+
+	return profile, nil
+}
+
+func (p profileConfig) selector() (domain.PlaylistSelector, error) {
+	include, err := domain.ParseNamePatterns(p.Include)
+	if err != nil {
+		return domain.PlaylistSelector{}, err
+	}
+
+	exclude, err := domain.ParseNamePatterns(p.Exclude)
+	if err != nil {
+		return domain.PlaylistSelector{}, err
+	}
+
+	return domain.PlaylistSelector{
+		Include:       include,
+		Exclude:       exclude,
+		IDs:           p.PlaylistIDs,
+		OwnedOnly:     p.OwnedOnly,
+		Collaborative: p.Collaborative,
+		MinTracks:     p.MinTracks,
+		MaxTracks:     p.MaxTracks,
+	}, nil
+}
+
+func getJournalPath(cmd *cli.Command) (string, error) {
+	if p := cmd.String("journal"); p != "" {
+		return p, nil
+	}
+
+	p, err := journal.DefaultPath()
+	if err != nil {
+		return "", fmt.Errorf("failed to get journal path: %w", err)
+	}
+	return p, nil
+}
+
+func applyJournal(ctx context.Context, cmd *cli.Command, to domain.Connector, j *journal.Journal) error {
+	res, err := domain.Apply(ctx, to, j.Plan().Operations, domain.WithJournal(j))
+	if err != nil {
+		j.Close()
+		return fmt.Errorf("failed to apply sync: %w", err)
+	}
+
+	fmt.Fprintf(cmd.Root().ErrWriter, "Applied: %d operations\n", len(res.Succeeded))
+	if len(res.Resumed) > 0 {
+		fmt.Fprintf(cmd.Root().ErrWriter, "Resumed: %d operations\n", len(res.Resumed))
+	}
+
+	if res.HasFailures() {
+		fmt.Fprintf(cmd.Root().ErrWriter, "Failed:  %d operations\n", len(res.Failed))
+		for _, op := range res.Failed {
+			fmt.Fprintf(cmd.Root().ErrWriter, "  - %s %q (batch %d): %v\n", op.Kind, op.Playlist.Name, op.Batch, op.Err)
+		}
+
+		j.Close()
+		return fmt.Errorf("sync finished with %d failed operations: run `nomuz apply --resume` to retry them", len(res.Failed))
+	}
+
+	return j.Remove()
+}
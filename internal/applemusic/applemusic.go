@@ -0,0 +1,315 @@
+package applemusic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+)
+
+const (
+	pageSize    = 100
+	searchLimit = 10
+	// addBatchSize is the number of tracks added to a playlist per request.
+	addBatchSize = 100
+)
+
+type Option func(*options)
+
+type options struct {
+	baseURL    string
+	storefront string
+}
+
+// WithBaseURL points the connector at another API server.
+func WithBaseURL(u string) Option {
+	return func(o *options) {
+		o.baseURL = u
+	}
+}
+
+// WithStorefront sets the catalog storefront (e.g. "us") instead of using
+// the one of the user's account.
+func WithStorefront(sf string) Option {
+	return func(o *options) {
+		o.storefront = sf
+	}
+}
+
+// NewConnector manages the library playlists of an Apple Music account. The
+// developer token is signed with the MusicKit key in keyFile; userToken is the
+// Music User Token of the account.
+func NewConnector(teamID, keyID, keyFile, userToken string, opts ...Option) (*connector, error) {
+	ctx := context.Background()
+
+	o := options{baseURL: apiURL}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if userToken == "" {
+		return nil, fmt.Errorf("apple music user token is required")
+	}
+
+	key, err := readPrivateKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	devToken, err := newDeveloperToken(teamID, keyID, key, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	c := &connector{
+		client: &client{
+			baseURL:        o.baseURL,
+			developerToken: devToken,
+			userToken:      userToken,
+			http:           http.DefaultClient,
+		},
+		storefront: o.storefront,
+	}
+
+	if c.storefront == "" {
+		var doc document[resource]
+		if err := c.client.do(ctx, http.MethodGet, "/v1/me/storefront", nil, nil, &doc); err != nil {
+			return nil, fmt.Errorf("failed to get storefront: %w", err)
+		}
+		if len(doc.Data) == 0 {
+			return nil, fmt.Errorf("failed to get storefront: no storefront for account")
+		}
+		c.storefront = doc.Data[0].ID
+	}
+
+	return c, nil
+}
+
+type connector struct {
+	client     *client
+	storefront string
+}
+
+var _ domain.Connector = (*connector)(nil)
+
+// Capabilities of Apple Music, whose API cannot remove tracks from or update
+// playlists.
+var Capabilities = domain.Capabilities{
+	CreatePlaylist: true,
+	ISRCLookup:     true,
+	MaxBatchSize:   100,
+}
+
+func (c *connector) Capabilities() domain.Capabilities {
+	return Capabilities
+}
+
+func (c *connector) CreatePlaylist(ctx context.Context, name string) (*domain.Playlist, error) {
+	var doc document[resource]
+	err := c.client.do(ctx, http.MethodPost, "/v1/me/library/playlists", nil, map[string]any{
+		"attributes": map[string]string{"name": name},
+	}, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create playlist: %w", err)
+	}
+	if len(doc.Data) == 0 {
+		return nil, fmt.Errorf("failed to create playlist: empty response")
+	}
+
+	pl := toDomainPlaylist(doc.Data[0])
+	pl.Tracks = []domain.Track{}
+	return pl, nil
+}
+
+// GetPlaylists fetches the tracks of every playlist, as Apple does not report
+// the track count of library playlists.
+func (c *connector) GetPlaylists(ctx context.Context) ([]*domain.Playlist, error) {
+	res, err := c.client.getAll(ctx, "/v1/me/library/playlists", url.Values{"limit": {strconv.Itoa(pageSize)}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlists: %w", err)
+	}
+
+	var pls []*domain.Playlist
+	for _, r := range res {
+		pl := toDomainPlaylist(r)
+		pl.Tracks, err = c.getTracks(ctx, pl.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tracks for playlist %s: %w", pl.Name, err)
+		}
+		pl.TrackCount = len(pl.Tracks)
+		pls = append(pls, pl)
+	}
+	return pls, nil
+}
+
+func (c *connector) GetPlaylistByName(ctx context.Context, name string) (*domain.Playlist, error) {
+	pls, err := c.GetPlaylists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pl := range pls {
+		if pl.Name == name {
+			return pl, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *connector) GetPlaylist(ctx context.Context, id string) (*domain.Playlist, error) {
+	var doc document[resource]
+	err := c.client.do(ctx, http.MethodGet, "/v1/me/library/playlists/"+url.PathEscape(id), nil, nil, &doc)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.Status == "404" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlist: %w", err)
+	}
+	if len(doc.Data) == 0 {
+		return nil, nil
+	}
+
+	pl := toDomainPlaylist(doc.Data[0])
+	pl.Tracks, err = c.getTracks(ctx, pl.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracks for playlist %s: %w", pl.Name, err)
+	}
+	pl.TrackCount = len(pl.Tracks)
+	return pl, nil
+}
+
+// AddTracksToPlaylist adds catalog songs, or library songs for IDs with the
+// "i." prefix Apple uses for them.
+func (c *connector) AddTracksToPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	for start := 0; start < len(tracks); start += addBatchSize {
+		var data []map[string]string
+		for _, t := range tracks[start:min(start+addBatchSize, len(tracks))] {
+			typ := "songs"
+			if strings.HasPrefix(t.ID, "i.") {
+				typ = "library-songs"
+			}
+			data = append(data, map[string]string{"id": t.ID, "type": typ})
+		}
+
+		err := c.client.do(ctx, http.MethodPost, "/v1/me/library/playlists/"+url.PathEscape(id)+"/tracks", nil, map[string]any{"data": data}, nil)
+		if err != nil {
+			return fmt.Errorf("failed to add tracks to playlist: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeleteTracksFromPlaylist is not supported: the Apple Music API has no way
+// to remove tracks from a library playlist.
+func (c *connector) DeleteTracksFromPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	return fmt.Errorf("failed to remove tracks from playlist: %w", domain.ErrUnsupported)
+}
+
+// UpdatePlaylist is not supported: library playlists cannot be edited
+// through the Apple Music API.
+func (c *connector) UpdatePlaylist(ctx context.Context, id string, meta domain.PlaylistMetadata) error {
+	return fmt.Errorf("failed to update playlist: %w", domain.ErrUnsupported)
+}
+
+// SearchTrack looks the ISRC up in the catalog and falls back to a search by
+// title and artist.
+func (c *connector) SearchTrack(ctx context.Context, filters domain.TrackFilters) ([]domain.Track, error) {
+	catalog := "/v1/catalog/" + url.PathEscape(c.storefront)
+
+	if filters.ISRC != "" {
+		var doc document[resource]
+		err := c.client.do(ctx, http.MethodGet, catalog+"/songs", url.Values{"filter[isrc]": {filters.ISRC}}, nil, &doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search track: %w", err)
+		}
+		if len(doc.Data) > 0 {
+			return toDomainTracks(doc.Data), nil
+		}
+	}
+
+	if filters.Title == "" {
+		return nil, nil
+	}
+
+	var res searchResults
+	err := c.client.do(ctx, http.MethodGet, catalog+"/search", url.Values{
+		"types": {"songs"},
+		"limit": {strconv.Itoa(searchLimit)},
+		"term":  {strings.TrimSpace(filters.Title + " " + filters.Artist)},
+	}, nil, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search track: %w", err)
+	}
+
+	return domain.RankMatches(toDomainTracks(res.Results.Songs.Data), filters), nil
+}
+
+func (c *connector) getTracks(ctx context.Context, id string) ([]domain.Track, error) {
+	res, err := c.client.getAll(ctx, "/v1/me/library/playlists/"+url.PathEscape(id)+"/tracks", url.Values{
+		"limit":   {strconv.Itoa(pageSize)},
+		"include": {"catalog"},
+	})
+	// Apple answers with not found for playlists without tracks.
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.Status == "404" {
+		return []domain.Track{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := []domain.Track{}
+	for _, r := range res {
+		t := toDomainTrack(r)
+		// Prefer the catalog song, which carries the ISRC and can be added to
+		// other playlists.
+		if songs := r.Relationships.Catalog.Data; len(songs) > 0 {
+			t = toDomainTrack(songs[0])
+		} else if r.Attributes.PlayParams.CatalogID != "" {
+			t.ID = r.Attributes.PlayParams.CatalogID
+		}
+		tracks = append(tracks, t)
+	}
+	return tracks, nil
+}
+
+func toDomainPlaylist(r resource) *domain.Playlist {
+	return &domain.Playlist{
+		ID:          r.ID,
+		Name:        r.Attributes.Name,
+		Description: r.Attributes.Description.Standard,
+		Owned:       r.Attributes.CanEdit,
+		Public:      r.Attributes.IsPublic,
+	}
+}
+
+func toDomainTracks(rs []resource) []domain.Track {
+	var tracks []domain.Track
+	for _, r := range rs {
+		tracks = append(tracks, toDomainTrack(r))
+	}
+	return tracks
+}
+
+func toDomainTrack(r resource) domain.Track {
+	return domain.Track{
+		ID:          r.ID,
+		ISRC:        r.Attributes.ISRC,
+		Title:       r.Attributes.Name,
+		Artist:      r.Attributes.ArtistName,
+		Album:       r.Attributes.AlbumName,
+		TrackNumber: r.Attributes.TrackNumber,
+		DiscNumber:  r.Attributes.DiscNumber,
+		ReleaseDate: r.Attributes.ReleaseDate,
+		Explicit:    r.Attributes.ContentRating == "explicit",
+		URL:         r.Attributes.URL,
+		Duration:    time.Duration(r.Attributes.Duration) * time.Millisecond,
+	}
+}
@@ -0,0 +1,151 @@
+package applemusic_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/applemusic"
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/domain/connectortest"
+	"github.com/pedrobarco/nomuz/internal/fakeservice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var tracks = []fakeservice.Track{
+	{ID: "1", ISRC: "USRC17607839", Title: "Song", Artist: "Band", Album: "Album", ReleaseDate: "1999-03-22", TrackNumber: 1, DiscNumber: 1, Duration: 201 * time.Second, Explicit: true},
+	{ID: "2", Title: "Song", Artist: "Tribute Band", Album: "Covers"},
+	{ID: "3", Title: "Other Song", Artist: "Other Band", Album: "Other Album"},
+}
+
+func newConnector(t *testing.T, srv *fakeservice.AppleMusic, keyFile string, opts ...applemusic.Option) domain.Connector {
+	c, err := applemusic.NewConnector(srv.TeamID, srv.KeyID, keyFile, srv.UserToken, append(opts, applemusic.WithBaseURL(srv.Server.URL))...)
+	require.NoError(t, err)
+	return c
+}
+
+// writeKey writes a new MusicKit-like .p8 key and returns its path.
+func writeKey(t *testing.T) (string, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	p := filepath.Join(t.TempDir(), "AuthKey_KEY123.p8")
+	require.NoError(t, os.WriteFile(p, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	return p, key
+}
+
+func TestConnector(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("invalid key", func(t *testing.T) {
+		keyFile, _ := writeKey(t)
+		_, other := writeKey(t)
+		srv := fakeservice.NewAppleMusic(t, fakeservice.Library{}, &other.PublicKey)
+
+		_, err := applemusic.NewConnector(srv.TeamID, srv.KeyID, keyFile, srv.UserToken, applemusic.WithBaseURL(srv.Server.URL))
+		assert.ErrorContains(t, err, "invalid developer token signature")
+	})
+
+	t.Run("playlists", func(t *testing.T) {
+		assert := assert.New(t)
+
+		keyFile, key := writeKey(t)
+		srv := fakeservice.NewAppleMusic(t, fakeservice.Library{
+			Tracks:    tracks,
+			Playlists: []fakeservice.Playlist{{ID: "p.0", Name: "Empty"}},
+		}, &key.PublicKey)
+		// Small pages make the connector follow the next links.
+		srv.PageSize = 2
+		c := newConnector(t, srv, keyFile)
+
+		pl, err := c.CreatePlaylist(ctx, "Mix")
+		assert.NoError(err)
+		mix, _ := srv.Playlist("Mix")
+		assert.Equal(mix.ID, pl.ID)
+
+		assert.NoError(c.AddTracksToPlaylist(ctx, pl.ID, []domain.Track{{ID: "1"}, {ID: "3"}, {ID: "2"}}))
+
+		pls, err := c.GetPlaylists(ctx)
+		assert.NoError(err)
+		assert.Len(pls, 2)
+		assert.Equal(0, pls[0].Size())
+		assert.Equal(3, pls[1].Size())
+
+		got, err := c.GetPlaylistByName(ctx, "Mix")
+		assert.NoError(err)
+		assert.Equal(domain.Track{
+			ID:          "1",
+			ISRC:        "USRC17607839",
+			Title:       "Song",
+			Artist:      "Band",
+			Album:       "Album",
+			TrackNumber: 1,
+			DiscNumber:  1,
+			ReleaseDate: "1999-03-22",
+			Explicit:    true,
+			URL:         "https://music.apple.com/us/song/1",
+			Duration:    201 * time.Second,
+		}, got.Tracks[0])
+		assert.Equal(domain.Track{ID: "3", Title: "Other Song", Artist: "Other Band", Album: "Other Album"}, got.Tracks[1])
+
+		err = c.DeleteTracksFromPlaylist(ctx, pl.ID, []domain.Track{{ID: "1"}})
+		assert.ErrorIs(err, domain.ErrUnsupported)
+		assert.False(c.Capabilities().DeleteTracks)
+		mix, _ = srv.Playlist("Mix")
+		assert.Equal([]string{"1", "3", "2"}, mix.Tracks)
+
+		got, err = c.GetPlaylistByName(ctx, "Missing")
+		assert.NoError(err)
+		assert.Nil(got)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		assert := assert.New(t)
+
+		keyFile, key := writeKey(t)
+		srv := fakeservice.NewAppleMusic(t, fakeservice.Library{Tracks: tracks}, &key.PublicKey)
+		c := newConnector(t, srv, keyFile, applemusic.WithStorefront("us"))
+
+		res, err := c.SearchTrack(ctx, domain.TrackFilters{ISRC: "USRC17607839", Title: "Nothing"})
+		assert.NoError(err)
+		assert.Len(res, 1)
+		assert.Equal("1", res[0].ID)
+		assert.Empty(srv.Searches())
+
+		res, err = c.SearchTrack(ctx, domain.TrackFilters{ISRC: "UNKNOWN", Title: "Song", Artist: "Tribute Band"})
+		assert.NoError(err)
+		assert.Len(res, 1)
+		assert.Equal("2", res[0].ID)
+		assert.Equal([]string{"Song Tribute Band"}, srv.Searches())
+
+		res, err = c.SearchTrack(ctx, domain.TrackFilters{ID: "spotify-id"})
+		assert.NoError(err)
+		assert.Empty(res)
+	})
+}
+
+func TestConformance(t *testing.T) {
+	keyFile, key := writeKey(t)
+
+	connectortest.Run(t, connectortest.Harness{
+		New: func(t *testing.T) domain.Connector {
+			srv := fakeservice.NewAppleMusic(t, fakeservice.Library{Tracks: []fakeservice.Track{tracks[0], tracks[2]}}, &key.PublicKey)
+			return newConnector(t, srv, keyFile)
+		},
+		Tracks: []domain.Track{
+			{ID: "1", ISRC: "USRC17607839", Title: "Song", Artist: "Band", Album: "Album"},
+			{ID: "3", Title: "Other Song", Artist: "Other Band", Album: "Other Album"},
+		},
+	})
+}
@@ -0,0 +1,155 @@
+package applemusic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const apiURL = "https://api.music.apple.com"
+
+type client struct {
+	baseURL        string
+	developerToken string
+	userToken      string
+	http           *http.Client
+}
+
+type apiError struct {
+	Status string `json:"status"`
+	Code   string `json:"code"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+func (e *apiError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("apple music error %s: %s: %s", e.Status, e.Title, e.Detail)
+	}
+	return fmt.Sprintf("apple music error %s: %s", e.Status, e.Title)
+}
+
+// document is the envelope of every Apple Music API response.
+type document[T any] struct {
+	Data   []T        `json:"data"`
+	Next   string     `json:"next"`
+	Errors []apiError `json:"errors"`
+}
+
+type resource struct {
+	ID         string     `json:"id"`
+	Type       string     `json:"type"`
+	Attributes attributes `json:"attributes"`
+	// Relationships holds the catalog song of library songs when requested
+	// with include=catalog.
+	Relationships struct {
+		Catalog struct {
+			Data []resource `json:"data"`
+		} `json:"catalog"`
+	} `json:"relationships"`
+}
+
+type attributes struct {
+	Name        string `json:"name"`
+	ArtistName  string `json:"artistName"`
+	AlbumName   string `json:"albumName"`
+	ISRC        string `json:"isrc"`
+	URL         string `json:"url"`
+	Duration    int64  `json:"durationInMillis"`
+	TrackNumber int    `json:"trackNumber"`
+	DiscNumber  int    `json:"discNumber"`
+	ReleaseDate string `json:"releaseDate"`
+	// ContentRating is "explicit", "clean" or empty.
+	ContentRating string `json:"contentRating"`
+	IsPublic      bool   `json:"isPublic"`
+	CanEdit       bool   `json:"canEdit"`
+	Description   struct {
+		Standard string `json:"standard"`
+	} `json:"description"`
+	PlayParams struct {
+		ID        string `json:"id"`
+		CatalogID string `json:"catalogId"`
+	} `json:"playParams"`
+}
+
+type searchResults struct {
+	Results struct {
+		Songs document[resource] `json:"songs"`
+	} `json:"results"`
+}
+
+// do calls the API with the developer and user tokens and decodes the
+// response into out, if set. path may be a full "next" link.
+func (c *client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	u := strings.TrimSuffix(c.baseURL, "/") + path
+	if len(query) > 0 {
+		sep := "?"
+		if strings.Contains(path, "?") {
+			sep = "&"
+		}
+		u += sep + query.Encode()
+	}
+
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.developerToken)
+	req.Header.Set("Music-User-Token", c.userToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var doc document[resource]
+		if json.NewDecoder(resp.Body).Decode(&doc) == nil && len(doc.Errors) > 0 {
+			return &doc.Errors[0]
+		}
+		return fmt.Errorf("failed to call %s %s: status code %d", method, path, resp.StatusCode)
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// getAll follows the next links of a paginated collection.
+func (c *client) getAll(ctx context.Context, path string, query url.Values) ([]resource, error) {
+	var all []resource
+	for path != "" {
+		var doc document[resource]
+		if err := c.do(ctx, http.MethodGet, path, query, nil, &doc); err != nil {
+			return nil, err
+		}
+		all = append(all, doc.Data...)
+
+		// The next link carries the offset but not the other parameters.
+		path = doc.Next
+	}
+	return all, nil
+}
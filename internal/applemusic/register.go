@@ -0,0 +1,31 @@
+package applemusic
+
+import (
+	"context"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/registry"
+)
+
+type Config struct {
+	TeamID     string `yaml:"team_id"`
+	KeyID      string `yaml:"key_id"`
+	PrivateKey string `yaml:"private_key"`
+	UserToken  string `yaml:"user_token"`
+	Storefront string `yaml:"storefront"`
+}
+
+func init() {
+	registry.Register(registry.Connector[Config]{
+		Name:         "applemusic",
+		Description:  "Apple Music library",
+		Capabilities: Capabilities,
+		New: func(ctx context.Context, cfg Config, arg string) (domain.Connector, error) {
+			var opts []Option
+			if cfg.Storefront != "" {
+				opts = append(opts, WithStorefront(cfg.Storefront))
+			}
+			return NewConnector(cfg.TeamID, cfg.KeyID, cfg.PrivateKey, cfg.UserToken, opts...)
+		},
+	})
+}
@@ -0,0 +1,79 @@
+package applemusic
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"time"
+)
+
+// developerTokenTTL is how long a generated developer token is valid. Apple
+// accepts up to six months; a fresh token is signed on every run.
+const developerTokenTTL = 12 * time.Hour
+
+// readPrivateKey reads the ES256 private key of a MusicKit .p8 file.
+func readPrivateKey(path string) (*ecdsa.PrivateKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode private key %s: no PEM data", path)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse private key: not an ECDSA key")
+	}
+	return ecKey, nil
+}
+
+// newDeveloperToken signs the JWT that identifies the developer to the Apple
+// Music API.
+func newDeveloperToken(teamID, keyID string, key *ecdsa.PrivateKey, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{
+		"alg": "ES256",
+		"kid": keyID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(map[string]any{
+		"iss": teamID,
+		"iat": now.Unix(),
+		"exp": now.Add(developerTokenTTL).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+
+	hash := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign developer token: %w", err)
+	}
+
+	// JWS encodes ES256 signatures as the fixed-size concatenation of r and s.
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
@@ -0,0 +1,234 @@
+// Package backup reads and writes portable snapshots of a music library.
+//
+// The JSON format is versioned: readers reject documents with a version they
+// do not know. The CSV format has one row per track and uses the column names
+// of Exportify, so files exported by either tool can be imported by the other.
+package backup
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+)
+
+// Version is the version of the JSON backup format written by this package.
+// Version 2 added the track kind; version 1 documents only hold regular
+// tracks.
+const Version = 2
+
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+)
+
+func Formats() []string {
+	return []string{string(FormatJSON), string(FormatCSV)}
+}
+
+func ParseFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(s)) {
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	default:
+		return "", fmt.Errorf("unknown backup format %q: expected %s", s, strings.Join(Formats(), ", "))
+	}
+}
+
+// FormatFromPath guesses the format from the file extension, defaulting to
+// JSON.
+func FormatFromPath(p string) Format {
+	if strings.EqualFold(filepath.Ext(p), ".csv") {
+		return FormatCSV
+	}
+	return FormatJSON
+}
+
+type Backup struct {
+	Version    int        `json:"version"`
+	Source     string     `json:"source,omitempty"`
+	ExportedAt time.Time  `json:"exported_at"`
+	Playlists  []Playlist `json:"playlists"`
+}
+
+type Playlist struct {
+	ID            string   `json:"id,omitempty"`
+	Name          string   `json:"name"`
+	Description   string   `json:"description,omitempty"`
+	Owner         string   `json:"owner,omitempty"`
+	Public        bool     `json:"public"`
+	Collaborative bool     `json:"collaborative"`
+	Folder        []string `json:"folder,omitempty"`
+	URL           string   `json:"url,omitempty"`
+	Tracks        []Track  `json:"tracks"`
+}
+
+type Track struct {
+	ID          string     `json:"id,omitempty"`
+	Kind        string     `json:"kind,omitempty"`
+	ISRC        string     `json:"isrc,omitempty"`
+	MBID        string     `json:"mbid,omitempty"`
+	Title       string     `json:"title"`
+	Artists     []string   `json:"artists,omitempty"`
+	Album       string     `json:"album,omitempty"`
+	AlbumArtist string     `json:"album_artist,omitempty"`
+	UPC         string     `json:"upc,omitempty"`
+	TrackNumber int        `json:"track_number,omitempty"`
+	DiscNumber  int        `json:"disc_number,omitempty"`
+	ReleaseDate string     `json:"release_date,omitempty"`
+	Explicit    bool       `json:"explicit,omitempty"`
+	Popularity  int        `json:"popularity,omitempty"`
+	DurationMS  int64      `json:"duration_ms,omitempty"`
+	AddedAt     *time.Time `json:"added_at,omitempty"`
+	AddedBy     string     `json:"added_by,omitempty"`
+	URL         string     `json:"url,omitempty"`
+}
+
+// New snapshots the given playlists, which must include their tracks.
+func New(source string, pls []*domain.Playlist) *Backup {
+	b := &Backup{
+		Version:    Version,
+		Source:     source,
+		ExportedAt: time.Now().UTC(),
+		Playlists:  []Playlist{},
+	}
+
+	for _, pl := range pls {
+		p := Playlist{
+			ID:            pl.ID,
+			Name:          pl.Name,
+			Description:   pl.Description,
+			Owner:         pl.Owner,
+			Public:        pl.Public,
+			Collaborative: pl.Collaborative,
+			Folder:        pl.Folder,
+			URL:           pl.URL,
+			Tracks:        []Track{},
+		}
+		for i, t := range pl.Tracks {
+			p.Tracks = append(p.Tracks, fromDomainTrack(t, pl.Item(i)))
+		}
+		b.Playlists = append(b.Playlists, p)
+	}
+
+	return b
+}
+
+// DomainPlaylists returns the playlists of the backup with their tracks.
+func (b *Backup) DomainPlaylists() []*domain.Playlist {
+	var pls []*domain.Playlist
+	for _, p := range b.Playlists {
+		pl := &domain.Playlist{
+			ID:            p.ID,
+			Name:          p.Name,
+			Description:   p.Description,
+			Owner:         p.Owner,
+			Owned:         true,
+			Public:        p.Public,
+			Collaborative: p.Collaborative,
+			Folder:        p.Folder,
+			URL:           p.URL,
+			TrackCount:    len(p.Tracks),
+		}
+		if pl.ID == "" {
+			pl.ID = p.Name
+		}
+		for _, t := range p.Tracks {
+			pl.Tracks = append(pl.Tracks, t.toDomain())
+			pl.Items = append(pl.Items, t.toDomainItem())
+		}
+		pls = append(pls, pl)
+	}
+	return pls
+}
+
+func Read(r io.Reader, format Format, name string) (*Backup, error) {
+	switch format {
+	case FormatJSON:
+		return readJSON(r)
+	case FormatCSV:
+		return readCSV(r, name)
+	default:
+		return nil, fmt.Errorf("unknown backup format: %s", format)
+	}
+}
+
+func Write(w io.Writer, format Format, b *Backup) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, b)
+	case FormatCSV:
+		return writeCSV(w, b)
+	default:
+		return fmt.Errorf("unknown backup format: %s", format)
+	}
+}
+
+func fromDomainTrack(t domain.Track, it domain.PlaylistItem) Track {
+	tr := Track{
+		ID:          t.ID,
+		Kind:        string(t.Kind),
+		ISRC:        t.ISRC,
+		MBID:        t.MBID,
+		Title:       t.Title,
+		Artists:     t.ArtistNames(),
+		Album:       t.Album,
+		AlbumArtist: t.AlbumArtist,
+		UPC:         t.UPC,
+		TrackNumber: t.TrackNumber,
+		DiscNumber:  t.DiscNumber,
+		ReleaseDate: t.ReleaseDate,
+		Explicit:    t.Explicit,
+		Popularity:  t.Popularity,
+		DurationMS:  t.Duration.Milliseconds(),
+		AddedBy:     it.AddedBy,
+		URL:         t.URL,
+	}
+	if !it.AddedAt.IsZero() {
+		addedAt := it.AddedAt.UTC()
+		tr.AddedAt = &addedAt
+	}
+	return tr
+}
+
+func (t Track) toDomain() domain.Track {
+	tr := domain.Track{
+		ID:          t.ID,
+		Kind:        domain.TrackKind(t.Kind),
+		ISRC:        t.ISRC,
+		MBID:        t.MBID,
+		Title:       t.Title,
+		Album:       t.Album,
+		AlbumArtist: t.AlbumArtist,
+		UPC:         t.UPC,
+		TrackNumber: t.TrackNumber,
+		DiscNumber:  t.DiscNumber,
+		ReleaseDate: t.ReleaseDate,
+		Explicit:    t.Explicit,
+		Popularity:  t.Popularity,
+		URL:         t.URL,
+		Duration:    time.Duration(t.DurationMS) * time.Millisecond,
+	}
+	for _, name := range t.Artists {
+		tr.Artists = append(tr.Artists, domain.Artist{Name: name})
+	}
+	if len(tr.Artists) > 0 {
+		tr.Artist = tr.Artists[0].Name
+	}
+	return tr
+}
+
+func (t Track) toDomainItem() domain.PlaylistItem {
+	it := domain.PlaylistItem{AddedBy: t.AddedBy}
+	if t.AddedAt != nil {
+		it.AddedAt = *t.AddedAt
+	}
+	return it
+}
@@ -0,0 +1,176 @@
+package backup_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/backup"
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/xspf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var addedAt = time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+
+var playlists = []*domain.Playlist{
+	{
+		ID:          "pl1",
+		Name:        "Road Trip",
+		Description: "Songs for the road",
+		Owner:       "alice",
+		Public:      true,
+		Folder:      []string{"Travel"},
+		Tracks: []domain.Track{
+			{
+				ID: "t1", ISRC: "USRC17607839", Title: "Song", Artist: "Band",
+				Artists: []domain.Artist{{Name: "Band"}, {Name: "Guest"}},
+				Album:   "Album", AlbumArtist: "Band", TrackNumber: 3, DiscNumber: 1, ReleaseDate: "1999-03-22",
+				Explicit: true, Popularity: 42, Duration: 201 * time.Second,
+			},
+			{ID: "t2", Title: "Other, Song", Artist: "Other Band", Artists: []domain.Artist{{Name: "Other Band"}}},
+		},
+		Items: []domain.PlaylistItem{{AddedAt: addedAt, AddedBy: "alice"}, {}},
+	},
+	{
+		ID:   "pl2",
+		Name: "Empty",
+	},
+}
+
+func TestBackup(t *testing.T) {
+	t.Run("json round trip", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var b bytes.Buffer
+		assert.NoError(backup.Write(&b, backup.FormatJSON, backup.New("spotify", playlists)))
+		assert.Contains(b.String(), `"version": 2`)
+		assert.Contains(b.String(), `"duration_ms": 201000`)
+		assert.Contains(b.String(), `"added_at": "2024-05-01T10:30:00Z"`)
+		assert.Contains(b.String(), `"added_by": "alice"`)
+		assert.Contains(b.String(), `"track_number": 3`)
+		assert.Contains(b.String(), `"folder": [`)
+
+		got, err := backup.Read(&b, backup.FormatJSON, "")
+		assert.NoError(err)
+		assert.Equal("spotify", got.Source)
+
+		pls := got.DomainPlaylists()
+		assert.Len(pls, 2)
+		assert.Equal("Songs for the road", pls[0].Description)
+		assert.Equal([]string{"Travel"}, pls[0].Folder)
+		assert.Equal(playlists[0].Tracks, pls[0].Tracks)
+		assert.Equal(playlists[0].Items, pls[0].Items)
+		assert.Empty(pls[1].Tracks)
+	})
+
+	t.Run("json unknown version", func(t *testing.T) {
+		_, err := backup.Read(strings.NewReader(`{"version": 3, "playlists": []}`), backup.FormatJSON, "")
+		assert.ErrorContains(t, err, "unsupported backup version 3")
+
+		_, err = backup.Read(strings.NewReader(`{"playlists": []}`), backup.FormatJSON, "")
+		assert.ErrorContains(t, err, "unsupported backup version 0")
+	})
+
+	t.Run("track kinds", func(t *testing.T) {
+		assert := assert.New(t)
+
+		pls := []*domain.Playlist{{
+			ID: "pl1", Name: "Mixed",
+			Tracks: []domain.Track{
+				{ID: "t1", Title: "Song"},
+				{ID: "e1", Kind: domain.TrackKindEpisode, Title: "Episode"},
+				{ID: "spotify:local:Band:Album:Demo:180", Kind: domain.TrackKindLocal, Title: "Demo"},
+			},
+		}}
+
+		for _, format := range []backup.Format{backup.FormatJSON, backup.FormatCSV} {
+			var b bytes.Buffer
+			assert.NoError(backup.Write(&b, format, backup.New("spotify", pls)))
+
+			got, err := backup.Read(&b, format, "")
+			assert.NoError(err)
+			assert.Equal(pls[0].Tracks, got.DomainPlaylists()[0].Tracks, format)
+		}
+
+		// Version 1 predates kinds.
+		got, err := backup.Read(strings.NewReader(`{"version": 1, "playlists": [{"name": "Old", "tracks": [{"title": "Song"}]}]}`), backup.FormatJSON, "")
+		assert.NoError(err)
+		assert.Equal(domain.TrackKindTrack, got.DomainPlaylists()[0].Tracks[0].Kind)
+
+		got, err = backup.Read(strings.NewReader("Track URI,Track Name\nspotify:episode:e1,Episode\n"), backup.FormatCSV, "Podcasts")
+		assert.NoError(err)
+		assert.Equal(domain.TrackKindEpisode, got.DomainPlaylists()[0].Tracks[0].Kind)
+	})
+
+	t.Run("csv round trip", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var b bytes.Buffer
+		assert.NoError(backup.Write(&b, backup.FormatCSV, backup.New("spotify", playlists)))
+		assert.Equal(
+			"Playlist Name,Track URI,Track Name,Artist Name(s),Album Name,Album Artist Name(s),Album Release Date,"+
+				"Disc Number,Track Number,Duration (ms),Explicit,Popularity,ISRC,Added At,Added By,Kind\n"+
+				"Road Trip,t1,Song,\"Band,Guest\",Album,Band,1999-03-22,1,3,201000,true,42,USRC17607839,2024-05-01T10:30:00Z,alice,\n"+
+				"Road Trip,t2,\"Other, Song\",Other Band,,,,,,,false,,,,,\n",
+			b.String(),
+		)
+
+		got, err := backup.Read(&b, backup.FormatCSV, "")
+		assert.NoError(err)
+
+		pls := got.DomainPlaylists()
+		assert.Len(pls, 1)
+		assert.Equal("Road Trip", pls[0].Name)
+		assert.Equal(playlists[0].Tracks, pls[0].Tracks)
+		assert.Equal(playlists[0].Items, pls[0].Items)
+	})
+
+	t.Run("exportify csv", func(t *testing.T) {
+		assert := assert.New(t)
+
+		data := "\ufeff\"Track URI\",\"Track Name\",\"Artist Name(s)\",\"Album Name\",\"Track Duration (ms)\",\"Popularity\",\"Added At\"\n" +
+			"\"spotify:track:4uLU6hMCjMI75M1A2tKUQC\",\"Song\",\"Band,Featured\",\"Album\",\"201000\",\"42\",\"2024-05-01T10:30:00Z\"\n"
+
+		got, err := backup.Read(strings.NewReader(data), backup.FormatCSV, "Liked Songs")
+		assert.NoError(err)
+		assert.Len(got.Playlists, 1)
+		assert.Equal("Liked Songs", got.Playlists[0].Name)
+
+		tr := got.Playlists[0].Tracks[0]
+		assert.Equal("4uLU6hMCjMI75M1A2tKUQC", tr.ID)
+		assert.Equal([]string{"Band", "Featured"}, tr.Artists)
+		assert.Equal(int64(201000), tr.DurationMS)
+		assert.Equal(42, tr.Popularity)
+		assert.Equal(addedAt, *tr.AddedAt)
+
+		_, err = backup.Read(strings.NewReader("Title\nSong\n"), backup.FormatCSV, "x")
+		assert.ErrorContains(err, `missing "Track Name" column`)
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		assert := assert.New(t)
+		ctx := context.Background()
+
+		to, err := xspf.NewConnector(t.TempDir(), xspf.FormatJSPF)
+		require.NoError(t, err)
+
+		from := backup.NewConnector(backup.New("spotify", playlists))
+		assert.True(from.Capabilities().Folders)
+		cl, err := domain.PlanSync(ctx, from, to)
+		assert.NoError(err)
+		_, err = domain.Sync(ctx, from, to, *cl)
+		assert.NoError(err)
+
+		pl, err := to.GetPlaylistByName(ctx, "Road Trip")
+		assert.NoError(err)
+		assert.Len(pl.Tracks, 2)
+		assert.Equal("USRC17607839", pl.Tracks[0].ISRC)
+
+		_, err = from.CreatePlaylist(ctx, "New")
+		assert.Error(err)
+	})
+}
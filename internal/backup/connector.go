@@ -0,0 +1,96 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+)
+
+var errReadOnly = fmt.Errorf("backup is read-only: %w", domain.ErrUnsupported)
+
+// NewConnector exposes a backup as a read-only connector, so it can be used as
+// the source of a sync.
+func NewConnector(b *Backup) *connector {
+	c := &connector{
+		playlists: b.DomainPlaylists(),
+		caps:      Capabilities,
+	}
+
+	// CSV backups do not record folders, so playlists are only read with
+	// their folders when the backup has some.
+	for _, pl := range c.playlists {
+		if len(pl.Folder) > 0 {
+			c.caps.Folders = true
+		}
+	}
+
+	return c
+}
+
+type connector struct {
+	playlists []*domain.Playlist
+	caps      domain.Capabilities
+}
+
+var _ domain.Connector = (*connector)(nil)
+
+// Capabilities of a backup, which is read-only.
+var Capabilities = domain.Capabilities{}
+
+func (c *connector) Capabilities() domain.Capabilities {
+	return c.caps
+}
+
+func (c *connector) CreatePlaylist(ctx context.Context, name string) (*domain.Playlist, error) {
+	return nil, errReadOnly
+}
+
+func (c *connector) GetPlaylists(ctx context.Context) ([]*domain.Playlist, error) {
+	return c.playlists, nil
+}
+
+func (c *connector) GetPlaylist(ctx context.Context, id string) (*domain.Playlist, error) {
+	for _, pl := range c.playlists {
+		if pl.ID == id {
+			return pl, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *connector) GetPlaylistByName(ctx context.Context, name string) (*domain.Playlist, error) {
+	for _, pl := range c.playlists {
+		if pl.Name == name {
+			return pl, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *connector) AddTracksToPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	return errReadOnly
+}
+
+func (c *connector) DeleteTracksFromPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	return errReadOnly
+}
+
+func (c *connector) UpdatePlaylist(ctx context.Context, id string, meta domain.PlaylistMetadata) error {
+	return errReadOnly
+}
+
+func (c *connector) SearchTrack(ctx context.Context, filters domain.TrackFilters) ([]domain.Track, error) {
+	for _, pl := range c.playlists {
+		for _, t := range pl.Tracks {
+			if t.ID != "" && t.ID == filters.ID {
+				return []domain.Track{t}, nil
+			}
+			if t.ISRC != "" && strings.EqualFold(t.ISRC, filters.ISRC) {
+				return []domain.Track{t}, nil
+			}
+		}
+	}
+	return nil, nil
+}
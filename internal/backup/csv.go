@@ -0,0 +1,234 @@
+package backup
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+)
+
+const (
+	columnPlaylist     = "Playlist Name"
+	columnURI          = "Track URI"
+	columnTitle        = "Track Name"
+	columnArtists      = "Artist Name(s)"
+	columnAlbum        = "Album Name"
+	columnAlbumArtists = "Album Artist Name(s)"
+	columnReleaseDate  = "Album Release Date"
+	columnDisc         = "Disc Number"
+	columnTrack        = "Track Number"
+	columnDuration     = "Duration (ms)"
+	columnExplicit     = "Explicit"
+	columnPopularity   = "Popularity"
+	columnISRC         = "ISRC"
+	columnAddedAt      = "Added At"
+	columnAddedBy      = "Added By"
+	columnKind         = "Kind"
+)
+
+var csvHeader = []string{
+	columnPlaylist,
+	columnURI,
+	columnTitle,
+	columnArtists,
+	columnAlbum,
+	columnAlbumArtists,
+	columnReleaseDate,
+	columnDisc,
+	columnTrack,
+	columnDuration,
+	columnExplicit,
+	columnPopularity,
+	columnISRC,
+	columnAddedAt,
+	columnAddedBy,
+	columnKind,
+}
+
+// columnAliases maps the column names used by older Exportify versions.
+var columnAliases = map[string]string{
+	"Track Duration (ms)": columnDuration,
+}
+
+const (
+	spotifyTrackURIPrefix   = "spotify:track:"
+	spotifyEpisodeURIPrefix = "spotify:episode:"
+	spotifyLocalURIPrefix   = "spotify:local:"
+)
+
+func writeCSV(w io.Writer, b *Backup) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	for _, p := range b.Playlists {
+		for _, t := range p.Tracks {
+			var duration, addedAt string
+			if t.DurationMS > 0 {
+				duration = strconv.FormatInt(t.DurationMS, 10)
+			}
+			if t.AddedAt != nil {
+				addedAt = t.AddedAt.Format(time.RFC3339)
+			}
+
+			row := []string{
+				p.Name, t.ID, t.Title, strings.Join(t.Artists, ","), t.Album, t.AlbumArtist, t.ReleaseDate,
+				formatInt(t.DiscNumber), formatInt(t.TrackNumber), duration, strconv.FormatBool(t.Explicit),
+				formatInt(t.Popularity), t.ISRC, addedAt, t.AddedBy, t.Kind,
+			}
+			if err := cw.Write(row); err != nil {
+				return fmt.Errorf("failed to write backup: %w", err)
+			}
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
+
+// readCSV reads a backup with one row per track. Files without a playlist
+// column, such as those exported by Exportify, hold a single playlist called
+// name.
+func readCSV(r io.Reader, name string) (*Backup, error) {
+	br := bufio.NewReader(r)
+	if bom, err := br.Peek(3); err == nil && string(bom) == "\xef\xbb\xbf" {
+		br.Discard(3)
+	}
+
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, h := range header {
+		h = strings.TrimSpace(h)
+		if alias, found := columnAliases[h]; found {
+			h = alias
+		}
+		columns[h] = i
+	}
+
+	if _, found := columns[columnTitle]; !found {
+		return nil, fmt.Errorf("failed to read backup: missing %q column", columnTitle)
+	}
+
+	b := &Backup{
+		Version:   Version,
+		Playlists: []Playlist{},
+	}
+	index := make(map[string]int)
+
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup: %w", err)
+		}
+
+		get := func(column string) string {
+			if i, found := columns[column]; found && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		t := Track{
+			ID:          strings.TrimPrefix(get(columnURI), spotifyTrackURIPrefix),
+			Kind:        get(columnKind),
+			Title:       get(columnTitle),
+			Album:       get(columnAlbum),
+			ReleaseDate: get(columnReleaseDate),
+			ISRC:        get(columnISRC),
+		}
+
+		// Exportify has no kind column but keeps the URI of episodes and
+		// local files.
+		if t.Kind == "" {
+			switch {
+			case strings.HasPrefix(t.ID, spotifyEpisodeURIPrefix):
+				t.Kind = string(domain.TrackKindEpisode)
+			case strings.HasPrefix(t.ID, spotifyLocalURIPrefix):
+				t.Kind = string(domain.TrackKindLocal)
+			}
+		}
+
+		// Exportify lists every album artist; the backup keeps the first.
+		t.AlbumArtist, _, _ = strings.Cut(get(columnAlbumArtists), ",")
+
+		for column, n := range map[string]*int{columnDisc: &t.DiscNumber, columnTrack: &t.TrackNumber, columnPopularity: &t.Popularity} {
+			if v := get(column); v != "" {
+				if *n, err = strconv.Atoi(v); err != nil {
+					return nil, fmt.Errorf("failed to read backup: invalid %s %q", strings.ToLower(column), v)
+				}
+			}
+		}
+
+		if v := get(columnExplicit); v != "" {
+			if t.Explicit, err = strconv.ParseBool(v); err != nil {
+				return nil, fmt.Errorf("failed to read backup: invalid explicit %q", v)
+			}
+		}
+
+		for _, a := range strings.Split(get(columnArtists), ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				t.Artists = append(t.Artists, a)
+			}
+		}
+
+		if v := get(columnDuration); v != "" {
+			ms, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read backup: invalid duration %q", v)
+			}
+			t.DurationMS = ms
+		}
+
+		if v := get(columnAddedAt); v != "" {
+			addedAt, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read backup: invalid added at %q", v)
+			}
+			t.AddedAt = &addedAt
+		}
+		t.AddedBy = get(columnAddedBy)
+
+		playlist := get(columnPlaylist)
+		if playlist == "" {
+			playlist = name
+		}
+
+		i, found := index[playlist]
+		if !found {
+			i = len(b.Playlists)
+			index[playlist] = i
+			b.Playlists = append(b.Playlists, Playlist{Name: playlist})
+		}
+		b.Playlists[i].Tracks = append(b.Playlists[i].Tracks, t)
+	}
+
+	return b, nil
+}
+
+// formatInt leaves unknown numbers, which are zero, empty.
+func formatInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
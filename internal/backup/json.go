@@ -0,0 +1,29 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+func readJSON(r io.Reader) (*Backup, error) {
+	var b Backup
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, fmt.Errorf("failed to decode backup: %w", err)
+	}
+
+	if b.Version < 1 || b.Version > Version {
+		return nil, fmt.Errorf("unsupported backup version %d: expected up to %d", b.Version, Version)
+	}
+
+	return &b, nil
+}
+
+func writeJSON(w io.Writer, b *Backup) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(b); err != nil {
+		return fmt.Errorf("failed to encode backup: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,411 @@
+// Package cassette records HTTP interactions with real services to files and
+// replays them in tests, so connectors can be tested offline. Secrets are
+// scrubbed before interactions are written.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+var record = flag.Bool("record", false, "record HTTP cassettes against the real services")
+
+// Recording reports whether tests run with -record.
+func Recording() bool {
+	return *record
+}
+
+type Mode int
+
+const (
+	ModeReplay Mode = iota
+	ModeRecord
+)
+
+type Cassette struct {
+	Interactions []*Interaction `yaml:"interactions"`
+}
+
+type Interaction struct {
+	Request  Request  `yaml:"request"`
+	Response Response `yaml:"response"`
+}
+
+type Request struct {
+	Method string `yaml:"method"`
+	URL    string `yaml:"url"`
+	Body   string `yaml:"body,omitempty"`
+}
+
+type Response struct {
+	Status  int               `yaml:"status"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    string            `yaml:"body,omitempty"`
+}
+
+// Scrubber removes secrets from an interaction before it is recorded or
+// matched.
+type Scrubber func(*Interaction)
+
+// ErrNoInteraction is returned when replaying a request that was not
+// recorded.
+var ErrNoInteraction = errors.New("no recorded interaction")
+
+// Redacted replaces scrubbed values.
+const Redacted = "REDACTED"
+
+// keptHeaders are the only response headers recorded; request headers are
+// never recorded, so credentials sent in them never reach the cassette.
+var keptHeaders = []string{"Content-Type", "Location", "Retry-After"}
+
+// defaultScrubbers redact the credentials commonly sent in URLs and bodies.
+var defaultScrubbers = []Scrubber{
+	ScrubQuery("access_token", "client_secret", "code", "refresh_token", "api_key", "token"),
+	ScrubJSON("access_token", "refresh_token", "client_secret", "id_token", "email"),
+}
+
+type Option func(*Recorder)
+
+// WithTransport sets the transport used to reach the real service when
+// recording.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = rt
+	}
+}
+
+// WithScrubbers adds scrubbers to the default ones.
+func WithScrubbers(s ...Scrubber) Option {
+	return func(r *Recorder) {
+		r.scrubbers = append(r.scrubbers, s...)
+	}
+}
+
+// Recorder is an http.RoundTripper that records interactions to a cassette
+// file or replays them from it.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+	scrubbers []Scrubber
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+var _ http.RoundTripper = (*Recorder)(nil)
+
+// New opens the cassette at path. In replay mode the file must exist; in
+// record mode it is overwritten by Stop.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+		scrubbers: defaultScrubbers,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if mode == ModeReplay {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		if err := yaml.Unmarshal(b, &r.cassette); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return r, nil
+}
+
+// ForTest opens the cassette testdata/cassettes/<name>.yaml, recording it when
+// the tests run with -record, and saves it when the test ends.
+func ForTest(t testing.TB, name string, opts ...Option) *Recorder {
+	t.Helper()
+
+	mode := ModeReplay
+	if Recording() {
+		mode = ModeRecord
+	}
+
+	r, err := New(filepath.Join("testdata", "cassettes", name+".yaml"), mode, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := r.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+	return r
+}
+
+// Client returns an HTTP client going through the recorder.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Stop writes the cassette when recording.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, err := yaml.Marshal(&r.cassette)
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette dir: %w", err)
+	}
+	if err := os.WriteFile(r.path, b, 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	in := &Interaction{Request: Request{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Body:   body,
+	}}
+
+	if r.mode == ModeRecord {
+		return r.record(req, in)
+	}
+	return r.replay(req, in)
+}
+
+func (r *Recorder) record(req *http.Request, in *Interaction) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+
+	in.Response = Response{Status: resp.StatusCode, Body: string(b)}
+	for _, h := range keptHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			if in.Response.Headers == nil {
+				in.Response.Headers = make(map[string]string)
+			}
+			in.Response.Headers[h] = v
+		}
+	}
+
+	r.scrub(in)
+	in.Request.Body = indentJSON(in.Request.Body)
+	in.Response.Body = indentJSON(in.Response.Body)
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, in *Interaction) (*http.Response, error) {
+	r.scrub(in)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, rec := range r.cassette.Interactions {
+		if r.used[i] || !matches(rec.Request, in.Request) {
+			continue
+		}
+		r.used[i] = true
+		return rec.Response.toHTTP(req), nil
+	}
+
+	return nil, fmt.Errorf("%w in %s for %s %s %s: run the tests with -record to update it",
+		ErrNoInteraction, r.path, in.Request.Method, in.Request.URL, in.Request.Body)
+}
+
+func (r *Recorder) scrub(in *Interaction) {
+	for _, s := range r.scrubbers {
+		s(in)
+	}
+}
+
+func (res Response) toHTTP(req *http.Request) *http.Response {
+	header := make(http.Header)
+	for k, v := range res.Headers {
+		header.Set(k, v)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", res.Status, http.StatusText(res.Status)),
+		StatusCode:    res.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(res.Body)),
+		ContentLength: int64(len(res.Body)),
+		Request:       req,
+	}
+}
+
+func readBody(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", nil
+	}
+
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	return string(b), nil
+}
+
+// matches compares requests regardless of the order of query parameters and
+// the formatting of JSON bodies.
+func matches(rec, live Request) bool {
+	if rec.Method != live.Method || normalizeURL(rec.URL) != normalizeURL(live.URL) {
+		return false
+	}
+
+	var a, b any
+	if json.Unmarshal([]byte(rec.Body), &a) == nil && json.Unmarshal([]byte(live.Body), &b) == nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return strings.TrimSpace(rec.Body) == strings.TrimSpace(live.Body)
+}
+
+func normalizeURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	u.RawQuery = u.Query().Encode()
+	return u.String()
+}
+
+func indentJSON(s string) string {
+	var b bytes.Buffer
+	if err := json.Indent(&b, []byte(s), "", "  "); err != nil {
+		return s
+	}
+	return b.String() + "\n"
+}
+
+// ScrubQuery redacts the given query parameters.
+func ScrubQuery(keys ...string) Scrubber {
+	return func(in *Interaction) {
+		u, err := url.Parse(in.Request.URL)
+		if err != nil {
+			return
+		}
+
+		q := u.Query()
+		changed := false
+		for _, k := range keys {
+			if _, found := q[k]; found {
+				q.Set(k, Redacted)
+				changed = true
+			}
+		}
+		if changed {
+			u.RawQuery = q.Encode()
+			in.Request.URL = u.String()
+		}
+	}
+}
+
+// ScrubJSON redacts the string values of the given keys anywhere in JSON
+// request and response bodies.
+func ScrubJSON(keys ...string) Scrubber {
+	return func(in *Interaction) {
+		in.Request.Body = scrubJSON(in.Request.Body, keys)
+		in.Response.Body = scrubJSON(in.Response.Body, keys)
+	}
+}
+
+func scrubJSON(body string, keys []string) string {
+	var v any
+	if err := json.Unmarshal([]byte(body), &v); err != nil {
+		return body
+	}
+	if !redact(v, keys) {
+		return body
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return string(b)
+}
+
+func redact(v any, keys []string) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if _, ok := child.(string); ok && slices.Contains(keys, k) {
+				v[k] = Redacted
+				changed = true
+				continue
+			}
+			changed = redact(child, keys) || changed
+		}
+	case []any:
+		for _, child := range v {
+			changed = redact(child, keys) || changed
+		}
+	}
+	return changed
+}
+
+// Replace replaces old with new in URLs and bodies, e.g. to hide user IDs.
+func Replace(old, new string) Scrubber {
+	return func(in *Interaction) {
+		if old == "" {
+			return
+		}
+		in.Request.URL = strings.ReplaceAll(in.Request.URL, old, new)
+		in.Request.Body = strings.ReplaceAll(in.Request.Body, old, new)
+		in.Response.Body = strings.ReplaceAll(in.Response.Body, old, new)
+		for k, v := range in.Response.Headers {
+			in.Response.Headers[k] = strings.ReplaceAll(v, old, new)
+		}
+	}
+}
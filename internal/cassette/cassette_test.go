@@ -0,0 +1,112 @@
+package cassette_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pedrobarco/nomuz/internal/cassette"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, c *http.Client, method, url, body string) (int, string) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret-token")
+
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(b)
+}
+
+func TestRecorder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "abc")
+		switch r.URL.Path {
+		case "/me":
+			w.Write([]byte(`{"id":"alice123","email":"alice@example.com","access_token":"live-token"}`))
+		case "/playlists":
+			w.WriteHeader(http.StatusCreated)
+			body, _ := io.ReadAll(r.Body)
+			w.Write(body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassettes", "test.yaml")
+	scrub := cassette.WithScrubbers(cassette.Replace("alice123", "user"), cassette.Replace(srv.URL, "https://api.example.com"))
+
+	t.Run("record", func(t *testing.T) {
+		assert := assert.New(t)
+
+		r, err := cassette.New(path, cassette.ModeRecord, scrub)
+		require.NoError(t, err)
+
+		status, body := get(t, r.Client(), http.MethodGet, srv.URL+"/me?b=2&a=1&access_token=live-token", "")
+		assert.Equal(http.StatusOK, status)
+		assert.Contains(body, "alice@example.com", "the caller gets the real response")
+
+		status, _ = get(t, r.Client(), http.MethodPost, srv.URL+"/playlists", `{"name":"Mix","owner":"alice123"}`)
+		assert.Equal(http.StatusCreated, status)
+
+		status, _ = get(t, r.Client(), http.MethodGet, srv.URL+"/missing", "")
+		assert.Equal(http.StatusNotFound, status)
+
+		require.NoError(t, r.Stop())
+
+		b, err := os.ReadFile(path)
+		require.NoError(t, err)
+		data := string(b)
+		assert.NotContains(data, "alice123")
+		assert.NotContains(data, "alice@example.com")
+		assert.NotContains(data, "live-token")
+		assert.NotContains(data, "secret-token")
+		assert.NotContains(data, "X-Request-Id")
+		assert.NotContains(data, srv.URL)
+		assert.Contains(data, "https://api.example.com/me?a=1&access_token=REDACTED&b=2")
+		assert.Contains(data, `"id": "user"`)
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		assert := assert.New(t)
+
+		r, err := cassette.New(path, cassette.ModeReplay, scrub)
+		require.NoError(t, err)
+
+		status, body := get(t, r.Client(), http.MethodGet, "https://api.example.com/me?access_token=other&a=1&b=2", "")
+		assert.Equal(http.StatusOK, status)
+		assert.JSONEq(`{"id":"user","email":"REDACTED","access_token":"REDACTED"}`, body)
+
+		status, body = get(t, r.Client(), http.MethodPost, "https://api.example.com/playlists", `{"owner": "user", "name": "Mix"}`)
+		assert.Equal(http.StatusCreated, status)
+		assert.JSONEq(`{"name":"Mix","owner":"user"}`, body)
+
+		status, _ = get(t, r.Client(), http.MethodGet, "https://api.example.com/missing", "")
+		assert.Equal(http.StatusNotFound, status)
+
+		// Every interaction is replayed once.
+		_, err = r.Client().Get("https://api.example.com/missing")
+		assert.ErrorIs(err, cassette.ErrNoInteraction)
+
+		_, err = r.Client().Post("https://api.example.com/playlists", "application/json", strings.NewReader(`{"name":"Other"}`))
+		assert.ErrorIs(err, cassette.ErrNoInteraction)
+	})
+
+	t.Run("missing cassette", func(t *testing.T) {
+		_, err := cassette.New(filepath.Join(t.TempDir(), "missing.yaml"), cassette.ModeReplay)
+		assert.ErrorContains(t, err, "failed to read cassette")
+	})
+}
@@ -0,0 +1,213 @@
+package deezer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/toqueteos/webbrowser"
+	"golang.org/x/oauth2"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	authURL         = "https://connect.deezer.com/oauth/auth.php"
+	tokenURL        = "https://connect.deezer.com/oauth/access_token.php"
+	authRedirectURI = "http://127.0.0.1:8080/callback"
+	authState       = "state-string"
+	// offline_access makes the token never expire.
+	authPerms   = "basic_access,manage_library,delete_library,offline_access"
+	successHTML = `
+	<html>
+	<body>
+		<h2>Authentication Successful!</h2>
+		<p>You can now close this window and return to your terminal.</p>
+		<script>window.close();</script>
+	</body>
+	</html>
+	`
+)
+
+// AuthCodeURL is the page where the user grants nomuz access to their
+// account.
+func AuthCodeURL(appID string) string {
+	q := url.Values{
+		"app_id":       {appID},
+		"redirect_uri": {authRedirectURI},
+		"perms":        {authPerms},
+		"state":        {authState},
+	}
+	return authURL + "?" + q.Encode()
+}
+
+// Exchange trades the authorization code for an access token. Deezer does not
+// follow the OAuth2 token endpoint spec, so this cannot use oauth2.Config.
+func Exchange(ctx context.Context, appID, secret, code string) (*oauth2.Token, error) {
+	q := url.Values{
+		"app_id": {appID},
+		"secret": {secret},
+		"code":   {code},
+		"output": {"json"},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deezer token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		AccessToken string `json:"access_token"`
+		Expires     int    `json:"expires"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("failed to decode deezer token: %w", err)
+	}
+
+	if res.AccessToken == "" {
+		return nil, fmt.Errorf("failed to get deezer token: empty access token")
+	}
+
+	token := &oauth2.Token{AccessToken: res.AccessToken}
+	if res.Expires > 0 {
+		token.Expiry = time.Now().Add(time.Duration(res.Expires) * time.Second)
+	}
+	return token, nil
+}
+
+// Login opens the browser to sign in to Deezer and stores the token once the
+// callback is received.
+func Login(ctx context.Context, appID, secret string) (*oauth2.Token, error) {
+	ch := make(chan *oauth2.Token)
+	server := NewAuthServer(appID, secret, ch)
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("failed to start server: %v", err)
+		}
+	}()
+	defer func() {
+		if err := server.Close(); err != nil {
+			log.Fatalf("failed to close server: %v", err)
+		}
+	}()
+
+	webbrowser.Open(AuthCodeURL(appID))
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case token := <-ch:
+		return token, nil
+	}
+}
+
+func NewAuthServer(appID, secret string, ch chan<- *oauth2.Token) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		if st := r.FormValue("state"); st != authState {
+			http.NotFound(w, r)
+			log.Fatalf("State mismatch: %s != %s\n", st, authState)
+		}
+
+		if reason := r.FormValue("error_reason"); reason != "" {
+			http.Error(w, "Couldn't get token", http.StatusForbidden)
+			log.Fatalf("Couldn't get token: %s", reason)
+		}
+
+		token, err := Exchange(r.Context(), appID, secret, r.FormValue("code"))
+		if err != nil {
+			http.Error(w, "Couldn't get token", http.StatusForbidden)
+			log.Fatalf("Couldn't get token: %v", err)
+		}
+
+		defer func() {
+			if err := SaveAuthToken(token); err != nil {
+				log.Printf("Failed to save deezer auth token: %v", err)
+			}
+		}()
+
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, strings.TrimSpace(successHTML))
+
+		ch <- token
+	})
+
+	return &http.Server{
+		Addr:    ":8080",
+		Handler: mux,
+	}
+}
+
+func getAuthConfigPath() (string, error) {
+	cfg, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home dir: %v", err)
+	}
+	return path.Join(cfg, "nomuz", "deezer_auth.yaml"), nil
+}
+
+type authConfig struct {
+	Token *oauth2.Token `yaml:"token"`
+}
+
+func SaveAuthToken(token *oauth2.Token) error {
+	filePath, err := getAuthConfigPath()
+	if err != nil {
+		return fmt.Errorf("failed to get deezer auth config path: %v", err)
+	}
+
+	if err := os.MkdirAll(path.Dir(filePath), 00755); err != nil {
+		return fmt.Errorf("failed to create deezer auth config dir: %v", err)
+	}
+
+	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 00600)
+	if err != nil {
+		return fmt.Errorf("failed to create deezer auth config file: %v", err)
+	}
+	defer f.Close()
+
+	cfg := &authConfig{Token: token}
+	if err := yaml.NewEncoder(f).Encode(cfg); err != nil {
+		return fmt.Errorf("failed to write deezer auth config file: %v", err)
+	}
+
+	return nil
+}
+
+func GetAuthToken() (*oauth2.Token, error) {
+	path, err := getAuthConfigPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deezer auth config path: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open deezer auth config file: %v", err)
+	}
+	defer f.Close()
+
+	var cfg authConfig
+	if err := yaml.NewDecoder(f).Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse deezer auth config file: %v", err)
+	}
+
+	return cfg.Token, nil
+}
+
+func IsInvalidAuthToken(token *oauth2.Token) bool {
+	return token == nil || !token.Valid()
+}
@@ -0,0 +1,151 @@
+package deezer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	apiURL   = "https://api.deezer.com"
+	pageSize = 100
+)
+
+type client struct {
+	baseURL     string
+	accessToken string
+	http        *http.Client
+}
+
+// apiError is returned in the body of successful HTTP responses.
+type apiError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("deezer error %d (%s): %s", e.Code, e.Type, e.Message)
+}
+
+// errCodeNoData is the error code of lookups that found nothing.
+const errCodeNoData = 800
+
+type user struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type playlist struct {
+	ID            int64  `json:"id"`
+	Title         string `json:"title"`
+	Description   string `json:"description"`
+	Public        bool   `json:"public"`
+	Collaborative bool   `json:"collaborative"`
+	NbTracks      int    `json:"nb_tracks"`
+	Link          string `json:"link"`
+	PictureXL     string `json:"picture_xl"`
+	Creator       user   `json:"creator"`
+}
+
+type track struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	ISRC  string `json:"isrc"`
+	Link  string `json:"link"`
+	// Duration is in seconds.
+	Duration int `json:"duration"`
+	// TimeAdd is when the track was added to a playlist, in Unix seconds.
+	TimeAdd  int64 `json:"time_add"`
+	Explicit bool  `json:"explicit_lyrics"`
+	// Rank is the popularity of the track, up to 1,000,000.
+	Rank   int    `json:"rank"`
+	Artist artist `json:"artist"`
+	// Contributors lists every artist of the track, but is only returned when
+	// fetching a single track.
+	Contributors  []artist `json:"contributors"`
+	TrackPosition int      `json:"track_position"`
+	DiskNumber    int      `json:"disk_number"`
+	ReleaseDate   string   `json:"release_date"`
+	Album         struct {
+		Title string `json:"title"`
+	} `json:"album"`
+}
+
+type artist struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type page[T any] struct {
+	Data  []T `json:"data"`
+	Total int `json:"total"`
+}
+
+// do calls the Deezer API and decodes the response into out, if set.
+func (c *client) do(ctx context.Context, method, path string, query url.Values, out any) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("access_token", c.accessToken)
+
+	u := strings.TrimSuffix(c.baseURL, "/") + path + "?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to call %s %s: status code %d", method, path, resp.StatusCode)
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return fmt.Errorf("failed to decode %s %s response: %w", method, path, err)
+	}
+
+	var e struct {
+		Error *apiError `json:"error"`
+	}
+	if json.Unmarshal(raw, &e) == nil && e.Error != nil {
+		return e.Error
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("failed to decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// getAll fetches every page of a paginated listing.
+func getAll[T any](ctx context.Context, c *client, path string) ([]T, error) {
+	var all []T
+	for {
+		var p page[T]
+		err := c.do(ctx, http.MethodGet, path, url.Values{
+			"index": {fmt.Sprint(len(all))},
+			"limit": {fmt.Sprint(pageSize)},
+		}, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, p.Data...)
+		if len(p.Data) == 0 || len(all) >= p.Total {
+			return all, nil
+		}
+	}
+}
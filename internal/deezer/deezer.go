@@ -0,0 +1,311 @@
+package deezer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+)
+
+type Option func(*options)
+
+type options struct {
+	baseURL     string
+	accessToken string
+}
+
+// WithBaseURL points the connector at another API server.
+func WithBaseURL(u string) Option {
+	return func(o *options) {
+		o.baseURL = u
+	}
+}
+
+// WithAccessToken uses the given token instead of the stored one, skipping
+// the login.
+func WithAccessToken(token string) Option {
+	return func(o *options) {
+		o.accessToken = token
+	}
+}
+
+func NewConnector(appID, secret string, opts ...Option) (*connector, error) {
+	ctx := context.Background()
+
+	o := options{baseURL: apiURL}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.accessToken == "" {
+		token, err := GetAuthToken()
+		if err != nil || IsInvalidAuthToken(token) {
+			token, err = Login(ctx, appID, secret)
+			if err != nil {
+				return nil, err
+			}
+		}
+		o.accessToken = token.AccessToken
+	}
+
+	c := &connector{
+		client: &client{
+			baseURL:     o.baseURL,
+			accessToken: o.accessToken,
+			http:        http.DefaultClient,
+		},
+	}
+
+	if err := c.client.do(ctx, http.MethodGet, "/user/me", nil, &c.user); err != nil {
+		return nil, fmt.Errorf("failed to get current user: %w", err)
+	}
+
+	return c, nil
+}
+
+type connector struct {
+	client *client
+	user   user
+}
+
+var _ domain.Connector = (*connector)(nil)
+
+var Capabilities = domain.Capabilities{
+	CreatePlaylist: true,
+	DeleteTracks:   true,
+	ISRCLookup:     true,
+	Descriptions:   true,
+	Visibility:     true,
+	Collaborative:  true,
+}
+
+func (c *connector) Capabilities() domain.Capabilities {
+	return Capabilities
+}
+
+func (c *connector) CreatePlaylist(ctx context.Context, name string) (*domain.Playlist, error) {
+	var res struct {
+		ID int64 `json:"id"`
+	}
+	err := c.client.do(ctx, http.MethodPost, "/user/me/playlists", url.Values{"title": {name}}, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create playlist: %w", err)
+	}
+
+	return &domain.Playlist{
+		ID:     strconv.FormatInt(res.ID, 10),
+		Name:   name,
+		Owner:  c.user.Name,
+		Owned:  true,
+		Tracks: []domain.Track{},
+	}, nil
+}
+
+func (c *connector) GetPlaylists(ctx context.Context) ([]*domain.Playlist, error) {
+	res, err := getAll[playlist](ctx, c.client, "/user/me/playlists")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlists: %w", err)
+	}
+
+	var pls []*domain.Playlist
+	for _, p := range res {
+		pl := c.toDomainPlaylist(p)
+		pl.Tracks = make([]domain.Track, pl.TrackCount)
+		pls = append(pls, pl)
+	}
+	return pls, nil
+}
+
+func (c *connector) GetPlaylistByName(ctx context.Context, name string) (*domain.Playlist, error) {
+	pls, err := c.GetPlaylists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pl := range pls {
+		if pl.Name == name {
+			return c.withTracks(ctx, pl)
+		}
+	}
+	return nil, nil
+}
+
+func (c *connector) GetPlaylist(ctx context.Context, id string) (*domain.Playlist, error) {
+	var p playlist
+	err := c.client.do(ctx, http.MethodGet, "/playlist/"+url.PathEscape(id), nil, &p)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.Code == errCodeNoData {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlist: %w", err)
+	}
+
+	return c.withTracks(ctx, c.toDomainPlaylist(p))
+}
+
+func (c *connector) withTracks(ctx context.Context, pl *domain.Playlist) (*domain.Playlist, error) {
+	res, err := getAll[track](ctx, c.client, "/playlist/"+pl.ID+"/tracks")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracks for playlist %s: %w", pl.Name, err)
+	}
+
+	pl.Tracks = []domain.Track{}
+	pl.Items = nil
+	for _, t := range res {
+		pl.Tracks = append(pl.Tracks, toDomainTrack(t))
+		pl.Items = append(pl.Items, toDomainItem(t))
+	}
+	return pl, nil
+}
+
+func (c *connector) AddTracksToPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	if len(tracks) == 0 {
+		return nil
+	}
+
+	err := c.client.do(ctx, http.MethodPost, "/playlist/"+id+"/tracks", url.Values{"songs": {trackIDs(tracks)}}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to add tracks to playlist: %w", err)
+	}
+	return nil
+}
+
+func (c *connector) DeleteTracksFromPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	if len(tracks) == 0 {
+		return nil
+	}
+
+	err := c.client.do(ctx, http.MethodDelete, "/playlist/"+id+"/tracks", url.Values{"songs": {trackIDs(tracks)}}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to remove tracks from playlist: %w", err)
+	}
+	return nil
+}
+
+// UpdatePlaylist sets the description and visibility; Deezer has no API to
+// upload playlist pictures.
+func (c *connector) UpdatePlaylist(ctx context.Context, id string, meta domain.PlaylistMetadata) error {
+	err := c.client.do(ctx, http.MethodPost, "/playlist/"+id, url.Values{
+		"description":   {meta.Description},
+		"public":        {strconv.FormatBool(meta.Public)},
+		"collaborative": {strconv.FormatBool(meta.Collaborative)},
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update playlist: %w", err)
+	}
+	return nil
+}
+
+// SearchTrack looks the track up by ISRC first and falls back to an advanced
+// search on title, artist and album.
+func (c *connector) SearchTrack(ctx context.Context, filters domain.TrackFilters) ([]domain.Track, error) {
+	if filters.ISRC != "" {
+		var t track
+		err := c.client.do(ctx, http.MethodGet, "/track/isrc:"+url.PathEscape(filters.ISRC), nil, &t)
+		var apiErr *apiError
+		switch {
+		case errors.As(err, &apiErr) && apiErr.Code == errCodeNoData:
+		case err != nil:
+			return nil, fmt.Errorf("failed to search track: %w", err)
+		case t.ID != 0:
+			return []domain.Track{toDomainTrack(t)}, nil
+		}
+	}
+
+	q := searchQuery(filters)
+	if q == "" {
+		return nil, nil
+	}
+
+	var res page[track]
+	if err := c.client.do(ctx, http.MethodGet, "/search/track", url.Values{"q": {q}}, &res); err != nil {
+		return nil, fmt.Errorf("failed to search track: %w", err)
+	}
+
+	var tracks []domain.Track
+	for _, t := range res.Data {
+		tracks = append(tracks, toDomainTrack(t))
+	}
+	return tracks, nil
+}
+
+func searchQuery(filters domain.TrackFilters) string {
+	if filters.Title == "" {
+		return ""
+	}
+
+	q := fmt.Sprintf("track:%q", filters.Title)
+	if filters.Artist != "" {
+		q += fmt.Sprintf(" artist:%q", filters.Artist)
+	}
+	if filters.Album != "" {
+		q += fmt.Sprintf(" album:%q", filters.Album)
+	}
+	return q
+}
+
+func trackIDs(tracks []domain.Track) string {
+	var ids []string
+	for _, t := range tracks {
+		ids = append(ids, t.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func (c *connector) toDomainPlaylist(p playlist) *domain.Playlist {
+	return &domain.Playlist{
+		ID:            strconv.FormatInt(p.ID, 10),
+		Name:          p.Title,
+		Description:   p.Description,
+		Owner:         p.Creator.Name,
+		Owned:         p.Creator.ID == c.user.ID,
+		Public:        p.Public,
+		Collaborative: p.Collaborative,
+		Image:         p.PictureXL,
+		URL:           p.Link,
+		TrackCount:    p.NbTracks,
+	}
+}
+
+func toDomainTrack(t track) domain.Track {
+	tr := domain.Track{
+		ID:          strconv.FormatInt(t.ID, 10),
+		ISRC:        t.ISRC,
+		Title:       t.Title,
+		Artist:      t.Artist.Name,
+		Album:       t.Album.Title,
+		TrackNumber: t.TrackPosition,
+		DiscNumber:  t.DiskNumber,
+		ReleaseDate: t.ReleaseDate,
+		Explicit:    t.Explicit,
+		Popularity:  min(t.Rank/10000, 100),
+		URL:         t.Link,
+		Duration:    time.Duration(t.Duration) * time.Second,
+	}
+
+	artists := t.Contributors
+	if len(artists) == 0 && t.Artist.Name != "" {
+		artists = []artist{t.Artist}
+	}
+	for _, a := range artists {
+		tr.Artists = append(tr.Artists, domain.Artist{ID: strconv.FormatInt(a.ID, 10), Name: a.Name})
+	}
+	return tr
+}
+
+// toDomainItem reads when a playlist track was added; Deezer does not say by
+// whom.
+func toDomainItem(t track) domain.PlaylistItem {
+	var it domain.PlaylistItem
+	if t.TimeAdd > 0 {
+		it.AddedAt = time.Unix(t.TimeAdd, 0).UTC()
+	}
+	return it
+}
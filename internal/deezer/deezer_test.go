@@ -0,0 +1,129 @@
+package deezer_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/deezer"
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/domain/connectortest"
+	"github.com/pedrobarco/nomuz/internal/fakeservice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var tracks = []fakeservice.Track{
+	{ID: "1", ISRC: "USRC17607839", Title: "Song", Artist: "Band", Album: "Album", Duration: 201 * time.Second},
+	{ID: "2", ISRC: "GBAYE0601498", Title: "Other Song", Artist: "Other Band", Album: "Other Album", Duration: 201 * time.Second, Explicit: true, Popularity: 65},
+}
+
+func newConnector(t *testing.T, srv *fakeservice.Deezer) domain.Connector {
+	c, err := deezer.NewConnector("app", "secret", deezer.WithBaseURL(srv.Server.URL), deezer.WithAccessToken(srv.AccessToken))
+	require.NoError(t, err)
+	return c
+}
+
+func TestConnector(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("invalid token", func(t *testing.T) {
+		srv := fakeservice.NewDeezer(t, fakeservice.Library{})
+		_, err := deezer.NewConnector("app", "secret", deezer.WithBaseURL(srv.Server.URL), deezer.WithAccessToken("wrong"))
+		assert.ErrorContains(t, err, "Invalid OAuth access token")
+	})
+
+	t.Run("playlists", func(t *testing.T) {
+		assert := assert.New(t)
+
+		srv := fakeservice.NewDeezer(t, fakeservice.Library{
+			Tracks:    tracks,
+			Playlists: []fakeservice.Playlist{{ID: "7", Name: "Shared", Owner: "bob"}},
+		})
+		c := newConnector(t, srv)
+
+		pl, err := c.CreatePlaylist(ctx, "Mix")
+		assert.NoError(err)
+		assert.Equal("1", pl.ID)
+
+		var many []domain.Track
+		for range 150 {
+			many = append(many, domain.Track{ID: "1"})
+		}
+		assert.NoError(c.AddTracksToPlaylist(ctx, pl.ID, append(many, domain.Track{ID: "2"})))
+
+		pls, err := c.GetPlaylists(ctx)
+		assert.NoError(err)
+		assert.Len(pls, 2)
+		assert.False(pls[0].Owned)
+		assert.True(pls[1].Owned)
+		assert.Equal(151, pls[1].Size())
+
+		got, err := c.GetPlaylistByName(ctx, "Mix")
+		assert.NoError(err)
+		assert.Len(got.Tracks, 151)
+		assert.Equal(domain.Track{
+			ID:         "2",
+			Title:      "Other Song",
+			Artist:     "Other Band",
+			Artists:    []domain.Artist{{ID: "2", Name: "Other Band"}},
+			Album:      "Other Album",
+			Explicit:   true,
+			Popularity: 65,
+			URL:        "https://www.deezer.com/track/2",
+			Duration:   201 * time.Second,
+		}, got.Tracks[150])
+		assert.WithinDuration(time.Now(), got.Item(150).AddedAt, time.Minute)
+
+		assert.NoError(c.DeleteTracksFromPlaylist(ctx, pl.ID, []domain.Track{{ID: "1"}}))
+		mix, _ := srv.Playlist("Mix")
+		assert.Equal([]string{"2"}, mix.Tracks)
+
+		assert.NoError(c.UpdatePlaylist(ctx, pl.ID, domain.PlaylistMetadata{Description: "Songs for the road", Collaborative: true}))
+		got, err = c.GetPlaylistByName(ctx, "Mix")
+		assert.NoError(err)
+		assert.Equal("Songs for the road", got.Description)
+		assert.True(got.Collaborative)
+		assert.Equal("https://cdn-images.dzcdn.net/images/playlist/1/1000x1000.jpg", got.Image)
+
+		got, err = c.GetPlaylistByName(ctx, "Missing")
+		assert.NoError(err)
+		assert.Nil(got)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		assert := assert.New(t)
+
+		srv := fakeservice.NewDeezer(t, fakeservice.Library{Tracks: tracks})
+		c := newConnector(t, srv)
+
+		res, err := c.SearchTrack(ctx, domain.TrackFilters{ISRC: "GBAYE0601498", Title: "Nothing"})
+		assert.NoError(err)
+		assert.Len(res, 1)
+		assert.Equal("2", res[0].ID)
+		assert.Equal("GBAYE0601498", res[0].ISRC)
+		assert.Empty(srv.Searches())
+
+		res, err = c.SearchTrack(ctx, domain.TrackFilters{ISRC: "UNKNOWN", Title: "Other Song", Artist: "Other Band", Album: "Other Album"})
+		assert.NoError(err)
+		assert.Len(res, 1)
+		assert.Equal("2", res[0].ID)
+		assert.Equal([]string{`track:"Other Song" artist:"Other Band" album:"Other Album"`}, srv.Searches())
+
+		res, err = c.SearchTrack(ctx, domain.TrackFilters{ID: "spotify-id"})
+		assert.NoError(err)
+		assert.Empty(res)
+	})
+}
+
+func TestConformance(t *testing.T) {
+	connectortest.Run(t, connectortest.Harness{
+		New: func(t *testing.T) domain.Connector {
+			return newConnector(t, fakeservice.NewDeezer(t, fakeservice.Library{Tracks: tracks}))
+		},
+		Tracks: []domain.Track{
+			{ID: "1", ISRC: "USRC17607839", Title: "Song", Artist: "Band", Album: "Album"},
+			{ID: "2", Title: "Other Song", Artist: "Other Band", Album: "Other Album"},
+		},
+	})
+}
@@ -0,0 +1,28 @@
+package deezer
+
+import (
+	"context"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/registry"
+)
+
+type Config struct {
+	AppID  string `yaml:"app_id"`
+	Secret string `yaml:"secret"`
+}
+
+func init() {
+	registry.Register(registry.Connector[Config]{
+		Name:         "deezer",
+		Description:  "Deezer account",
+		Capabilities: Capabilities,
+		New: func(ctx context.Context, cfg Config, arg string) (domain.Connector, error) {
+			return NewConnector(cfg.AppID, cfg.Secret)
+		},
+		Login: func(ctx context.Context, cfg Config) error {
+			_, err := Login(ctx, cfg.AppID, cfg.Secret)
+			return err
+		},
+	})
+}
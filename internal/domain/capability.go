@@ -0,0 +1,43 @@
+package domain
+
+import "errors"
+
+// ErrUnsupported is returned by connectors for operations the service does
+// not allow.
+var ErrUnsupported = errors.New("operation not supported")
+
+// Capabilities describes what a connector and the service behind it support,
+// so that plans can be adjusted instead of failing half-way.
+type Capabilities struct {
+	CreatePlaylist bool `json:"create_playlist" yaml:"create_playlist"`
+	DeleteTracks   bool `json:"delete_tracks" yaml:"delete_tracks"`
+	// ISRCLookup is set when SearchTrack finds tracks by ISRC.
+	ISRCLookup bool `json:"isrc_lookup" yaml:"isrc_lookup"`
+	// MaxBatchSize is the most tracks added or removed in one call, or 0
+	// when there is no limit.
+	MaxBatchSize int `json:"max_batch_size" yaml:"max_batch_size"`
+	// Descriptions, Visibility, Collaborative and Images tell which playlist
+	// details the connector reads and UpdatePlaylist can change.
+	Descriptions  bool `json:"descriptions" yaml:"descriptions"`
+	Visibility    bool `json:"visibility" yaml:"visibility"`
+	Collaborative bool `json:"collaborative" yaml:"collaborative"`
+	Images        bool `json:"images" yaml:"images"`
+	// Folders is set when playlists are read with the folder holding them,
+	// and CreateFolders when UpdatePlaylist can move playlists into folders,
+	// creating them as needed.
+	Folders       bool `json:"folders" yaml:"folders"`
+	CreateFolders bool `json:"create_folders" yaml:"create_folders"`
+}
+
+// UpdatesPlaylists reports whether UpdatePlaylist can change any detail.
+func (c Capabilities) UpdatesPlaylists() bool {
+	return c.Descriptions || c.Visibility || c.Collaborative || c.Images || c.CreateFolders
+}
+
+// BatchSize caps n to the connector's maximum batch size.
+func (c Capabilities) BatchSize(n int) int {
+	if c.MaxBatchSize > 0 && c.MaxBatchSize < n {
+		return c.MaxBatchSize
+	}
+	return n
+}
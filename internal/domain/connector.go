@@ -1,16 +1,59 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type TrackFilters struct {
-	ID string
+	ID       string
+	ISRC     string
+	MBID     string
+	Title    string
+	Artist   string
+	Album    string
+	Duration time.Duration
+}
+
+// PlaylistMetadata holds the details of a playlist that can be updated.
+type PlaylistMetadata struct {
+	Description   string
+	Public        bool
+	Collaborative bool
+	// Image is the URL of a cover image to upload; empty leaves the cover
+	// as is.
+	Image string
+	// Folder is the path of folders the playlist belongs in, from the top
+	// level.
+	Folder []string
 }
 
 type Connector interface {
 	CreatePlaylist(ctx context.Context, name string) (*Playlist, error)
 	GetPlaylists(ctx context.Context) ([]*Playlist, error)
 	GetPlaylistByName(ctx context.Context, name string) (*Playlist, error)
+	// GetPlaylist returns the playlist with the given ID and its tracks, or
+	// nil when there is none. Unlike GetPlaylistByName it tells apart
+	// playlists sharing a name.
+	GetPlaylist(ctx context.Context, id string) (*Playlist, error)
 	AddTracksToPlaylist(ctx context.Context, id string, tracks []Track) error
 	DeleteTracksFromPlaylist(ctx context.Context, id string, tracks []Track) error
+	// UpdatePlaylist sets the details the connector supports, as reported by
+	// its capabilities, and ignores the others.
+	UpdatePlaylist(ctx context.Context, id string, meta PlaylistMetadata) error
 	SearchTrack(ctx context.Context, filters TrackFilters) ([]Track, error)
+	Capabilities() Capabilities
+}
+
+// SavedTracksReader is implemented by connectors that read the tracks the
+// user liked or saved outside of playlists, such as Spotify's liked songs.
+type SavedTracksReader interface {
+	// GetSavedTracks returns the saved tracks with when they were saved.
+	GetSavedTracks(ctx context.Context) ([]Track, []PlaylistItem, error)
+}
+
+// GenreReader is implemented by connectors that know the genres of artists.
+type GenreReader interface {
+	// GetArtistGenres maps the given artist IDs to their genres.
+	GetArtistGenres(ctx context.Context, ids []string) (map[string][]string, error)
 }
@@ -3,18 +3,28 @@ package domain_test
 import (
 	"context"
 	"fmt"
+	"testing"
 
 	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/domain/connectortest"
 )
 
 type mockConnector struct {
 	Playlists []*domain.Playlist
 	Tracks    []domain.Track
+	// FailPlaylists makes changes to playlists with the given IDs or names fail.
+	FailPlaylists map[string]error
+	// Caps overrides the default capabilities, which support everything.
+	Caps *domain.Capabilities
 }
 
 var _ domain.Connector = (*mockConnector)(nil)
 
 func (m *mockConnector) CreatePlaylist(ctx context.Context, name string) (*domain.Playlist, error) {
+	if err, found := m.FailPlaylists[name]; found {
+		return nil, err
+	}
+
 	pl := &domain.Playlist{
 		ID:     fmt.Sprintf("pl%d", len(m.Playlists)+1),
 		Name:   name,
@@ -28,6 +38,32 @@ func (m *mockConnector) GetPlaylists(ctx context.Context) ([]*domain.Playlist, e
 	return m.Playlists, nil
 }
 
+// listingConnector lists playlists with placeholder tracks, like remote
+// services do, and only returns the tracks when a playlist is fetched by ID.
+type listingConnector struct {
+	mockConnector
+}
+
+func (l *listingConnector) GetPlaylists(ctx context.Context) ([]*domain.Playlist, error) {
+	var pls []*domain.Playlist
+	for _, pl := range l.Playlists {
+		listed := *pl
+		listed.TrackCount = len(pl.Tracks)
+		listed.Tracks = make([]domain.Track, len(pl.Tracks))
+		pls = append(pls, &listed)
+	}
+	return pls, nil
+}
+
+func (m *mockConnector) GetPlaylist(ctx context.Context, id string) (*domain.Playlist, error) {
+	for _, pl := range m.Playlists {
+		if pl.ID == id {
+			return pl, nil
+		}
+	}
+	return nil, nil
+}
+
 func (m *mockConnector) GetPlaylistByName(ctx context.Context, name string) (*domain.Playlist, error) {
 	for _, pl := range m.Playlists {
 		if pl.Name == name {
@@ -39,14 +75,33 @@ func (m *mockConnector) GetPlaylistByName(ctx context.Context, name string) (*do
 
 func (m *mockConnector) SearchTrack(ctx context.Context, filters domain.TrackFilters) ([]domain.Track, error) {
 	for _, tr := range m.Tracks {
-		if tr.ID == filters.ID {
+		if tr.ID == filters.ID || (filters.ISRC != "" && tr.ISRC == filters.ISRC) {
 			return []domain.Track{tr}, nil
 		}
 	}
 	return nil, nil
 }
 
+func (m *mockConnector) Capabilities() domain.Capabilities {
+	if m.Caps != nil {
+		return *m.Caps
+	}
+	return domain.Capabilities{
+		CreatePlaylist: true,
+		DeleteTracks:   true,
+		ISRCLookup:     true,
+		Descriptions:   true,
+		Visibility:     true,
+		Collaborative:  true,
+		Images:         true,
+	}
+}
+
 func (m *mockConnector) AddTracksToPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	if err, found := m.FailPlaylists[id]; found {
+		return err
+	}
+
 	for _, pl := range m.Playlists {
 		if pl.ID == id {
 			pl.Tracks = append(pl.Tracks, tracks...)
@@ -57,6 +112,10 @@ func (m *mockConnector) AddTracksToPlaylist(ctx context.Context, id string, trac
 }
 
 func (m *mockConnector) DeleteTracksFromPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	if err, found := m.FailPlaylists[id]; found {
+		return err
+	}
+
 	for _, pl := range m.Playlists {
 		if pl.ID == id {
 			trackMap := make(map[string]struct{})
@@ -77,3 +136,48 @@ func (m *mockConnector) DeleteTracksFromPlaylist(ctx context.Context, id string,
 	}
 	return fmt.Errorf("playlist with id %s not found", id)
 }
+
+func (m *mockConnector) UpdatePlaylist(ctx context.Context, id string, meta domain.PlaylistMetadata) error {
+	if err, found := m.FailPlaylists[id]; found {
+		return err
+	}
+
+	for _, pl := range m.Playlists {
+		if pl.ID == id {
+			pl.Description = meta.Description
+			pl.Public = meta.Public
+			pl.Collaborative = meta.Collaborative
+			pl.Folder = meta.Folder
+			if meta.Image != "" {
+				pl.Image = meta.Image
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("playlist with id %s not found", id)
+}
+
+func TestMockConnector(t *testing.T) {
+	tracks := []domain.Track{
+		{ID: "1", ISRC: "USRC17607839", Title: "Song", Artist: "Band"},
+		{ID: "2", ISRC: "GBAYE0601498", Title: "Other Song", Artist: "Other Band"},
+	}
+
+	t.Run("mock", func(t *testing.T) {
+		connectortest.Run(t, connectortest.Harness{
+			New: func(t *testing.T) domain.Connector {
+				return &mockConnector{Tracks: tracks}
+			},
+			Tracks: tracks,
+		})
+	})
+
+	t.Run("listing", func(t *testing.T) {
+		connectortest.Run(t, connectortest.Harness{
+			New: func(t *testing.T) domain.Connector {
+				return &listingConnector{mockConnector{Tracks: tracks}}
+			},
+			Tracks: tracks,
+		})
+	})
+}
@@ -0,0 +1,283 @@
+// Package connectortest checks that a domain.Connector behaves the way the
+// sync engine expects. Connector packages run it against their fake servers:
+//
+//	connectortest.Run(t, connectortest.Harness{
+//		New:    func(t *testing.T) domain.Connector { ... },
+//		Tracks: tracks,
+//	})
+package connectortest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Harness provides the connector under test.
+type Harness struct {
+	// New returns a connector backed by an empty library in which Tracks can
+	// be found. It is called once per test case.
+	New func(t *testing.T) domain.Connector
+	// Tracks are found by SearchTrack through their metadata; at least two
+	// are needed.
+	Tracks []domain.Track
+	// CreatesOnFirstAdd is set for services that cannot hold empty
+	// playlists, where created playlists only show up once tracks are added.
+	CreatesOnFirstAdd bool
+}
+
+const playlistName = "nomuz conformance"
+
+// Run runs the conformance suite.
+func Run(t *testing.T, h Harness) {
+	require.GreaterOrEqual(t, len(h.Tracks), 2, "the harness needs at least two tracks")
+
+	cases := []struct {
+		name string
+		run  func(t *testing.T, h Harness, c domain.Connector)
+	}{
+		{"capabilities", testCapabilities},
+		{"missing playlist", testMissingPlaylist},
+		{"create playlist", testCreatePlaylist},
+		{"search track", testSearchTrack},
+		{"add tracks", testAddTracks},
+		{"listed tracks", testListedTracks},
+		{"add tracks again", testAddTracksAgain},
+		{"delete tracks", testDeleteTracks},
+		{"update playlist", testUpdatePlaylist},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.run(t, h, h.New(t))
+		})
+	}
+}
+
+func testCapabilities(t *testing.T, h Harness, c domain.Connector) {
+	caps := c.Capabilities()
+	assert.GreaterOrEqual(t, caps.MaxBatchSize, 0)
+	assert.Equal(t, 1, caps.BatchSize(1))
+}
+
+func testMissingPlaylist(t *testing.T, h Harness, c domain.Connector) {
+	pl, err := c.GetPlaylistByName(context.Background(), playlistName+" missing")
+	assert.NoError(t, err, "a missing playlist is not an error")
+	assert.Nil(t, pl, "a missing playlist is nil")
+}
+
+func testCreatePlaylist(t *testing.T, h Harness, c domain.Connector) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	pl, err := c.CreatePlaylist(ctx, playlistName)
+	if !c.Capabilities().CreatePlaylist {
+		assert.ErrorIs(err, domain.ErrUnsupported)
+		return
+	}
+	require.NoError(t, err)
+	require.NotNil(t, pl)
+	assert.NotEmpty(pl.ID)
+	assert.Equal(playlistName, pl.Name)
+	assert.Empty(pl.Tracks)
+
+	if h.CreatesOnFirstAdd {
+		return
+	}
+
+	got, err := c.GetPlaylistByName(ctx, playlistName)
+	require.NoError(t, err)
+	require.NotNil(t, got, "created playlists can be fetched by name")
+	assert.Equal(pl.ID, got.ID)
+	assert.Empty(got.Tracks)
+
+	pls, err := c.GetPlaylists(ctx)
+	require.NoError(t, err)
+	assert.NotNil(findPlaylist(pls, pl.ID), "created playlists are listed")
+}
+
+func testSearchTrack(t *testing.T, h Harness, c domain.Connector) {
+	for _, tr := range h.Tracks {
+		res := search(t, c, tr)
+		require.NotEmpty(t, res, "no match for %q by %q", tr.Title, tr.Artist)
+		assert.Equal(t, tr.ID, res[0].ID, "best match for %q by %q", tr.Title, tr.Artist)
+	}
+}
+
+func testAddTracks(t *testing.T, h Harness, c domain.Connector) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	pl, tracks := createWithTracks(t, h, c)
+
+	got, err := c.GetPlaylistByName(ctx, playlistName)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(ids(tracks), ids(got.Tracks), "tracks are returned in the order they were added")
+	assert.True(got.HasTracks(), "fetched playlists hold the actual tracks")
+	assert.Equal(len(tracks), got.Size())
+	if !h.CreatesOnFirstAdd {
+		assert.Equal(pl.ID, got.ID)
+	}
+
+	byID, err := c.GetPlaylist(ctx, got.ID)
+	require.NoError(t, err)
+	require.NotNil(t, byID, "playlists can be fetched by ID")
+	assert.Equal(got.ID, byID.ID)
+	assert.Equal(playlistName, byID.Name)
+	assert.Equal(ids(tracks), ids(byID.Tracks))
+	assert.Equal(len(tracks), byID.Size())
+}
+
+func testListedTracks(t *testing.T, h Harness, c domain.Connector) {
+	assert := assert.New(t)
+
+	_, tracks := createWithTracks(t, h, c)
+
+	pls, err := c.GetPlaylists(context.Background())
+	require.NoError(t, err)
+
+	var listed *domain.Playlist
+	for _, pl := range pls {
+		if pl.Name == playlistName {
+			listed = pl
+		}
+	}
+	require.NotNil(t, listed, "playlists with tracks are listed")
+	assert.Equal(len(tracks), listed.Size())
+	assert.Len(listed.Tracks, len(tracks), "listed playlists hold the tracks or as many placeholders")
+	if listed.HasTracks() {
+		assert.Equal(ids(tracks), ids(listed.Tracks))
+	}
+}
+
+// testAddTracksAgain checks that retrying an add, as resuming a sync does,
+// does not fail and loses no tracks.
+func testAddTracksAgain(t *testing.T, h Harness, c domain.Connector) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	pl, tracks := createWithTracks(t, h, c)
+	id := pl.ID
+
+	assert.NoError(c.AddTracksToPlaylist(ctx, id, nil), "adding no tracks is a no-op")
+	got := getPlaylist(t, c)
+	assert.Equal(ids(tracks), ids(got.Tracks))
+
+	assert.NoError(c.AddTracksToPlaylist(ctx, id, tracks[:1]))
+	got = getPlaylist(t, c)
+	assert.Subset(ids(got.Tracks), ids(tracks))
+}
+
+func testDeleteTracks(t *testing.T, h Harness, c domain.Connector) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	pl, tracks := createWithTracks(t, h, c)
+	id := pl.ID
+
+	err := c.DeleteTracksFromPlaylist(ctx, id, tracks[:1])
+	if !c.Capabilities().DeleteTracks {
+		assert.ErrorIs(err, domain.ErrUnsupported)
+		return
+	}
+	require.NoError(t, err)
+
+	got := getPlaylist(t, c)
+	assert.Equal(ids(tracks[1:]), ids(got.Tracks))
+
+	assert.NoError(c.DeleteTracksFromPlaylist(ctx, id, nil), "deleting no tracks is a no-op")
+	assert.NoError(c.DeleteTracksFromPlaylist(ctx, id, tracks[:1]), "deleting absent tracks is a no-op")
+	got = getPlaylist(t, c)
+	assert.Equal(ids(tracks[1:]), ids(got.Tracks))
+}
+
+func testUpdatePlaylist(t *testing.T, h Harness, c domain.Connector) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	caps := c.Capabilities()
+
+	pl, tracks := createWithTracks(t, h, c)
+
+	meta := domain.PlaylistMetadata{Description: "Songs for the road", Public: true, Folder: []string{"Road"}}
+	err := c.UpdatePlaylist(ctx, pl.ID, meta)
+	if !caps.UpdatesPlaylists() {
+		assert.ErrorIs(err, domain.ErrUnsupported)
+		return
+	}
+	require.NoError(t, err)
+
+	got := getPlaylist(t, c)
+	if caps.Descriptions {
+		assert.Equal(meta.Description, got.Description)
+	}
+	if caps.Visibility {
+		assert.True(got.Public)
+	}
+	if caps.CreateFolders {
+		assert.Equal(meta.Folder, got.Folder)
+	}
+	assert.Equal(ids(tracks), ids(got.Tracks), "updates keep the tracks")
+}
+
+// createWithTracks creates the test playlist and adds the harness tracks as
+// found by the connector. Later changes go through the ID returned on
+// creation, as sync plans do.
+func createWithTracks(t *testing.T, h Harness, c domain.Connector) (*domain.Playlist, []domain.Track) {
+	ctx := context.Background()
+
+	if !c.Capabilities().CreatePlaylist {
+		t.Skip("the connector cannot create playlists")
+	}
+
+	pl, err := c.CreatePlaylist(ctx, playlistName)
+	require.NoError(t, err)
+
+	var tracks []domain.Track
+	for _, tr := range h.Tracks {
+		res := search(t, c, tr)
+		require.NotEmpty(t, res, "no match for %q by %q", tr.Title, tr.Artist)
+		tracks = append(tracks, res[0])
+	}
+
+	require.NoError(t, c.AddTracksToPlaylist(ctx, pl.ID, tracks))
+	return pl, tracks
+}
+
+func getPlaylist(t *testing.T, c domain.Connector) *domain.Playlist {
+	pl, err := c.GetPlaylistByName(context.Background(), playlistName)
+	require.NoError(t, err)
+	require.NotNil(t, pl)
+	return pl
+}
+
+func search(t *testing.T, c domain.Connector, tr domain.Track) []domain.Track {
+	f := tr.Filters()
+	// IDs belong to the service, matching goes through the metadata.
+	f.ID = ""
+
+	res, err := c.SearchTrack(context.Background(), f)
+	require.NoError(t, err, fmt.Sprintf("search for %q by %q", tr.Title, tr.Artist))
+	return res
+}
+
+func findPlaylist(pls []*domain.Playlist, id string) *domain.Playlist {
+	for _, pl := range pls {
+		if pl.ID == id {
+			return pl
+		}
+	}
+	return nil
+}
+
+func ids(tracks []domain.Track) []string {
+	res := []string{}
+	for _, tr := range tracks {
+		res = append(res, tr.ID)
+	}
+	return res
+}
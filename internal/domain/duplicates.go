@@ -0,0 +1,211 @@
+package domain
+
+import "strings"
+
+type DuplicateKind string
+
+const (
+	// DuplicateExact is the same track ID appearing more than once.
+	DuplicateExact DuplicateKind = "exact"
+	// DuplicateRecording is the same recording under different track IDs,
+	// e.g. the single and the album version of a song.
+	DuplicateRecording DuplicateKind = "recording"
+)
+
+type DuplicateGroup struct {
+	Kind DuplicateKind
+	// Tracks holds every copy in playlist order; the first one is kept.
+	Tracks []Track
+	// Positions are the indexes of Tracks in the analyzed slice.
+	Positions []int
+}
+
+func (g DuplicateGroup) Kept() Track {
+	return g.Tracks[0]
+}
+
+func (g DuplicateGroup) Extra() []Track {
+	return g.Tracks[1:]
+}
+
+// FindDuplicates reports exact-ID duplicates and tracks sharing a recording,
+// either by ISRC or by normalized title and artist.
+func FindDuplicates(tracks []Track) []DuplicateGroup {
+	var groups []DuplicateGroup
+
+	first := make(map[string]int)
+	groupOf := make(map[string]int)
+	var unique []int
+	for i, tr := range tracks {
+		if tr.ID == "" {
+			continue
+		}
+
+		j, found := first[tr.ID]
+		if !found {
+			first[tr.ID] = i
+			unique = append(unique, i)
+			continue
+		}
+
+		g, found := groupOf[tr.ID]
+		if !found {
+			groups = append(groups, DuplicateGroup{
+				Kind:      DuplicateExact,
+				Tracks:    []Track{tracks[j]},
+				Positions: []int{j},
+			})
+			g = len(groups) - 1
+			groupOf[tr.ID] = g
+		}
+		groups[g].Tracks = append(groups[g].Tracks, tr)
+		groups[g].Positions = append(groups[g].Positions, i)
+	}
+
+	parent := make(map[int]int)
+	var find func(i int) int
+	find = func(i int) int {
+		p, found := parent[i]
+		if !found || p == i {
+			return i
+		}
+		root := find(p)
+		parent[i] = root
+		return root
+	}
+	union := func(a, b int) {
+		ra, rb := find(a), find(b)
+		if ra == rb {
+			return
+		}
+		parent[max(ra, rb)] = min(ra, rb)
+	}
+
+	byISRC := make(map[string]int)
+	byMeta := make(map[string]int)
+	for _, i := range unique {
+		tr := tracks[i]
+		if isrc := strings.ToUpper(strings.TrimSpace(tr.ISRC)); isrc != "" {
+			if j, found := byISRC[isrc]; found {
+				union(i, j)
+			} else {
+				byISRC[isrc] = i
+			}
+		}
+		if key := tr.RecordingKey(); key != "" {
+			if j, found := byMeta[key]; found {
+				union(i, j)
+			} else {
+				byMeta[key] = i
+			}
+		}
+	}
+
+	recordings := make(map[int][]int)
+	var roots []int
+	for _, i := range unique {
+		root := find(i)
+		if _, found := recordings[root]; !found {
+			roots = append(roots, root)
+		}
+		recordings[root] = append(recordings[root], i)
+	}
+
+	for _, root := range roots {
+		positions := recordings[root]
+		if len(positions) < 2 {
+			continue
+		}
+
+		g := DuplicateGroup{
+			Kind:      DuplicateRecording,
+			Positions: positions,
+		}
+		for _, i := range positions {
+			g.Tracks = append(g.Tracks, tracks[i])
+		}
+		groups = append(groups, g)
+	}
+
+	return groups
+}
+
+// DuplicateChanges returns the changes that leave a single copy of every
+// recording in the playlist.
+func DuplicateChanges(tracks []Track) PlaylistTracksChangelog {
+	var cl PlaylistTracksChangelog
+	dedupeChangelog(tracks, &cl, true)
+	return cl
+}
+
+// dedupeChangelog rewrites the changelog so that applying it to dst leaves no
+// duplicates: duplicated additions are dropped and duplicated tracks already
+// in dst are removed. Connectors delete every occurrence of a track ID, so an
+// exact duplicate is removed and a single copy is added back at the end,
+// unless canDelete is false: the removal then never happens, so adding the
+// copy back would only leave one more duplicate.
+func dedupeChangelog(dst []Track, cl *PlaylistTracksChangelog, canDelete bool) {
+	removed := make(map[string]struct{})
+	for _, tr := range cl.Removed {
+		removed[tr.ID] = struct{}{}
+	}
+
+	var result []Track
+	for _, tr := range dst {
+		if _, found := removed[tr.ID]; !found {
+			result = append(result, tr)
+		}
+	}
+	kept := len(result)
+	result = append(result, cl.Added...)
+
+	groups := FindDuplicates(result)
+
+	dropped := make(map[int]struct{})
+	removeIDs := make(map[string]struct{})
+	for _, g := range groups {
+		if g.Kind != DuplicateRecording {
+			continue
+		}
+		for _, pos := range g.Positions[1:] {
+			if pos >= kept {
+				dropped[pos] = struct{}{}
+				continue
+			}
+			removeIDs[result[pos].ID] = struct{}{}
+			cl.Removed = append(cl.Removed, result[pos])
+		}
+	}
+
+	var readded []Track
+	for _, g := range groups {
+		if g.Kind != DuplicateExact {
+			continue
+		}
+
+		var readd bool
+		for _, pos := range g.Positions[1:] {
+			if pos >= kept {
+				dropped[pos] = struct{}{}
+				continue
+			}
+			readd = true
+		}
+
+		tr := g.Kept()
+		if _, found := removeIDs[tr.ID]; readd && !found {
+			cl.Removed = append(cl.Removed, tr)
+			if canDelete {
+				readded = append(readded, tr)
+			}
+		}
+	}
+
+	var added []Track
+	for i, tr := range cl.Added {
+		if _, found := dropped[kept+i]; !found {
+			added = append(added, tr)
+		}
+	}
+	cl.Added = append(added, readded...)
+}
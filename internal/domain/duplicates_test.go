@@ -0,0 +1,56 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	assert := assert.New(t)
+
+	tracks := []domain.Track{
+		{ID: "t1", ISRC: "USRC17607839", Title: "Song", Artist: "Band", Album: "Single"},
+		{ID: "t2", Title: "Other", Artist: "Band"},
+		{ID: "t1", ISRC: "USRC17607839", Title: "Song", Artist: "Band", Album: "Single"},
+		{ID: "t3", ISRC: "usrc17607839", Title: "Song (Remastered)", Artist: "Band", Album: "Best Of"},
+		{ID: "t4", Title: "song", Artist: " band ", Album: "Album"},
+		{ID: "t5", Title: "Song", Artist: "Someone Else"},
+	}
+
+	groups := domain.FindDuplicates(tracks)
+	assert.Len(groups, 2)
+
+	assert.Equal(domain.DuplicateExact, groups[0].Kind)
+	assert.Equal([]int{0, 2}, groups[0].Positions)
+
+	assert.Equal(domain.DuplicateRecording, groups[1].Kind)
+	assert.Equal([]int{0, 3, 4}, groups[1].Positions)
+	assert.Equal("t1", groups[1].Kept().ID)
+	assert.Len(groups[1].Extra(), 2)
+}
+
+func TestDuplicateChanges(t *testing.T) {
+	assert := assert.New(t)
+
+	tracks := []domain.Track{
+		{ID: "t1", Title: "Song", Artist: "Band"},
+		{ID: "t2", Title: "Other", Artist: "Band"},
+		{ID: "t1", Title: "Song", Artist: "Band"},
+		{ID: "t3", Title: "Song", Artist: "Band"},
+		{ID: "t3", Title: "Song", Artist: "Band"},
+	}
+
+	cl := domain.DuplicateChanges(tracks)
+	assert.Equal([]string{"t3", "t1"}, trackIDs(cl.Removed))
+	assert.Equal([]string{"t1"}, trackIDs(cl.Added))
+}
+
+func trackIDs(tracks []domain.Track) []string {
+	var ids []string
+	for _, tr := range tracks {
+		ids = append(ids, tr.ID)
+	}
+	return ids
+}
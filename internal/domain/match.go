@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// durationTolerance is how far apart two durations of the same recording can
+// be, as services round and trim silence differently.
+const durationTolerance = 3 * time.Second
+
+// RankMatches orders search candidates by how well they match the filters:
+// same MusicBrainz ID, then same ISRC, then same title and artist, preferring
+// the same album and a similar duration. Candidates matching none of these
+// are dropped, which suits services whose search returns loosely related
+// results.
+func RankMatches(candidates []Track, filters TrackFilters) []Track {
+	key := Track{Title: filters.Title, Artist: filters.Artist}.RecordingKey()
+	album := NormalizeText(filters.Album)
+
+	var byMBID, byISRC, byKey []Track
+	scores := make(map[string]int)
+	for _, t := range candidates {
+		switch {
+		case filters.MBID != "" && strings.EqualFold(t.MBID, filters.MBID):
+			byMBID = append(byMBID, t)
+		case filters.ISRC != "" && strings.EqualFold(t.ISRC, filters.ISRC):
+			byISRC = append(byISRC, t)
+		case key != "" && t.RecordingKey() == key:
+			var score int
+			if album != "" && NormalizeText(t.Album) == album {
+				score += 2
+			}
+			if filters.Duration > 0 && t.Duration > 0 && (t.Duration-filters.Duration).Abs() <= durationTolerance {
+				score++
+			}
+			scores[t.ID] = score
+			byKey = append(byKey, t)
+		}
+	}
+
+	slices.SortStableFunc(byKey, func(a, b Track) int {
+		return cmp.Compare(scores[b.ID], scores[a.ID])
+	})
+
+	return append(append(byMBID, byISRC...), byKey...)
+}
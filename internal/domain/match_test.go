@@ -0,0 +1,35 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankMatches(t *testing.T) {
+	assert := assert.New(t)
+
+	candidates := []domain.Track{
+		{ID: "live", Title: "Song", Artist: "Band", Album: "Live"},
+		{ID: "album", Title: "song", Artist: "BAND", Album: "Album"},
+		{ID: "isrc", ISRC: "ISRC1", Title: "Song (Remastered)", Artist: "Band"},
+		{ID: "mbid", MBID: "mbid1", Title: "Song", Artist: "Band", Album: "Live"},
+		{ID: "other", Title: "Other Song", Artist: "Band"},
+	}
+
+	res := domain.RankMatches(candidates, domain.TrackFilters{MBID: "MBID1", ISRC: "isrc1", Title: "Song", Artist: "Band", Album: "Album"})
+	assert.Equal([]string{"mbid", "isrc", "album", "live"}, trackIDs(res))
+
+	res = domain.RankMatches([]domain.Track{
+		{ID: "long", Title: "Song", Artist: "Band", Duration: 300 * time.Second},
+		{ID: "other-album", Title: "Song", Artist: "Band", Album: "Live", Duration: 201 * time.Second},
+		{ID: "close", Title: "Song", Artist: "Band", Duration: 202 * time.Second},
+		{ID: "unknown", Title: "Song", Artist: "Band"},
+	}, domain.TrackFilters{Title: "Song", Artist: "Band", Album: "Album", Duration: 200 * time.Second})
+	assert.Equal([]string{"other-album", "close", "long", "unknown"}, trackIDs(res))
+
+	res = domain.RankMatches(candidates, domain.TrackFilters{Title: "Nothing"})
+	assert.Empty(res)
+}
@@ -1,14 +1,204 @@
 package domain
 
+import (
+	"strings"
+	"time"
+)
+
 type Playlist struct {
-	ID     string
-	Name   string
-	Tracks []Track
+	ID            string
+	Name          string
+	Description   string
+	Owner         string
+	Owned         bool
+	Public        bool
+	Collaborative bool
+	// Image is the URL of the cover image.
+	Image string
+	// Folder is the path of folders holding the playlist, from the top
+	// level; it is empty for playlists outside any folder.
+	Folder []string
+	URL    string
+	// TrackCount is the number of tracks reported by the service, which is
+	// known even when Tracks has not been fetched.
+	TrackCount int
+	Tracks     []Track
+	// Items holds how each track in Tracks came to be in the playlist, at
+	// the same index, for connectors that know it.
+	Items []PlaylistItem
+}
+
+// PlaylistItem is the entry of a track in a playlist, as opposed to the track
+// itself.
+type PlaylistItem struct {
+	// AddedAt is when the track was added, and AddedBy the user who added
+	// it, if known.
+	AddedAt time.Time
+	AddedBy string
+}
+
+// Item returns the entry of the i-th track, which is empty when unknown.
+func (p Playlist) Item(i int) PlaylistItem {
+	if i < len(p.Items) {
+		return p.Items[i]
+	}
+	return PlaylistItem{}
+}
+
+func (p Playlist) Size() int {
+	if p.TrackCount > 0 {
+		return p.TrackCount
+	}
+	return len(p.Tracks)
+}
+
+// HasTracks reports whether Tracks holds the actual tracks, as opposed to the
+// empty placeholders connectors return when listing playlists.
+func (p Playlist) HasTracks() bool {
+	for _, t := range p.Tracks {
+		if t.ID == "" {
+			return false
+		}
+	}
+	return true
 }
 
+// Metadata returns the details of the playlist other than its name and
+// tracks.
+func (p Playlist) Metadata() PlaylistMetadata {
+	return PlaylistMetadata{
+		Description:   p.Description,
+		Public:        p.Public,
+		Collaborative: p.Collaborative,
+		Image:         p.Image,
+		Folder:        p.Folder,
+	}
+}
+
+// FolderSeparator separates folder names in a folder path.
+const FolderSeparator = " / "
+
+// FolderPath joins folder names, e.g. "Moods / Chill".
+func FolderPath(folder []string) string {
+	return strings.Join(folder, FolderSeparator)
+}
+
+func (p Playlist) FolderPath() string {
+	return FolderPath(p.Folder)
+}
+
+func (p Playlist) Visibility() string {
+	if p.Public {
+		return "public"
+	}
+	return "private"
+}
+
+// Artist is an artist credited on a track. ID is the artist's ID on the
+// service the track comes from, if it has one.
+type Artist struct {
+	ID   string
+	Name string
+}
+
+// TrackKind tells regular tracks apart from the other items a playlist can
+// hold.
+type TrackKind string
+
+const (
+	TrackKindTrack   TrackKind = ""
+	TrackKindEpisode TrackKind = "episode"
+	TrackKindLocal   TrackKind = "local"
+)
+
 type Track struct {
-	ID     string
-	Title  string
+	ID   string
+	Kind TrackKind
+	ISRC string
+	// MBID is the MusicBrainz recording ID.
+	MBID  string
+	Title string
+	// Artist is the main artist, the first of Artists.
 	Artist string
-	Album  string
+	// Artists are all the artists credited on the track, in order.
+	Artists     []Artist
+	Album       string
+	AlbumArtist string
+	// UPC is the barcode of the album.
+	UPC string
+	// TrackNumber and DiscNumber are the position of the track on its album,
+	// starting at 1, or zero when unknown.
+	TrackNumber int
+	DiscNumber  int
+	// ReleaseDate is the album's release date as reported by the service,
+	// with the precision it knows it: "2006", "2006-03" or "2006-03-22".
+	ReleaseDate string
+	Explicit    bool
+	// Popularity goes from 0 to 100 and is zero when unknown.
+	Popularity int
+	// Unavailable is set for tracks that cannot be played, e.g. in the
+	// user's country.
+	Unavailable bool
+	URL         string
+	// Duration is zero when unknown.
+	Duration time.Duration
+}
+
+// ArtistNames returns the names of all the artists credited on the track,
+// falling back to Artist for tracks that only know their main artist.
+func (t Track) ArtistNames() []string {
+	if len(t.Artists) == 0 {
+		if t.Artist == "" {
+			return nil
+		}
+		return []string{t.Artist}
+	}
+
+	names := make([]string, len(t.Artists))
+	for i, a := range t.Artists {
+		names[i] = a.Name
+	}
+	return names
+}
+
+// SkipReason explains why the track cannot be synced: episodes, local files
+// and unavailable tracks have nothing to look up on another service. It is
+// empty for tracks that can be synced.
+func (t Track) SkipReason() string {
+	switch {
+	case t.Kind == TrackKindEpisode:
+		return "podcast episode"
+	case t.Kind == TrackKindLocal:
+		return "local file"
+	case t.Unavailable:
+		return "unavailable"
+	default:
+		return ""
+	}
+}
+
+func (t Track) Filters() TrackFilters {
+	return TrackFilters{
+		ID:       t.ID,
+		ISRC:     t.ISRC,
+		MBID:     t.MBID,
+		Title:    t.Title,
+		Artist:   t.Artist,
+		Album:    t.Album,
+		Duration: t.Duration,
+	}
+}
+
+// RecordingKey identifies a recording by its normalized title and artist,
+// regardless of the service or release it comes from.
+func (t Track) RecordingKey() string {
+	title := NormalizeText(t.Title)
+	if title == "" {
+		return ""
+	}
+	return title + "\x00" + NormalizeText(t.Artist)
+}
+
+func NormalizeText(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
 }
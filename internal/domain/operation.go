@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+const DefaultBatchSize = 100
+
+type OperationKind string
+
+const (
+	OperationCreatePlaylist OperationKind = "create_playlist"
+	OperationAddTracks      OperationKind = "add_tracks"
+	OperationRemoveTracks   OperationKind = "remove_tracks"
+	OperationUpdatePlaylist OperationKind = "update_playlist"
+)
+
+type Operation struct {
+	Kind     OperationKind
+	Playlist PlaylistRef
+	Batch    int
+	Tracks   []Track
+	// Metadata is set for playlist updates.
+	Metadata *PlaylistMetadata `json:",omitempty"`
+}
+
+// Key identifies an operation within a plan so it can be matched against a
+// journal when resuming.
+func (op Operation) Key() string {
+	return fmt.Sprintf("%s/%s/%s/%d", op.Kind, op.Playlist.ID, op.Playlist.Name, op.Batch)
+}
+
+type AppliedOperation struct {
+	Operation
+	Target PlaylistRef
+}
+
+type FailedOperation struct {
+	Operation
+	Err error
+}
+
+type Journal interface {
+	Applied() []AppliedOperation
+	Record(op AppliedOperation) error
+}
+
+type SyncResult struct {
+	Succeeded []AppliedOperation
+	Resumed   []AppliedOperation
+	Failed    []FailedOperation
+}
+
+func (r *SyncResult) HasFailures() bool {
+	return len(r.Failed) > 0
+}
+
+func (r *SyncResult) Err() error {
+	var errs []error
+	for _, op := range r.Failed {
+		errs = append(errs, fmt.Errorf("%s %s: %w", op.Kind, op.Playlist.Name, op.Err))
+	}
+	return errors.Join(errs...)
+}
+
+type SyncOption func(*syncOptions)
+
+type syncOptions struct {
+	journal   Journal
+	batchSize int
+}
+
+func WithJournal(j Journal) SyncOption {
+	return func(o *syncOptions) {
+		o.journal = j
+	}
+}
+
+func WithBatchSize(n int) SyncOption {
+	return func(o *syncOptions) {
+		o.batchSize = n
+	}
+}
+
+func newSyncOptions(opts []SyncOption) syncOptions {
+	o := syncOptions{
+		batchSize: DefaultBatchSize,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func batchOperations(kind OperationKind, ref PlaylistRef, tracks []Track, size int) []Operation {
+	if size <= 0 {
+		size = len(tracks)
+	}
+
+	var ops []Operation
+	for i := 0; i < len(tracks); i += size {
+		end := min(i+size, len(tracks))
+		ops = append(ops, Operation{
+			Kind:     kind,
+			Playlist: ref,
+			Batch:    i / size,
+			Tracks:   tracks[i:end],
+		})
+	}
+	return ops
+}
@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// NamePattern matches playlist names either with a case-insensitive glob
+// (`*`, `?` and `[...]` classes) or, when prefixed with `re:`, with a regular
+// expression.
+type NamePattern struct {
+	raw string
+	re  *regexp.Regexp
+}
+
+func ParseNamePattern(s string) (NamePattern, error) {
+	expr, isRegexp := strings.CutPrefix(s, "re:")
+	if !isRegexp {
+		expr = "(?i)^" + globToRegexp(s) + "$"
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return NamePattern{}, fmt.Errorf("invalid playlist name pattern %q: %w", s, err)
+	}
+
+	return NamePattern{
+		raw: s,
+		re:  re,
+	}, nil
+}
+
+func ParseNamePatterns(ss []string) ([]NamePattern, error) {
+	var patterns []NamePattern
+	for _, s := range ss {
+		p, err := ParseNamePattern(s)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+func (p NamePattern) Match(name string) bool {
+	return p.re.MatchString(name)
+}
+
+func (p NamePattern) String() string {
+	return p.raw
+}
+
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta(glob[i:]))
+				return b.String()
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// PlaylistSelector decides which source playlists take part in a sync. A
+// playlist is selected when it matches an include pattern or explicit ID (or
+// when none are given) and passes every other rule.
+type PlaylistSelector struct {
+	Include       []NamePattern
+	Exclude       []NamePattern
+	IDs           []string
+	OwnedOnly     bool
+	Collaborative *bool
+	MinTracks     int
+	MaxTracks     int
+}
+
+func (s PlaylistSelector) Match(pl *Playlist) bool {
+	if len(s.Include) > 0 || len(s.IDs) > 0 {
+		included := slices.Contains(s.IDs, pl.ID) || slices.ContainsFunc(s.Include, func(p NamePattern) bool {
+			return p.Match(pl.Name)
+		})
+		if !included {
+			return false
+		}
+	}
+
+	if slices.ContainsFunc(s.Exclude, func(p NamePattern) bool { return p.Match(pl.Name) }) {
+		return false
+	}
+
+	if s.OwnedOnly && !pl.Owned {
+		return false
+	}
+
+	if s.Collaborative != nil && *s.Collaborative != pl.Collaborative {
+		return false
+	}
+
+	if s.MinTracks > 0 && pl.Size() < s.MinTracks {
+		return false
+	}
+
+	if s.MaxTracks > 0 && pl.Size() > s.MaxTracks {
+		return false
+	}
+
+	return true
+}
+
+func (s PlaylistSelector) Select(pls []*Playlist) []*Playlist {
+	var selected []*Playlist
+	for _, pl := range pls {
+		if s.Match(pl) {
+			selected = append(selected, pl)
+		}
+	}
+	return selected
+}
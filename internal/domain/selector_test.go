@@ -0,0 +1,85 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamePattern(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"Daily*", "Daily Mix 1", true},
+		{"daily*", "Daily Mix 1", true},
+		{"Daily*", "My Daily Mix", false},
+		{"Mix ?", "Mix 1", true},
+		{"Mix [12]", "Mix 3", false},
+		{"Mix [!12]", "Mix 3", true},
+		{"Rock/Metal*", "Rock/Metal 2024", true},
+		{"a.b", "axb", false},
+		{"re:^Discover (Weekly|Daily)$", "Discover Weekly", true},
+		{"re:^Discover (Weekly|Daily)$", "discover weekly", false},
+	}
+
+	for _, c := range cases {
+		p, err := domain.ParseNamePattern(c.pattern)
+		assert.NoError(err)
+		assert.Equal(c.match, p.Match(c.name), "%s ~ %s", c.pattern, c.name)
+	}
+
+	_, err := domain.ParseNamePattern("re:(")
+	assert.Error(err)
+}
+
+func TestPlaylistSelector(t *testing.T) {
+	assert := assert.New(t)
+
+	pls := []*domain.Playlist{
+		{ID: "pl1", Name: "Daily Mix 1", Tracks: make([]domain.Track, 50)},
+		{ID: "pl2", Name: "Road Trip", Owned: true, Tracks: make([]domain.Track, 10)},
+		{ID: "pl3", Name: "Party", Owned: true, Collaborative: true, Tracks: make([]domain.Track, 200)},
+		{ID: "pl4", Name: "Daily Mix 2", Tracks: make([]domain.Track, 50)},
+	}
+
+	names := func(pls []*domain.Playlist) []string {
+		var res []string
+		for _, pl := range pls {
+			res = append(res, pl.Name)
+		}
+		return res
+	}
+
+	mustParse := func(ss ...string) []domain.NamePattern {
+		ps, err := domain.ParseNamePatterns(ss)
+		assert.NoError(err)
+		return ps
+	}
+
+	collaborative := false
+
+	cases := []struct {
+		name     string
+		selector domain.PlaylistSelector
+		expected []string
+	}{
+		{"empty selects all", domain.PlaylistSelector{}, []string{"Daily Mix 1", "Road Trip", "Party", "Daily Mix 2"}},
+		{"include", domain.PlaylistSelector{Include: mustParse("Daily*")}, []string{"Daily Mix 1", "Daily Mix 2"}},
+		{"include and exclude", domain.PlaylistSelector{Include: mustParse("Daily*"), Exclude: mustParse("*2")}, []string{"Daily Mix 1"}},
+		{"explicit ids", domain.PlaylistSelector{Include: mustParse("Daily*"), IDs: []string{"pl3"}}, []string{"Daily Mix 1", "Party", "Daily Mix 2"}},
+		{"owned only", domain.PlaylistSelector{OwnedOnly: true}, []string{"Road Trip", "Party"}},
+		{"not collaborative", domain.PlaylistSelector{OwnedOnly: true, Collaborative: &collaborative}, []string{"Road Trip"}},
+		{"track count", domain.PlaylistSelector{MinTracks: 20, MaxTracks: 100}, []string{"Daily Mix 1", "Daily Mix 2"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(c.expected, names(c.selector.Select(pls)))
+		})
+	}
+}
@@ -1,28 +1,91 @@
 package domain
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
 )
 
-type playlistTracksChangelog struct {
+type PlaylistTracksChangelog struct {
 	Added   []Track
 	Removed []Track
 	Missing []Track
+	// Kept are tracks that should be removed but stay, as the destination
+	// cannot remove tracks.
+	Kept []Track
+	// Skipped are source items that cannot be synced, such as episodes.
+	Skipped []SkippedTrack
+	// Items tells, by track ID, when and by whom a track was added to the
+	// source playlist, or to the destination one for removed tracks. Only
+	// known items are set.
+	Items map[string]PlaylistItem
 }
 
-func (cl *playlistTracksChangelog) HasChanges() bool {
-	return len(cl.Added) > 0 || len(cl.Removed) > 0 || len(cl.Missing) > 0
+// Item returns when and by whom the track was added, if known.
+func (cl *PlaylistTracksChangelog) Item(id string) PlaylistItem {
+	return cl.Items[id]
 }
 
-type playlistChangelog struct {
+func (cl *PlaylistTracksChangelog) setItem(id string, it PlaylistItem) {
+	if it == (PlaylistItem{}) {
+		return
+	}
+	if cl.Items == nil {
+		cl.Items = make(map[string]PlaylistItem)
+	}
+	cl.Items[id] = it
+}
+
+type SkippedTrack struct {
+	Track  Track
+	Reason string
+}
+
+func (cl *PlaylistTracksChangelog) HasChanges() bool {
+	return len(cl.Added) > 0 || len(cl.Removed) > 0 || len(cl.Missing) > 0 || len(cl.Kept) > 0 || len(cl.Skipped) > 0
+}
+
+type PlaylistChangelog struct {
 	Added []PlaylistRef
+	// Skipped are playlists missing from a destination that cannot create
+	// playlists.
+	Skipped []PlaylistRef
+}
+
+// PlaylistMetadataChangelog holds the details of a destination playlist
+// before and after the sync.
+type PlaylistMetadataChangelog struct {
+	From PlaylistMetadata
+	To   PlaylistMetadata
+}
+
+// Changes names the details that differ.
+func (cl PlaylistMetadataChangelog) Changes() []string {
+	var changes []string
+	if cl.From.Description != cl.To.Description {
+		changes = append(changes, "description")
+	}
+	if cl.From.Public != cl.To.Public {
+		changes = append(changes, "visibility")
+	}
+	if cl.From.Collaborative != cl.To.Collaborative {
+		changes = append(changes, "collaborative")
+	}
+	if cl.To.Image != "" && cl.To.Image != cl.From.Image {
+		changes = append(changes, "cover image")
+	}
+	if !slices.Equal(cl.From.Folder, cl.To.Folder) {
+		changes = append(changes, "folder")
+	}
+	return changes
 }
 
-type changelog struct {
-	Playlists        playlistChangelog
-	TracksByPlaylist map[PlaylistRef]playlistTracksChangelog
+type Changelog struct {
+	Playlists          PlaylistChangelog
+	TracksByPlaylist   map[PlaylistRef]PlaylistTracksChangelog
+	MetadataByPlaylist map[PlaylistRef]PlaylistMetadataChangelog
 }
 
 type PlaylistRef struct {
@@ -30,23 +93,89 @@ type PlaylistRef struct {
 	Name string
 }
 
-func PlanSync(ctx context.Context, from, to Connector) (*changelog, error) {
+type PlanOption func(*planOptions)
+
+type planOptions struct {
+	selector   *PlaylistSelector
+	dedupe     bool
+	addedOrder bool
+}
+
+func WithPlaylistSelector(s PlaylistSelector) PlanOption {
+	return func(o *planOptions) {
+		o.selector = &s
+	}
+}
+
+// WithDedupe keeps destination playlists free of duplicates: duplicated
+// additions are skipped and duplicates already in the destination removed.
+func WithDedupe() PlanOption {
+	return func(o *planOptions) {
+		o.dedupe = true
+	}
+}
+
+// WithAddedOrder fills the playlists created on the destination in the order
+// their tracks were added to the source, oldest first, so that sorting them
+// by date added matches the source.
+func WithAddedOrder() PlanOption {
+	return func(o *planOptions) {
+		o.addedOrder = true
+	}
+}
+
+// PlanSync compares the source playlists with the destination and plans the
+// changes, leaving out those the destination does not support: playlists it
+// cannot create are skipped, tracks it cannot remove are kept and only the
+// playlist details it supports are updated.
+func PlanSync(ctx context.Context, from, to Connector, opts ...PlanOption) (*Changelog, error) {
+	var o planOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	caps := to.Capabilities()
+	srcCaps := from.Capabilities()
+
 	pls, err := from.GetPlaylists(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get playlists from source: %w", err)
 	}
 
-	changelog := &changelog{
-		Playlists:        playlistChangelog{},
-		TracksByPlaylist: make(map[PlaylistRef]playlistTracksChangelog),
+	if o.selector != nil {
+		pls = o.selector.Select(pls)
+	}
+
+	changelog := &Changelog{
+		Playlists:          PlaylistChangelog{},
+		TracksByPlaylist:   make(map[PlaylistRef]PlaylistTracksChangelog),
+		MetadataByPlaylist: make(map[PlaylistRef]PlaylistMetadataChangelog),
 	}
 
 	for _, src := range pls {
+		if !src.HasTracks() {
+			full, err := from.GetPlaylist(ctx, src.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get playlist %s from source: %w", src.Name, err)
+			}
+			if full != nil {
+				src = full
+			}
+		}
+
 		dst, err := to.GetPlaylistByName(ctx, src.Name)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get playlist %s from destination: %w", src.Name, err)
 		}
 
+		if dst == nil && !caps.CreatePlaylist {
+			changelog.Playlists.Skipped = append(changelog.Playlists.Skipped, PlaylistRef{
+				ID:   src.ID,
+				Name: src.Name,
+			})
+			continue
+		}
+
 		if dst == nil {
 			dst = &Playlist{
 				ID:   src.ID,
@@ -56,6 +185,9 @@ func PlanSync(ctx context.Context, from, to Connector) (*changelog, error) {
 				ID:   src.ID,
 				Name: src.Name,
 			})
+			if o.addedOrder {
+				src = sortByAddedAt(*src)
+			}
 		}
 
 		cl, err := syncPlaylist(ctx, *src, *dst, to)
@@ -63,104 +195,319 @@ func PlanSync(ctx context.Context, from, to Connector) (*changelog, error) {
 			return nil, fmt.Errorf("failed to sync playlist %s: %w", src.Name, err)
 		}
 
-		if !cl.HasChanges() {
-			continue
+		if o.dedupe {
+			dedupeChangelog(dst.Tracks, cl, caps.DeleteTracks)
+		}
+
+		if !caps.DeleteTracks {
+			cl.Kept = append(cl.Kept, cl.Removed...)
+			cl.Removed = nil
 		}
 
 		ref := PlaylistRef{
 			ID:   dst.ID,
 			Name: dst.Name,
 		}
-		changelog.TracksByPlaylist[ref] = *cl
+
+		if meta := planMetadata(*src, *dst, srcCaps, caps); len(meta.Changes()) > 0 {
+			changelog.MetadataByPlaylist[ref] = meta
+		}
+
+		if cl.HasChanges() {
+			changelog.TracksByPlaylist[ref] = *cl
+		}
 	}
 
 	return changelog, nil
 }
 
-func Sync(ctx context.Context, from, to Connector, cl changelog) error {
-	createdPl := make(map[string]PlaylistRef)
+// sortByAddedAt returns a copy of the playlist with its tracks in the order
+// they were added. Tracks with an unknown date come first, in playlist order.
+func sortByAddedAt(p Playlist) *Playlist {
+	order := make([]int, len(p.Tracks))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return p.Item(a).AddedAt.Compare(p.Item(b).AddedAt)
+	})
+
+	tracks := make([]Track, 0, len(order))
+	items := make([]PlaylistItem, 0, len(order))
+	for _, i := range order {
+		tracks = append(tracks, p.Tracks[i])
+		items = append(items, p.Item(i))
+	}
+	p.Tracks = tracks
+	p.Items = items
+	return &p
+}
+
+// planMetadata mirrors the details of the source playlist that both
+// connectors support. Cover images cannot be compared across services, so
+// they are only copied to playlists without one. Destinations without
+// folders keep their playlists at the top level.
+func planMetadata(src, dst Playlist, srcCaps, dstCaps Capabilities) PlaylistMetadataChangelog {
+	cl := PlaylistMetadataChangelog{From: dst.Metadata()}
+	cl.To = cl.From
+	cl.To.Image = ""
+
+	if srcCaps.Descriptions && dstCaps.Descriptions {
+		cl.To.Description = src.Description
+	}
+	if srcCaps.Visibility && dstCaps.Visibility {
+		cl.To.Public = src.Public
+	}
+	if srcCaps.Collaborative && dstCaps.Collaborative {
+		cl.To.Collaborative = src.Collaborative
+	}
+	if srcCaps.Images && dstCaps.Images && dst.Image == "" {
+		cl.To.Image = src.Image
+	}
+	if srcCaps.Folders && dstCaps.CreateFolders {
+		cl.To.Folder = src.Folder
+	}
+
+	return cl
+}
+
+// Operations flattens the changelog into an ordered list of operations:
+// playlist creations first, then per playlist (sorted by name) the track
+// removals and additions split into batches of at most batchSize tracks,
+// and the update of its details. Removals go first so that a duplicated
+// track can be removed and added back.
+func (cl *Changelog) Operations(batchSize int) []Operation {
+	var ops []Operation
 	for _, ref := range cl.Playlists.Added {
-		pl, err := to.CreatePlaylist(ctx, ref.Name)
+		ops = append(ops, Operation{
+			Kind:     OperationCreatePlaylist,
+			Playlist: ref,
+		})
+	}
+
+	refs := make([]PlaylistRef, 0, len(cl.TracksByPlaylist))
+	for ref := range cl.TracksByPlaylist {
+		refs = append(refs, ref)
+	}
+	for ref := range cl.MetadataByPlaylist {
+		if _, found := cl.TracksByPlaylist[ref]; !found {
+			refs = append(refs, ref)
+		}
+	}
+	slices.SortFunc(refs, func(a, b PlaylistRef) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+
+	for _, ref := range refs {
+		tracks := cl.TracksByPlaylist[ref]
+		ops = append(ops, batchOperations(OperationRemoveTracks, ref, tracks.Removed, batchSize)...)
+		ops = append(ops, batchOperations(OperationAddTracks, ref, tracks.Added, batchSize)...)
+
+		if meta, found := cl.MetadataByPlaylist[ref]; found {
+			ops = append(ops, Operation{
+				Kind:     OperationUpdatePlaylist,
+				Playlist: ref,
+				Metadata: &meta.To,
+			})
+		}
+	}
+
+	return ops
+}
+
+func Sync(ctx context.Context, from, to Connector, cl Changelog, opts ...SyncOption) (*SyncResult, error) {
+	o := newSyncOptions(opts)
+	return Apply(ctx, to, cl.Operations(to.Capabilities().BatchSize(o.batchSize)), opts...)
+}
+
+// Apply runs the operations against the destination connector. Operations
+// already recorded in the journal are skipped, and a failing operation does
+// not stop the run: it is reported in the result together with every later
+// operation that depends on it.
+func Apply(ctx context.Context, to Connector, ops []Operation, opts ...SyncOption) (*SyncResult, error) {
+	o := newSyncOptions(opts)
+
+	applied := make(map[string]AppliedOperation)
+	if o.journal != nil {
+		for _, op := range o.journal.Applied() {
+			applied[op.Key()] = op
+		}
+	}
+
+	res := &SyncResult{}
+	targets := make(map[PlaylistRef]PlaylistRef)
+	failedPl := make(map[PlaylistRef]error)
+
+	for _, op := range ops {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+
+		if prev, found := applied[op.Key()]; found {
+			targets[op.Playlist] = prev.Target
+			res.Resumed = append(res.Resumed, prev)
+			continue
+		}
+
+		if err, found := failedPl[op.Playlist]; found {
+			res.Failed = append(res.Failed, FailedOperation{
+				Operation: op,
+				Err:       fmt.Errorf("skipped after earlier failure: %w", err),
+			})
+			continue
+		}
+
+		target, found := targets[op.Playlist]
+		if !found {
+			target = op.Playlist
+		}
+
+		target, err := applyOperation(ctx, to, op, target)
 		if err != nil {
-			return fmt.Errorf("failed to create playlist %s: %w", ref.Name, err)
+			if op.Kind == OperationCreatePlaylist {
+				failedPl[op.Playlist] = err
+			}
+
+			slog.Error("failed to apply operation",
+				"operation", op.Kind,
+				"playlist_name", op.Playlist.Name,
+				"batch", op.Batch,
+				"error", err,
+			)
+
+			res.Failed = append(res.Failed, FailedOperation{
+				Operation: op,
+				Err:       err,
+			})
+			continue
 		}
 
-		createdPl[ref.Name] = PlaylistRef{
-			ID:   pl.ID,
-			Name: pl.Name,
+		targets[op.Playlist] = target
+		done := AppliedOperation{
+			Operation: op,
+			Target:    target,
+		}
+
+		if o.journal != nil {
+			if err := o.journal.Record(done); err != nil {
+				return res, fmt.Errorf("failed to record operation in journal: %w", err)
+			}
+		}
+
+		res.Succeeded = append(res.Succeeded, done)
+	}
+
+	return res, nil
+}
+
+func applyOperation(ctx context.Context, to Connector, op Operation, target PlaylistRef) (PlaylistRef, error) {
+	switch op.Kind {
+	case OperationCreatePlaylist:
+		pl, err := to.CreatePlaylist(ctx, op.Playlist.Name)
+		if err != nil {
+			return target, fmt.Errorf("failed to create playlist %s: %w", op.Playlist.Name, err)
 		}
 
 		slog.Info("created playlist",
 			"playlist_id", pl.ID,
 			"playlist_name", pl.Name,
 		)
-	}
 
-	for ref, tracks := range cl.TracksByPlaylist {
-		if _, found := createdPl[ref.Name]; found {
-			ref = createdPl[ref.Name]
+		return PlaylistRef{
+			ID:   pl.ID,
+			Name: pl.Name,
+		}, nil
+	case OperationAddTracks:
+		if err := to.AddTracksToPlaylist(ctx, target.ID, op.Tracks); err != nil {
+			return target, fmt.Errorf("failed to add tracks to playlist %s: %w", target.Name, err)
 		}
 
-		if len(tracks.Added) > 0 {
-			if err := to.AddTracksToPlaylist(ctx, ref.ID, tracks.Added); err != nil {
-				return fmt.Errorf("failed to add tracks to playlist %s: %w", ref.Name, err)
-			}
+		slog.Info("added tracks to playlist",
+			"playlist_id", target.ID,
+			"playlist_name", target.Name,
+			"added_count", len(op.Tracks),
+		)
 
-			slog.Info("added tracks to playlist",
-				"playlist_id", ref.ID,
-				"playlist_name", ref.Name,
-				"added_count", len(tracks.Added),
-			)
+		return target, nil
+	case OperationRemoveTracks:
+		if err := to.DeleteTracksFromPlaylist(ctx, target.ID, op.Tracks); err != nil {
+			return target, fmt.Errorf("failed to remove tracks from playlist %s: %w", target.Name, err)
 		}
 
-		if len(tracks.Removed) > 0 {
-			if err := to.DeleteTracksFromPlaylist(ctx, ref.ID, tracks.Removed); err != nil {
-				return fmt.Errorf("failed to remove tracks from playlist %s: %w", ref.Name, err)
-			}
+		slog.Info("removed tracks from playlist",
+			"playlist_id", target.ID,
+			"playlist_name", target.Name,
+			"removed_count", len(op.Tracks),
+		)
 
-			slog.Info("removed tracks from playlist",
-				"playlist_id", ref.ID,
-				"playlist_name", ref.Name,
-				"removed_count", len(tracks.Removed),
-			)
+		return target, nil
+	case OperationUpdatePlaylist:
+		if op.Metadata == nil {
+			return target, fmt.Errorf("failed to update playlist %s: no details to set", target.Name)
 		}
-	}
 
-	return nil
+		if err := to.UpdatePlaylist(ctx, target.ID, *op.Metadata); err != nil {
+			return target, fmt.Errorf("failed to update playlist %s: %w", target.Name, err)
+		}
+
+		slog.Info("updated playlist",
+			"playlist_id", target.ID,
+			"playlist_name", target.Name,
+		)
+
+		return target, nil
+	default:
+		return target, fmt.Errorf("unknown operation: %s", op.Kind)
+	}
 }
 
-func syncPlaylist(ctx context.Context, src, dst Playlist, to Connector) (*playlistTracksChangelog, error) {
+func syncPlaylist(ctx context.Context, src, dst Playlist, to Connector) (*PlaylistTracksChangelog, error) {
 	dstLookup := make(map[string]Track)
 	for _, tr := range dst.Tracks {
 		dstLookup[tr.ID] = tr
 	}
 
-	cl := new(playlistTracksChangelog)
-
-	srcLookup := make(map[string]Track)
-	for _, tr := range src.Tracks {
-		srcLookup[tr.ID] = tr
+	cl := new(PlaylistTracksChangelog)
 
+	matched := make(map[string]struct{})
+	for i, tr := range src.Tracks {
 		if _, found := dstLookup[tr.ID]; found {
+			matched[tr.ID] = struct{}{}
+			continue
+		}
+
+		if reason := tr.SkipReason(); reason != "" {
+			cl.Skipped = append(cl.Skipped, SkippedTrack{Track: tr, Reason: reason})
+			cl.setItem(tr.ID, src.Item(i))
 			continue
 		}
 
-		tracks, err := to.SearchTrack(ctx, TrackFilters{ID: tr.ID})
+		tracks, err := to.SearchTrack(ctx, tr.Filters())
 		if err != nil {
 			return nil, fmt.Errorf("failed to search track %s in destination: %w", tr.ID, err)
 		}
 
 		if len(tracks) == 0 {
 			cl.Missing = append(cl.Missing, tr)
+			cl.setItem(tr.ID, src.Item(i))
+			continue
+		}
+
+		if _, found := dstLookup[tracks[0].ID]; found {
+			matched[tracks[0].ID] = struct{}{}
 			continue
 		}
 
 		cl.Added = append(cl.Added, tracks[0])
+		cl.setItem(tracks[0].ID, src.Item(i))
 	}
 
-	for _, tr := range dst.Tracks {
-		if _, found := srcLookup[tr.ID]; !found {
+	// Episodes and local files are left alone, as connectors only remove
+	// tracks.
+	for i, tr := range dst.Tracks {
+		if _, found := matched[tr.ID]; !found && tr.Kind == TrackKindTrack {
 			cl.Removed = append(cl.Removed, tr)
+			cl.setItem(tr.ID, dst.Item(i))
 		}
 	}
 
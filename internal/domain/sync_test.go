@@ -2,7 +2,9 @@ package domain_test
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/pedrobarco/nomuz/internal/domain"
 	"github.com/stretchr/testify/assert"
@@ -133,6 +135,320 @@ func TestPlanSync(t *testing.T) {
 		assert.Len(cl.TracksByPlaylist[ref1].Removed, 0)
 		assert.Len(cl.TracksByPlaylist[ref1].Missing, 0)
 	})
+	t.Run("sync selected playlists", func(t *testing.T) {
+		src := &mockConnector{
+			Playlists: []*domain.Playlist{
+				{ID: "pl1", Name: "Playlist 1", Owned: true, Tracks: tracks},
+				{ID: "pl2", Name: "Playlist 2", Tracks: tracks},
+				{ID: "pl3", Name: "Other", Owned: true, Tracks: tracks},
+			},
+		}
+
+		dst := &mockConnector{
+			Tracks: tracks,
+		}
+
+		include, err := domain.ParseNamePatterns([]string{"Playlist*"})
+		assert.NoError(err)
+
+		cl, err := domain.PlanSync(ctx, src, dst, domain.WithPlaylistSelector(domain.PlaylistSelector{
+			Include:   include,
+			OwnedOnly: true,
+		}))
+		assert.NoError(err)
+		assert.Len(cl.Playlists.Added, 1)
+		assert.Equal("Playlist 1", cl.Playlists.Added[0].Name)
+		assert.Len(cl.TracksByPlaylist, 1)
+	})
+	t.Run("match tracks across services", func(t *testing.T) {
+		src := &mockConnector{
+			Playlists: []*domain.Playlist{
+				{ID: "pl1", Name: "Playlist 1", Tracks: []domain.Track{
+					{ID: "s1", ISRC: "ISRC1", Title: "Track 1"},
+					{ID: "s2", ISRC: "ISRC2", Title: "Track 2"},
+				}},
+			},
+		}
+
+		d1 := domain.Track{ID: "d1", ISRC: "ISRC1", Title: "Track 1"}
+		d2 := domain.Track{ID: "d2", ISRC: "ISRC2", Title: "Track 2"}
+		dst := &mockConnector{
+			Tracks: []domain.Track{d1, d2},
+			Playlists: []*domain.Playlist{
+				{ID: "pl1", Name: "Playlist 1", Tracks: []domain.Track{d1, {ID: "d3", Title: "Track 3"}}},
+			},
+		}
+
+		ref := domain.PlaylistRef{ID: "pl1", Name: "Playlist 1"}
+
+		cl, err := domain.PlanSync(ctx, src, dst)
+		assert.NoError(err)
+		assert.Equal([]string{"d2"}, trackIDs(cl.TracksByPlaylist[ref].Added))
+		assert.Equal([]string{"d3"}, trackIDs(cl.TracksByPlaylist[ref].Removed))
+	})
+
+	t.Run("fetch tracks of listed playlists", func(t *testing.T) {
+		src := &listingConnector{mockConnector{
+			Tracks: []domain.Track{{ID: "t1"}},
+			Playlists: []*domain.Playlist{
+				{ID: "pl1", Name: "Playlist 1", Tracks: []domain.Track{{ID: "t1"}}},
+			},
+		}}
+		dst := &mockConnector{
+			Tracks: []domain.Track{{ID: "t1"}},
+		}
+
+		cl, err := domain.PlanSync(ctx, src, dst)
+		assert.NoError(err)
+		assert.Equal([]string{"t1"}, trackIDs(cl.TracksByPlaylist[domain.PlaylistRef{ID: "pl1", Name: "Playlist 1"}].Added))
+	})
+
+	t.Run("downgrade to destination capabilities", func(t *testing.T) {
+		src := &mockConnector{
+			Playlists: []*domain.Playlist{
+				{ID: "pl1", Name: "Playlist 1", Tracks: tracks[:2]},
+				{ID: "pl2", Name: "Playlist 2", Tracks: tracks},
+			},
+		}
+
+		dst := &mockConnector{
+			Tracks: tracks,
+			Playlists: []*domain.Playlist{
+				{ID: "pl1", Name: "Playlist 1", Tracks: tracks[1:]},
+			},
+			Caps: &domain.Capabilities{MaxBatchSize: 1},
+		}
+
+		ref := domain.PlaylistRef{ID: "pl1", Name: "Playlist 1"}
+
+		cl, err := domain.PlanSync(ctx, src, dst)
+		assert.NoError(err)
+		assert.Empty(cl.Playlists.Added)
+		assert.Equal([]domain.PlaylistRef{{ID: "pl2", Name: "Playlist 2"}}, cl.Playlists.Skipped)
+		assert.Len(cl.TracksByPlaylist, 1)
+		assert.Equal([]string{"t1"}, trackIDs(cl.TracksByPlaylist[ref].Added))
+		assert.Empty(cl.TracksByPlaylist[ref].Removed)
+		assert.Equal([]string{"t3"}, trackIDs(cl.TracksByPlaylist[ref].Kept))
+
+		res, err := domain.Sync(ctx, src, dst, *cl)
+		assert.NoError(err)
+		assert.Len(res.Succeeded, 1)
+		assert.Equal([]string{"t2", "t3", "t1"}, trackIDs(dst.Playlists[0].Tracks))
+	})
+
+	t.Run("keep destination duplicate-free", func(t *testing.T) {
+		single := domain.Track{ID: "t5", ISRC: "ISRC1", Title: "Track 1", Artist: "Artist A", Album: "Single"}
+		tracks := append(tracks, domain.Track{ID: "t4", ISRC: "ISRC1", Title: "Track 1", Artist: "Artist A", Album: "Album"})
+
+		src := &mockConnector{
+			Playlists: []*domain.Playlist{
+				{ID: "pl1", Name: "Playlist 1", Tracks: append(tracks, tracks[1], single)},
+			},
+		}
+
+		dst := &mockConnector{
+			Tracks: append(tracks, single),
+			Playlists: []*domain.Playlist{
+				{ID: "pl1", Name: "Playlist 1", Tracks: []domain.Track{tracks[0], tracks[0], tracks[3]}},
+			},
+		}
+
+		ref := domain.PlaylistRef{ID: "pl1", Name: "Playlist 1"}
+
+		cl, err := domain.PlanSync(ctx, src, dst, domain.WithDedupe())
+		assert.NoError(err)
+		assert.Equal([]string{"t4", "t1"}, trackIDs(cl.TracksByPlaylist[ref].Removed))
+		assert.Equal([]string{"t2", "t3", "t1"}, trackIDs(cl.TracksByPlaylist[ref].Added))
+
+		_, err = domain.Sync(ctx, src, dst, *cl)
+		assert.NoError(err)
+
+		p1, err := dst.GetPlaylistByName(ctx, "Playlist 1")
+		assert.NoError(err)
+		assert.Empty(domain.FindDuplicates(p1.Tracks))
+		assert.Equal([]string{"t2", "t3", "t1"}, trackIDs(p1.Tracks))
+	})
+
+	t.Run("dedupe without removing tracks", func(t *testing.T) {
+		tracks := append(tracks, domain.Track{ID: "t4", ISRC: "ISRC1", Title: "Track 1", Artist: "Artist A", Album: "Album"})
+
+		src := &mockConnector{
+			Playlists: []*domain.Playlist{
+				{ID: "pl1", Name: "Playlist 1", Tracks: append(tracks, tracks[1])},
+			},
+		}
+
+		dst := &mockConnector{
+			Tracks: tracks,
+			Playlists: []*domain.Playlist{
+				{ID: "pl1", Name: "Playlist 1", Tracks: []domain.Track{tracks[0], tracks[0], tracks[3]}},
+			},
+			Caps: &domain.Capabilities{DeleteTracks: false},
+		}
+
+		ref := domain.PlaylistRef{ID: "pl1", Name: "Playlist 1"}
+
+		cl, err := domain.PlanSync(ctx, src, dst, domain.WithDedupe())
+		assert.NoError(err)
+		assert.Empty(cl.TracksByPlaylist[ref].Removed)
+		assert.Equal([]string{"t4", "t1"}, trackIDs(cl.TracksByPlaylist[ref].Kept))
+		// The duplicate cannot be removed, so no copy is added back.
+		assert.Equal([]string{"t2", "t3"}, trackIDs(cl.TracksByPlaylist[ref].Added))
+
+		_, err = domain.Sync(ctx, src, dst, *cl)
+		assert.NoError(err)
+
+		cl, err = domain.PlanSync(ctx, src, dst, domain.WithDedupe())
+		assert.NoError(err)
+		assert.Empty(cl.TracksByPlaylist[ref].Added)
+	})
+
+	t.Run("fill new playlists in added order", func(t *testing.T) {
+		day := func(d int) domain.PlaylistItem {
+			return domain.PlaylistItem{AddedAt: time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC)}
+		}
+
+		src := &mockConnector{
+			Playlists: []*domain.Playlist{
+				{ID: "pl1", Name: "Playlist 1", Tracks: tracks, Items: []domain.PlaylistItem{day(3), {}, day(1)}},
+			},
+		}
+
+		dst := &mockConnector{Tracks: tracks}
+
+		ref := domain.PlaylistRef{ID: "pl1", Name: "Playlist 1"}
+
+		cl, err := domain.PlanSync(ctx, src, dst)
+		assert.NoError(err)
+		assert.Equal([]string{"t1", "t2", "t3"}, trackIDs(cl.TracksByPlaylist[ref].Added))
+		tcl := cl.TracksByPlaylist[ref]
+		assert.Equal(day(3), tcl.Item("t1"))
+		assert.Equal(domain.PlaylistItem{}, tcl.Item("t2"))
+
+		cl, err = domain.PlanSync(ctx, src, dst, domain.WithAddedOrder())
+		assert.NoError(err)
+		assert.Equal([]string{"t2", "t3", "t1"}, trackIDs(cl.TracksByPlaylist[ref].Added))
+	})
+
+	t.Run("mirror playlist details", func(t *testing.T) {
+		src := &mockConnector{
+			Playlists: []*domain.Playlist{
+				{ID: "pl1", Name: "Playlist 1", Description: "Songs for the road", Public: true, Image: "https://example.com/pl1.jpg", Tracks: tracks},
+				{ID: "pl2", Name: "Playlist 2", Description: "Shared", Collaborative: true, Image: "https://example.com/pl2.jpg", Tracks: tracks},
+			},
+		}
+
+		dst := &mockConnector{
+			Tracks: tracks,
+			Playlists: []*domain.Playlist{
+				{ID: "pl1", Name: "Playlist 1", Description: "Old", Image: "https://example.com/cover.jpg", Tracks: tracks},
+			},
+			Caps: &domain.Capabilities{CreatePlaylist: true, Descriptions: true, Visibility: true, Images: true},
+		}
+
+		ref1 := domain.PlaylistRef{ID: "pl1", Name: "Playlist 1"}
+		ref2 := domain.PlaylistRef{ID: "pl2", Name: "Playlist 2"}
+
+		cl, err := domain.PlanSync(ctx, src, dst)
+		assert.NoError(err)
+		assert.NotContains(cl.TracksByPlaylist, ref1)
+
+		// The destination keeps its own cover and cannot be collaborative.
+		assert.Equal([]string{"description", "visibility"}, cl.MetadataByPlaylist[ref1].Changes())
+		assert.Equal([]string{"description", "cover image"}, cl.MetadataByPlaylist[ref2].Changes())
+
+		res, err := domain.Sync(ctx, src, dst, *cl)
+		assert.NoError(err)
+		assert.False(res.HasFailures())
+
+		p1, err := dst.GetPlaylistByName(ctx, "Playlist 1")
+		assert.NoError(err)
+		assert.Equal(domain.PlaylistMetadata{Description: "Songs for the road", Public: true, Image: "https://example.com/cover.jpg"}, p1.Metadata())
+
+		p2, err := dst.GetPlaylistByName(ctx, "Playlist 2")
+		assert.NoError(err)
+		assert.Equal(domain.PlaylistMetadata{Description: "Shared", Image: "https://example.com/pl2.jpg"}, p2.Metadata())
+
+		cl, err = domain.PlanSync(ctx, src, dst)
+		assert.NoError(err)
+		assert.Empty(cl.MetadataByPlaylist)
+	})
+
+	t.Run("recreate folders", func(t *testing.T) {
+		srcCaps := domain.Capabilities{Folders: true}
+		src := &mockConnector{
+			Playlists: []*domain.Playlist{
+				{ID: "pl1", Name: "Playlist 1", Folder: []string{"Moods", "Chill"}, Tracks: tracks},
+				{ID: "pl2", Name: "Playlist 2", Tracks: tracks},
+			},
+			Caps: &srcCaps,
+		}
+
+		dst := &mockConnector{
+			Tracks: tracks,
+			Caps:   &domain.Capabilities{CreatePlaylist: true, Folders: true, CreateFolders: true},
+		}
+
+		ref1 := domain.PlaylistRef{ID: "pl1", Name: "Playlist 1"}
+		ref2 := domain.PlaylistRef{ID: "pl2", Name: "Playlist 2"}
+
+		cl, err := domain.PlanSync(ctx, src, dst)
+		assert.NoError(err)
+		assert.Equal([]string{"folder"}, cl.MetadataByPlaylist[ref1].Changes())
+		assert.NotContains(cl.MetadataByPlaylist, ref2)
+
+		_, err = domain.Sync(ctx, src, dst, *cl)
+		assert.NoError(err)
+
+		p1, err := dst.GetPlaylistByName(ctx, "Playlist 1")
+		assert.NoError(err)
+		assert.Equal("Moods / Chill", p1.FolderPath())
+
+		cl, err = domain.PlanSync(ctx, src, dst)
+		assert.NoError(err)
+		assert.Empty(cl.MetadataByPlaylist)
+
+		// Destinations without folders keep the playlists flat.
+		flat := &mockConnector{
+			Tracks: tracks,
+			Caps:   &domain.Capabilities{CreatePlaylist: true, Descriptions: true},
+		}
+		cl, err = domain.PlanSync(ctx, src, flat)
+		assert.NoError(err)
+		assert.Empty(cl.MetadataByPlaylist)
+	})
+
+	t.Run("skip episodes, local files and unavailable tracks", func(t *testing.T) {
+		episode := domain.Track{ID: "e1", Kind: domain.TrackKindEpisode, Title: "Episode 1"}
+		local := domain.Track{ID: "spotify:local:Band::Demo:180", Kind: domain.TrackKindLocal, Title: "Demo"}
+		unavailable := domain.Track{ID: "t4", ISRC: "ISRC1", Title: "Track 1", Unavailable: true}
+
+		src := &mockConnector{
+			Playlists: []*domain.Playlist{
+				{ID: "pl1", Name: "Playlist 1", Tracks: []domain.Track{tracks[0], episode, local, unavailable}},
+			},
+		}
+
+		dst := &mockConnector{
+			Tracks: tracks,
+			Playlists: []*domain.Playlist{
+				{ID: "pl1", Name: "Playlist 1", Tracks: []domain.Track{{ID: "e2", Kind: domain.TrackKindEpisode}}},
+			},
+		}
+
+		ref := domain.PlaylistRef{ID: "pl1", Name: "Playlist 1"}
+
+		cl, err := domain.PlanSync(ctx, src, dst)
+		assert.NoError(err)
+		assert.Equal([]string{"t1"}, trackIDs(cl.TracksByPlaylist[ref].Added))
+		assert.Empty(cl.TracksByPlaylist[ref].Removed)
+		assert.Empty(cl.TracksByPlaylist[ref].Missing)
+		assert.Equal([]domain.SkippedTrack{
+			{Track: episode, Reason: "podcast episode"},
+			{Track: local, Reason: "local file"},
+			{Track: unavailable, Reason: "unavailable"},
+		}, cl.TracksByPlaylist[ref].Skipped)
+	})
 }
 
 func TestSync(t *testing.T) {
@@ -202,8 +518,10 @@ func TestSync(t *testing.T) {
 	cl, err := domain.PlanSync(ctx, src, dst)
 	assert.NoError(err)
 
-	err = domain.Sync(ctx, src, dst, *cl)
+	res, err := domain.Sync(ctx, src, dst, *cl)
 	assert.NoError(err)
+	assert.False(res.HasFailures())
+	assert.Len(res.Succeeded, 4)
 
 	p1, err := dst.GetPlaylistByName(ctx, "Playlist 1")
 	assert.NoError(err)
@@ -213,3 +531,156 @@ func TestSync(t *testing.T) {
 	assert.NoError(err)
 	assert.Len(p2.Tracks, 3)
 }
+
+type memJournal struct {
+	ops []domain.AppliedOperation
+}
+
+func (j *memJournal) Applied() []domain.AppliedOperation {
+	return j.ops
+}
+
+func (j *memJournal) Record(op domain.AppliedOperation) error {
+	j.ops = append(j.ops, op)
+	return nil
+}
+
+func TestChangelogOperations(t *testing.T) {
+	assert := assert.New(t)
+
+	tracks := []domain.Track{{ID: "t1"}, {ID: "t2"}, {ID: "t3"}}
+	refA := domain.PlaylistRef{ID: "a", Name: "A"}
+	refB := domain.PlaylistRef{ID: "b", Name: "B"}
+	refC := domain.PlaylistRef{ID: "c", Name: "C"}
+	meta := domain.PlaylistMetadataChangelog{To: domain.PlaylistMetadata{Description: "New"}}
+
+	cl := domain.Changelog{
+		Playlists: domain.PlaylistChangelog{
+			Added: []domain.PlaylistRef{refB},
+		},
+		TracksByPlaylist: map[domain.PlaylistRef]domain.PlaylistTracksChangelog{
+			refB: {Added: tracks},
+			refA: {Added: tracks[:1], Removed: tracks[1:]},
+		},
+		MetadataByPlaylist: map[domain.PlaylistRef]domain.PlaylistMetadataChangelog{
+			refB: meta,
+			refC: meta,
+		},
+	}
+
+	ops := cl.Operations(2)
+	assert.Len(ops, 7)
+	assert.Equal(domain.OperationCreatePlaylist, ops[0].Kind)
+	assert.Equal(refB, ops[0].Playlist)
+	assert.Equal(domain.OperationRemoveTracks, ops[1].Kind)
+	assert.Equal(refA, ops[1].Playlist)
+	assert.Len(ops[1].Tracks, 2)
+	assert.Equal(domain.OperationAddTracks, ops[2].Kind)
+	assert.Equal(refB, ops[3].Playlist)
+	assert.Equal(0, ops[3].Batch)
+	assert.Len(ops[3].Tracks, 2)
+	assert.Equal(1, ops[4].Batch)
+	assert.Len(ops[4].Tracks, 1)
+
+	// Details are updated once the tracks are in place.
+	assert.Equal(domain.OperationUpdatePlaylist, ops[5].Kind)
+	assert.Equal(refB, ops[5].Playlist)
+	assert.Equal(&meta.To, ops[5].Metadata)
+	assert.Equal(domain.OperationUpdatePlaylist, ops[6].Kind)
+	assert.Equal(refC, ops[6].Playlist)
+}
+
+func TestApply(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	tracks := []domain.Track{{ID: "t1"}, {ID: "t2"}, {ID: "t3"}}
+	ref1 := domain.PlaylistRef{ID: "pl1", Name: "Playlist 1"}
+	ref2 := domain.PlaylistRef{ID: "src2", Name: "Playlist 2"}
+
+	cl := domain.Changelog{
+		Playlists: domain.PlaylistChangelog{
+			Added: []domain.PlaylistRef{ref2},
+		},
+		TracksByPlaylist: map[domain.PlaylistRef]domain.PlaylistTracksChangelog{
+			ref1: {Added: tracks},
+			ref2: {Added: tracks},
+		},
+	}
+
+	t.Run("partial failure", func(t *testing.T) {
+		dst := &mockConnector{
+			Playlists: []*domain.Playlist{
+				{ID: "pl1", Name: "Playlist 1"},
+			},
+			FailPlaylists: map[string]error{
+				"pl1": errors.New("boom"),
+			},
+		}
+
+		res, err := domain.Apply(ctx, dst, cl.Operations(2))
+		assert.NoError(err)
+		assert.True(res.HasFailures())
+		assert.Len(res.Failed, 2)
+		assert.Len(res.Succeeded, 3)
+		assert.ErrorContains(res.Err(), "boom")
+
+		p2, err := dst.GetPlaylistByName(ctx, "Playlist 2")
+		assert.NoError(err)
+		assert.Len(p2.Tracks, 3)
+	})
+
+	t.Run("resume from journal", func(t *testing.T) {
+		dst := &mockConnector{
+			Playlists: []*domain.Playlist{
+				{ID: "pl1", Name: "Playlist 1"},
+			},
+			FailPlaylists: map[string]error{
+				"pl1": errors.New("boom"),
+			},
+		}
+
+		j := &memJournal{}
+		ops := cl.Operations(2)
+
+		res, err := domain.Apply(ctx, dst, ops, domain.WithJournal(j))
+		assert.NoError(err)
+		assert.Len(res.Failed, 2)
+		assert.Len(j.ops, 3)
+
+		delete(dst.FailPlaylists, "pl1")
+
+		res, err = domain.Apply(ctx, dst, ops, domain.WithJournal(j))
+		assert.NoError(err)
+		assert.False(res.HasFailures())
+		assert.Len(res.Resumed, 3)
+		assert.Len(res.Succeeded, 2)
+		assert.Len(dst.Playlists, 2)
+
+		p1, err := dst.GetPlaylistByName(ctx, "Playlist 1")
+		assert.NoError(err)
+		assert.Len(p1.Tracks, 3)
+
+		p2, err := dst.GetPlaylistByName(ctx, "Playlist 2")
+		assert.NoError(err)
+		assert.Len(p2.Tracks, 3)
+	})
+
+	t.Run("failed creation skips playlist operations", func(t *testing.T) {
+		dst := &mockConnector{
+			Playlists: []*domain.Playlist{
+				{ID: "pl1", Name: "Playlist 1"},
+			},
+			FailPlaylists: map[string]error{
+				"Playlist 2": errors.New("boom"),
+			},
+		}
+
+		res, err := domain.Apply(ctx, dst, cl.Operations(2))
+		assert.NoError(err)
+		assert.Len(res.Failed, 3)
+		assert.Len(res.Succeeded, 2)
+		assert.Len(dst.Playlists, 1)
+	})
+}
@@ -0,0 +1,319 @@
+package fakeservice
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const appleMusicJSON = "application/json"
+
+// AppleMusic is a fake Apple Music API with the "us" storefront. Clients
+// authenticate with a music user token and a developer token signed with the
+// MusicKit key of the team.
+type AppleMusic struct {
+	*store
+	Server    *httptest.Server
+	UserToken string
+	TeamID    string
+	KeyID     string
+	// PageSize is the number of playlists and playlist tracks per page.
+	PageSize int
+
+	key      *ecdsa.PublicKey
+	searches []string
+}
+
+// NewAppleMusic returns a fake that checks developer tokens against key.
+func NewAppleMusic(t testing.TB, lib Library, key *ecdsa.PublicKey) *AppleMusic {
+	s := &AppleMusic{
+		store:     newStore(lib, prefixedIDs),
+		UserToken: "user-token",
+		TeamID:    "TEAM123",
+		KeyID:     "KEY123",
+		PageSize:  100,
+		key:       key,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/me/storefront", s.auth(s.storefront))
+	mux.HandleFunc("GET /v1/me/library/playlists", s.auth(s.getPlaylists))
+	mux.HandleFunc("POST /v1/me/library/playlists", s.auth(s.postPlaylist))
+	mux.HandleFunc("GET /v1/me/library/playlists/{id}", s.auth(s.getPlaylist))
+	mux.HandleFunc("GET /v1/me/library/playlists/{id}/tracks", s.auth(s.getTracks))
+	mux.HandleFunc("POST /v1/me/library/playlists/{id}/tracks", s.auth(s.postTracks))
+	mux.HandleFunc("GET /v1/catalog/us/songs", s.auth(s.getSongs))
+	mux.HandleFunc("GET /v1/catalog/us/search", s.auth(s.search))
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Server.Close)
+	return s
+}
+
+// Searches returns the catalog search terms received, in order.
+func (s *AppleMusic) Searches() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.searches)
+}
+
+func (s *AppleMusic) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := s.verify(r); err != nil {
+			appleMusicError(w, http.StatusUnauthorized, "Unauthorized", err.Error())
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		next(w, r)
+	}
+}
+
+// verify checks the tokens of a request, including the ES256 signature of
+// the developer token.
+func (s *AppleMusic) verify(r *http.Request) error {
+	if r.Header.Get("Music-User-Token") != s.UserToken {
+		return errors.New("invalid music user token")
+	}
+
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("malformed developer token")
+	}
+
+	var header map[string]string
+	b, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	if json.Unmarshal(b, &header) != nil || header["alg"] != "ES256" || header["kid"] != s.KeyID {
+		return errors.New("invalid developer token header")
+	}
+
+	var claims map[string]any
+	b, _ = base64.RawURLEncoding.DecodeString(parts[1])
+	if json.Unmarshal(b, &claims) != nil || claims["iss"] != s.TeamID {
+		return errors.New("invalid developer token claims")
+	}
+
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if len(sig) != 64 || !ecdsa.Verify(s.key, hash[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		return errors.New("invalid developer token signature")
+	}
+	return nil
+}
+
+func (s *AppleMusic) storefront(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, appleMusicJSON, http.StatusOK, map[string]any{
+		"data": []map[string]string{{"id": "us", "type": "storefronts"}},
+	})
+}
+
+func (s *AppleMusic) getPlaylists(w http.ResponseWriter, r *http.Request) {
+	var data []any
+	for _, p := range s.playlists {
+		data = append(data, s.playlistJSON(p))
+	}
+	writeJSON(w, appleMusicJSON, http.StatusOK, s.page(r, data))
+}
+
+func (s *AppleMusic) postPlaylist(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Attributes struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+		} `json:"attributes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appleMusicError(w, http.StatusBadRequest, "Invalid Request Body", err.Error())
+		return
+	}
+
+	pl := s.createPlaylist(req.Attributes.Name, req.Attributes.Description, false)
+	writeJSON(w, appleMusicJSON, http.StatusCreated, map[string]any{"data": []any{s.playlistJSON(pl)}})
+}
+
+func (s *AppleMusic) getPlaylist(w http.ResponseWriter, r *http.Request) {
+	if pl := s.find(w, r); pl != nil {
+		writeJSON(w, appleMusicJSON, http.StatusOK, map[string]any{"data": []any{s.playlistJSON(pl)}})
+	}
+}
+
+// getTracks lists the library songs of a playlist, and answers 404 for an
+// empty one like the real API. Songs without an ISRC come without their
+// catalog song, as those Apple could not match to the catalog.
+func (s *AppleMusic) getTracks(w http.ResponseWriter, r *http.Request) {
+	pl := s.find(w, r)
+	if pl == nil {
+		return
+	}
+	if len(pl.items) == 0 {
+		appleMusicError(w, http.StatusNotFound, "Resource Not Found", "")
+		return
+	}
+
+	include := r.URL.Query().Get("include") == "catalog"
+	var data []any
+	for _, it := range pl.items {
+		t, _ := s.track(it.trackID)
+		res := map[string]any{
+			"id":   "i." + it.itemID,
+			"type": "library-songs",
+			"attributes": map[string]any{
+				"name":       t.Title,
+				"artistName": t.Artist,
+				"albumName":  t.Album,
+				"playParams": map[string]string{"id": "i." + it.itemID, "catalogId": t.ID},
+			},
+		}
+		if include && t.ISRC != "" {
+			res["relationships"] = map[string]any{"catalog": map[string]any{"data": []any{appleMusicSong(t)}}}
+		}
+		data = append(data, res)
+	}
+	writeJSON(w, appleMusicJSON, http.StatusOK, s.page(r, data))
+}
+
+// postTracks adds catalog songs, or the library songs of any playlist, to a
+// playlist.
+func (s *AppleMusic) postTracks(w http.ResponseWriter, r *http.Request) {
+	pl := s.find(w, r)
+	if pl == nil {
+		return
+	}
+
+	var req struct {
+		Data []struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		appleMusicError(w, http.StatusBadRequest, "Invalid Request Body", err.Error())
+		return
+	}
+
+	var ids []string
+	for _, d := range req.Data {
+		switch d.Type {
+		case "songs":
+			ids = append(ids, d.ID)
+		case "library-songs":
+			ids = append(ids, s.librarySong(d.ID))
+		default:
+			appleMusicError(w, http.StatusBadRequest, "Invalid Type", fmt.Sprintf("invalid type %q", d.Type))
+			return
+		}
+	}
+	if err := s.addTracks(pl, ids); err != nil {
+		appleMusicError(w, http.StatusBadRequest, "Invalid Parameter Value", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *AppleMusic) getSongs(w http.ResponseWriter, r *http.Request) {
+	isrc := r.URL.Query().Get("filter[isrc]")
+	data := []any{}
+	for _, t := range s.tracks {
+		if t.ISRC != "" && strings.EqualFold(t.ISRC, isrc) {
+			data = append(data, appleMusicSong(t))
+		}
+	}
+	writeJSON(w, appleMusicJSON, http.StatusOK, map[string]any{"data": data})
+}
+
+// search finds the songs whose title is part of the term.
+func (s *AppleMusic) search(w http.ResponseWriter, r *http.Request) {
+	term := r.URL.Query().Get("term")
+	s.searches = append(s.searches, term)
+
+	data := []any{}
+	for _, t := range s.tracks {
+		if containsFold(term, t.Title) {
+			data = append(data, appleMusicSong(t))
+		}
+	}
+	writeJSON(w, appleMusicJSON, http.StatusOK, map[string]any{
+		"results": map[string]any{"songs": map[string]any{"data": data}},
+	})
+}
+
+func (s *AppleMusic) find(w http.ResponseWriter, r *http.Request) *playlist {
+	pl := s.playlist(r.PathValue("id"))
+	if pl == nil {
+		appleMusicError(w, http.StatusNotFound, "Resource Not Found", "")
+	}
+	return pl
+}
+
+// librarySong returns the track of a library song ID.
+func (s *AppleMusic) librarySong(id string) string {
+	for _, p := range s.playlists {
+		for _, it := range p.items {
+			if "i."+it.itemID == id {
+				return it.trackID
+			}
+		}
+	}
+	return id
+}
+
+// page returns the page of data selected by the offset and limit parameters.
+// Like the real API, the link to the next page only carries the offset.
+func (s *AppleMusic) page(r *http.Request, data []any) map[string]any {
+	limit := min(queryInt(r, "limit", 25), s.PageSize)
+	start, end := bounds(len(data), queryInt(r, "offset", 0), limit)
+
+	res := map[string]any{"data": append([]any{}, data[start:end]...)}
+	if end < len(data) {
+		res["next"] = fmt.Sprintf("%s?offset=%d", r.URL.Path, end)
+	}
+	return res
+}
+
+func (s *AppleMusic) playlistJSON(p *playlist) map[string]any {
+	return map[string]any{
+		"id":   p.ID,
+		"type": "library-playlists",
+		"attributes": map[string]any{
+			"name":        p.Name,
+			"description": map[string]string{"standard": p.Description},
+			"canEdit":     p.Owner == s.user,
+			"isPublic":    p.Public,
+		},
+	}
+}
+
+func appleMusicSong(t Track) map[string]any {
+	attrs := map[string]any{
+		"name":             t.Title,
+		"artistName":       t.Artist,
+		"albumName":        t.Album,
+		"isrc":             t.ISRC,
+		"durationInMillis": t.Duration.Milliseconds(),
+		"trackNumber":      t.TrackNumber,
+		"discNumber":       t.DiscNumber,
+		"releaseDate":      t.ReleaseDate,
+		"url":              "https://music.apple.com/us/song/" + t.ID,
+	}
+	if t.Explicit {
+		attrs["contentRating"] = "explicit"
+	}
+	return map[string]any{"id": t.ID, "type": "songs", "attributes": attrs}
+}
+
+func appleMusicError(w http.ResponseWriter, status int, title, detail string) {
+	writeJSON(w, appleMusicJSON, status, map[string]any{
+		"errors": []map[string]string{{"status": fmt.Sprint(status), "title": title, "detail": detail}},
+	})
+}
@@ -0,0 +1,249 @@
+package fakeservice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const deezerJSON = "application/json; charset=utf-8"
+
+// Deezer is a fake Deezer API. Track IDs must be integers, like those of the
+// service. Errors are reported in the body of successful responses, as the
+// real API does.
+type Deezer struct {
+	*store
+	Server      *httptest.Server
+	AccessToken string
+
+	searches []string
+}
+
+func NewDeezer(t testing.TB, lib Library) *Deezer {
+	s := &Deezer{
+		store:       newStore(lib, numericIDs),
+		AccessToken: "deezer-access",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /user/me", s.auth(s.me))
+	mux.HandleFunc("GET /user/me/playlists", s.auth(s.getPlaylists))
+	mux.HandleFunc("POST /user/me/playlists", s.auth(s.postPlaylist))
+	mux.HandleFunc("GET /playlist/{id}", s.auth(s.getPlaylist))
+	mux.HandleFunc("POST /playlist/{id}", s.auth(s.postPlaylistDetails))
+	mux.HandleFunc("GET /playlist/{id}/tracks", s.auth(s.getTracks))
+	mux.HandleFunc("POST /playlist/{id}/tracks", s.auth(s.postTracks))
+	mux.HandleFunc("DELETE /playlist/{id}/tracks", s.auth(s.deleteTracks))
+	mux.HandleFunc("GET /track/{id}", s.auth(s.getTrack))
+	mux.HandleFunc("GET /search/track", s.auth(s.search))
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Server.Close)
+	return s
+}
+
+// Searches returns the advanced search queries received, in order.
+func (s *Deezer) Searches() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.searches)
+}
+
+func (s *Deezer) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("access_token") != s.AccessToken {
+			deezerError(w, "OAuthException", "Invalid OAuth access token.", 300)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		next(w, r)
+	}
+}
+
+func (s *Deezer) me(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, deezerJSON, http.StatusOK, s.userJSON(s.user))
+}
+
+func (s *Deezer) getPlaylists(w http.ResponseWriter, r *http.Request) {
+	var data []any
+	for _, p := range s.playlists {
+		data = append(data, s.playlistJSON(p))
+	}
+	writeJSON(w, deezerJSON, http.StatusOK, deezerPage(r, data))
+}
+
+func (s *Deezer) postPlaylist(w http.ResponseWriter, r *http.Request) {
+	pl := s.createPlaylist(r.URL.Query().Get("title"), "", false)
+	writeJSON(w, deezerJSON, http.StatusOK, map[string]any{"id": numericID(pl.ID)})
+}
+
+func (s *Deezer) getPlaylist(w http.ResponseWriter, r *http.Request) {
+	if pl := s.find(w, r); pl != nil {
+		writeJSON(w, deezerJSON, http.StatusOK, s.playlistJSON(pl))
+	}
+}
+
+func (s *Deezer) postPlaylistDetails(w http.ResponseWriter, r *http.Request) {
+	pl := s.find(w, r)
+	if pl == nil {
+		return
+	}
+	if pl.Owner != s.user {
+		deezerError(w, "PermissionException", "You do not own this playlist", 200)
+		return
+	}
+
+	q := r.URL.Query()
+	if q.Has("description") {
+		pl.Description = q.Get("description")
+	}
+	if q.Has("public") {
+		pl.Public = q.Get("public") == "true"
+	}
+	if q.Has("collaborative") {
+		pl.Collaborative = q.Get("collaborative") == "true"
+	}
+	writeJSON(w, deezerJSON, http.StatusOK, true)
+}
+
+// getTracks lists the tracks of a playlist, which come without their ISRC
+// like those of the real API.
+func (s *Deezer) getTracks(w http.ResponseWriter, r *http.Request) {
+	pl := s.find(w, r)
+	if pl == nil {
+		return
+	}
+
+	var data []any
+	for _, it := range pl.items {
+		t, _ := s.track(it.trackID)
+		res := s.trackJSON(t)
+		delete(res, "isrc")
+		res["time_add"] = it.addedAt.Unix()
+		data = append(data, res)
+	}
+	writeJSON(w, deezerJSON, http.StatusOK, deezerPage(r, data))
+}
+
+func (s *Deezer) postTracks(w http.ResponseWriter, r *http.Request) {
+	pl := s.find(w, r)
+	if pl == nil {
+		return
+	}
+
+	if err := s.addTracks(pl, strings.Split(r.URL.Query().Get("songs"), ",")); err != nil {
+		deezerError(w, "DataException", "no data", 800)
+		return
+	}
+	writeJSON(w, deezerJSON, http.StatusOK, true)
+}
+
+func (s *Deezer) deleteTracks(w http.ResponseWriter, r *http.Request) {
+	pl := s.find(w, r)
+	if pl == nil {
+		return
+	}
+
+	remove := strings.Split(r.URL.Query().Get("songs"), ",")
+	s.removeItems(pl, func(it item) bool { return slices.Contains(remove, it.trackID) })
+	writeJSON(w, deezerJSON, http.StatusOK, true)
+}
+
+// getTrack only looks tracks up by ISRC, as in /track/isrc:<code>.
+func (s *Deezer) getTrack(w http.ResponseWriter, r *http.Request) {
+	if isrc, found := strings.CutPrefix(r.PathValue("id"), "isrc:"); found {
+		for _, t := range s.tracks {
+			if strings.EqualFold(t.ISRC, isrc) {
+				writeJSON(w, deezerJSON, http.StatusOK, s.trackJSON(t))
+				return
+			}
+		}
+	}
+	deezerError(w, "DataException", "no data", 800)
+}
+
+func (s *Deezer) search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	s.searches = append(s.searches, q)
+
+	fields := searchFields(q)
+	data := []any{}
+	for _, t := range s.searchTracks(fields["track"], fields["artist"], fields["album"]) {
+		res := s.trackJSON(t)
+		delete(res, "isrc")
+		data = append(data, res)
+	}
+	writeJSON(w, deezerJSON, http.StatusOK, map[string]any{"data": data, "total": len(data)})
+}
+
+func (s *Deezer) find(w http.ResponseWriter, r *http.Request) *playlist {
+	pl := s.playlist(r.PathValue("id"))
+	if pl == nil {
+		deezerError(w, "DataException", "no data", 800)
+	}
+	return pl
+}
+
+// userJSON returns the user with the given name: the library user has ID 1
+// and every other user ID 2.
+func (s *Deezer) userJSON(name string) map[string]any {
+	id := 2
+	if name == s.user {
+		id = 1
+	}
+	return map[string]any{"id": id, "name": name}
+}
+
+func (s *Deezer) playlistJSON(p *playlist) map[string]any {
+	return map[string]any{
+		"id":            numericID(p.ID),
+		"title":         p.Name,
+		"description":   p.Description,
+		"public":        p.Public,
+		"collaborative": p.Collaborative,
+		"nb_tracks":     len(p.items),
+		"link":          "https://www.deezer.com/playlist/" + p.ID,
+		"picture_xl":    "https://cdn-images.dzcdn.net/images/playlist/" + p.ID + "/1000x1000.jpg",
+		"creator":       s.userJSON(p.Owner),
+	}
+}
+
+// trackJSON returns the track with its rank, which goes up to 1,000,000, and
+// its artist, whose ID is its position among the artists of the catalog.
+func (s *Deezer) trackJSON(t Track) map[string]any {
+	var artists []string
+	for _, c := range s.tracks {
+		if !slices.Contains(artists, c.Artist) {
+			artists = append(artists, c.Artist)
+		}
+	}
+
+	return map[string]any{
+		"id":              numericID(t.ID),
+		"title":           t.Title,
+		"isrc":            t.ISRC,
+		"link":            "https://www.deezer.com/track/" + t.ID,
+		"duration":        int(t.Duration.Seconds()),
+		"explicit_lyrics": t.Explicit,
+		"rank":            t.Popularity * 10000,
+		"artist":          map[string]any{"id": slices.Index(artists, t.Artist) + 1, "name": t.Artist},
+		"album":           map[string]string{"title": t.Album},
+	}
+}
+
+// deezerPage returns the page of data selected by the index and limit
+// parameters.
+func deezerPage(r *http.Request, data []any) map[string]any {
+	start, end := bounds(len(data), queryInt(r, "index", 0), queryInt(r, "limit", 25))
+	return map[string]any{"data": append([]any{}, data[start:end]...), "total": len(data)}
+}
+
+func deezerError(w http.ResponseWriter, typ, message string, code int) {
+	writeJSON(w, deezerJSON, http.StatusOK, map[string]any{
+		"error": map[string]any{"type": typ, "message": message, "code": code},
+	})
+}
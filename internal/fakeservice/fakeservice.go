@@ -0,0 +1,314 @@
+// Package fakeservice emulates the parts of the streaming service and media
+// server APIs used by nomuz with in-memory libraries, so connectors and the
+// CLI can be tested end to end without the real services.
+package fakeservice
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Track is a track of the catalog of a fake service. Featuring lists the
+// artists of the track after Artist and AlbumArtist defaults to Artist; the
+// media server fakes identify artists by the slug of their name.
+type Track struct {
+	ID          string
+	ISRC        string
+	MBID        string
+	Title       string
+	Artist      string
+	Featuring   []string
+	Album       string
+	AlbumArtist string
+	// ReleaseDate is a year or a date, as in 2006-01-02.
+	ReleaseDate string
+	TrackNumber int
+	DiscNumber  int
+	Duration    time.Duration
+	Explicit    bool
+	// Popularity is between 0 and 100.
+	Popularity int
+}
+
+func (t Track) artists() []string {
+	return append([]string{t.Artist}, t.Featuring...)
+}
+
+// year returns the year of the release date, or 0 if unknown.
+func (t Track) year() int {
+	year, _ := strconv.Atoi(strings.SplitN(t.ReleaseDate, "-", 2)[0])
+	return year
+}
+
+func (t Track) albumArtist() string {
+	if t.AlbumArtist != "" {
+		return t.AlbumArtist
+	}
+	return t.Artist
+}
+
+// Playlist is a playlist of a fake service. Tracks holds the IDs of its
+// tracks in order, Image the URL of its uploaded cover and Folder the path of
+// folders holding it, which only TIDAL exposes.
+type Playlist struct {
+	ID            string
+	Name          string
+	Description   string
+	Owner         string
+	Public        bool
+	Collaborative bool
+	Image         string
+	Folder        []string
+	Tracks        []string
+}
+
+// Library seeds a fake service. Playlists without an ID or owner get one
+// generated and the library user, respectively.
+type Library struct {
+	User      string
+	Tracks    []Track
+	Playlists []Playlist
+	// Saved holds the IDs of the tracks the user saved and Genres the genres
+	// of artists by name, which only Spotify exposes.
+	Saved  []string
+	Genres map[string][]string
+}
+
+// seededAt is when the tracks of seeded playlists were added.
+var seededAt = time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+
+type item struct {
+	trackID string
+	itemID  string
+	addedAt time.Time
+	addedBy string
+}
+
+type playlist struct {
+	Playlist
+	items []item
+}
+
+type idKind int
+
+const (
+	prefixedIDs idKind = iota
+	// numericIDs are for services whose IDs are integers.
+	numericIDs
+)
+
+// store holds the state shared by the fake services.
+type store struct {
+	mu        sync.Mutex
+	user      string
+	tracks    []Track
+	playlists []*playlist
+	saved     []item
+	genres    map[string][]string
+	ids       idKind
+	nextID    int
+}
+
+func newStore(lib Library, ids idKind) *store {
+	s := &store{
+		user:   lib.User,
+		tracks: slices.Clone(lib.Tracks),
+		genres: lib.Genres,
+		ids:    ids,
+	}
+	if s.user == "" {
+		s.user = "alice"
+	}
+
+	for _, p := range lib.Playlists {
+		if p.ID == "" {
+			p.ID = s.newID("playlist")
+		}
+		if p.Owner == "" {
+			p.Owner = s.user
+		}
+
+		pl := &playlist{Playlist: p}
+		for _, id := range p.Tracks {
+			pl.items = append(pl.items, item{trackID: id, itemID: s.newID("item"), addedAt: seededAt, addedBy: p.Owner})
+		}
+		pl.Tracks = nil
+		s.playlists = append(s.playlists, pl)
+	}
+
+	for _, id := range lib.Saved {
+		s.saved = append(s.saved, item{trackID: id, itemID: s.newID("item"), addedAt: seededAt, addedBy: s.user})
+	}
+	return s
+}
+
+// newID returns a unique ID; the caller must hold the lock or own the store.
+func (s *store) newID(prefix string) string {
+	s.nextID++
+	if s.ids == numericIDs {
+		return strconv.Itoa(s.nextID)
+	}
+	return prefix + strconv.Itoa(s.nextID)
+}
+
+func (s *store) track(id string) (Track, bool) {
+	for _, t := range s.tracks {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return Track{}, false
+}
+
+func (s *store) playlist(id string) *playlist {
+	for _, p := range s.playlists {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *store) createPlaylist(name, description string, public bool) *playlist {
+	pl := &playlist{Playlist: Playlist{
+		ID:          s.newID("playlist"),
+		Name:        name,
+		Description: description,
+		Owner:       s.user,
+		Public:      public,
+	}}
+	s.playlists = append(s.playlists, pl)
+	return pl
+}
+
+func (s *store) addTracks(pl *playlist, ids []string) error {
+	for _, id := range ids {
+		if _, found := s.track(id); !found {
+			return fmt.Errorf("unknown track %s", id)
+		}
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, id := range ids {
+		pl.items = append(pl.items, item{trackID: id, itemID: s.newID("item"), addedAt: now, addedBy: s.user})
+	}
+	return nil
+}
+
+// removeItems removes the items for which remove returns true.
+func (s *store) removeItems(pl *playlist, remove func(item) bool) {
+	pl.items = slices.DeleteFunc(pl.items, remove)
+}
+
+// searchTracks returns the tracks whose title, artist and album contain the
+// given values, ignoring case.
+func (s *store) searchTracks(title, artist, album string) []Track {
+	var res []Track
+	for _, t := range s.tracks {
+		if containsFold(t.Title, title) && containsFold(t.Artist, artist) && containsFold(t.Album, album) {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+func (s *store) snapshot(p *playlist) Playlist {
+	pl := p.Playlist
+	pl.Tracks = []string{}
+	for _, it := range p.items {
+		pl.Tracks = append(pl.Tracks, it.trackID)
+	}
+	return pl
+}
+
+// Playlists returns the current playlists of the service.
+func (s *store) Playlists() []Playlist {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pls []Playlist
+	for _, p := range s.playlists {
+		pls = append(pls, s.snapshot(p))
+	}
+	return pls
+}
+
+// Playlist returns the first playlist with the given name.
+func (s *store) Playlist(name string) (Playlist, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.playlists {
+		if p.Name == name {
+			return s.snapshot(p), true
+		}
+	}
+	return Playlist{}, false
+}
+
+// searchField matches the track, artist and album fields of advanced search
+// queries.
+var searchField = regexp.MustCompile(`(track|artist|album):"([^"]*)"`)
+
+// searchFields returns the fields of an advanced search query by name.
+func searchFields(q string) map[string]string {
+	fields := make(map[string]string)
+	for _, m := range searchField.FindAllStringSubmatch(q, -1) {
+		fields[m[1]] = m[2]
+	}
+	return fields
+}
+
+// numericID returns the number of an ID made of numericIDs.
+func numericID(id string) int64 {
+	n, _ := strconv.ParseInt(id, 10, 64)
+	return n
+}
+
+func slug(s string) string {
+	return strings.ReplaceAll(strings.ToLower(s), " ", "-")
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// bounds clamps offset and limit to n items.
+func bounds(n, offset, limit int) (int, int) {
+	start := min(max(offset, 0), n)
+	return start, min(start+limit, n)
+}
+
+func queryInt(r *http.Request, key string, def int) int {
+	if v, err := strconv.Atoi(r.URL.Query().Get(key)); err == nil {
+		return v
+	}
+	return def
+}
+
+func writeJSON(w http.ResponseWriter, contentType string, status int, v any) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// bearer reports whether the request carries the access token.
+func bearer(r *http.Request, token string) bool {
+	return r.Header.Get("Authorization") == "Bearer "+token
+}
+
+// clientCredentials extracts the client ID and secret sent with a token
+// request, either with basic auth or in the form.
+func clientCredentials(r *http.Request) (string, string) {
+	if id, secret, ok := r.BasicAuth(); ok {
+		return id, secret
+	}
+	return r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+}
@@ -0,0 +1,195 @@
+package fakeservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const jellyfinJSON = "application/json; charset=utf-8"
+
+// Jellyfin is a fake Jellyfin server. Besides the library user it has an
+// admin, so clients have to pick the right user.
+type Jellyfin struct {
+	*store
+	Server *httptest.Server
+	APIKey string
+	// UserID is the ID of the library user.
+	UserID string
+}
+
+func NewJellyfin(t testing.TB, lib Library) *Jellyfin {
+	s := &Jellyfin{
+		store:  newStore(lib, prefixedIDs),
+		APIKey: "jellyfin-key",
+	}
+	s.UserID = "user-" + slug(s.user)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /Users", s.auth(s.getUsers))
+	mux.HandleFunc("GET /Users/{user}/Items", s.auth(s.getItems))
+	mux.HandleFunc("POST /Playlists", s.auth(s.postPlaylist))
+	mux.HandleFunc("GET /Playlists/{id}/Items", s.auth(s.getPlaylistItems))
+	mux.HandleFunc("POST /Playlists/{id}/Items", s.auth(s.postPlaylistItems))
+	mux.HandleFunc("DELETE /Playlists/{id}/Items", s.auth(s.deletePlaylistItems))
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Server.Close)
+	return s
+}
+
+func (s *Jellyfin) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Emby-Token") != s.APIKey {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		next(w, r)
+	}
+}
+
+func (s *Jellyfin) getUsers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, jellyfinJSON, http.StatusOK, []map[string]string{
+		{"Id": "user-admin", "Name": "admin"},
+		{"Id": s.UserID, "Name": s.user},
+	})
+}
+
+// getItems lists the playlists of the user, or searches the audio items by
+// title.
+func (s *Jellyfin) getItems(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("user") != s.UserID {
+		http.NotFound(w, r)
+		return
+	}
+
+	q := r.URL.Query()
+	items := []any{}
+	switch q.Get("IncludeItemTypes") {
+	case "Playlist":
+		for _, p := range s.playlists {
+			if ids := q.Get("Ids"); ids != "" && !slices.Contains(strings.Split(ids, ","), p.ID) {
+				continue
+			}
+			items = append(items, map[string]any{
+				"Id":         p.ID,
+				"Name":       p.Name,
+				"Overview":   p.Description,
+				"ChildCount": len(p.items),
+			})
+		}
+	case "Audio":
+		for _, t := range s.searchTracks(q.Get("SearchTerm"), "", "") {
+			items = append(items, jellyfinItem(t))
+		}
+	}
+	writeJSON(w, jellyfinJSON, http.StatusOK, map[string]any{"Items": items, "TotalRecordCount": len(items)})
+}
+
+func (s *Jellyfin) postPlaylist(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name   string   `json:"Name"`
+		UserID string   `json:"UserId"`
+		IDs    []string `json:"Ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID != s.UserID {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	pl := s.createPlaylist(req.Name, "", false)
+	if err := s.addTracks(pl, req.IDs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, jellyfinJSON, http.StatusOK, map[string]string{"Id": pl.ID})
+}
+
+func (s *Jellyfin) getPlaylistItems(w http.ResponseWriter, r *http.Request) {
+	pl := s.find(w, r)
+	if pl == nil {
+		return
+	}
+
+	items := []any{}
+	for _, it := range pl.items {
+		t, _ := s.track(it.trackID)
+		res := jellyfinItem(t)
+		res["PlaylistItemId"] = it.itemID
+		items = append(items, res)
+	}
+	writeJSON(w, jellyfinJSON, http.StatusOK, map[string]any{"Items": items, "TotalRecordCount": len(items)})
+}
+
+func (s *Jellyfin) postPlaylistItems(w http.ResponseWriter, r *http.Request) {
+	pl := s.find(w, r)
+	if pl == nil {
+		return
+	}
+
+	if err := s.addTracks(pl, strings.Split(r.URL.Query().Get("Ids"), ",")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Jellyfin) deletePlaylistItems(w http.ResponseWriter, r *http.Request) {
+	pl := s.find(w, r)
+	if pl == nil {
+		return
+	}
+
+	remove := strings.Split(r.URL.Query().Get("EntryIds"), ",")
+	s.removeItems(pl, func(it item) bool { return slices.Contains(remove, it.itemID) })
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Jellyfin) find(w http.ResponseWriter, r *http.Request) *playlist {
+	pl := s.playlist(r.PathValue("id"))
+	if pl == nil {
+		http.NotFound(w, r)
+	}
+	return pl
+}
+
+func jellyfinItem(t Track) map[string]any {
+	var artists []map[string]string
+	for _, name := range t.artists() {
+		artists = append(artists, map[string]string{"Id": slug(name), "Name": name})
+	}
+
+	res := map[string]any{
+		"Id":                t.ID,
+		"Name":              t.Title,
+		"Type":              "Audio",
+		"Album":             t.Album,
+		"Artists":           t.artists(),
+		"ArtistItems":       artists,
+		"AlbumArtist":       t.albumArtist(),
+		"IndexNumber":       t.TrackNumber,
+		"ParentIndexNumber": t.DiscNumber,
+		"ProductionYear":    t.year(),
+		// Ticks are 100 nanoseconds.
+		"RunTimeTicks": t.Duration.Nanoseconds() / 100,
+	}
+	if strings.Count(t.ReleaseDate, "-") == 2 {
+		res["PremiereDate"] = t.ReleaseDate + "T00:00:00.0000000Z"
+	}
+
+	ids := map[string]string{}
+	if t.ISRC != "" {
+		ids["ISRC"] = t.ISRC
+	}
+	if t.MBID != "" {
+		ids["MusicBrainzRecording"] = t.MBID
+	}
+	res["ProviderIds"] = ids
+	return res
+}
@@ -0,0 +1,223 @@
+package fakeservice
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const plexJSON = "application/json"
+
+// Plex is a fake Plex Media Server with a single music library. Like the real
+// server, it cannot create a playlist without items.
+type Plex struct {
+	*store
+	Server    *httptest.Server
+	Token     string
+	MachineID string
+}
+
+func NewPlex(t testing.TB, lib Library) *Plex {
+	s := &Plex{
+		store:     newStore(lib, numericIDs),
+		Token:     "plex-token",
+		MachineID: "abc123",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /identity", s.auth(s.identity))
+	mux.HandleFunc("GET /library/sections", s.auth(s.sections))
+	mux.HandleFunc("GET /playlists", s.auth(s.getPlaylists))
+	mux.HandleFunc("POST /playlists", s.auth(s.postPlaylist))
+	mux.HandleFunc("GET /playlists/{id}", s.auth(s.getPlaylist))
+	mux.HandleFunc("PUT /playlists/{id}", s.auth(s.putPlaylist))
+	mux.HandleFunc("GET /playlists/{id}/items", s.auth(s.getItems))
+	mux.HandleFunc("PUT /playlists/{id}/items", s.auth(s.putItems))
+	mux.HandleFunc("DELETE /playlists/{id}/items/{item}", s.auth(s.deleteItem))
+	mux.HandleFunc("GET /hubs/search", s.auth(s.search))
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Server.Close)
+	return s
+}
+
+func (s *Plex) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Plex-Token") != s.Token {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		next(w, r)
+	}
+}
+
+func (s *Plex) identity(w http.ResponseWriter, r *http.Request) {
+	plexReply(w, map[string]any{"machineIdentifier": s.MachineID})
+}
+
+func (s *Plex) sections(w http.ResponseWriter, r *http.Request) {
+	plexReply(w, map[string]any{"Directory": []map[string]string{
+		{"key": "1", "type": "movie", "title": "Movies"},
+		{"key": "2", "type": "artist", "title": "Music"},
+	}})
+}
+
+func (s *Plex) getPlaylists(w http.ResponseWriter, r *http.Request) {
+	var data []any
+	for _, p := range s.playlists {
+		data = append(data, plexPlaylist(p))
+	}
+	plexReply(w, map[string]any{"Metadata": data})
+}
+
+func (s *Plex) postPlaylist(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	ids, err := s.parseURI(q.Get("uri"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pl := s.createPlaylist(q.Get("title"), "", false)
+	if err := s.addTracks(pl, ids); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	plexReply(w, map[string]any{"Metadata": []any{plexPlaylist(pl)}})
+}
+
+func (s *Plex) getPlaylist(w http.ResponseWriter, r *http.Request) {
+	if pl := s.find(w, r); pl != nil {
+		plexReply(w, map[string]any{"Metadata": []any{plexPlaylist(pl)}})
+	}
+}
+
+func (s *Plex) putPlaylist(w http.ResponseWriter, r *http.Request) {
+	pl := s.find(w, r)
+	if pl == nil {
+		return
+	}
+	if q := r.URL.Query(); q.Has("summary") {
+		pl.Description = q.Get("summary")
+	}
+	plexReply(w, map[string]any{})
+}
+
+func (s *Plex) getItems(w http.ResponseWriter, r *http.Request) {
+	pl := s.find(w, r)
+	if pl == nil {
+		return
+	}
+
+	var data []any
+	for _, it := range pl.items {
+		t, _ := s.track(it.trackID)
+		res := plexTrack(t)
+		res["playlistItemID"] = numericID(it.itemID)
+		data = append(data, res)
+	}
+	plexReply(w, map[string]any{"Metadata": data})
+}
+
+func (s *Plex) putItems(w http.ResponseWriter, r *http.Request) {
+	pl := s.find(w, r)
+	if pl == nil {
+		return
+	}
+
+	ids, err := s.parseURI(r.URL.Query().Get("uri"))
+	if err == nil {
+		err = s.addTracks(pl, ids)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	plexReply(w, map[string]any{})
+}
+
+func (s *Plex) deleteItem(w http.ResponseWriter, r *http.Request) {
+	pl := s.find(w, r)
+	if pl == nil {
+		return
+	}
+	s.removeItems(pl, func(it item) bool { return it.itemID == r.PathValue("item") })
+	w.WriteHeader(http.StatusOK)
+}
+
+// search finds tracks by title and answers with an artist hub first, as the
+// real server does.
+func (s *Plex) search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("query")
+
+	var data []any
+	for _, t := range s.searchTracks(query, "", "") {
+		data = append(data, plexTrack(t))
+	}
+	plexReply(w, map[string]any{"Hub": []map[string]any{
+		{"type": "artist", "Metadata": []map[string]string{{"ratingKey": slug(query), "title": query}}},
+		{"type": "track", "Metadata": data},
+	}})
+}
+
+func (s *Plex) find(w http.ResponseWriter, r *http.Request) *playlist {
+	pl := s.playlist(r.PathValue("id"))
+	if pl == nil {
+		http.NotFound(w, r)
+	}
+	return pl
+}
+
+// parseURI returns the rating keys referenced by a library items URI.
+func (s *Plex) parseURI(uri string) ([]string, error) {
+	prefix := "server://" + s.MachineID + "/com.plexapp.plugins.library/library/metadata/"
+	ids, found := strings.CutPrefix(uri, prefix)
+	if !found {
+		return nil, fmt.Errorf("invalid uri %q", uri)
+	}
+	return strings.Split(ids, ","), nil
+}
+
+func plexPlaylist(p *playlist) map[string]any {
+	return map[string]any{
+		"ratingKey":    p.ID,
+		"type":         "playlist",
+		"title":        p.Name,
+		"summary":      p.Description,
+		"playlistType": "audio",
+		"leafCount":    len(p.items),
+	}
+}
+
+// plexTrack returns the track under its album artist, with the track artist
+// as the original title when they differ.
+func plexTrack(t Track) map[string]any {
+	res := map[string]any{
+		"ratingKey":            t.ID,
+		"type":                 "track",
+		"title":                t.Title,
+		"grandparentTitle":     t.albumArtist(),
+		"grandparentRatingKey": slug(t.albumArtist()),
+		"parentTitle":          t.Album,
+		"parentYear":           t.year(),
+		"index":                t.TrackNumber,
+		"parentIndex":          t.DiscNumber,
+		"duration":             t.Duration.Milliseconds(),
+	}
+	if t.Artist != t.albumArtist() {
+		res["originalTitle"] = t.Artist
+	}
+	if t.MBID != "" {
+		res["Guid"] = []map[string]string{{"id": "mbid://" + t.MBID}}
+	}
+	return res
+}
+
+func plexReply(w http.ResponseWriter, container map[string]any) {
+	writeJSON(w, plexJSON, http.StatusOK, map[string]any{"MediaContainer": container})
+}
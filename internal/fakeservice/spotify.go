@@ -0,0 +1,485 @@
+package fakeservice
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const spotifyJSON = "application/json; charset=utf-8"
+
+// Spotify is a fake Spotify Web API. It only accepts its current access
+// token, which clients obtain by refreshing the token returned by Token.
+type Spotify struct {
+	*store
+	Server       *httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	accessToken  string
+	refreshToken string
+	refreshes    int
+	// images holds the uploaded covers by playlist ID.
+	images map[string][]byte
+}
+
+func NewSpotify(t testing.TB, lib Library) *Spotify {
+	s := &Spotify{
+		store:        newStore(lib, prefixedIDs),
+		ClientID:     "spotify-client",
+		ClientSecret: "spotify-secret",
+		accessToken:  "spotify-access-0",
+		refreshToken: "spotify-refresh",
+		images:       make(map[string][]byte),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/token", s.token)
+	mux.HandleFunc("GET /v1/me", s.auth(s.me))
+	mux.HandleFunc("GET /v1/users/{user}/playlists", s.auth(s.getPlaylists))
+	mux.HandleFunc("POST /v1/users/{user}/playlists", s.auth(s.postPlaylist))
+	mux.HandleFunc("GET /v1/playlists/{id}", s.auth(s.getPlaylist))
+	mux.HandleFunc("PUT /v1/playlists/{id}", s.auth(s.putPlaylist))
+	mux.HandleFunc("PUT /v1/playlists/{id}/images", s.auth(s.putImage))
+	mux.HandleFunc("GET /v1/playlists/{id}/tracks", s.auth(s.getItems))
+	mux.HandleFunc("POST /v1/playlists/{id}/tracks", s.auth(s.postItems))
+	mux.HandleFunc("DELETE /v1/playlists/{id}/tracks", s.auth(s.deleteItems))
+	mux.HandleFunc("GET /v1/search", s.auth(s.search))
+	mux.HandleFunc("GET /v1/me/tracks", s.auth(s.getSavedTracks))
+	mux.HandleFunc("GET /v1/artists", s.auth(s.getArtists))
+	mux.HandleFunc("GET /images/{id}", s.getImage)
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Server.Close)
+	return s
+}
+
+// BaseURL is the base URL of the Web API.
+func (s *Spotify) BaseURL() string {
+	return s.Server.URL + "/v1/"
+}
+
+// TokenURL is the endpoint tokens are refreshed at.
+func (s *Spotify) TokenURL() string {
+	return s.Server.URL + "/api/token"
+}
+
+// Token returns an expired token that can be refreshed, as stored after
+// logging in.
+func (s *Spotify) Token() *oauth2.Token {
+	return &oauth2.Token{
+		AccessToken:  "expired",
+		TokenType:    "Bearer",
+		RefreshToken: s.refreshToken,
+		Expiry:       time.Now().Add(-time.Hour),
+	}
+}
+
+// Refreshes returns how many times the token was refreshed.
+func (s *Spotify) Refreshes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refreshes
+}
+
+func (s *Spotify) token(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, secret := clientCredentials(r)
+	if id != s.ClientID || secret != s.ClientSecret {
+		writeJSON(w, spotifyJSON, http.StatusBadRequest, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if r.PostFormValue("grant_type") != "refresh_token" || r.PostFormValue("refresh_token") != s.refreshToken {
+		writeJSON(w, spotifyJSON, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	s.refreshes++
+	s.accessToken = fmt.Sprintf("spotify-access-%d", s.refreshes)
+	writeJSON(w, spotifyJSON, http.StatusOK, map[string]any{
+		"access_token": s.accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+	})
+}
+
+func (s *Spotify) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		ok := bearer(r, s.accessToken)
+		s.mu.Unlock()
+
+		if !ok {
+			spotifyError(w, http.StatusUnauthorized, "Invalid access token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Spotify) me(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, spotifyJSON, http.StatusOK, spotifyUser(s.user))
+}
+
+func (s *Spotify) getPlaylists(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []any
+	for _, p := range s.playlists {
+		items = append(items, s.playlistJSON(p))
+	}
+	writeJSON(w, spotifyJSON, http.StatusOK, s.page(r, items))
+}
+
+func (s *Spotify) postPlaylist(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name        string `json:"name"`
+		Public      bool   `json:"public"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		spotifyError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.PathValue("user") != s.user {
+		spotifyError(w, http.StatusForbidden, "You cannot create a playlist for another user")
+		return
+	}
+
+	pl := s.createPlaylist(req.Name, req.Description, req.Public)
+	res := s.playlistJSON(pl)
+	res["tracks"] = map[string]any{"items": []any{}, "total": 0}
+	writeJSON(w, spotifyJSON, http.StatusCreated, res)
+}
+
+// getPlaylist leaves the items out of the tracks page, which the connector
+// fetches separately.
+func (s *Spotify) getPlaylist(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pl := s.playlist(r.PathValue("id"))
+	if pl == nil {
+		spotifyError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+
+	res := s.playlistJSON(pl)
+	res["tracks"] = map[string]any{"items": []any{}, "total": len(pl.items)}
+	writeJSON(w, spotifyJSON, http.StatusOK, res)
+}
+
+func (s *Spotify) putPlaylist(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name          *string `json:"name"`
+		Public        *bool   `json:"public"`
+		Collaborative *bool   `json:"collaborative"`
+		Description   *string `json:"description"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		spotifyError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pl := s.playlist(r.PathValue("id"))
+	if pl == nil {
+		spotifyError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+	if pl.Owner != s.user {
+		spotifyError(w, http.StatusForbidden, "You cannot change details of a playlist you do not own")
+		return
+	}
+
+	if req.Name != nil {
+		pl.Name = *req.Name
+	}
+	if req.Public != nil {
+		pl.Public = *req.Public
+	}
+	if req.Collaborative != nil {
+		pl.Collaborative = *req.Collaborative
+	}
+	if req.Description != nil {
+		pl.Description = *req.Description
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// putImage accepts a base64 encoded JPEG and serves it from the fake server.
+func (s *Spotify) putImage(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, r.Body))
+	if err != nil || r.Header.Get("Content-Type") != "image/jpeg" {
+		spotifyError(w, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pl := s.playlist(r.PathValue("id"))
+	if pl == nil {
+		spotifyError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+
+	s.images[pl.ID] = data
+	pl.Image = s.Server.URL + "/images/" + pl.ID
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Spotify) getImage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, found := s.images[r.PathValue("id")]
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Write(data)
+}
+
+func (s *Spotify) getItems(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pl := s.playlist(r.PathValue("id"))
+	if pl == nil {
+		spotifyError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+
+	var items []any
+	for _, it := range pl.items {
+		t, _ := s.track(it.trackID)
+		items = append(items, map[string]any{
+			"added_at": it.addedAt.Format(time.RFC3339),
+			"added_by": map[string]string{"id": it.addedBy, "type": "user"},
+			"is_local": false,
+			"track":    spotifyTrack(t),
+		})
+	}
+	writeJSON(w, spotifyJSON, http.StatusOK, s.page(r, items))
+}
+
+func (s *Spotify) postItems(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URIs []string `json:"uris"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		spotifyError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pl := s.playlist(r.PathValue("id"))
+	if pl == nil {
+		spotifyError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+
+	var ids []string
+	for _, uri := range req.URIs {
+		ids = append(ids, strings.TrimPrefix(uri, "spotify:track:"))
+	}
+	if err := s.addTracks(pl, ids); err != nil {
+		spotifyError(w, http.StatusBadRequest, "Invalid base62 id")
+		return
+	}
+	writeJSON(w, spotifyJSON, http.StatusCreated, map[string]string{"snapshot_id": s.newID("snapshot")})
+}
+
+func (s *Spotify) deleteItems(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Tracks []struct {
+			URI string `json:"uri"`
+		} `json:"tracks"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		spotifyError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pl := s.playlist(r.PathValue("id"))
+	if pl == nil {
+		spotifyError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+
+	// Every occurrence of the tracks is removed, like the real API does.
+	remove := make(map[string]bool)
+	for _, t := range req.Tracks {
+		remove[strings.TrimPrefix(t.URI, "spotify:track:")] = true
+	}
+	s.removeItems(pl, func(it item) bool { return remove[it.trackID] })
+	writeJSON(w, spotifyJSON, http.StatusOK, map[string]string{"snapshot_id": s.newID("snapshot")})
+}
+
+func (s *Spotify) getSavedTracks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []any
+	for _, it := range s.saved {
+		t, _ := s.track(it.trackID)
+		items = append(items, map[string]any{
+			"added_at": it.addedAt.Format(time.RFC3339),
+			"track":    spotifyTrack(t),
+		})
+	}
+	writeJSON(w, spotifyJSON, http.StatusOK, s.page(r, items))
+}
+
+// getArtists returns the artists of the catalog with the requested IDs, and
+// null for unknown ones like the real API.
+func (s *Spotify) getArtists(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	artists := []any{}
+	for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		var artist any
+		for _, t := range s.tracks {
+			if slug(t.Artist) == id {
+				artist = map[string]any{
+					"id":     id,
+					"name":   t.Artist,
+					"genres": append([]string{}, s.genres[t.Artist]...),
+					"type":   "artist",
+				}
+				break
+			}
+		}
+		artists = append(artists, artist)
+	}
+	writeJSON(w, spotifyJSON, http.StatusOK, map[string]any{"artists": artists})
+}
+
+func (s *Spotify) search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tracks []Track
+	switch {
+	case strings.HasPrefix(q, "isrc:"):
+		isrc := strings.TrimPrefix(q, "isrc:")
+		for _, t := range s.tracks {
+			if strings.EqualFold(t.ISRC, isrc) {
+				tracks = append(tracks, t)
+			}
+		}
+	case searchField.MatchString(q):
+		fields := searchFields(q)
+		tracks = s.searchTracks(fields["track"], fields["artist"], fields["album"])
+	default:
+		if t, found := s.track(q); found {
+			tracks = append(tracks, t)
+		}
+	}
+
+	var items []any
+	for _, t := range tracks {
+		items = append(items, spotifyTrack(t))
+	}
+	writeJSON(w, spotifyJSON, http.StatusOK, map[string]any{"tracks": s.page(r, items)})
+}
+
+// page returns the requested page of items with a link to the next one.
+func (s *Spotify) page(r *http.Request, items []any) map[string]any {
+	offset := queryInt(r, "offset", 0)
+	limit := queryInt(r, "limit", 20)
+	start, end := bounds(len(items), offset, limit)
+
+	res := map[string]any{
+		"href":   s.Server.URL + r.URL.String(),
+		"items":  append([]any{}, items[start:end]...),
+		"limit":  limit,
+		"offset": start,
+		"total":  len(items),
+		"next":   nil,
+	}
+	if end < len(items) {
+		q := r.URL.Query()
+		q.Set("offset", fmt.Sprint(end))
+		q.Set("limit", fmt.Sprint(limit))
+		res["next"] = s.Server.URL + r.URL.Path + "?" + q.Encode()
+	}
+	return res
+}
+
+func (s *Spotify) playlistJSON(p *playlist) map[string]any {
+	images := []any{}
+	if p.Image != "" {
+		images = append(images, map[string]any{"url": p.Image})
+	}
+
+	return map[string]any{
+		"id":            p.ID,
+		"name":          p.Name,
+		"description":   p.Description,
+		"public":        p.Public,
+		"collaborative": p.Collaborative,
+		"images":        images,
+		"owner":         spotifyUser(p.Owner),
+		"tracks":        map[string]any{"total": len(p.items)},
+		"external_urls": map[string]string{"spotify": "https://open.spotify.com/playlist/" + p.ID},
+		"type":          "playlist",
+		"uri":           "spotify:playlist:" + p.ID,
+	}
+}
+
+func spotifyUser(id string) map[string]any {
+	return map[string]any{
+		"id":           id,
+		"display_name": id,
+		"type":         "user",
+		"uri":          "spotify:user:" + id,
+	}
+}
+
+func spotifyTrack(t Track) map[string]any {
+	return map[string]any{
+		"id":            t.ID,
+		"name":          t.Title,
+		"artists":       []any{map[string]string{"id": slug(t.Artist), "name": t.Artist, "type": "artist"}},
+		"album":         map[string]string{"id": slug(t.Album), "name": t.Album, "type": "album"},
+		"duration_ms":   t.Duration.Milliseconds(),
+		"external_ids":  map[string]string{"isrc": t.ISRC},
+		"external_urls": map[string]string{"spotify": "https://open.spotify.com/track/" + t.ID},
+		"type":          "track",
+		"uri":           "spotify:track:" + t.ID,
+	}
+}
+
+func spotifyError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, spotifyJSON, status, map[string]any{
+		"error": map[string]any{"status": status, "message": message},
+	})
+}
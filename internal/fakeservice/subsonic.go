@@ -0,0 +1,219 @@
+package fakeservice
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const subsonicJSON = "application/json"
+
+// Subsonic is a fake Subsonic server, as implemented by Navidrome and others.
+// Clients authenticate with a salted token of the password.
+type Subsonic struct {
+	*store
+	Server   *httptest.Server
+	Password string
+
+	queries []string
+}
+
+func NewSubsonic(t testing.TB, lib Library) *Subsonic {
+	s := &Subsonic{
+		store:    newStore(lib, prefixedIDs),
+		Password: "secret",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/ping.view", s.auth(s.ping))
+	mux.HandleFunc("/rest/getPlaylists.view", s.auth(s.getPlaylists))
+	mux.HandleFunc("/rest/getPlaylist.view", s.auth(s.getPlaylist))
+	mux.HandleFunc("/rest/createPlaylist.view", s.auth(s.newPlaylist))
+	mux.HandleFunc("/rest/updatePlaylist.view", s.auth(s.updatePlaylist))
+	mux.HandleFunc("/rest/search3.view", s.auth(s.search3))
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Server.Close)
+	return s
+}
+
+// Queries returns the search queries received, in order.
+func (s *Subsonic) Queries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.queries)
+}
+
+func (s *Subsonic) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		q := r.URL.Query()
+		sum := md5.Sum([]byte(s.Password + q.Get("s")))
+		if q.Get("u") != s.user || q.Get("t") != hex.EncodeToString(sum[:]) || q.Has("p") {
+			subsonicError(w, 40, "Wrong username or password")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Subsonic) ping(w http.ResponseWriter, r *http.Request) {
+	subsonicOK(w, nil)
+}
+
+func (s *Subsonic) getPlaylists(w http.ResponseWriter, r *http.Request) {
+	pls := []any{}
+	for _, p := range s.playlists {
+		pls = append(pls, s.playlistJSON(p, false))
+	}
+	subsonicOK(w, map[string]any{"playlists": map[string]any{"playlist": pls}})
+}
+
+func (s *Subsonic) getPlaylist(w http.ResponseWriter, r *http.Request) {
+	if pl := s.find(w, r.URL.Query().Get("id")); pl != nil {
+		subsonicOK(w, map[string]any{"playlist": s.playlistJSON(pl, true)})
+	}
+}
+
+func (s *Subsonic) newPlaylist(w http.ResponseWriter, r *http.Request) {
+	pl := s.createPlaylist(r.URL.Query().Get("name"), "", false)
+	subsonicOK(w, map[string]any{"playlist": s.playlistJSON(pl, true)})
+}
+
+// updatePlaylist removes the songs at the given indexes before adding the new
+// ones at the end.
+func (s *Subsonic) updatePlaylist(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	pl := s.find(w, q.Get("playlistId"))
+	if pl == nil {
+		return
+	}
+
+	var remove []string
+	for _, v := range q["songIndexToRemove"] {
+		if i, err := strconv.Atoi(v); err == nil && i >= 0 && i < len(pl.items) {
+			remove = append(remove, pl.items[i].itemID)
+		}
+	}
+	s.removeItems(pl, func(it item) bool { return slices.Contains(remove, it.itemID) })
+
+	if err := s.addTracks(pl, q["songIdToAdd"]); err != nil {
+		subsonicError(w, 70, "Song not found")
+		return
+	}
+	if q.Has("comment") {
+		pl.Description = q.Get("comment")
+	}
+	if q.Has("public") {
+		pl.Public = q.Get("public") == "true"
+	}
+	subsonicOK(w, nil)
+}
+
+// search3 finds the songs whose title, artist and MusicBrainz ID contain every
+// word of the query.
+func (s *Subsonic) search3(w http.ResponseWriter, r *http.Request) {
+	query := strings.ToLower(r.URL.Query().Get("query"))
+	s.queries = append(s.queries, query)
+
+	songs := []any{}
+	for _, t := range s.tracks {
+		if containsWords(strings.ToLower(t.Title+" "+t.Artist+" "+t.MBID), query) {
+			songs = append(songs, subsonicSong(t))
+		}
+	}
+	subsonicOK(w, map[string]any{"searchResult3": map[string]any{"song": songs}})
+}
+
+func containsWords(text, query string) bool {
+	for _, word := range strings.Fields(query) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Subsonic) find(w http.ResponseWriter, id string) *playlist {
+	pl := s.playlist(id)
+	if pl == nil {
+		subsonicError(w, 70, "Playlist not found")
+	}
+	return pl
+}
+
+func (s *Subsonic) playlistJSON(p *playlist, withEntries bool) map[string]any {
+	res := map[string]any{
+		"id":        p.ID,
+		"name":      p.Name,
+		"owner":     p.Owner,
+		"comment":   p.Description,
+		"public":    p.Public,
+		"songCount": len(p.items),
+	}
+	if withEntries {
+		entries := []any{}
+		for _, it := range p.items {
+			t, _ := s.track(it.trackID)
+			entries = append(entries, subsonicSong(t))
+		}
+		res["entry"] = entries
+	}
+	return res
+}
+
+func subsonicSong(t Track) map[string]any {
+	var artists []map[string]string
+	for _, name := range t.artists() {
+		artists = append(artists, map[string]string{"id": slug(name), "name": name})
+	}
+
+	res := map[string]any{
+		"id":                 t.ID,
+		"title":              t.Title,
+		"artist":             t.Artist,
+		"artistId":           slug(t.Artist),
+		"artists":            artists,
+		"album":              t.Album,
+		"displayAlbumArtist": t.albumArtist(),
+		"track":              t.TrackNumber,
+		"discNumber":         t.DiscNumber,
+		"year":               t.year(),
+		"duration":           int(t.Duration.Seconds()),
+		"musicBrainzId":      t.MBID,
+	}
+	if t.ISRC != "" {
+		res["isrc"] = []string{t.ISRC}
+	}
+	if t.Explicit {
+		res["explicitStatus"] = "explicit"
+	}
+	return res
+}
+
+func subsonicOK(w http.ResponseWriter, body map[string]any) {
+	if body == nil {
+		body = map[string]any{}
+	}
+	body["status"] = "ok"
+	subsonicReply(w, body)
+}
+
+func subsonicError(w http.ResponseWriter, code int, message string) {
+	subsonicReply(w, map[string]any{
+		"status": "failed",
+		"error":  map[string]any{"code": code, "message": message},
+	})
+}
+
+func subsonicReply(w http.ResponseWriter, body map[string]any) {
+	body["version"] = "1.16.1"
+	writeJSON(w, subsonicJSON, http.StatusOK, map[string]any{"subsonic-response": body})
+}
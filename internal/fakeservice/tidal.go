@@ -0,0 +1,803 @@
+package fakeservice
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const tidalJSON = "application/vnd.api+json"
+
+// Tidal is a fake TIDAL API. Clients authenticate with client credentials.
+type Tidal struct {
+	*store
+	Server       *httptest.Server
+	ClientID     string
+	ClientSecret string
+	// PageSize is the number of playlists and playlist items per page.
+	PageSize int
+	// NoFolders makes the folder endpoints answer 404, like API versions
+	// without them.
+	NoFolders bool
+
+	accessToken string
+	tokens      int
+	folderReads int
+	artworks    map[string]*tidalArtwork
+	// covers maps playlist IDs to the IDs of their cover artworks.
+	covers  map[string]string
+	folders []*tidalFolder
+}
+
+// tidalFolder is a playlist folder. Folders cannot be nested, so the playlists
+// in a folder are those whose folder path joins into its name.
+type tidalFolder struct {
+	id   string
+	name string
+}
+
+// tidalArtwork is an artwork created by a client, whose image is uploaded
+// separately.
+type tidalArtwork struct {
+	md5Hash string
+	size    int64
+	data    []byte
+}
+
+func NewTidal(t testing.TB, lib Library) *Tidal {
+	s := &Tidal{
+		store:        newStore(lib, prefixedIDs),
+		ClientID:     "tidal-client",
+		ClientSecret: "tidal-secret",
+		PageSize:     20,
+		accessToken:  "tidal-access",
+		artworks:     make(map[string]*tidalArtwork),
+		covers:       make(map[string]string),
+	}
+	for _, p := range s.playlists {
+		if name := folderName(p.Folder); name != "" && s.folder(name) == nil {
+			s.folders = append(s.folders, &tidalFolder{id: s.newID("folder"), name: name})
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/oauth2/token", s.token)
+	mux.HandleFunc("GET /v2/playlists", s.auth(s.getPlaylists))
+	mux.HandleFunc("POST /v2/playlists", s.auth(s.postPlaylist))
+	mux.HandleFunc("GET /v2/playlists/{id}", s.auth(s.getPlaylist))
+	mux.HandleFunc("PATCH /v2/playlists/{id}", s.auth(s.patchPlaylist))
+	mux.HandleFunc("PATCH /v2/playlists/{id}/relationships/coverArt", s.auth(s.patchCoverArt))
+	mux.HandleFunc("POST /v2/artworks", s.auth(s.postArtwork))
+	mux.HandleFunc("PUT /uploads/{id}", s.upload)
+	mux.HandleFunc("GET /images/{id}", s.getImage)
+	mux.HandleFunc("GET /v2/playlists/{id}/relationships/items", s.auth(s.getItems))
+	mux.HandleFunc("POST /v2/playlists/{id}/relationships/items", s.auth(s.postItems))
+	mux.HandleFunc("DELETE /v2/playlists/{id}/relationships/items", s.auth(s.deleteItems))
+	mux.HandleFunc("GET /v2/userCollectionFolders", s.auth(s.getFolders))
+	mux.HandleFunc("POST /v2/userCollectionFolders", s.auth(s.postFolder))
+	mux.HandleFunc("GET /v2/userCollectionFolders/{id}/relationships/items", s.auth(s.getFolderItems))
+	mux.HandleFunc("POST /v2/userCollectionFolders/{id}/relationships/items", s.auth(s.postFolderItems))
+	mux.HandleFunc("DELETE /v2/userCollectionFolders/{id}/relationships/items", s.auth(s.deleteFolderItems))
+	mux.HandleFunc("GET /v2/tracks", s.auth(s.getTracks))
+	mux.HandleFunc("GET /v2/searchResults/{query}/relationships/tracks", s.auth(s.search))
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Server.Close)
+	return s
+}
+
+// BaseURL is the base URL of the API.
+func (s *Tidal) BaseURL() string {
+	return s.Server.URL + "/v2"
+}
+
+// TokenURL is the endpoint client credentials are exchanged at.
+func (s *Tidal) TokenURL() string {
+	return s.Server.URL + "/v1/oauth2/token"
+}
+
+// Tokens returns how many access tokens were issued.
+func (s *Tidal) Tokens() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens
+}
+
+// FolderReads returns how many times the folder list was requested.
+func (s *Tidal) FolderReads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.folderReads
+}
+
+func (s *Tidal) token(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, secret := clientCredentials(r)
+	if id != s.ClientID || secret != s.ClientSecret {
+		writeJSON(w, "application/json", http.StatusUnauthorized, map[string]any{
+			"status": 401, "error": "invalid_client", "error_description": "Client not found",
+		})
+		return
+	}
+
+	s.tokens++
+	writeJSON(w, "application/json", http.StatusOK, map[string]any{
+		"access_token": s.accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   86400,
+	})
+}
+
+func (s *Tidal) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !bearer(r, s.accessToken) {
+			tidalError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid access token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Tidal) getPlaylists(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var data []any
+	for _, p := range s.playlists {
+		data = append(data, s.playlistJSON(p))
+	}
+	res := s.page(r, data)
+
+	// Only the covers of the playlists in the page are included.
+	if slices.Contains(r.URL.Query()["include"], "coverArt") {
+		included := []any{}
+		for _, p := range res["data"].([]any) {
+			if id, found := s.covers[p.(map[string]any)["id"].(string)]; found {
+				included = append(included, s.artworkJSON(id))
+			}
+		}
+		res["included"] = included
+	}
+	writeJSON(w, tidalJSON, http.StatusOK, res)
+}
+
+func (s *Tidal) postPlaylist(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Data struct {
+			Attributes struct {
+				Name        string `json:"name"`
+				Description string `json:"description"`
+				AccessType  string `json:"accessType"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		tidalError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a := req.Data.Attributes
+	pl := s.createPlaylist(a.Name, a.Description, a.AccessType == "PUBLIC")
+	writeJSON(w, tidalJSON, http.StatusCreated, map[string]any{"data": s.playlistJSON(pl)})
+}
+
+func (s *Tidal) getPlaylist(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pl := s.playlist(r.PathValue("id"))
+	if pl == nil {
+		tidalError(w, http.StatusNotFound, "NOT_FOUND", "Playlist not found")
+		return
+	}
+
+	res := map[string]any{"data": s.playlistJSON(pl), "links": map[string]any{"self": r.URL.String()}}
+	if id, found := s.covers[pl.ID]; found && slices.Contains(r.URL.Query()["include"], "coverArt") {
+		res["included"] = []any{s.artworkJSON(id)}
+	}
+	writeJSON(w, tidalJSON, http.StatusOK, res)
+}
+
+func (s *Tidal) patchPlaylist(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Data struct {
+			ID         string `json:"id"`
+			Attributes struct {
+				Name        *string `json:"name"`
+				Description *string `json:"description"`
+				AccessType  *string `json:"accessType"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		tidalError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pl := s.playlist(r.PathValue("id"))
+	if pl == nil || req.Data.ID != pl.ID {
+		tidalError(w, http.StatusNotFound, "NOT_FOUND", "Playlist not found")
+		return
+	}
+
+	a := req.Data.Attributes
+	if a.Name != nil {
+		pl.Name = *a.Name
+	}
+	if a.Description != nil {
+		pl.Description = *a.Description
+	}
+	if a.AccessType != nil {
+		pl.Public = *a.AccessType == "PUBLIC"
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// postArtwork creates an artwork and returns the link its image is uploaded
+// to.
+func (s *Tidal) postArtwork(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Data struct {
+			Attributes struct {
+				MediaType  string `json:"mediaType"`
+				SourceFile struct {
+					MD5Hash string `json:"md5Hash"`
+					Size    int64  `json:"size"`
+				} `json:"sourceFile"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		tidalError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", err.Error())
+		return
+	}
+
+	a := req.Data.Attributes
+	if a.MediaType != "IMAGE" || a.SourceFile.MD5Hash == "" || a.SourceFile.Size <= 0 {
+		tidalError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid source file")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.newID("artwork")
+	s.artworks[id] = &tidalArtwork{md5Hash: a.SourceFile.MD5Hash, size: a.SourceFile.Size}
+
+	res := s.artworkJSON(id)
+	res["attributes"].(map[string]any)["sourceFile"] = map[string]any{
+		"md5Hash": a.SourceFile.MD5Hash,
+		"size":    a.SourceFile.Size,
+		"status":  map[string]string{"moderationFileStatus": "NOT_MODERATED"},
+		"uploadLink": map[string]any{
+			"href": s.Server.URL + "/uploads/" + id,
+			"meta": map[string]any{
+				"method":  "PUT",
+				"headers": map[string]string{"X-Upload-Token": id},
+			},
+		},
+	}
+	writeJSON(w, tidalJSON, http.StatusCreated, map[string]any{"data": res})
+}
+
+// upload stores the image of an artwork if it matches the announced hash and
+// size.
+func (s *Tidal) upload(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	art, found := s.artworks[id]
+	if !found || r.Header.Get("X-Upload-Token") != id {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	sum := md5.Sum(data)
+	if base64.StdEncoding.EncodeToString(sum[:]) != art.md5Hash || int64(len(data)) != art.size {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	art.data = data
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Tidal) patchCoverArt(w http.ResponseWriter, r *http.Request) {
+	var req tidalItems
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		tidalError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pl := s.playlist(r.PathValue("id"))
+	if pl == nil {
+		tidalError(w, http.StatusNotFound, "NOT_FOUND", "Playlist not found")
+		return
+	}
+
+	if len(req.Data) != 1 || req.Data[0].Type != "artworks" {
+		tidalError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Expected one artwork")
+		return
+	}
+	id := req.Data[0].ID
+	if art, found := s.artworks[id]; !found || art.data == nil {
+		tidalError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Artwork not uploaded")
+		return
+	}
+
+	s.covers[pl.ID] = id
+	pl.Image = s.imageURL(id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Tidal) getImage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	art, found := s.artworks[r.PathValue("id")]
+	if !found || art.data == nil {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeContent(w, r, "", seededAt, bytes.NewReader(art.data))
+}
+
+func (s *Tidal) imageURL(artworkID string) string {
+	return s.Server.URL + "/images/" + artworkID
+}
+
+func (s *Tidal) artworkJSON(id string) map[string]any {
+	files := []any{}
+	if art := s.artworks[id]; art != nil && art.data != nil {
+		files = append(files, map[string]any{
+			"href": s.imageURL(id),
+			"meta": map[string]int{"width": 1080, "height": 1080},
+		})
+	}
+
+	return map[string]any{
+		"id":   id,
+		"type": "artworks",
+		"attributes": map[string]any{
+			"mediaType": "IMAGE",
+			"files":     files,
+		},
+	}
+}
+
+func (s *Tidal) getItems(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pl := s.playlist(r.PathValue("id"))
+	if pl == nil {
+		tidalError(w, http.StatusNotFound, "NOT_FOUND", "Playlist not found")
+		return
+	}
+
+	var data []any
+	for _, it := range pl.items {
+		data = append(data, map[string]any{
+			"id":   it.trackID,
+			"type": "tracks",
+			"meta": map[string]any{"itemId": it.itemID, "addedAt": it.addedAt.Format(time.RFC3339)},
+		})
+	}
+	writeJSON(w, tidalJSON, http.StatusOK, s.page(r, data))
+}
+
+type tidalItems struct {
+	Data []struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Meta struct {
+			ItemID string `json:"itemId"`
+		} `json:"meta"`
+	} `json:"data"`
+}
+
+func (s *Tidal) postItems(w http.ResponseWriter, r *http.Request) {
+	var req tidalItems
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		tidalError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pl := s.playlist(r.PathValue("id"))
+	if pl == nil {
+		tidalError(w, http.StatusNotFound, "NOT_FOUND", "Playlist not found")
+		return
+	}
+
+	var ids []string
+	for _, d := range req.Data {
+		ids = append(ids, d.ID)
+	}
+	if err := s.addTracks(pl, ids); err != nil {
+		tidalError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Tidal) deleteItems(w http.ResponseWriter, r *http.Request) {
+	var req tidalItems
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		tidalError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pl := s.playlist(r.PathValue("id"))
+	if pl == nil {
+		tidalError(w, http.StatusNotFound, "NOT_FOUND", "Playlist not found")
+		return
+	}
+
+	remove := make(map[string]bool)
+	for _, d := range req.Data {
+		remove[d.Meta.ItemID] = true
+	}
+	s.removeItems(pl, func(it item) bool { return remove[it.itemID] })
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Tidal) getFolders(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.folderReads++
+	if s.NoFolders {
+		tidalError(w, http.StatusNotFound, "NOT_FOUND", "Not found")
+		return
+	}
+	var data []any
+	for _, f := range s.folders {
+		data = append(data, map[string]any{
+			"id":   f.id,
+			"type": "userCollectionFolders",
+			"attributes": map[string]any{
+				"name":           f.name,
+				"collectionType": "PLAYLISTS",
+				"numberOfItems":  len(s.folderPlaylists(f)),
+			},
+		})
+	}
+	writeJSON(w, tidalJSON, http.StatusOK, s.page(r, data))
+}
+
+func (s *Tidal) postFolder(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Data struct {
+			Type       string `json:"type"`
+			Attributes struct {
+				Name           string `json:"name"`
+				CollectionType string `json:"collectionType"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		tidalError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", err.Error())
+		return
+	}
+
+	a := req.Data.Attributes
+	if req.Data.Type != "userCollectionFolders" || a.Name == "" || a.CollectionType != "PLAYLISTS" {
+		tidalError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Invalid folder")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f := &tidalFolder{id: s.newID("folder"), name: a.Name}
+	s.folders = append(s.folders, f)
+	writeJSON(w, tidalJSON, http.StatusCreated, map[string]any{"data": map[string]any{
+		"id":         f.id,
+		"type":       "userCollectionFolders",
+		"attributes": map[string]any{"name": f.name, "collectionType": "PLAYLISTS", "numberOfItems": 0},
+	}})
+}
+
+func (s *Tidal) getFolderItems(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f := s.folderByID(r.PathValue("id"))
+	if f == nil {
+		tidalError(w, http.StatusNotFound, "NOT_FOUND", "Folder not found")
+		return
+	}
+
+	var data []any
+	for _, p := range s.folderPlaylists(f) {
+		data = append(data, map[string]string{"id": p.ID, "type": "playlists"})
+	}
+	writeJSON(w, tidalJSON, http.StatusOK, s.page(r, data))
+}
+
+func (s *Tidal) postFolderItems(w http.ResponseWriter, r *http.Request) {
+	s.editFolderItems(w, r, func(f *tidalFolder, p *playlist) {
+		p.Folder = strings.Split(f.name, " / ")
+	})
+}
+
+func (s *Tidal) deleteFolderItems(w http.ResponseWriter, r *http.Request) {
+	s.editFolderItems(w, r, func(f *tidalFolder, p *playlist) {
+		if folderName(p.Folder) == f.name {
+			p.Folder = nil
+		}
+	})
+}
+
+// editFolderItems applies edit to the folder and each playlist in the
+// request.
+func (s *Tidal) editFolderItems(w http.ResponseWriter, r *http.Request, edit func(*tidalFolder, *playlist)) {
+	var req tidalItems
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		tidalError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f := s.folderByID(r.PathValue("id"))
+	if f == nil {
+		tidalError(w, http.StatusNotFound, "NOT_FOUND", "Folder not found")
+		return
+	}
+
+	var pls []*playlist
+	for _, d := range req.Data {
+		p := s.playlist(d.ID)
+		if d.Type != "playlists" || p == nil {
+			tidalError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Unknown playlist "+d.ID)
+			return
+		}
+		pls = append(pls, p)
+	}
+
+	for _, p := range pls {
+		edit(f, p)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Tidal) folder(name string) *tidalFolder {
+	for _, f := range s.folders {
+		if f.name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func (s *Tidal) folderByID(id string) *tidalFolder {
+	for _, f := range s.folders {
+		if f.id == id {
+			return f
+		}
+	}
+	return nil
+}
+
+func (s *Tidal) folderPlaylists(f *tidalFolder) []*playlist {
+	var pls []*playlist
+	for _, p := range s.playlists {
+		if folderName(p.Folder) == f.name {
+			pls = append(pls, p)
+		}
+	}
+	return pls
+}
+
+// folderName is the name of the folder for a folder path.
+func folderName(path []string) string {
+	return strings.Join(path, " / ")
+}
+
+func (s *Tidal) getTracks(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	ids := q["filter[id]"]
+	isrcs := q["filter[isrc]"]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tracks []Track
+	for _, t := range s.tracks {
+		if slices.Contains(ids, t.ID) || slices.ContainsFunc(isrcs, func(isrc string) bool {
+			return strings.EqualFold(isrc, t.ISRC)
+		}) {
+			tracks = append(tracks, t)
+		}
+	}
+
+	data := []any{}
+	included := []any{}
+	seen := make(map[string]bool)
+	for _, t := range tracks {
+		data = append(data, tidalTrack(t))
+		for _, inc := range []map[string]any{tidalArtist(t), tidalAlbum(t)} {
+			key := fmt.Sprint(inc["type"], "/", inc["id"])
+			if !seen[key] {
+				seen[key] = true
+				included = append(included, inc)
+			}
+		}
+	}
+	writeJSON(w, tidalJSON, http.StatusOK, map[string]any{
+		"data":     data,
+		"included": included,
+		"links":    map[string]string{"self": r.URL.String()},
+	})
+}
+
+// search matches tracks whose title or artist contain every word of the
+// query.
+func (s *Tidal) search(w http.ResponseWriter, r *http.Request) {
+	words := strings.Fields(r.PathValue("query"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := []any{}
+	for _, t := range s.tracks {
+		match := true
+		for _, word := range words {
+			if !containsFold(t.Title+" "+t.Artist, word) {
+				match = false
+				break
+			}
+		}
+		if match {
+			data = append(data, map[string]string{"id": t.ID, "type": "tracks"})
+		}
+	}
+	writeJSON(w, tidalJSON, http.StatusOK, map[string]any{
+		"data":  data,
+		"links": map[string]string{"self": r.URL.String()},
+	})
+}
+
+// page returns the page of data selected by the cursor, which is the offset
+// of its first resource, with a link to the next one.
+func (s *Tidal) page(r *http.Request, data []any) map[string]any {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("page[cursor]"))
+	start, end := bounds(len(data), offset, s.PageSize)
+
+	links := map[string]string{"self": r.URL.String()}
+	if end < len(data) {
+		q := r.URL.Query()
+		q.Set("page[cursor]", strconv.Itoa(end))
+		links["next"] = strings.TrimPrefix(r.URL.Path, "/v2") + "?" + q.Encode()
+	}
+	return map[string]any{
+		"data":  append([]any{}, data[start:end]...),
+		"links": links,
+	}
+}
+
+func (s *Tidal) playlistJSON(p *playlist) map[string]any {
+	access := "UNLISTED"
+	if p.Public {
+		access = "PUBLIC"
+	}
+	playlistType := "USER"
+	if p.Owner != s.user {
+		playlistType = "EDITORIAL"
+	}
+
+	coverArt := []any{}
+	if id, found := s.covers[p.ID]; found {
+		coverArt = append(coverArt, map[string]string{"id": id, "type": "artworks"})
+	}
+
+	return map[string]any{
+		"id":   p.ID,
+		"type": "playlists",
+		"relationships": map[string]any{
+			"coverArt": map[string]any{"data": coverArt, "links": map[string]string{}},
+		},
+		"attributes": map[string]any{
+			"name":           p.Name,
+			"description":    p.Description,
+			"accessType":     access,
+			"playlistType":   playlistType,
+			"numberOfItems":  len(p.items),
+			"bounded":        true,
+			"createdAt":      seededAt.Format(time.RFC3339),
+			"lastModifiedAt": seededAt.Format(time.RFC3339),
+			"externalLinks":  []any{tidalLink("playlist", p.ID)},
+		},
+	}
+}
+
+func tidalTrack(t Track) map[string]any {
+	return map[string]any{
+		"id":   t.ID,
+		"type": "tracks",
+		"attributes": map[string]any{
+			"title":         t.Title,
+			"isrc":          t.ISRC,
+			"duration":      isoDuration(t.Duration),
+			"explicit":      false,
+			"popularity":    0.5,
+			"mediaTags":     []string{"LOSSLESS"},
+			"externalLinks": []any{tidalLink("track", t.ID)},
+		},
+		"relationships": map[string]any{
+			"artists": map[string]any{"data": []any{map[string]string{"id": slug(t.Artist), "type": "artists"}}},
+			"albums":  map[string]any{"data": []any{map[string]string{"id": slug(t.Album), "type": "albums"}}},
+		},
+	}
+}
+
+func tidalArtist(t Track) map[string]any {
+	return map[string]any{
+		"id":         slug(t.Artist),
+		"type":       "artists",
+		"attributes": map[string]any{"name": t.Artist, "popularity": 0.5},
+	}
+}
+
+func tidalAlbum(t Track) map[string]any {
+	return map[string]any{
+		"id":         slug(t.Album),
+		"type":       "albums",
+		"attributes": map[string]any{"title": t.Album, "type": "ALBUM"},
+	}
+}
+
+func tidalLink(kind, id string) map[string]any {
+	return map[string]any{
+		"href": "https://tidal.com/browse/" + kind + "/" + url.PathEscape(id),
+		"meta": map[string]string{"type": "TIDAL_SHARING"},
+	}
+}
+
+func tidalError(w http.ResponseWriter, status int, code, detail string) {
+	writeJSON(w, tidalJSON, status, map[string]any{
+		"errors": []any{map[string]any{"status": strconv.Itoa(status), "code": code, "detail": detail}},
+	})
+}
+
+// isoDuration formats d as an ISO 8601 duration such as PT3M21S.
+func isoDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("PT%dM%dS", int(d.Minutes()), int(d.Seconds())%60)
+}
@@ -0,0 +1,323 @@
+package fakeservice
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const ytMusicJSON = "application/json; charset=UTF-8"
+
+// YTMusic is a fake YouTube Data API with the Google OAuth device flow, which
+// signs in at once. Its tracks are videos: Title is the title of the video
+// and Artist the channel that uploaded it, so tracks of seeded playlists that
+// are not in the catalog come back as deleted videos.
+type YTMusic struct {
+	*store
+	Server       *httptest.Server
+	ClientID     string
+	ClientSecret string
+	AccessToken  string
+	// PageSize is the number of playlists and playlist items per page.
+	PageSize int
+	// Unlisted holds the IDs of the unlisted playlists, which are neither
+	// public nor private.
+	Unlisted map[string]bool
+
+	logins int
+}
+
+func NewYTMusic(t testing.TB, lib Library) *YTMusic {
+	s := &YTMusic{
+		store:        newStore(lib, prefixedIDs),
+		ClientID:     "yt-client",
+		ClientSecret: "yt-secret",
+		AccessToken:  "yt-access",
+		PageSize:     50,
+		Unlisted:     make(map[string]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /device/code", s.deviceCode)
+	mux.HandleFunc("POST /token", s.token)
+	mux.HandleFunc("GET /playlists", s.auth(s.getPlaylists))
+	mux.HandleFunc("POST /playlists", s.auth(s.postPlaylist))
+	mux.HandleFunc("PUT /playlists", s.auth(s.putPlaylist))
+	mux.HandleFunc("GET /playlistItems", s.auth(s.getItems))
+	mux.HandleFunc("POST /playlistItems", s.auth(s.postItem))
+	mux.HandleFunc("DELETE /playlistItems", s.auth(s.deleteItem))
+	mux.HandleFunc("GET /search", s.auth(s.search))
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Server.Close)
+	return s
+}
+
+// DeviceURL is the endpoint device codes are requested at.
+func (s *YTMusic) DeviceURL() string {
+	return s.Server.URL + "/device/code"
+}
+
+// TokenURL is the endpoint the device flow polls for tokens.
+func (s *YTMusic) TokenURL() string {
+	return s.Server.URL + "/token"
+}
+
+// Logins returns the number of device flow sign-ins.
+func (s *YTMusic) Logins() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.logins
+}
+
+// Privacy returns the privacy status of a playlist.
+func (s *YTMusic) Privacy(id string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pl := s.playlist(id); pl != nil {
+		return s.privacy(pl)
+	}
+	return ""
+}
+
+func (s *YTMusic) deviceCode(w http.ResponseWriter, r *http.Request) {
+	if r.PostFormValue("client_id") != s.ClientID {
+		writeJSON(w, ytMusicJSON, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	writeJSON(w, ytMusicJSON, http.StatusOK, map[string]any{
+		"device_code":      "device",
+		"user_code":        "ABCD-EFGH",
+		"verification_url": "https://www.google.com/device",
+		"expires_in":       60,
+		"interval":         1,
+	})
+}
+
+func (s *YTMusic) token(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, secret := clientCredentials(r)
+	if id != s.ClientID || secret != s.ClientSecret {
+		writeJSON(w, ytMusicJSON, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if r.PostFormValue("device_code") != "device" {
+		writeJSON(w, ytMusicJSON, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	s.logins++
+	writeJSON(w, ytMusicJSON, http.StatusOK, map[string]any{
+		"access_token":  s.AccessToken,
+		"refresh_token": "yt-refresh",
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+	})
+}
+
+func (s *YTMusic) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !bearer(r, s.AccessToken) {
+			ytMusicError(w, http.StatusUnauthorized, "Invalid Credentials")
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		next(w, r)
+	}
+}
+
+// getPlaylists lists the playlists of the user, or the one with the given
+// ID.
+func (s *YTMusic) getPlaylists(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+
+	var items []any
+	for _, p := range s.playlists {
+		if id != "" && p.ID != id {
+			continue
+		}
+		items = append(items, s.playlistJSON(p))
+	}
+	writeJSON(w, ytMusicJSON, http.StatusOK, s.page(r, items))
+}
+
+func (s *YTMusic) postPlaylist(w http.ResponseWriter, r *http.Request) {
+	var req ytMusicPlaylist
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ytMusicError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	pl := s.createPlaylist(req.Snippet.Title, req.Snippet.Description, false)
+	s.setPrivacy(pl, req.Status.PrivacyStatus)
+	writeJSON(w, ytMusicJSON, http.StatusOK, s.playlistJSON(pl))
+}
+
+// putPlaylist replaces the snippet and status of a playlist, and rejects
+// snippets without a title like the real API.
+func (s *YTMusic) putPlaylist(w http.ResponseWriter, r *http.Request) {
+	var req ytMusicPlaylist
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ytMusicError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	pl := s.find(w, req.ID)
+	if pl == nil {
+		return
+	}
+	if req.Snippet.Title == "" {
+		ytMusicError(w, http.StatusBadRequest, "Missing title")
+		return
+	}
+
+	pl.Name = req.Snippet.Title
+	pl.Description = req.Snippet.Description
+	s.setPrivacy(pl, req.Status.PrivacyStatus)
+	writeJSON(w, ytMusicJSON, http.StatusOK, s.playlistJSON(pl))
+}
+
+func (s *YTMusic) getItems(w http.ResponseWriter, r *http.Request) {
+	pl := s.find(w, r.URL.Query().Get("playlistId"))
+	if pl == nil {
+		return
+	}
+
+	var items []any
+	for _, it := range pl.items {
+		snippet := map[string]any{
+			"title":       "Deleted video",
+			"publishedAt": it.addedAt,
+			"resourceId":  map[string]string{"kind": "youtube#video", "videoId": it.trackID},
+		}
+		if t, found := s.track(it.trackID); found {
+			snippet["title"] = t.Title
+			snippet["videoOwnerChannelTitle"] = t.Artist
+		}
+		items = append(items, map[string]any{"id": it.itemID, "snippet": snippet})
+	}
+	writeJSON(w, ytMusicJSON, http.StatusOK, s.page(r, items))
+}
+
+func (s *YTMusic) postItem(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Snippet struct {
+			PlaylistID string `json:"playlistId"`
+			ResourceID struct {
+				VideoID string `json:"videoId"`
+			} `json:"resourceId"`
+		} `json:"snippet"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ytMusicError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	pl := s.find(w, req.Snippet.PlaylistID)
+	if pl == nil {
+		return
+	}
+	if err := s.addTracks(pl, []string{req.Snippet.ResourceID.VideoID}); err != nil {
+		ytMusicError(w, http.StatusNotFound, "Video not found")
+		return
+	}
+	writeJSON(w, ytMusicJSON, http.StatusOK, map[string]string{"id": pl.items[len(pl.items)-1].itemID})
+}
+
+func (s *YTMusic) deleteItem(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	for _, p := range s.playlists {
+		s.removeItems(p, func(it item) bool { return it.itemID == id })
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// search finds the videos whose title and channel contain every word of the
+// query.
+func (s *YTMusic) search(w http.ResponseWriter, r *http.Request) {
+	query := strings.ToLower(r.URL.Query().Get("q"))
+
+	items := []any{}
+	for _, t := range s.tracks {
+		if containsWords(strings.ToLower(t.Title+" "+t.Artist), query) {
+			items = append(items, map[string]any{
+				"id":      map[string]string{"kind": "youtube#video", "videoId": t.ID},
+				"snippet": map[string]string{"title": t.Title, "channelTitle": t.Artist},
+			})
+		}
+	}
+	writeJSON(w, ytMusicJSON, http.StatusOK, map[string]any{"items": items})
+}
+
+func (s *YTMusic) find(w http.ResponseWriter, id string) *playlist {
+	pl := s.playlist(id)
+	if pl == nil {
+		ytMusicError(w, http.StatusNotFound, "Playlist not found")
+	}
+	return pl
+}
+
+func (s *YTMusic) privacy(p *playlist) string {
+	switch {
+	case p.Public:
+		return "public"
+	case s.Unlisted[p.ID]:
+		return "unlisted"
+	default:
+		return "private"
+	}
+}
+
+func (s *YTMusic) setPrivacy(p *playlist, privacy string) {
+	p.Public = privacy == "public"
+	s.Unlisted[p.ID] = privacy == "unlisted"
+}
+
+// page returns the page of items selected by the page token, which is the
+// offset of its first item.
+func (s *YTMusic) page(r *http.Request, items []any) map[string]any {
+	var offset int
+	fmt.Sscanf(r.URL.Query().Get("pageToken"), "page%d", &offset)
+	start, end := bounds(len(items), offset, min(queryInt(r, "maxResults", 5), s.PageSize))
+
+	res := map[string]any{"items": append([]any{}, items[start:end]...)}
+	if end < len(items) {
+		res["nextPageToken"] = fmt.Sprintf("page%d", end)
+	}
+	return res
+}
+
+func (s *YTMusic) playlistJSON(p *playlist) map[string]any {
+	return map[string]any{
+		"id":             p.ID,
+		"snippet":        map[string]string{"title": p.Name, "description": p.Description, "channelTitle": p.Owner},
+		"status":         map[string]string{"privacyStatus": s.privacy(p)},
+		"contentDetails": map[string]int{"itemCount": len(p.items)},
+	}
+}
+
+// ytMusicPlaylist is the body of playlist inserts and updates.
+type ytMusicPlaylist struct {
+	ID      string `json:"id"`
+	Snippet struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+	} `json:"snippet"`
+	Status struct {
+		PrivacyStatus string `json:"privacyStatus"`
+	} `json:"status"`
+}
+
+func ytMusicError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, ytMusicJSON, status, map[string]any{
+		"error": map[string]any{"code": status, "message": message},
+	})
+}
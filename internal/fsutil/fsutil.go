@@ -0,0 +1,57 @@
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteFileAtomic replaces the file by writing a temporary file in the same
+// directory and renaming it over the original, so readers never observe a
+// partially written file.
+func WriteFileAtomic(filePath string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+	return nil
+}
+
+var fileNameReplacer = strings.NewReplacer(
+	"/", "_",
+	`\`, "_",
+	":", "_",
+	"*", "_",
+	"?", "_",
+	`"`, "_",
+	"<", "_",
+	">", "_",
+	"|", "_",
+)
+
+// SafeFileName turns a playlist name into a file name valid on common
+// filesystems.
+func SafeFileName(name string) string {
+	name = strings.TrimSpace(fileNameReplacer.Replace(name))
+	if name == "" || name == "." || name == ".." {
+		return "playlist"
+	}
+	return name
+}
@@ -0,0 +1,60 @@
+package imageutil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxSize caps downloaded images; services accept far smaller covers.
+const maxSize = 10 << 20
+
+// Image is a downloaded image.
+type Image struct {
+	Data []byte
+	// ContentType is the media type without parameters, e.g. image/jpeg.
+	ContentType string
+}
+
+// Fetch downloads an image with a plain client, so that the credentials of a
+// connector's client are never sent to the host serving the image.
+func Fetch(ctx context.Context, url string) (*Image, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create image request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download image: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download image: %w", err)
+	}
+	if len(data) > maxSize {
+		return nil, fmt.Errorf("image is larger than %d bytes", maxSize)
+	}
+
+	contentType, _, _ := strings.Cut(resp.Header.Get("Content-Type"), ";")
+	contentType = strings.TrimSpace(contentType)
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("%s is not an image", contentType)
+	}
+
+	return &Image{
+		Data:        data,
+		ContentType: contentType,
+	}, nil
+}
@@ -0,0 +1,112 @@
+package jellyfin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type client struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+type user struct {
+	ID   string `json:"Id"`
+	Name string `json:"Name"`
+}
+
+type itemsResponse struct {
+	Items            []item `json:"Items"`
+	TotalRecordCount int    `json:"TotalRecordCount"`
+}
+
+type item struct {
+	ID          string   `json:"Id"`
+	Name        string   `json:"Name"`
+	Overview    string   `json:"Overview"`
+	ChildCount  int      `json:"ChildCount"`
+	Album       string   `json:"Album"`
+	Artists     []string `json:"Artists"`
+	ArtistItems []struct {
+		ID   string `json:"Id"`
+		Name string `json:"Name"`
+	} `json:"ArtistItems"`
+	AlbumArtist       string `json:"AlbumArtist"`
+	IndexNumber       int    `json:"IndexNumber"`
+	ParentIndexNumber int    `json:"ParentIndexNumber"`
+	ProductionYear    int    `json:"ProductionYear"`
+	// PremiereDate is the release date, as an RFC 3339 timestamp.
+	PremiereDate   string `json:"PremiereDate"`
+	RunTimeTicks   int64  `json:"RunTimeTicks"`
+	PlaylistItemID string `json:"PlaylistItemId"`
+	// ProviderIDs holds external IDs such as MusicBrainzRecording and, when
+	// tagged, ISRC.
+	ProviderIDs map[string]string `json:"ProviderIds"`
+}
+
+type createPlaylistRequest struct {
+	Name      string   `json:"Name"`
+	UserID    string   `json:"UserId"`
+	MediaType string   `json:"MediaType"`
+	IDs       []string `json:"Ids"`
+}
+
+type createPlaylistResponse struct {
+	ID string `json:"Id"`
+}
+
+// do sends a request authenticated with the API key and decodes the JSON
+// response into out, if set. The X-Emby-Token header is understood by both
+// Jellyfin and Emby.
+func (c *client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	u := strings.TrimSuffix(c.baseURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-Emby-Token", c.apiKey)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to call %s %s: status code %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
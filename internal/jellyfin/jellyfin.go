@@ -0,0 +1,296 @@
+package jellyfin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+)
+
+const searchLimit = 20
+
+// NewConnector connects to a Jellyfin or Emby server with an API key. The
+// playlists are those of username, which is resolved to its user ID.
+func NewConnector(baseURL, apiKey, username string) (*connector, error) {
+	if baseURL == "" || apiKey == "" || username == "" {
+		return nil, fmt.Errorf("jellyfin url, api key and user are required")
+	}
+
+	c := &connector{
+		client: &client{
+			baseURL: baseURL,
+			apiKey:  apiKey,
+			http:    http.DefaultClient,
+		},
+	}
+
+	var users []user
+	if err := c.client.do(context.Background(), http.MethodGet, "/Users", nil, nil, &users); err != nil {
+		return nil, fmt.Errorf("failed to get jellyfin users: %w", err)
+	}
+
+	for _, u := range users {
+		if strings.EqualFold(u.Name, username) || u.ID == username {
+			c.userID = u.ID
+			break
+		}
+	}
+
+	if c.userID == "" {
+		return nil, fmt.Errorf("jellyfin user %s not found", username)
+	}
+
+	return c, nil
+}
+
+type connector struct {
+	client *client
+	userID string
+}
+
+var _ domain.Connector = (*connector)(nil)
+
+var Capabilities = domain.Capabilities{
+	CreatePlaylist: true,
+	DeleteTracks:   true,
+}
+
+func (c *connector) Capabilities() domain.Capabilities {
+	return Capabilities
+}
+
+func (c *connector) CreatePlaylist(ctx context.Context, name string) (*domain.Playlist, error) {
+	var res createPlaylistResponse
+	err := c.client.do(ctx, http.MethodPost, "/Playlists", nil, createPlaylistRequest{
+		Name:      name,
+		UserID:    c.userID,
+		MediaType: "Audio",
+		IDs:       []string{},
+	}, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create playlist: %w", err)
+	}
+
+	return &domain.Playlist{
+		ID:     res.ID,
+		Name:   name,
+		Owned:  true,
+		Tracks: []domain.Track{},
+	}, nil
+}
+
+func (c *connector) GetPlaylists(ctx context.Context) ([]*domain.Playlist, error) {
+	var res itemsResponse
+	err := c.client.do(ctx, http.MethodGet, "/Users/"+c.userID+"/Items", url.Values{
+		"IncludeItemTypes": {"Playlist"},
+		"Recursive":        {"true"},
+		"Fields":           {"Overview,ChildCount"},
+	}, nil, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlists: %w", err)
+	}
+
+	var pls []*domain.Playlist
+	for _, it := range res.Items {
+		pl := toDomainPlaylist(it)
+		pl.Tracks = make([]domain.Track, pl.TrackCount)
+		pls = append(pls, pl)
+	}
+	return pls, nil
+}
+
+func (c *connector) GetPlaylistByName(ctx context.Context, name string) (*domain.Playlist, error) {
+	pls, err := c.GetPlaylists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pl := range pls {
+		if pl.Name == name {
+			return c.withTracks(ctx, pl)
+		}
+	}
+	return nil, nil
+}
+
+func (c *connector) GetPlaylist(ctx context.Context, id string) (*domain.Playlist, error) {
+	var res itemsResponse
+	err := c.client.do(ctx, http.MethodGet, "/Users/"+c.userID+"/Items", url.Values{
+		"IncludeItemTypes": {"Playlist"},
+		"Recursive":        {"true"},
+		"Fields":           {"Overview,ChildCount"},
+		"Ids":              {id},
+	}, nil, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlist: %w", err)
+	}
+
+	if len(res.Items) == 0 {
+		return nil, nil
+	}
+	return c.withTracks(ctx, toDomainPlaylist(res.Items[0]))
+}
+
+func (c *connector) withTracks(ctx context.Context, pl *domain.Playlist) (*domain.Playlist, error) {
+	items, err := c.getPlaylistItems(ctx, pl.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracks for playlist %s: %w", pl.Name, err)
+	}
+
+	pl.Tracks = []domain.Track{}
+	for _, it := range items {
+		pl.Tracks = append(pl.Tracks, toDomainTrack(it))
+	}
+	pl.TrackCount = len(pl.Tracks)
+	return pl, nil
+}
+
+func (c *connector) AddTracksToPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	if len(tracks) == 0 {
+		return nil
+	}
+
+	var ids []string
+	for _, t := range tracks {
+		ids = append(ids, t.ID)
+	}
+
+	err := c.client.do(ctx, http.MethodPost, "/Playlists/"+id+"/Items", url.Values{
+		"Ids":    {strings.Join(ids, ",")},
+		"UserId": {c.userID},
+	}, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to add tracks to playlist: %w", err)
+	}
+	return nil
+}
+
+// DeleteTracksFromPlaylist removes every entry of the given tracks. Entries
+// are removed by their playlist entry ID, so the playlist is fetched first.
+func (c *connector) DeleteTracksFromPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	items, err := c.getPlaylistItems(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to remove tracks from playlist: %w", err)
+	}
+
+	remove := make(map[string]struct{})
+	for _, t := range tracks {
+		remove[t.ID] = struct{}{}
+	}
+
+	var entryIDs []string
+	for _, it := range items {
+		if _, found := remove[it.ID]; found {
+			entryIDs = append(entryIDs, it.PlaylistItemID)
+		}
+	}
+
+	if len(entryIDs) == 0 {
+		return nil
+	}
+
+	err = c.client.do(ctx, http.MethodDelete, "/Playlists/"+id+"/Items", url.Values{
+		"EntryIds": {strings.Join(entryIDs, ",")},
+	}, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to remove tracks from playlist: %w", err)
+	}
+	return nil
+}
+
+// UpdatePlaylist is not supported: the connector does not read playlist
+// details.
+func (c *connector) UpdatePlaylist(ctx context.Context, id string, meta domain.PlaylistMetadata) error {
+	return fmt.Errorf("failed to update playlist: %w", domain.ErrUnsupported)
+}
+
+// SearchTrack searches audio items by title and ranks them by MusicBrainz ID,
+// ISRC and title and artist.
+func (c *connector) SearchTrack(ctx context.Context, filters domain.TrackFilters) ([]domain.Track, error) {
+	if filters.Title == "" {
+		return nil, nil
+	}
+
+	var res itemsResponse
+	err := c.client.do(ctx, http.MethodGet, "/Users/"+c.userID+"/Items", url.Values{
+		"SearchTerm":       {filters.Title},
+		"IncludeItemTypes": {"Audio"},
+		"Recursive":        {"true"},
+		"Fields":           {"ProviderIds"},
+		"Limit":            {strconv.Itoa(searchLimit)},
+	}, nil, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search track: %w", err)
+	}
+
+	var tracks []domain.Track
+	for _, it := range res.Items {
+		tracks = append(tracks, toDomainTrack(it))
+	}
+	return domain.RankMatches(tracks, filters), nil
+}
+
+func (c *connector) getPlaylistItems(ctx context.Context, id string) ([]item, error) {
+	var res itemsResponse
+	err := c.client.do(ctx, http.MethodGet, "/Playlists/"+id+"/Items", url.Values{
+		"UserId": {c.userID},
+		"Fields": {"ProviderIds"},
+	}, nil, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res.Items, nil
+}
+
+func toDomainPlaylist(it item) *domain.Playlist {
+	return &domain.Playlist{
+		ID:          it.ID,
+		Name:        it.Name,
+		Description: it.Overview,
+		Owned:       true,
+		TrackCount:  it.ChildCount,
+	}
+}
+
+func toDomainTrack(it item) domain.Track {
+	t := domain.Track{
+		ID:          it.ID,
+		ISRC:        it.ProviderIDs["ISRC"],
+		MBID:        it.ProviderIDs["MusicBrainzRecording"],
+		Title:       it.Name,
+		Album:       it.Album,
+		AlbumArtist: it.AlbumArtist,
+		TrackNumber: it.IndexNumber,
+		DiscNumber:  it.ParentIndexNumber,
+		// Ticks are 100 nanoseconds.
+		Duration: time.Duration(it.RunTimeTicks) * 100,
+	}
+
+	if date, _, found := strings.Cut(it.PremiereDate, "T"); found {
+		t.ReleaseDate = date
+	} else if it.ProductionYear > 0 {
+		t.ReleaseDate = strconv.Itoa(it.ProductionYear)
+	}
+
+	if len(it.ArtistItems) > 0 {
+		for _, a := range it.ArtistItems {
+			t.Artists = append(t.Artists, domain.Artist{ID: a.ID, Name: a.Name})
+		}
+	} else {
+		for _, name := range it.Artists {
+			t.Artists = append(t.Artists, domain.Artist{Name: name})
+		}
+	}
+
+	if len(t.Artists) > 0 {
+		t.Artist = t.Artists[0].Name
+	} else {
+		t.Artist = it.AlbumArtist
+	}
+	return t
+}
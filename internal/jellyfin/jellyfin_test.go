@@ -0,0 +1,117 @@
+package jellyfin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/domain/connectortest"
+	"github.com/pedrobarco/nomuz/internal/fakeservice"
+	"github.com/pedrobarco/nomuz/internal/jellyfin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newConnector(t *testing.T, srv *fakeservice.Jellyfin) domain.Connector {
+	c, err := jellyfin.NewConnector(srv.Server.URL, srv.APIKey, "alice")
+	require.NoError(t, err)
+	return c
+}
+
+func TestConnector(t *testing.T) {
+	ctx := context.Background()
+
+	tracks := []fakeservice.Track{
+		{ID: "a1", ISRC: "USRC17607839", MBID: "4f3d9fc1-4ad4-4d1f-9c1a-5f5bbd6c2c36", Title: "Song", Artist: "Band",
+			Featuring: []string{"Guest"}, Album: "Album", ReleaseDate: "1999-03-22", TrackNumber: 1, Duration: 201 * time.Second},
+		{ID: "a2", Title: "Song", Artist: "Band", Album: "Live"},
+		{ID: "a3", Title: "Other Song", Artist: "Other Band"},
+	}
+
+	t.Run("unknown user", func(t *testing.T) {
+		srv := fakeservice.NewJellyfin(t, fakeservice.Library{})
+		_, err := jellyfin.NewConnector(srv.Server.URL, srv.APIKey, "bob")
+		assert.ErrorContains(t, err, "jellyfin user bob not found")
+
+		_, err = jellyfin.NewConnector(srv.Server.URL, "wrong", "alice")
+		assert.ErrorContains(t, err, "status code 401")
+	})
+
+	t.Run("playlists", func(t *testing.T) {
+		assert := assert.New(t)
+
+		srv := fakeservice.NewJellyfin(t, fakeservice.Library{Tracks: tracks})
+		c := newConnector(t, srv)
+
+		pl, err := c.CreatePlaylist(ctx, "Mix")
+		assert.NoError(err)
+		assert.NoError(c.AddTracksToPlaylist(ctx, pl.ID, []domain.Track{{ID: "a1"}, {ID: "a3"}, {ID: "a1"}}))
+
+		pls, err := c.GetPlaylists(ctx)
+		assert.NoError(err)
+		assert.Len(pls, 1)
+		assert.Equal(3, pls[0].Size())
+
+		got, err := c.GetPlaylistByName(ctx, "Mix")
+		assert.NoError(err)
+		assert.Len(got.Tracks, 3)
+		assert.Equal(domain.Track{
+			ID:          "a1",
+			ISRC:        "USRC17607839",
+			MBID:        "4f3d9fc1-4ad4-4d1f-9c1a-5f5bbd6c2c36",
+			Title:       "Song",
+			Artist:      "Band",
+			Artists:     []domain.Artist{{ID: "band", Name: "Band"}, {ID: "guest", Name: "Guest"}},
+			Album:       "Album",
+			AlbumArtist: "Band",
+			TrackNumber: 1,
+			ReleaseDate: "1999-03-22",
+			Duration:    201 * time.Second,
+		}, got.Tracks[0])
+		assert.Equal([]domain.Artist{{ID: "other-band", Name: "Other Band"}}, got.Tracks[1].Artists)
+
+		assert.NoError(c.DeleteTracksFromPlaylist(ctx, pl.ID, []domain.Track{{ID: "a1"}}))
+		mix, _ := srv.Playlist("Mix")
+		assert.Equal([]string{"a3"}, mix.Tracks)
+
+		got, err = c.GetPlaylistByName(ctx, "Missing")
+		assert.NoError(err)
+		assert.Nil(got)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		assert := assert.New(t)
+
+		c := newConnector(t, fakeservice.NewJellyfin(t, fakeservice.Library{Tracks: tracks}))
+
+		res, err := c.SearchTrack(ctx, domain.TrackFilters{ISRC: "USRC17607839", Title: "Song", Artist: "Band", Album: "Live"})
+		assert.NoError(err)
+		assert.Equal("a1", res[0].ID)
+
+		res, err = c.SearchTrack(ctx, domain.TrackFilters{Title: "Song", Artist: "Band", Album: "Live"})
+		assert.NoError(err)
+		assert.Equal([]string{"a2", "a1"}, []string{res[0].ID, res[1].ID})
+
+		res, err = c.SearchTrack(ctx, domain.TrackFilters{Title: "Song", Artist: "Nobody"})
+		assert.NoError(err)
+		assert.Empty(res)
+	})
+}
+
+func TestConformance(t *testing.T) {
+	connectortest.Run(t, connectortest.Harness{
+		New: func(t *testing.T) domain.Connector {
+			return newConnector(t, fakeservice.NewJellyfin(t, fakeservice.Library{
+				Tracks: []fakeservice.Track{
+					{ID: "a1", Title: "Song", Artist: "Band", Album: "Album"},
+					{ID: "a2", Title: "Other Song", Artist: "Other Band", Album: "Other Album"},
+				},
+			}))
+		},
+		Tracks: []domain.Track{
+			{ID: "a1", Title: "Song", Artist: "Band", Album: "Album"},
+			{ID: "a2", Title: "Other Song", Artist: "Other Band", Album: "Other Album"},
+		},
+	})
+}
@@ -0,0 +1,31 @@
+package jellyfin
+
+import (
+	"context"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/registry"
+)
+
+type Config struct {
+	URL    string `yaml:"url"`
+	APIKey string `yaml:"api_key"`
+	User   string `yaml:"user"`
+}
+
+func init() {
+	for _, name := range []string{"jellyfin", "emby"} {
+		registry.Register(registry.Connector[Config]{
+			Name:         name,
+			Usage:        name + "[:<user>]",
+			Description:  "Jellyfin/Emby server",
+			Capabilities: Capabilities,
+			New: func(ctx context.Context, cfg Config, arg string) (domain.Connector, error) {
+				if arg != "" {
+					cfg.User = arg
+				}
+				return NewConnector(cfg.URL, cfg.APIKey, cfg.User)
+			},
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+)
+
+type Plan struct {
+	From       string             `json:"from"`
+	To         string             `json:"to"`
+	CreatedAt  time.Time          `json:"created_at"`
+	Operations []domain.Operation `json:"operations"`
+}
+
+type entry struct {
+	Plan    *Plan                    `json:"plan,omitempty"`
+	Applied *domain.AppliedOperation `json:"applied,omitempty"`
+}
+
+type Journal struct {
+	mu      sync.Mutex
+	path    string
+	f       *os.File
+	plan    Plan
+	applied []domain.AppliedOperation
+}
+
+var _ domain.Journal = (*Journal)(nil)
+
+// DefaultPath returns ~/.config/nomuz/journal.jsonl, next to the config
+// file, on every platform.
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home dir: %w", err)
+	}
+	return path.Join(home, ".config", "nomuz", "journal.jsonl"), nil
+}
+
+func Exists(filePath string) (bool, error) {
+	_, err := os.Stat(filePath)
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to stat journal file: %w", err)
+}
+
+// Create starts a new journal for the plan, replacing any previous file.
+func Create(filePath string, plan Plan) (*Journal, error) {
+	if err := os.MkdirAll(path.Dir(filePath), 00755); err != nil {
+		return nil, fmt.Errorf("failed to create journal dir: %w", err)
+	}
+
+	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 00644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create journal file: %w", err)
+	}
+
+	if plan.CreatedAt.IsZero() {
+		plan.CreatedAt = time.Now().UTC()
+	}
+
+	j := &Journal{
+		path: filePath,
+		f:    f,
+		plan: plan,
+	}
+
+	if err := j.write(entry{Plan: &plan}); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// Open loads an existing journal so an interrupted sync can be resumed. A
+// truncated last line, left behind by a crash mid-write, is dropped.
+func Open(filePath string) (*Journal, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal file: %w", err)
+	}
+
+	j := &Journal{
+		path: filePath,
+	}
+
+	var hasPlan bool
+	var offset int
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			offset += len(line) + 1
+			continue
+		}
+
+		var e entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			if i == len(lines)-1 {
+				if err := os.Truncate(filePath, int64(offset)); err != nil {
+					return nil, fmt.Errorf("failed to truncate journal file: %w", err)
+				}
+				break
+			}
+			return nil, fmt.Errorf("failed to parse journal line %d: %w", i+1, err)
+		}
+		offset += len(line) + 1
+
+		switch {
+		case e.Plan != nil:
+			j.plan = *e.Plan
+			hasPlan = true
+		case e.Applied != nil:
+			j.applied = append(j.applied, *e.Applied)
+		}
+	}
+
+	if !hasPlan {
+		return nil, fmt.Errorf("journal %s has no plan", filePath)
+	}
+
+	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 00644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal file: %w", err)
+	}
+	j.f = f
+
+	return j, nil
+}
+
+func (j *Journal) Path() string {
+	return j.path
+}
+
+func (j *Journal) Plan() Plan {
+	return j.plan
+}
+
+func (j *Journal) Applied() []domain.AppliedOperation {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]domain.AppliedOperation(nil), j.applied...)
+}
+
+func (j *Journal) Record(op domain.AppliedOperation) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.write(entry{Applied: &op}); err != nil {
+		return err
+	}
+
+	j.applied = append(j.applied, op)
+	return nil
+}
+
+func (j *Journal) Close() error {
+	return j.f.Close()
+}
+
+// Remove closes and deletes the journal once every operation succeeded.
+func (j *Journal) Remove() error {
+	if err := j.f.Close(); err != nil {
+		return fmt.Errorf("failed to close journal file: %w", err)
+	}
+	if err := os.Remove(j.path); err != nil {
+		return fmt.Errorf("failed to remove journal file: %w", err)
+	}
+	return nil
+}
+
+func (j *Journal) write(e entry) error {
+	w := bufio.NewWriter(j.f)
+	if err := json.NewEncoder(w).Encode(e); err != nil {
+		return fmt.Errorf("failed to encode journal entry: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write journal entry: %w", err)
+	}
+	if err := j.f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package journal_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/journal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournal(t *testing.T) {
+	assert := assert.New(t)
+
+	filePath := path.Join(t.TempDir(), "journal.jsonl")
+	ref := domain.PlaylistRef{ID: "src1", Name: "Playlist 1"}
+	ops := []domain.Operation{
+		{Kind: domain.OperationCreatePlaylist, Playlist: ref},
+		{Kind: domain.OperationAddTracks, Playlist: ref, Tracks: []domain.Track{{ID: "t1"}}},
+	}
+
+	j, err := journal.Create(filePath, journal.Plan{
+		From:       "spotify",
+		To:         "tidal",
+		Operations: ops,
+	})
+	assert.NoError(err)
+
+	err = j.Record(domain.AppliedOperation{
+		Operation: ops[0],
+		Target:    domain.PlaylistRef{ID: "dst1", Name: "Playlist 1"},
+	})
+	assert.NoError(err)
+	assert.NoError(j.Close())
+
+	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 0)
+	assert.NoError(err)
+	_, err = f.WriteString(`{"applied":{"Kind":"add_tr`)
+	assert.NoError(err)
+	assert.NoError(f.Close())
+
+	j, err = journal.Open(filePath)
+	assert.NoError(err)
+	assert.Equal("tidal", j.Plan().To)
+	assert.Equal(ops, j.Plan().Operations)
+
+	applied := j.Applied()
+	assert.Len(applied, 1)
+	assert.Equal(ops[0].Key(), applied[0].Key())
+	assert.Equal("dst1", applied[0].Target.ID)
+
+	err = j.Record(domain.AppliedOperation{Operation: ops[1], Target: applied[0].Target})
+	assert.NoError(err)
+	assert.NoError(j.Close())
+
+	j, err = journal.Open(filePath)
+	assert.NoError(err)
+	assert.Len(j.Applied(), 2)
+
+	assert.NoError(j.Remove())
+
+	exists, err := journal.Exists(filePath)
+	assert.NoError(err)
+	assert.False(exists)
+}
+
+func TestDefaultPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", path.Join(home, "elsewhere"))
+
+	p, err := journal.DefaultPath()
+	assert.NoError(t, err)
+	assert.Equal(t, path.Join(home, ".config", "nomuz", "journal.jsonl"), p)
+}
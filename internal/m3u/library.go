@@ -0,0 +1,159 @@
+package m3u
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+)
+
+var audioExtensions = map[string]struct{}{
+	".mp3":  {},
+	".flac": {},
+	".m4a":  {},
+	".aac":  {},
+	".ogg":  {},
+	".opus": {},
+	".wav":  {},
+	".aiff": {},
+}
+
+type libraryTrack struct {
+	track domain.Track
+	// tagged is false when the metadata was guessed from the file name.
+	tagged bool
+}
+
+type library struct {
+	byPath map[string]*libraryTrack
+	byISRC map[string][]*libraryTrack
+	byKey  map[string][]*libraryTrack
+}
+
+func newLibrary() *library {
+	return &library{
+		byPath: make(map[string]*libraryTrack),
+		byISRC: make(map[string][]*libraryTrack),
+		byKey:  make(map[string][]*libraryTrack),
+	}
+}
+
+// indexLibrary walks the music library and indexes every audio file by path,
+// ISRC and normalized title and artist.
+func indexLibrary(root string) (*library, error) {
+	lib := newLibrary()
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isAudioFile(p) {
+			return nil
+		}
+
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return err
+		}
+
+		lib.add(abs, trackFromFile(abs))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to index music library: %w", err)
+	}
+
+	return lib, nil
+}
+
+func (l *library) add(p string, lt *libraryTrack) {
+	l.byPath[p] = lt
+	if isrc := strings.ToUpper(lt.track.ISRC); isrc != "" {
+		l.byISRC[isrc] = append(l.byISRC[isrc], lt)
+	}
+	if key := lt.track.RecordingKey(); key != "" {
+		l.byKey[key] = append(l.byKey[key], lt)
+	}
+}
+
+func (l *library) search(filters domain.TrackFilters) []domain.Track {
+	if lt, found := l.byPath[filters.ID]; found {
+		return []domain.Track{lt.track}
+	}
+
+	var matches []*libraryTrack
+	if filters.ISRC != "" {
+		matches = l.byISRC[strings.ToUpper(filters.ISRC)]
+	}
+
+	if len(matches) == 0 {
+		key := domain.Track{Title: filters.Title, Artist: filters.Artist}.RecordingKey()
+		matches = l.byKey[key]
+	}
+
+	var tracks, others []domain.Track
+	album := domain.NormalizeText(filters.Album)
+	for _, lt := range matches {
+		if album != "" && domain.NormalizeText(lt.track.Album) == album {
+			tracks = append(tracks, lt.track)
+		} else {
+			others = append(others, lt.track)
+		}
+	}
+	return append(tracks, others...)
+}
+
+func trackFromFile(p string) *libraryTrack {
+	t, err := readTags(p)
+	if err != nil && err != errNoTags {
+		slog.Warn("failed to read audio file tags", "path", p, "error", err)
+	}
+
+	tagged := t.Title != ""
+	if !tagged {
+		stem := strings.TrimSuffix(filepath.Base(p), filepath.Ext(p))
+		if artist, title, found := strings.Cut(stem, " - "); found {
+			t.Artists, t.Title = []string{strings.TrimSpace(artist)}, strings.TrimSpace(title)
+		} else {
+			t.Title = stem
+		}
+	}
+
+	tr := domain.Track{
+		ID:          p,
+		ISRC:        t.ISRC,
+		Title:       t.Title,
+		Album:       t.Album,
+		AlbumArtist: t.AlbumArtist,
+		UPC:         t.UPC,
+		TrackNumber: t.TrackNumber,
+		DiscNumber:  t.DiscNumber,
+		ReleaseDate: t.Date,
+		URL:         fileURL(p),
+		Duration:    t.Duration,
+	}
+	for _, name := range t.Artists {
+		tr.Artists = append(tr.Artists, domain.Artist{Name: name})
+	}
+	if len(tr.Artists) > 0 {
+		tr.Artist = tr.Artists[0].Name
+	}
+
+	return &libraryTrack{track: tr, tagged: tagged}
+}
+
+func fileURL(p string) string {
+	if strings.Contains(p, "://") {
+		return p
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(p)}).String()
+}
+
+func isAudioFile(name string) bool {
+	_, found := audioExtensions[strings.ToLower(filepath.Ext(name))]
+	return found
+}
@@ -0,0 +1,312 @@
+package m3u
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/fsutil"
+)
+
+// NewConnector treats every .m3u/.m3u8 file in dir as a playlist. When
+// libraryDir is set, the audio files below it are indexed so tracks from
+// other services can be matched to local files.
+func NewConnector(dir, libraryDir string) (*connector, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("m3u playlists dir is required")
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve m3u playlists dir: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 00755); err != nil {
+		return nil, fmt.Errorf("failed to create m3u playlists dir: %w", err)
+	}
+
+	lib := newLibrary()
+	if libraryDir != "" {
+		lib, err = indexLibrary(libraryDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &connector{
+		dir:     dir,
+		library: lib,
+	}, nil
+}
+
+type connector struct {
+	mu      sync.Mutex
+	dir     string
+	library *library
+}
+
+var _ domain.Connector = (*connector)(nil)
+
+var Capabilities = domain.Capabilities{
+	CreatePlaylist: true,
+	DeleteTracks:   true,
+	ISRCLookup:     true,
+}
+
+func (c *connector) Capabilities() domain.Capabilities {
+	return Capabilities
+}
+
+func (c *connector) CreatePlaylist(ctx context.Context, name string) (*domain.Playlist, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if pf, err := c.findPlaylist(name); err != nil {
+		return nil, err
+	} else if pf != nil {
+		return nil, fmt.Errorf("playlist %s already exists", name)
+	}
+
+	pf := &playlistFile{
+		path:   filepath.Join(c.dir, fsutil.SafeFileName(name)+".m3u8"),
+		name:   name,
+		header: []string{headerTag, playlistTag + name},
+	}
+
+	if _, err := os.Stat(pf.path); err == nil {
+		return nil, fmt.Errorf("playlist file %s already exists", pf.path)
+	}
+
+	if err := pf.write(); err != nil {
+		return nil, fmt.Errorf("failed to create playlist: %w", err)
+	}
+
+	return c.toDomainPlaylist(pf), nil
+}
+
+func (c *connector) GetPlaylists(ctx context.Context) ([]*domain.Playlist, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pfs, err := c.readPlaylists()
+	if err != nil {
+		return nil, err
+	}
+
+	var pls []*domain.Playlist
+	for _, pf := range pfs {
+		pls = append(pls, c.toDomainPlaylist(pf))
+	}
+	return pls, nil
+}
+
+func (c *connector) GetPlaylistByName(ctx context.Context, name string) (*domain.Playlist, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pf, err := c.findPlaylist(name)
+	if err != nil || pf == nil {
+		return nil, err
+	}
+	return c.toDomainPlaylist(pf), nil
+}
+
+func (c *connector) GetPlaylist(ctx context.Context, id string) (*domain.Playlist, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pf, err := c.readPlaylist(id)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.toDomainPlaylist(pf), nil
+}
+
+func (c *connector) AddTracksToPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pf, err := c.readPlaylist(id)
+	if err != nil {
+		return err
+	}
+
+	for _, tr := range tracks {
+		info := extinf{
+			Duration: tr.Duration,
+			Title:    tr.Title,
+			Artist:   tr.Artist,
+			Album:    tr.Album,
+		}
+		if lt, found := c.library.byPath[tr.ID]; found && lt.track.Duration > 0 {
+			info.Duration = lt.track.Duration
+		}
+		pf.entries = append(pf.entries, newEntry(pf.relativize(tr.ID), info))
+	}
+
+	if err := pf.write(); err != nil {
+		return fmt.Errorf("failed to add tracks to playlist: %w", err)
+	}
+	return nil
+}
+
+func (c *connector) DeleteTracksFromPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pf, err := c.readPlaylist(id)
+	if err != nil {
+		return err
+	}
+
+	remove := make(map[string]struct{})
+	for _, tr := range tracks {
+		remove[tr.ID] = struct{}{}
+	}
+
+	var entries []entry
+	for _, e := range pf.entries {
+		if _, found := remove[pf.resolve(e.location)]; !found {
+			entries = append(entries, e)
+		}
+	}
+	pf.entries = entries
+
+	if err := pf.write(); err != nil {
+		return fmt.Errorf("failed to remove tracks from playlist: %w", err)
+	}
+	return nil
+}
+
+// UpdatePlaylist is not supported: M3U files hold no playlist details.
+func (c *connector) UpdatePlaylist(ctx context.Context, id string, meta domain.PlaylistMetadata) error {
+	return fmt.Errorf("failed to update playlist: %w", domain.ErrUnsupported)
+}
+
+func (c *connector) SearchTrack(ctx context.Context, filters domain.TrackFilters) ([]domain.Track, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.library.search(filters), nil
+}
+
+func (c *connector) readPlaylists() ([]*playlistFile, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read m3u playlists dir: %w", err)
+	}
+
+	var pfs []*playlistFile
+	for _, e := range entries {
+		if e.IsDir() || !isPlaylistFile(e.Name()) {
+			continue
+		}
+
+		pf, err := readPlaylistFile(filepath.Join(c.dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read playlist %s: %w", e.Name(), err)
+		}
+		pfs = append(pfs, pf)
+	}
+
+	sort.Slice(pfs, func(i, j int) bool {
+		return pfs[i].path < pfs[j].path
+	})
+	return pfs, nil
+}
+
+func (c *connector) findPlaylist(name string) (*playlistFile, error) {
+	pfs, err := c.readPlaylists()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pf := range pfs {
+		if pf.name == name {
+			return pf, nil
+		}
+	}
+	return nil, nil
+}
+
+// readPlaylist loads a playlist by ID, which is the file name relative to the
+// playlists dir.
+func (c *connector) readPlaylist(id string) (*playlistFile, error) {
+	if id != filepath.Base(id) || !isPlaylistFile(id) {
+		return nil, fmt.Errorf("invalid m3u playlist id: %s", id)
+	}
+
+	pf, err := readPlaylistFile(filepath.Join(c.dir, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read playlist %s: %w", id, err)
+	}
+	return pf, nil
+}
+
+func (c *connector) toDomainPlaylist(pf *playlistFile) *domain.Playlist {
+	tracks := make([]domain.Track, 0, len(pf.entries))
+	for _, e := range pf.entries {
+		tracks = append(tracks, c.toDomainTrack(pf, e))
+	}
+
+	return &domain.Playlist{
+		ID:         filepath.Base(pf.path),
+		Name:       pf.name,
+		Owned:      true,
+		URL:        fileURL(pf.path),
+		TrackCount: len(tracks),
+		Tracks:     tracks,
+	}
+}
+
+// toDomainTrack prefers the tags of the indexed (or readable) file and falls
+// back to the #EXTINF metadata for entries that are not available locally or
+// carry no tags.
+func (c *connector) toDomainTrack(pf *playlistFile, e entry) domain.Track {
+	p := pf.resolve(e.location)
+
+	lt, found := c.library.byPath[p]
+	if !found && !strings.Contains(p, "://") {
+		if _, err := os.Stat(p); err == nil {
+			lt = trackFromFile(p)
+			c.library.add(p, lt)
+		}
+	}
+
+	info := e.info()
+	tr := domain.Track{
+		ID:       p,
+		Title:    info.Title,
+		Artist:   info.Artist,
+		Album:    info.Album,
+		URL:      fileURL(p),
+		Duration: info.Duration,
+	}
+
+	switch {
+	case lt == nil:
+	case lt.tagged:
+		extinf := tr
+		tr = lt.track
+		tr.Title = cmp.Or(tr.Title, extinf.Title)
+		tr.Artist = cmp.Or(tr.Artist, extinf.Artist)
+		tr.Album = cmp.Or(tr.Album, extinf.Album)
+		tr.Duration = cmp.Or(tr.Duration, extinf.Duration)
+	default:
+		tr.Title = cmp.Or(tr.Title, lt.track.Title)
+		tr.Artist = cmp.Or(tr.Artist, lt.track.Artist)
+	}
+
+	return tr
+}
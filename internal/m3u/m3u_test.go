@@ -0,0 +1,221 @@
+package m3u_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/domain/connectortest"
+	"github.com/pedrobarco/nomuz/internal/m3u"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func id3Frame(id, value string) []byte {
+	var b bytes.Buffer
+	b.WriteString(id)
+	binary.Write(&b, binary.BigEndian, uint32(len(value)+1))
+	b.Write([]byte{0, 0, 3})
+	b.WriteString(value)
+	return b.Bytes()
+}
+
+func writeMP3(t *testing.T, p string, frames map[string]string) {
+	var body bytes.Buffer
+	for _, id := range []string{"TIT2", "TPE1", "TALB", "TPE2", "TSRC", "TRCK", "TPOS", "TYER", "TLEN"} {
+		if v, found := frames[id]; found {
+			body.Write(id3Frame(id, v))
+		}
+	}
+
+	size := body.Len()
+	header := []byte{'I', 'D', '3', 3, 0, 0,
+		byte(size >> 21 & 0x7f), byte(size >> 14 & 0x7f), byte(size >> 7 & 0x7f), byte(size & 0x7f)}
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+	require.NoError(t, os.WriteFile(p, append(append(header, body.Bytes()...), 0xff, 0xfb), 0o644))
+}
+
+func writeFLAC(t *testing.T, p string, comments ...string) {
+	var vc bytes.Buffer
+	binary.Write(&vc, binary.LittleEndian, uint32(len("nomuz")))
+	vc.WriteString("nomuz")
+	binary.Write(&vc, binary.LittleEndian, uint32(len(comments)))
+	for _, c := range comments {
+		binary.Write(&vc, binary.LittleEndian, uint32(len(c)))
+		vc.WriteString(c)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("fLaC")
+	b.Write([]byte{0x84, byte(vc.Len() >> 16), byte(vc.Len() >> 8), byte(vc.Len())})
+	b.Write(vc.Bytes())
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+	require.NoError(t, os.WriteFile(p, b.Bytes(), 0o644))
+}
+
+func TestConnector(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	root := t.TempDir()
+	libDir := filepath.Join(root, "music")
+	plDir := filepath.Join(root, "playlists")
+
+	song := filepath.Join(libDir, "Band", "Album", "01 Song.mp3")
+	writeMP3(t, song, map[string]string{
+		"TIT2": "Song",
+		"TPE1": "Band\x00Guest",
+		"TALB": "Album",
+		"TPE2": "Band",
+		"TSRC": "USRC17607839",
+		"TRCK": "1/12",
+		"TPOS": "1/1",
+		"TYER": "1999",
+		"TLEN": "201000",
+	})
+	other := filepath.Join(libDir, "Other Band", "02.flac")
+	writeFLAC(t, other, "TITLE=Other Song", "ARTIST=Other Band", "ARTIST=Guest", "ALBUM=Other Album",
+		"ALBUMARTIST=Various Artists", "ISRC=GBAYE0601498", "BARCODE=00602537518357", "TRACKNUMBER=2", "DATE=2006-03-22")
+	untagged := filepath.Join(libDir, "Someone - Untagged.mp3")
+	require.NoError(t, os.WriteFile(untagged, []byte{0xff, 0xfb}, 0o644))
+
+	require.NoError(t, os.MkdirAll(plDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(plDir, "Mix.m3u8"), []byte(
+		"\xef\xbb\xbf#EXTM3U\n"+
+			"#EXTINF:201,Wrong - Title\n"+
+			"../music/Band/Album/01 Song.mp3\n"+
+			"#EXTINF:180,Offline Artist - Offline Song\n"+
+			"#EXTALB:Offline Album\n"+
+			"#EXTGENRE:Rock\n"+
+			"/missing/file.mp3\n",
+	), 0o644))
+
+	c, err := m3u.NewConnector(plDir, libDir)
+	require.NoError(t, err)
+
+	t.Run("read playlists", func(t *testing.T) {
+		pls, err := c.GetPlaylists(ctx)
+		assert.NoError(err)
+		assert.Len(pls, 1)
+		assert.Equal("Mix.m3u8", pls[0].ID)
+		assert.Equal("Mix", pls[0].Name)
+		assert.Equal(2, pls[0].Size())
+
+		tracks := pls[0].Tracks
+		assert.Equal(song, tracks[0].ID)
+		assert.Equal("Song", tracks[0].Title)
+		assert.Equal("Band", tracks[0].Artist)
+		assert.Equal([]domain.Artist{{Name: "Band"}, {Name: "Guest"}}, tracks[0].Artists)
+		assert.Equal("Band", tracks[0].AlbumArtist)
+		assert.Equal(1, tracks[0].TrackNumber)
+		assert.Equal(1, tracks[0].DiscNumber)
+		assert.Equal("1999", tracks[0].ReleaseDate)
+		assert.Equal("USRC17607839", tracks[0].ISRC)
+		assert.Equal("/missing/file.mp3", tracks[1].ID)
+		assert.Equal("Offline Song", tracks[1].Title)
+		assert.Equal("Offline Artist", tracks[1].Artist)
+		assert.Equal("Offline Album", tracks[1].Album)
+	})
+
+	t.Run("search library", func(t *testing.T) {
+		res, err := c.SearchTrack(ctx, domain.TrackFilters{ISRC: "gbaye0601498"})
+		assert.NoError(err)
+		assert.Len(res, 1)
+		assert.Equal(other, res[0].ID)
+		assert.Equal([]string{"Other Band", "Guest"}, res[0].ArtistNames())
+		assert.Equal("Various Artists", res[0].AlbumArtist)
+		assert.Equal("00602537518357", res[0].UPC)
+		assert.Equal(2, res[0].TrackNumber)
+		assert.Equal("2006-03-22", res[0].ReleaseDate)
+
+		res, err = c.SearchTrack(ctx, domain.TrackFilters{ID: "spotify-id", Title: "untagged", Artist: "someone"})
+		assert.NoError(err)
+		assert.Len(res, 1)
+		assert.Equal(untagged, res[0].ID)
+
+		res, err = c.SearchTrack(ctx, domain.TrackFilters{Title: "Nope"})
+		assert.NoError(err)
+		assert.Empty(res)
+	})
+
+	t.Run("create, add and delete", func(t *testing.T) {
+		pl, err := c.CreatePlaylist(ctx, "Road: Trip")
+		assert.NoError(err)
+		assert.Equal("Road_ Trip.m3u8", pl.ID)
+
+		_, err = c.CreatePlaylist(ctx, "Road: Trip")
+		assert.Error(err)
+
+		res, err := c.SearchTrack(ctx, domain.TrackFilters{ISRC: "USRC17607839"})
+		assert.NoError(err)
+		assert.NoError(c.AddTracksToPlaylist(ctx, pl.ID, res))
+
+		data, err := os.ReadFile(filepath.Join(plDir, pl.ID))
+		assert.NoError(err)
+		assert.Equal("#EXTM3U\n#PLAYLIST:Road: Trip\n#EXTINF:201,Band - Song\n#EXTALB:Album\n"+song+"\n", string(data))
+
+		got, err := c.GetPlaylistByName(ctx, "Road: Trip")
+		assert.NoError(err)
+		assert.Len(got.Tracks, 1)
+
+		mix, err := c.GetPlaylistByName(ctx, "Mix")
+		assert.NoError(err)
+		assert.NoError(c.DeleteTracksFromPlaylist(ctx, mix.ID, mix.Tracks[:1]))
+
+		data, err = os.ReadFile(filepath.Join(plDir, mix.ID))
+		assert.NoError(err)
+		assert.Equal("#EXTM3U\n#EXTINF:180,Offline Artist - Offline Song\n#EXTALB:Offline Album\n#EXTGENRE:Rock\n/missing/file.mp3\n", string(data))
+
+		entries, err := os.ReadDir(plDir)
+		assert.NoError(err)
+		assert.Len(entries, 2)
+	})
+
+	t.Run("sync to local collection", func(t *testing.T) {
+		srcDir := filepath.Join(root, "backup")
+		require.NoError(t, os.MkdirAll(srcDir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(srcDir, "Favorites.m3u"), []byte(
+			"#EXTM3U\n#EXTINF:-1,Other Band - Other Song\nhttps://example.com/1\n#EXTINF:-1,Nobody - Nothing\nhttps://example.com/2\n",
+		), 0o644))
+
+		src, err := m3u.NewConnector(srcDir, "")
+		require.NoError(t, err)
+
+		cl, err := domain.PlanSync(ctx, src, c)
+		assert.NoError(err)
+
+		_, err = domain.Sync(ctx, src, c, *cl)
+		assert.NoError(err)
+
+		pl, err := c.GetPlaylistByName(ctx, "Favorites")
+		assert.NoError(err)
+		assert.Len(pl.Tracks, 1)
+		assert.Equal(other, pl.Tracks[0].ID)
+	})
+}
+
+func TestConformance(t *testing.T) {
+	libDir := t.TempDir()
+	song := filepath.Join(libDir, "Band", "01 Song.mp3")
+	writeMP3(t, song, map[string]string{"TIT2": "Song", "TPE1": "Band", "TSRC": "USRC17607839"})
+	other := filepath.Join(libDir, "Other Band", "02.flac")
+	writeFLAC(t, other, "TITLE=Other Song", "ARTIST=Other Band")
+
+	connectortest.Run(t, connectortest.Harness{
+		New: func(t *testing.T) domain.Connector {
+			c, err := m3u.NewConnector(t.TempDir(), libDir)
+			require.NoError(t, err)
+			return c
+		},
+		Tracks: []domain.Track{
+			{ID: song, ISRC: "USRC17607839", Title: "Song", Artist: "Band"},
+			{ID: other, Title: "Other Song", Artist: "Other Band"},
+		},
+	})
+}
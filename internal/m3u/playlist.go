@@ -0,0 +1,194 @@
+package m3u
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/fsutil"
+)
+
+const (
+	headerTag   = "#EXTM3U"
+	playlistTag = "#PLAYLIST:"
+	extinfTag   = "#EXTINF:"
+	artistTag   = "#EXTART:"
+	albumTag    = "#EXTALB:"
+)
+
+type entry struct {
+	// directives are the comment lines preceding the location, kept verbatim
+	// so rewriting a playlist does not lose information we do not parse.
+	directives []string
+	location   string
+}
+
+type playlistFile struct {
+	path    string
+	name    string
+	header  []string
+	entries []entry
+}
+
+func readPlaylistFile(filePath string) (*playlistFile, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read playlist file: %w", err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	pf := &playlistFile{
+		path: filePath,
+		name: playlistName(filePath),
+	}
+
+	var pending []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "":
+			continue
+		case line == headerTag:
+			pf.header = append(pf.header, line)
+		case strings.HasPrefix(line, playlistTag):
+			pf.header = append(pf.header, line)
+			if name := strings.TrimSpace(strings.TrimPrefix(line, playlistTag)); name != "" {
+				pf.name = name
+			}
+		case strings.HasPrefix(line, "#"):
+			pending = append(pending, line)
+		default:
+			pf.entries = append(pf.entries, entry{
+				directives: pending,
+				location:   line,
+			})
+			pending = nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse playlist file: %w", err)
+	}
+
+	return pf, nil
+}
+
+// write replaces the playlist file atomically.
+func (pf *playlistFile) write() error {
+	var b bytes.Buffer
+	if len(pf.header) == 0 {
+		pf.header = []string{headerTag}
+	}
+	for _, line := range pf.header {
+		b.WriteString(line + "\n")
+	}
+	for _, e := range pf.entries {
+		for _, d := range e.directives {
+			b.WriteString(d + "\n")
+		}
+		b.WriteString(e.location + "\n")
+	}
+
+	return fsutil.WriteFileAtomic(pf.path, b.Bytes())
+}
+
+// resolve returns the absolute path of a local entry, or the location as is
+// for URLs.
+func (pf *playlistFile) resolve(location string) string {
+	if strings.Contains(location, "://") {
+		return location
+	}
+	location = filepath.FromSlash(location)
+	if !filepath.IsAbs(location) {
+		location = filepath.Join(filepath.Dir(pf.path), location)
+	}
+	return filepath.Clean(location)
+}
+
+// relativize returns the location to write for a track path: relative to the
+// playlist when the file lives below it, absolute otherwise.
+func (pf *playlistFile) relativize(trackPath string) string {
+	if strings.Contains(trackPath, "://") {
+		return trackPath
+	}
+	rel, err := filepath.Rel(filepath.Dir(pf.path), trackPath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return trackPath
+	}
+	return filepath.ToSlash(rel)
+}
+
+type extinf struct {
+	Duration time.Duration
+	Title    string
+	Artist   string
+	Album    string
+}
+
+func (e entry) info() extinf {
+	var info extinf
+	for _, d := range e.directives {
+		switch {
+		case strings.HasPrefix(d, extinfTag):
+			rest := strings.TrimPrefix(d, extinfTag)
+			attrs, display, _ := strings.Cut(rest, ",")
+			if fields := strings.Fields(attrs); len(fields) > 0 {
+				if secs, err := strconv.ParseFloat(fields[0], 64); err == nil && secs > 0 {
+					info.Duration = time.Duration(secs * float64(time.Second))
+				}
+			}
+			if artist, title, found := strings.Cut(display, " - "); found {
+				info.Artist = strings.TrimSpace(artist)
+				info.Title = strings.TrimSpace(title)
+			} else {
+				info.Title = strings.TrimSpace(display)
+			}
+		case strings.HasPrefix(d, artistTag):
+			info.Artist = strings.TrimSpace(strings.TrimPrefix(d, artistTag))
+		case strings.HasPrefix(d, albumTag):
+			info.Album = strings.TrimSpace(strings.TrimPrefix(d, albumTag))
+		}
+	}
+	return info
+}
+
+func newEntry(location string, info extinf) entry {
+	secs := -1
+	if info.Duration > 0 {
+		secs = int(info.Duration.Round(time.Second) / time.Second)
+	}
+
+	display := info.Title
+	if info.Artist != "" {
+		display = info.Artist + " - " + info.Title
+	}
+
+	e := entry{
+		directives: []string{fmt.Sprintf("%s%d,%s", extinfTag, secs, display)},
+		location:   location,
+	}
+	if info.Album != "" {
+		e.directives = append(e.directives, albumTag+info.Album)
+	}
+	return e
+}
+
+func playlistName(filePath string) string {
+	base := filepath.Base(filePath)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+func isPlaylistFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".m3u", ".m3u8":
+		return true
+	default:
+		return false
+	}
+}
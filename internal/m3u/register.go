@@ -0,0 +1,28 @@
+package m3u
+
+import (
+	"context"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/registry"
+)
+
+type Config struct {
+	Dir     string `yaml:"dir"`
+	Library string `yaml:"library"`
+}
+
+func init() {
+	registry.Register(registry.Connector[Config]{
+		Name:         "m3u",
+		Usage:        "m3u[:<dir>]",
+		Description:  "Directory of M3U/M3U8 playlists",
+		Capabilities: Capabilities,
+		New: func(ctx context.Context, cfg Config, arg string) (domain.Connector, error) {
+			if arg != "" {
+				cfg.Dir = arg
+			}
+			return NewConnector(cfg.Dir, cfg.Library)
+		},
+	})
+}
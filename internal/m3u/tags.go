@@ -0,0 +1,289 @@
+package m3u
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+type tags struct {
+	Title       string
+	Artists     []string
+	Album       string
+	AlbumArtist string
+	ISRC        string
+	UPC         string
+	TrackNumber int
+	DiscNumber  int
+	Date        string
+	Duration    time.Duration
+}
+
+var errNoTags = errors.New("no supported tags found")
+
+// readTags reads the ID3v2 tags of MP3 files and the Vorbis comments of FLAC
+// files, which cover most local libraries without pulling a tagging library.
+func readTags(filePath string) (tags, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return tags{}, fmt.Errorf("failed to open audio file: %w", err)
+	}
+	defer f.Close()
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return tags{}, errNoTags
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, []byte("ID3")):
+		return readID3v2(f, magic)
+	case bytes.Equal(magic, []byte("fLaC")):
+		return readFLAC(f)
+	default:
+		return tags{}, errNoTags
+	}
+}
+
+func readID3v2(r io.Reader, magic []byte) (tags, error) {
+	header := make([]byte, 10)
+	copy(header, magic)
+	if _, err := io.ReadFull(r, header[4:]); err != nil {
+		return tags{}, fmt.Errorf("failed to read id3 header: %w", err)
+	}
+
+	version := header[3]
+	flags := header[5]
+	size := syncsafe(header[6:10])
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return tags{}, fmt.Errorf("failed to read id3 tag: %w", err)
+	}
+
+	if flags&0x40 != 0 && version >= 3 && len(data) >= 4 {
+		ext := int(binary.BigEndian.Uint32(data[:4]))
+		if version == 4 {
+			ext = syncsafe(data[:4])
+		} else {
+			ext += 4
+		}
+		if ext > len(data) {
+			return tags{}, fmt.Errorf("invalid id3 extended header")
+		}
+		data = data[ext:]
+	}
+
+	idLen, headerLen := 4, 10
+	if version == 2 {
+		idLen, headerLen = 3, 6
+	}
+
+	var t tags
+	for len(data) >= headerLen && data[0] != 0 {
+		id := string(data[:idLen])
+
+		var frameSize int
+		switch version {
+		case 2:
+			frameSize = int(data[3])<<16 | int(data[4])<<8 | int(data[5])
+		case 3:
+			frameSize = int(binary.BigEndian.Uint32(data[4:8]))
+		default:
+			frameSize = syncsafe(data[4:8])
+		}
+
+		if frameSize > len(data)-headerLen {
+			break
+		}
+
+		values := decodeID3Text(data[headerLen : headerLen+frameSize])
+		var value string
+		if len(values) > 0 {
+			value = values[0]
+		}
+
+		switch id {
+		case "TIT2", "TT2":
+			t.Title = value
+		case "TPE1", "TP1":
+			t.Artists = values
+		case "TALB", "TAL":
+			t.Album = value
+		case "TPE2", "TP2":
+			t.AlbumArtist = value
+		case "TSRC", "TRC":
+			t.ISRC = value
+		case "TRCK", "TRK":
+			t.TrackNumber = parsePosition(value)
+		case "TPOS", "TPA":
+			t.DiscNumber = parsePosition(value)
+		case "TDRC", "TYER", "TYE":
+			t.Date = cmp.Or(t.Date, value)
+		case "TLEN", "TLE":
+			var ms int
+			if _, err := fmt.Sscanf(value, "%d", &ms); err == nil {
+				t.Duration = time.Duration(ms) * time.Millisecond
+			}
+		}
+
+		data = data[headerLen+frameSize:]
+	}
+
+	return t, nil
+}
+
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7f)<<21 | int(b[1]&0x7f)<<14 | int(b[2]&0x7f)<<7 | int(b[3]&0x7f)
+}
+
+// decodeID3Text decodes the values of a text frame.
+func decodeID3Text(b []byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+
+	enc, b := b[0], b[1:]
+	var s string
+	switch enc {
+	case 1, 2:
+		var order binary.ByteOrder = binary.BigEndian
+		if enc == 1 && len(b) >= 2 {
+			if b[0] == 0xff && b[1] == 0xfe {
+				order = binary.LittleEndian
+			}
+			b = b[2:]
+		}
+
+		u := make([]uint16, 0, len(b)/2)
+		for i := 0; i+1 < len(b); i += 2 {
+			u = append(u, order.Uint16(b[i:]))
+		}
+		s = string(utf16.Decode(u))
+	case 3:
+		s = string(b)
+	default:
+		r := make([]rune, len(b))
+		for i, c := range b {
+			r[i] = rune(c)
+		}
+		s = string(r)
+	}
+
+	// Multiple values are separated by NUL.
+	var values []string
+	for _, v := range strings.Split(s, "\x00") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// parsePosition parses track and disc numbers such as "3" or "3/12".
+func parsePosition(s string) int {
+	s, _, _ = strings.Cut(s, "/")
+	n, _ := strconv.Atoi(strings.TrimSpace(s))
+	return n
+}
+
+func readFLAC(r io.Reader) (tags, error) {
+	var t tags
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return t, fmt.Errorf("failed to read flac metadata block: %w", err)
+		}
+
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7f
+		size := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+
+		block := make([]byte, size)
+		if _, err := io.ReadFull(r, block); err != nil {
+			return t, fmt.Errorf("failed to read flac metadata block: %w", err)
+		}
+
+		switch blockType {
+		case 0:
+			if len(block) >= 18 {
+				sampleRate := uint64(block[10])<<12 | uint64(block[11])<<4 | uint64(block[12])>>4
+				samples := uint64(block[13]&0x0f)<<32 | uint64(binary.BigEndian.Uint32(block[14:18]))
+				if sampleRate > 0 {
+					t.Duration = time.Duration(samples * uint64(time.Second) / sampleRate)
+				}
+			}
+		case 4:
+			parseVorbisComments(block, &t)
+		}
+
+		if last {
+			return t, nil
+		}
+	}
+}
+
+func parseVorbisComments(b []byte, t *tags) {
+	read := func() (string, bool) {
+		if len(b) < 4 {
+			return "", false
+		}
+		n := int(binary.LittleEndian.Uint32(b))
+		if n > len(b)-4 {
+			return "", false
+		}
+		s := string(b[4 : 4+n])
+		b = b[4+n:]
+		return s, true
+	}
+
+	if _, ok := read(); !ok {
+		return
+	}
+	if len(b) < 4 {
+		return
+	}
+	count := int(binary.LittleEndian.Uint32(b))
+	b = b[4:]
+
+	for range count {
+		comment, ok := read()
+		if !ok {
+			return
+		}
+
+		key, value, found := strings.Cut(comment, "=")
+		if !found {
+			continue
+		}
+
+		switch strings.ToUpper(key) {
+		case "TITLE":
+			t.Title = value
+		case "ARTIST":
+			t.Artists = append(t.Artists, value)
+		case "ALBUM":
+			t.Album = value
+		case "ALBUMARTIST":
+			t.AlbumArtist = value
+		case "ISRC":
+			t.ISRC = value
+		case "BARCODE", "UPC":
+			t.UPC = value
+		case "TRACKNUMBER":
+			t.TrackNumber = parsePosition(value)
+		case "DISCNUMBER":
+			t.DiscNumber = parsePosition(value)
+		case "DATE":
+			t.Date = value
+		}
+	}
+}
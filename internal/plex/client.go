@@ -0,0 +1,119 @@
+package plex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const clientIdentifier = "nomuz"
+
+// errNotFound is returned for items that do not exist on the server.
+var errNotFound = errors.New("not found")
+
+type client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+type response struct {
+	MediaContainer mediaContainer `json:"MediaContainer"`
+}
+
+type mediaContainer struct {
+	MachineIdentifier string      `json:"machineIdentifier"`
+	Size              int         `json:"size"`
+	Directory         []directory `json:"Directory"`
+	Metadata          []metadata  `json:"Metadata"`
+	Hub               []hub       `json:"Hub"`
+}
+
+type directory struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+}
+
+type hub struct {
+	Type     string     `json:"type"`
+	Metadata []metadata `json:"Metadata"`
+}
+
+type metadata struct {
+	RatingKey string `json:"ratingKey"`
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Summary   string `json:"summary"`
+	// For tracks, grandparentTitle is the album artist and originalTitle the
+	// track artist when it differs.
+	GrandparentTitle     string `json:"grandparentTitle"`
+	GrandparentRatingKey string `json:"grandparentRatingKey"`
+	OriginalTitle        string `json:"originalTitle"`
+	ParentTitle          string `json:"parentTitle"`
+	// ParentYear is the release year of the album of a track.
+	ParentYear  int `json:"parentYear"`
+	Index       int `json:"index"`
+	ParentIndex int `json:"parentIndex"`
+	// Duration is in milliseconds.
+	Duration       int64  `json:"duration"`
+	LeafCount      int    `json:"leafCount"`
+	Smart          bool   `json:"smart"`
+	PlaylistType   string `json:"playlistType"`
+	PlaylistItemID int64  `json:"playlistItemID"`
+	Guid           []guid `json:"Guid"`
+}
+
+type guid struct {
+	ID string `json:"id"`
+}
+
+// do sends a request authenticated with the X-Plex-Token header and decodes
+// the JSON media container, if any.
+func (c *client) do(ctx context.Context, method, path string, query url.Values) (*mediaContainer, error) {
+	u := strings.TrimSuffix(c.baseURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-Plex-Token", c.token)
+	req.Header.Set("X-Plex-Client-Identifier", clientIdentifier)
+	req.Header.Set("X-Plex-Product", clientIdentifier)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("failed to call %s %s: %w", method, path, errNotFound)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("failed to call %s %s: status code %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s %s response: %w", method, path, err)
+	}
+
+	var res response
+	if len(strings.TrimSpace(string(body))) > 0 {
+		if err := json.Unmarshal(body, &res); err != nil {
+			return nil, fmt.Errorf("failed to decode %s %s response: %w", method, path, err)
+		}
+	}
+	return &res.MediaContainer, nil
+}
@@ -0,0 +1,352 @@
+package plex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+)
+
+const (
+	searchLimit = 20
+	mbidScheme  = "mbid://"
+	// pendingPrefix marks playlists that do not exist on the server yet; see
+	// CreatePlaylist.
+	pendingPrefix = "pending:"
+)
+
+// NewConnector connects to a Plex Media Server with an X-Plex-Token. When
+// section is set, only that music library is searched.
+func NewConnector(baseURL, token, section string) (*connector, error) {
+	if baseURL == "" || token == "" {
+		return nil, fmt.Errorf("plex url and token are required")
+	}
+
+	ctx := context.Background()
+	c := &connector{
+		client: &client{
+			baseURL: baseURL,
+			token:   token,
+			http:    http.DefaultClient,
+		},
+	}
+
+	identity, err := c.client.do(ctx, http.MethodGet, "/identity", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plex server identity: %w", err)
+	}
+	c.machineID = identity.MachineIdentifier
+
+	sections, err := c.client.do(ctx, http.MethodGet, "/library/sections", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plex library sections: %w", err)
+	}
+
+	for _, d := range sections.Directory {
+		if d.Type != "artist" {
+			continue
+		}
+		if section == "" || strings.EqualFold(d.Title, section) {
+			c.sections = append(c.sections, d.Key)
+		}
+	}
+
+	if len(c.sections) == 0 {
+		if section != "" {
+			return nil, fmt.Errorf("plex music library %s not found", section)
+		}
+		return nil, fmt.Errorf("no plex music library found")
+	}
+
+	return c, nil
+}
+
+type connector struct {
+	client    *client
+	machineID string
+	// sections are the keys of the music libraries to search.
+	sections []string
+}
+
+var _ domain.Connector = (*connector)(nil)
+
+var Capabilities = domain.Capabilities{
+	CreatePlaylist: true,
+	DeleteTracks:   true,
+	Descriptions:   true,
+}
+
+func (c *connector) Capabilities() domain.Capabilities {
+	return Capabilities
+}
+
+// CreatePlaylist only reserves the name: Plex cannot create an empty
+// playlist, so it is created along with the first tracks added to it.
+func (c *connector) CreatePlaylist(ctx context.Context, name string) (*domain.Playlist, error) {
+	return &domain.Playlist{
+		ID:     pendingPrefix + name,
+		Name:   name,
+		Owned:  true,
+		Tracks: []domain.Track{},
+	}, nil
+}
+
+func (c *connector) GetPlaylists(ctx context.Context) ([]*domain.Playlist, error) {
+	res, err := c.client.do(ctx, http.MethodGet, "/playlists", url.Values{"playlistType": {"audio"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlists: %w", err)
+	}
+
+	var pls []*domain.Playlist
+	for _, m := range res.Metadata {
+		pl := toDomainPlaylist(m)
+		pl.Tracks = make([]domain.Track, pl.TrackCount)
+		pls = append(pls, pl)
+	}
+	return pls, nil
+}
+
+func (c *connector) GetPlaylistByName(ctx context.Context, name string) (*domain.Playlist, error) {
+	pls, err := c.GetPlaylists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pl := range pls {
+		if pl.Name == name {
+			return c.withTracks(ctx, pl)
+		}
+	}
+	return nil, nil
+}
+
+func (c *connector) GetPlaylist(ctx context.Context, id string) (*domain.Playlist, error) {
+	if name, found := strings.CutPrefix(id, pendingPrefix); found {
+		return c.GetPlaylistByName(ctx, name)
+	}
+
+	res, err := c.client.do(ctx, http.MethodGet, "/playlists/"+id, nil)
+	if errors.Is(err, errNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlist: %w", err)
+	}
+
+	if len(res.Metadata) == 0 {
+		return nil, nil
+	}
+	return c.withTracks(ctx, toDomainPlaylist(res.Metadata[0]))
+}
+
+func (c *connector) withTracks(ctx context.Context, pl *domain.Playlist) (*domain.Playlist, error) {
+	items, err := c.getPlaylistItems(ctx, pl.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracks for playlist %s: %w", pl.Name, err)
+	}
+
+	pl.Tracks = []domain.Track{}
+	for _, m := range items {
+		pl.Tracks = append(pl.Tracks, toDomainTrack(m))
+	}
+	pl.TrackCount = len(pl.Tracks)
+	return pl, nil
+}
+
+func (c *connector) AddTracksToPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	if len(tracks) == 0 {
+		return nil
+	}
+
+	if name, found := strings.CutPrefix(id, pendingPrefix); found {
+		pl, err := c.GetPlaylistByName(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to add tracks to playlist: %w", err)
+		}
+
+		// An earlier batch may have created the playlist already.
+		if pl == nil {
+			_, err := c.client.do(ctx, http.MethodPost, "/playlists", url.Values{
+				"type":  {"audio"},
+				"title": {name},
+				"smart": {"0"},
+				"uri":   {c.itemsURI(tracks)},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create playlist: %w", err)
+			}
+			return nil
+		}
+		id = pl.ID
+	}
+
+	_, err := c.client.do(ctx, http.MethodPut, "/playlists/"+id+"/items", url.Values{
+		"uri": {c.itemsURI(tracks)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add tracks to playlist: %w", err)
+	}
+	return nil
+}
+
+// DeleteTracksFromPlaylist removes every entry of the given tracks. Entries
+// are removed one at a time by their playlist item ID.
+func (c *connector) DeleteTracksFromPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	if name, found := strings.CutPrefix(id, pendingPrefix); found {
+		pl, err := c.GetPlaylistByName(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to remove tracks from playlist: %w", err)
+		}
+
+		// Nothing to remove until tracks were added.
+		if pl == nil {
+			return nil
+		}
+		id = pl.ID
+	}
+
+	items, err := c.getPlaylistItems(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to remove tracks from playlist: %w", err)
+	}
+
+	remove := make(map[string]struct{})
+	for _, t := range tracks {
+		remove[t.ID] = struct{}{}
+	}
+
+	for _, m := range items {
+		if _, found := remove[m.RatingKey]; !found {
+			continue
+		}
+
+		path := fmt.Sprintf("/playlists/%s/items/%d", id, m.PlaylistItemID)
+		if _, err := c.client.do(ctx, http.MethodDelete, path, nil); err != nil {
+			return fmt.Errorf("failed to remove tracks from playlist: %w", err)
+		}
+	}
+	return nil
+}
+
+// UpdatePlaylist sets the summary of the playlist.
+func (c *connector) UpdatePlaylist(ctx context.Context, id string, meta domain.PlaylistMetadata) error {
+	if name, found := strings.CutPrefix(id, pendingPrefix); found {
+		pl, err := c.GetPlaylistByName(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to update playlist: %w", err)
+		}
+
+		// Playlists without tracks do not exist, so there is nothing to update.
+		if pl == nil {
+			return nil
+		}
+		id = pl.ID
+	}
+
+	_, err := c.client.do(ctx, http.MethodPut, "/playlists/"+id, url.Values{
+		"summary": {meta.Description},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update playlist: %w", err)
+	}
+	return nil
+}
+
+// SearchTrack searches the music libraries by title and ranks the tracks by
+// MusicBrainz ID, ISRC, title and artist, album and duration.
+func (c *connector) SearchTrack(ctx context.Context, filters domain.TrackFilters) ([]domain.Track, error) {
+	if filters.Title == "" {
+		return nil, nil
+	}
+
+	query := url.Values{
+		"query":        {filters.Title},
+		"limit":        {strconv.Itoa(searchLimit)},
+		"includeGuids": {"1"},
+	}
+	if len(c.sections) == 1 {
+		query.Set("sectionId", c.sections[0])
+	}
+
+	res, err := c.client.do(ctx, http.MethodGet, "/hubs/search", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search track: %w", err)
+	}
+
+	var tracks []domain.Track
+	for _, h := range res.Hub {
+		if h.Type != "track" {
+			continue
+		}
+		for _, m := range h.Metadata {
+			tracks = append(tracks, toDomainTrack(m))
+		}
+	}
+	return domain.RankMatches(tracks, filters), nil
+}
+
+func (c *connector) getPlaylistItems(ctx context.Context, id string) ([]metadata, error) {
+	res, err := c.client.do(ctx, http.MethodGet, "/playlists/"+id+"/items", url.Values{"includeGuids": {"1"}})
+	if err != nil {
+		return nil, err
+	}
+	return res.Metadata, nil
+}
+
+// itemsURI references library tracks the way the playlist endpoints expect.
+func (c *connector) itemsURI(tracks []domain.Track) string {
+	var ids []string
+	for _, t := range tracks {
+		ids = append(ids, t.ID)
+	}
+	return fmt.Sprintf("server://%s/com.plexapp.plugins.library/library/metadata/%s", c.machineID, strings.Join(ids, ","))
+}
+
+func toDomainPlaylist(m metadata) *domain.Playlist {
+	return &domain.Playlist{
+		ID:          m.RatingKey,
+		Name:        m.Title,
+		Description: m.Summary,
+		// Smart playlists are generated from rules and cannot be edited.
+		Owned:      !m.Smart,
+		TrackCount: m.LeafCount,
+	}
+}
+
+func toDomainTrack(m metadata) domain.Track {
+	t := domain.Track{
+		ID:          m.RatingKey,
+		Title:       m.Title,
+		Artist:      m.GrandparentTitle,
+		Album:       m.ParentTitle,
+		AlbumArtist: m.GrandparentTitle,
+		TrackNumber: m.Index,
+		DiscNumber:  m.ParentIndex,
+		Duration:    time.Duration(m.Duration) * time.Millisecond,
+	}
+	if m.ParentYear > 0 {
+		t.ReleaseDate = strconv.Itoa(m.ParentYear)
+	}
+
+	// Tracks by other artists than the album's only have their name.
+	if m.OriginalTitle != "" {
+		t.Artist = m.OriginalTitle
+		t.Artists = []domain.Artist{{Name: m.OriginalTitle}}
+	} else if m.GrandparentTitle != "" {
+		t.Artists = []domain.Artist{{ID: m.GrandparentRatingKey, Name: m.GrandparentTitle}}
+	}
+
+	for _, g := range m.Guid {
+		if mbid, found := strings.CutPrefix(g.ID, mbidScheme); found {
+			t.MBID = mbid
+			break
+		}
+	}
+	return t
+}
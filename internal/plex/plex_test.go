@@ -0,0 +1,133 @@
+package plex_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/domain/connectortest"
+	"github.com/pedrobarco/nomuz/internal/fakeservice"
+	"github.com/pedrobarco/nomuz/internal/plex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newConnector(t *testing.T, srv *fakeservice.Plex) domain.Connector {
+	c, err := plex.NewConnector(srv.Server.URL, srv.Token, "")
+	require.NoError(t, err)
+	return c
+}
+
+func TestConnector(t *testing.T) {
+	ctx := context.Background()
+
+	tracks := []fakeservice.Track{
+		{ID: "10", MBID: "4f3d9fc1-4ad4-4d1f-9c1a-5f5bbd6c2c36", Title: "Song", Artist: "Band", Album: "Album",
+			ReleaseDate: "1999", TrackNumber: 3, DiscNumber: 1, Duration: 201 * time.Second},
+		{ID: "11", Title: "Song", Artist: "Band", Album: "Live", Duration: 260 * time.Second},
+		{ID: "12", Title: "Song", Artist: "Band", Album: "Greatest Hits", Duration: 200 * time.Second},
+		{ID: "13", Title: "Other Song", Artist: "Other Band", AlbumArtist: "Various Artists", Album: "Compilation"},
+	}
+
+	t.Run("connect", func(t *testing.T) {
+		srv := fakeservice.NewPlex(t, fakeservice.Library{})
+
+		_, err := plex.NewConnector(srv.Server.URL, "wrong", "")
+		assert.ErrorContains(t, err, "status code 401")
+
+		_, err = plex.NewConnector(srv.Server.URL, srv.Token, "Audiobooks")
+		assert.ErrorContains(t, err, "plex music library Audiobooks not found")
+
+		_, err = plex.NewConnector(srv.Server.URL, srv.Token, "music")
+		assert.NoError(t, err)
+	})
+
+	t.Run("playlists", func(t *testing.T) {
+		assert := assert.New(t)
+
+		srv := fakeservice.NewPlex(t, fakeservice.Library{Tracks: tracks})
+		c := newConnector(t, srv)
+
+		pl, err := c.CreatePlaylist(ctx, "Mix")
+		assert.NoError(err)
+		assert.Empty(srv.Playlists())
+
+		assert.NoError(c.AddTracksToPlaylist(ctx, pl.ID, []domain.Track{{ID: "10"}, {ID: "13"}}))
+		assert.NoError(c.AddTracksToPlaylist(ctx, pl.ID, []domain.Track{{ID: "10"}}))
+		assert.Len(srv.Playlists(), 1)
+
+		pls, err := c.GetPlaylists(ctx)
+		assert.NoError(err)
+		assert.Len(pls, 1)
+		assert.Equal(3, pls[0].Size())
+
+		got, err := c.GetPlaylistByName(ctx, "Mix")
+		assert.NoError(err)
+		assert.Equal(domain.Track{
+			ID:          "10",
+			MBID:        "4f3d9fc1-4ad4-4d1f-9c1a-5f5bbd6c2c36",
+			Title:       "Song",
+			Artist:      "Band",
+			Artists:     []domain.Artist{{ID: "band", Name: "Band"}},
+			Album:       "Album",
+			AlbumArtist: "Band",
+			TrackNumber: 3,
+			DiscNumber:  1,
+			ReleaseDate: "1999",
+			Duration:    201 * time.Second,
+		}, got.Tracks[0])
+		assert.Equal("Other Band", got.Tracks[1].Artist)
+		assert.Equal("Various Artists", got.Tracks[1].AlbumArtist)
+
+		assert.NoError(c.DeleteTracksFromPlaylist(ctx, got.ID, []domain.Track{{ID: "10"}}))
+		mix, _ := srv.Playlist("Mix")
+		assert.Equal([]string{"13"}, mix.Tracks)
+
+		assert.NoError(c.UpdatePlaylist(ctx, pl.ID, domain.PlaylistMetadata{Description: "Songs for the road"}))
+		mix, _ = srv.Playlist("Mix")
+		assert.Equal("Songs for the road", mix.Description)
+		assert.NoError(c.UpdatePlaylist(ctx, "pending:Empty", domain.PlaylistMetadata{Description: "Nothing"}))
+
+		got, err = c.GetPlaylistByName(ctx, "Missing")
+		assert.NoError(err)
+		assert.Nil(got)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		assert := assert.New(t)
+
+		c := newConnector(t, fakeservice.NewPlex(t, fakeservice.Library{Tracks: tracks}))
+
+		res, err := c.SearchTrack(ctx, domain.TrackFilters{MBID: "4f3d9fc1-4ad4-4d1f-9c1a-5f5bbd6c2c36", Title: "Song", Artist: "Band"})
+		assert.NoError(err)
+		assert.Equal("10", res[0].ID)
+
+		res, err = c.SearchTrack(ctx, domain.TrackFilters{Title: "Song", Artist: "Band", Duration: 200 * time.Second})
+		assert.NoError(err)
+		assert.Equal([]string{"10", "12", "11"}, []string{res[0].ID, res[1].ID, res[2].ID})
+
+		res, err = c.SearchTrack(ctx, domain.TrackFilters{Title: "Other Song", Artist: "Other Band"})
+		assert.NoError(err)
+		assert.Len(res, 1)
+		assert.Equal("13", res[0].ID)
+	})
+}
+
+func TestConformance(t *testing.T) {
+	connectortest.Run(t, connectortest.Harness{
+		New: func(t *testing.T) domain.Connector {
+			return newConnector(t, fakeservice.NewPlex(t, fakeservice.Library{
+				Tracks: []fakeservice.Track{
+					{ID: "10", Title: "Song", Artist: "Band", Album: "Album", Duration: 201 * time.Second},
+					{ID: "20", Title: "Other Song", Artist: "Other Band", Album: "Other Album", Duration: 180 * time.Second},
+				},
+			}))
+		},
+		Tracks: []domain.Track{
+			{ID: "10", Title: "Song", Artist: "Band", Album: "Album", Duration: 201 * time.Second},
+			{ID: "20", Title: "Other Song", Artist: "Other Band", Album: "Other Album", Duration: 180 * time.Second},
+		},
+		CreatesOnFirstAdd: true,
+	})
+}
@@ -0,0 +1,29 @@
+package plex
+
+import (
+	"context"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/registry"
+)
+
+type Config struct {
+	URL     string `yaml:"url"`
+	Token   string `yaml:"token"`
+	Section string `yaml:"section"`
+}
+
+func init() {
+	registry.Register(registry.Connector[Config]{
+		Name:         "plex",
+		Usage:        "plex[:<library>]",
+		Description:  "Plex Media Server",
+		Capabilities: Capabilities,
+		New: func(ctx context.Context, cfg Config, arg string) (domain.Connector, error) {
+			if arg != "" {
+				cfg.Section = arg
+			}
+			return NewConnector(cfg.URL, cfg.Token, cfg.Section)
+		},
+	})
+}
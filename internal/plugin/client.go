@@ -0,0 +1,270 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/registry"
+)
+
+// Config tells how to start a plugin.
+type Config struct {
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args,omitempty"`
+}
+
+// Register adds the plugin to the connector registry under name. Its config
+// section is passed to the plugin as JSON.
+func Register(name string, cfg Config) error {
+	if cfg.Command == "" {
+		return fmt.Errorf("plugin %s has no command", name)
+	}
+
+	return registry.TryRegister(registry.Connector[map[string]any]{
+		Name:        name,
+		Usage:       name + "[:<arg>]",
+		Description: "Plugin: " + cfg.Command,
+		New: func(ctx context.Context, config map[string]any, arg string) (domain.Connector, error) {
+			return NewConnector(ctx, cfg, config, arg)
+		},
+		Login: func(ctx context.Context, config map[string]any) error {
+			return Login(ctx, cfg, config)
+		},
+	})
+}
+
+// process is a running plugin. Calls are serialized, as plugins handle one
+// request at a time.
+type process struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	out   *bufio.Reader
+
+	mu     sync.Mutex
+	nextID int64
+}
+
+func start(ctx context.Context, cfg Config) (*process, error) {
+	cmd := exec.CommandContext(ctx, cfg.Command, cfg.Args...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plugin stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plugin stdout: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", cfg.Command, err)
+	}
+
+	return &process{
+		cmd:   cmd,
+		stdin: stdin,
+		out:   bufio.NewReader(stdout),
+	}, nil
+}
+
+func (p *process) call(method string, params, result any) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.nextID++
+	req := request{JSONRPC: "2.0", ID: p.nextID, Method: method}
+	if params != nil {
+		b, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s params: %w", method, err)
+		}
+		req.Params = b
+	}
+
+	b, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+	if _, err := p.stdin.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("failed to send %s request: %w", method, err)
+	}
+
+	for {
+		line, err := p.out.ReadBytes('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read %s response: %w", method, err)
+		}
+
+		var resp response
+		if err := json.Unmarshal(line, &resp); err != nil {
+			return fmt.Errorf("failed to decode %s response: %w", method, err)
+		}
+		// Responses to other requests can only be stale ones, skip them.
+		if resp.ID != req.ID {
+			continue
+		}
+
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil || len(resp.Result) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("failed to decode %s result: %w", method, err)
+		}
+		return nil
+	}
+}
+
+// close asks the plugin to shut down and waits for it to exit.
+func (p *process) close() error {
+	err := p.call(MethodShutdown, nil, nil)
+	p.stdin.Close()
+	if werr := p.cmd.Wait(); werr != nil && err == nil {
+		err = werr
+	}
+	return err
+}
+
+// NewConnector starts the plugin and initializes it with its config section
+// and the argument of the connector spec.
+func NewConnector(ctx context.Context, cfg Config, config map[string]any, arg string) (*connector, error) {
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode plugin config: %w", err)
+	}
+
+	p, err := start(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var res initializeResult
+	err = p.call(MethodInitialize, initializeParams{Version: ProtocolVersion, Config: raw, Arg: arg}, &res)
+	if err != nil {
+		p.stdin.Close()
+		p.cmd.Wait()
+		return nil, fmt.Errorf("failed to initialize plugin %s: %w", cfg.Command, err)
+	}
+
+	return &connector{
+		process: p,
+		caps:    res.Capabilities,
+	}, nil
+}
+
+// Login starts the plugin only to run its authentication flow.
+func Login(ctx context.Context, cfg Config, config map[string]any) error {
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to encode plugin config: %w", err)
+	}
+
+	p, err := start(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	err = p.call(MethodLogin, loginParams{Config: raw}, nil)
+	if cerr := p.close(); cerr != nil && err == nil {
+		err = cerr
+	}
+
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) && rpcErr.Code == CodeMethodNotFound {
+		return fmt.Errorf("plugin %s has no login: %w", cfg.Command, domain.ErrUnsupported)
+	}
+	return err
+}
+
+type connector struct {
+	*process
+	caps domain.Capabilities
+}
+
+var _ domain.Connector = (*connector)(nil)
+
+// Close shuts the plugin down.
+func (c *connector) Close() error {
+	return c.process.close()
+}
+
+func (c *connector) Capabilities() domain.Capabilities {
+	return c.caps
+}
+
+func (c *connector) CreatePlaylist(ctx context.Context, name string) (*domain.Playlist, error) {
+	var pl *playlist
+	if err := c.call(MethodCreatePlaylist, nameParams{Name: name}, &pl); err != nil {
+		return nil, fmt.Errorf("failed to create playlist: %w", err)
+	}
+	return pl.toDomain(), nil
+}
+
+func (c *connector) GetPlaylists(ctx context.Context) ([]*domain.Playlist, error) {
+	var pls []*playlist
+	if err := c.call(MethodGetPlaylists, nil, &pls); err != nil {
+		return nil, fmt.Errorf("failed to get playlists: %w", err)
+	}
+
+	var res []*domain.Playlist
+	for _, pl := range pls {
+		res = append(res, pl.toDomain())
+	}
+	return res, nil
+}
+
+func (c *connector) GetPlaylist(ctx context.Context, id string) (*domain.Playlist, error) {
+	var pl *playlist
+	if err := c.call(MethodGetPlaylist, idParams{ID: id}, &pl); err != nil {
+		return nil, fmt.Errorf("failed to get playlist: %w", err)
+	}
+	return pl.toDomain(), nil
+}
+
+func (c *connector) GetPlaylistByName(ctx context.Context, name string) (*domain.Playlist, error) {
+	var pl *playlist
+	if err := c.call(MethodGetPlaylistByName, nameParams{Name: name}, &pl); err != nil {
+		return nil, fmt.Errorf("failed to get playlist: %w", err)
+	}
+	return pl.toDomain(), nil
+}
+
+func (c *connector) AddTracksToPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	if err := c.call(MethodAddTracksToPlaylist, tracksParams{ID: id, Tracks: fromTracks(tracks)}, nil); err != nil {
+		return fmt.Errorf("failed to add tracks to playlist: %w", err)
+	}
+	return nil
+}
+
+func (c *connector) DeleteTracksFromPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	if err := c.call(MethodDeleteTracksFromPlaylist, tracksParams{ID: id, Tracks: fromTracks(tracks)}, nil); err != nil {
+		return fmt.Errorf("failed to remove tracks from playlist: %w", err)
+	}
+	return nil
+}
+
+func (c *connector) UpdatePlaylist(ctx context.Context, id string, meta domain.PlaylistMetadata) error {
+	if err := c.call(MethodUpdatePlaylist, fromMetadata(id, meta), nil); err != nil {
+		return fmt.Errorf("failed to update playlist: %w", err)
+	}
+	return nil
+}
+
+func (c *connector) SearchTrack(ctx context.Context, f domain.TrackFilters) ([]domain.Track, error) {
+	var tracks []track
+	if err := c.call(MethodSearchTrack, fromFilters(f), &tracks); err != nil {
+		return nil, fmt.Errorf("failed to search track: %w", err)
+	}
+	return toTracks(tracks), nil
+}
@@ -0,0 +1,262 @@
+package plugin_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/domain/connectortest"
+	"github.com/pedrobarco/nomuz/internal/plugin"
+	"github.com/pedrobarco/nomuz/internal/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The test binary doubles as the plugin when this variable is set.
+const pluginEnv = "NOMUZ_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(pluginEnv) != "" {
+		plugin.Main(plugin.Plugin{New: newMemConnector})
+	}
+	os.Exit(m.Run())
+}
+
+type memConfig struct {
+	User string `json:"user"`
+}
+
+// addedAt is when memConnector says tracks were added.
+var addedAt = time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+
+// memConnector keeps playlists in memory and cannot remove tracks.
+type memConnector struct {
+	user      string
+	playlists []*domain.Playlist
+}
+
+func newMemConnector(ctx context.Context, config json.RawMessage, arg string) (domain.Connector, error) {
+	var cfg memConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return nil, err
+	}
+	if cfg.User == "" {
+		return nil, fmt.Errorf("missing user")
+	}
+	return &memConnector{user: cfg.User + arg}, nil
+}
+
+func (m *memConnector) CreatePlaylist(ctx context.Context, name string) (*domain.Playlist, error) {
+	pl := &domain.Playlist{ID: fmt.Sprintf("pl%d", len(m.playlists)+1), Name: name, Owner: m.user, Owned: true}
+	m.playlists = append(m.playlists, pl)
+	return pl, nil
+}
+
+func (m *memConnector) GetPlaylists(ctx context.Context) ([]*domain.Playlist, error) {
+	var pls []*domain.Playlist
+	for _, pl := range m.playlists {
+		listed := *pl
+		listed.TrackCount = len(pl.Tracks)
+		listed.Tracks = make([]domain.Track, len(pl.Tracks))
+		pls = append(pls, &listed)
+	}
+	return pls, nil
+}
+
+func (m *memConnector) GetPlaylist(ctx context.Context, id string) (*domain.Playlist, error) {
+	for _, pl := range m.playlists {
+		if pl.ID == id {
+			return pl, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memConnector) GetPlaylistByName(ctx context.Context, name string) (*domain.Playlist, error) {
+	for _, pl := range m.playlists {
+		if pl.Name == name {
+			return pl, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memConnector) AddTracksToPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	for _, pl := range m.playlists {
+		if pl.ID == id {
+			pl.Tracks = append(pl.Tracks, tracks...)
+			for range tracks {
+				pl.Items = append(pl.Items, domain.PlaylistItem{AddedAt: addedAt, AddedBy: m.user})
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("playlist with id %s not found", id)
+}
+
+func (m *memConnector) DeleteTracksFromPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	return domain.ErrUnsupported
+}
+
+func (m *memConnector) UpdatePlaylist(ctx context.Context, id string, meta domain.PlaylistMetadata) error {
+	for _, pl := range m.playlists {
+		if pl.ID == id {
+			pl.Description = meta.Description
+			return nil
+		}
+	}
+	return fmt.Errorf("playlist with id %s not found", id)
+}
+
+func (m *memConnector) SearchTrack(ctx context.Context, f domain.TrackFilters) ([]domain.Track, error) {
+	if f.ISRC == "" {
+		return nil, nil
+	}
+	return []domain.Track{{ID: "t-" + f.ISRC, ISRC: f.ISRC, Title: f.Title, Duration: f.Duration}}, nil
+}
+
+func (m *memConnector) Capabilities() domain.Capabilities {
+	return domain.Capabilities{CreatePlaylist: true, ISRCLookup: true, MaxBatchSize: 50, Descriptions: true}
+}
+
+func testPlugin(t *testing.T) plugin.Config {
+	t.Setenv(pluginEnv, "1")
+	return plugin.Config{Command: os.Args[0]}
+}
+
+func TestConnector(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("initialize error", func(t *testing.T) {
+		_, err := plugin.NewConnector(ctx, testPlugin(t), nil, "")
+		assert.ErrorContains(t, err, "missing user")
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		assert := assert.New(t)
+
+		c, err := plugin.NewConnector(ctx, testPlugin(t), map[string]any{"user": "alice"}, "-2")
+		require.NoError(t, err)
+		defer func() { assert.NoError(c.Close()) }()
+
+		assert.Equal(domain.Capabilities{CreatePlaylist: true, ISRCLookup: true, MaxBatchSize: 50, Descriptions: true}, c.Capabilities())
+
+		pl, err := c.CreatePlaylist(ctx, "Mix")
+		assert.NoError(err)
+		assert.Equal("pl1", pl.ID)
+		assert.Equal("alice-2", pl.Owner)
+
+		tracks := []domain.Track{
+			{
+				ID: "1", ISRC: "USRC17607839", Title: "Song", Artist: "Band",
+				Artists: []domain.Artist{{ID: "a1", Name: "Band"}, {ID: "a2", Name: "Guest"}},
+				Album:   "Album", AlbumArtist: "Band", UPC: "00602537518357", TrackNumber: 1, DiscNumber: 1,
+				ReleaseDate: "1999-03-22", Explicit: true, Popularity: 42, Duration: 201 * time.Second,
+			},
+			{ID: "2", Title: "Other Song"},
+		}
+		assert.NoError(c.AddTracksToPlaylist(ctx, pl.ID, tracks))
+
+		pls, err := c.GetPlaylists(ctx)
+		assert.NoError(err)
+		assert.Len(pls, 1)
+		assert.Equal(2, pls[0].Size())
+		assert.False(pls[0].HasTracks())
+
+		got, err := c.GetPlaylistByName(ctx, "Mix")
+		assert.NoError(err)
+		assert.Equal(tracks, got.Tracks)
+		assert.Equal([]domain.PlaylistItem{{AddedAt: addedAt, AddedBy: "alice-2"}, {AddedAt: addedAt, AddedBy: "alice-2"}}, got.Items)
+
+		got, err = c.GetPlaylist(ctx, pl.ID)
+		assert.NoError(err)
+		assert.Equal("Mix", got.Name)
+		assert.Equal(tracks, got.Tracks)
+
+		got, err = c.GetPlaylistByName(ctx, "Missing")
+		assert.NoError(err)
+		assert.Nil(got)
+
+		err = c.DeleteTracksFromPlaylist(ctx, pl.ID, tracks)
+		assert.ErrorIs(err, domain.ErrUnsupported)
+
+		assert.NoError(c.UpdatePlaylist(ctx, pl.ID, domain.PlaylistMetadata{Description: "Songs for the road"}))
+		got, err = c.GetPlaylistByName(ctx, "Mix")
+		assert.NoError(err)
+		assert.Equal("Songs for the road", got.Description)
+
+		err = c.AddTracksToPlaylist(ctx, "unknown", tracks)
+		assert.ErrorContains(err, "playlist with id unknown not found")
+
+		res, err := c.SearchTrack(ctx, domain.TrackFilters{ISRC: "GBAYE0601498", Title: "Other", Duration: time.Minute})
+		assert.NoError(err)
+		assert.Equal([]domain.Track{{ID: "t-GBAYE0601498", ISRC: "GBAYE0601498", Title: "Other", Duration: time.Minute}}, res)
+
+		res, err = c.SearchTrack(ctx, domain.TrackFilters{Title: "Other"})
+		assert.NoError(err)
+		assert.Empty(res)
+	})
+
+	t.Run("login unsupported", func(t *testing.T) {
+		err := plugin.Login(ctx, testPlugin(t), map[string]any{"user": "alice"})
+		assert.ErrorIs(t, err, domain.ErrUnsupported)
+	})
+
+	t.Run("registry", func(t *testing.T) {
+		assert := assert.New(t)
+
+		cfg := testPlugin(t)
+		require.NoError(t, plugin.Register("memory", cfg))
+		assert.Error(plugin.Register("memory", cfg))
+
+		e, found := registry.Lookup("memory")
+		assert.True(found)
+		assert.True(e.HasLogin())
+
+		c, err := registry.New(ctx, "memory:-3", func(name string, v any) error {
+			assert.Equal("memory", name)
+			*v.(*map[string]any) = map[string]any{"user": "bob"}
+			return nil
+		})
+		require.NoError(t, err)
+
+		pl, err := c.CreatePlaylist(ctx, "Mix")
+		assert.NoError(err)
+		assert.Equal("bob-3", pl.Owner)
+	})
+}
+
+func TestClose(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	c, err := plugin.NewConnector(ctx, testPlugin(t), map[string]any{"user": "alice"}, "")
+	require.NoError(t, err)
+
+	// The CLI closes connectors through io.Closer.
+	var closer io.Closer = c
+	assert.NoError(closer.Close())
+
+	_, err = c.GetPlaylists(ctx)
+	assert.Error(err)
+}
+
+func TestConformance(t *testing.T) {
+	connectortest.Run(t, connectortest.Harness{
+		New: func(t *testing.T) domain.Connector {
+			c, err := plugin.NewConnector(context.Background(), testPlugin(t), map[string]any{"user": "alice"}, "")
+			require.NoError(t, err)
+			t.Cleanup(func() { c.Close() })
+			return c
+		},
+		Tracks: []domain.Track{
+			{ID: "t-USRC17607839", ISRC: "USRC17607839", Title: "Song"},
+			{ID: "t-GBAYE0601498", ISRC: "GBAYE0601498", Title: "Other Song"},
+		},
+	})
+}
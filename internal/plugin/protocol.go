@@ -0,0 +1,349 @@
+// Package plugin runs connectors as external processes. A plugin reads
+// JSON-RPC 2.0 requests from stdin and writes responses to stdout, one JSON
+// object per line; stderr is passed through to the user.
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+)
+
+// ProtocolVersion is sent on initialize; plugins reject versions they do not
+// know.
+const ProtocolVersion = 1
+
+const (
+	MethodInitialize               = "initialize"
+	MethodLogin                    = "login"
+	MethodCreatePlaylist           = "create_playlist"
+	MethodGetPlaylists             = "get_playlists"
+	MethodGetPlaylist              = "get_playlist"
+	MethodGetPlaylistByName        = "get_playlist_by_name"
+	MethodAddTracksToPlaylist      = "add_tracks_to_playlist"
+	MethodDeleteTracksFromPlaylist = "delete_tracks_from_playlist"
+	MethodUpdatePlaylist           = "update_playlist"
+	MethodSearchTrack              = "search_track"
+	MethodShutdown                 = "shutdown"
+)
+
+// Error codes besides the ones defined by JSON-RPC.
+const (
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternal       = -32603
+	// CodeUnsupported maps to domain.ErrUnsupported.
+	CodeUnsupported = -32001
+)
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error returned by a plugin.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+func (e *Error) Unwrap() error {
+	if e.Code == CodeUnsupported {
+		return domain.ErrUnsupported
+	}
+	return nil
+}
+
+func toError(err error) *Error {
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+	if errors.Is(err, domain.ErrUnsupported) {
+		return &Error{Code: CodeUnsupported, Message: err.Error()}
+	}
+	return &Error{Code: CodeInternal, Message: err.Error()}
+}
+
+type initializeParams struct {
+	Version int `json:"version"`
+	// Config is the connector's section of the config file.
+	Config json.RawMessage `json:"config"`
+	// Arg is the text after the colon in the connector spec.
+	Arg string `json:"arg,omitempty"`
+}
+
+type initializeResult struct {
+	Capabilities domain.Capabilities `json:"capabilities"`
+}
+
+type loginParams struct {
+	Config json.RawMessage `json:"config"`
+}
+
+type nameParams struct {
+	Name string `json:"name"`
+}
+
+type idParams struct {
+	ID string `json:"id"`
+}
+
+type tracksParams struct {
+	ID     string  `json:"id"`
+	Tracks []track `json:"tracks"`
+}
+
+type updateParams struct {
+	ID            string   `json:"id"`
+	Description   string   `json:"description"`
+	Public        bool     `json:"public"`
+	Collaborative bool     `json:"collaborative"`
+	Image         string   `json:"image,omitempty"`
+	Folder        []string `json:"folder,omitempty"`
+}
+
+type playlist struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Description   string   `json:"description,omitempty"`
+	Owner         string   `json:"owner,omitempty"`
+	Owned         bool     `json:"owned,omitempty"`
+	Public        bool     `json:"public,omitempty"`
+	Collaborative bool     `json:"collaborative,omitempty"`
+	URL           string   `json:"url,omitempty"`
+	Image         string   `json:"image,omitempty"`
+	Folder        []string `json:"folder,omitempty"`
+	TrackCount    int      `json:"track_count,omitempty"`
+	Tracks        []track  `json:"tracks,omitempty"`
+}
+
+type track struct {
+	ID          string     `json:"id"`
+	ISRC        string     `json:"isrc,omitempty"`
+	MBID        string     `json:"mbid,omitempty"`
+	Title       string     `json:"title,omitempty"`
+	Artist      string     `json:"artist,omitempty"`
+	Artists     []artist   `json:"artists,omitempty"`
+	Album       string     `json:"album,omitempty"`
+	AlbumArtist string     `json:"album_artist,omitempty"`
+	UPC         string     `json:"upc,omitempty"`
+	TrackNumber int        `json:"track_number,omitempty"`
+	DiscNumber  int        `json:"disc_number,omitempty"`
+	ReleaseDate string     `json:"release_date,omitempty"`
+	Explicit    bool       `json:"explicit,omitempty"`
+	Popularity  int        `json:"popularity,omitempty"`
+	URL         string     `json:"url,omitempty"`
+	DurationMS  int64      `json:"duration_ms,omitempty"`
+	AddedAt     *time.Time `json:"added_at,omitempty"`
+	AddedBy     string     `json:"added_by,omitempty"`
+}
+
+type artist struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+type filters struct {
+	ID         string `json:"id,omitempty"`
+	ISRC       string `json:"isrc,omitempty"`
+	MBID       string `json:"mbid,omitempty"`
+	Title      string `json:"title,omitempty"`
+	Artist     string `json:"artist,omitempty"`
+	Album      string `json:"album,omitempty"`
+	DurationMS int64  `json:"duration_ms,omitempty"`
+}
+
+func fromPlaylist(p *domain.Playlist) *playlist {
+	if p == nil {
+		return nil
+	}
+	return &playlist{
+		ID:            p.ID,
+		Name:          p.Name,
+		Description:   p.Description,
+		Owner:         p.Owner,
+		Owned:         p.Owned,
+		Public:        p.Public,
+		Collaborative: p.Collaborative,
+		URL:           p.URL,
+		Image:         p.Image,
+		Folder:        p.Folder,
+		TrackCount:    p.TrackCount,
+		Tracks:        fromItems(p),
+	}
+}
+
+func (p *playlist) toDomain() *domain.Playlist {
+	if p == nil {
+		return nil
+	}
+	return &domain.Playlist{
+		ID:            p.ID,
+		Name:          p.Name,
+		Description:   p.Description,
+		Owner:         p.Owner,
+		Owned:         p.Owned,
+		Public:        p.Public,
+		Collaborative: p.Collaborative,
+		URL:           p.URL,
+		Image:         p.Image,
+		Folder:        p.Folder,
+		TrackCount:    p.TrackCount,
+		Tracks:        toTracks(p.Tracks),
+		Items:         toItems(p.Tracks),
+	}
+}
+
+func fromMetadata(id string, m domain.PlaylistMetadata) updateParams {
+	return updateParams{
+		ID:            id,
+		Description:   m.Description,
+		Public:        m.Public,
+		Collaborative: m.Collaborative,
+		Image:         m.Image,
+		Folder:        m.Folder,
+	}
+}
+
+func (p updateParams) toDomain() domain.PlaylistMetadata {
+	return domain.PlaylistMetadata{
+		Description:   p.Description,
+		Public:        p.Public,
+		Collaborative: p.Collaborative,
+		Image:         p.Image,
+		Folder:        p.Folder,
+	}
+}
+
+func fromTracks(ts []domain.Track) []track {
+	var res []track
+	for _, t := range ts {
+		wt := track{
+			ID:          t.ID,
+			ISRC:        t.ISRC,
+			MBID:        t.MBID,
+			Title:       t.Title,
+			Artist:      t.Artist,
+			Album:       t.Album,
+			AlbumArtist: t.AlbumArtist,
+			UPC:         t.UPC,
+			TrackNumber: t.TrackNumber,
+			DiscNumber:  t.DiscNumber,
+			ReleaseDate: t.ReleaseDate,
+			Explicit:    t.Explicit,
+			Popularity:  t.Popularity,
+			URL:         t.URL,
+			DurationMS:  t.Duration.Milliseconds(),
+		}
+		for _, a := range t.Artists {
+			wt.Artists = append(wt.Artists, artist{ID: a.ID, Name: a.Name})
+		}
+		res = append(res, wt)
+	}
+	return res
+}
+
+// fromItems returns the tracks of the playlist with the details of their
+// playlist items.
+func fromItems(p *domain.Playlist) []track {
+	res := fromTracks(p.Tracks)
+	for i := range res {
+		it := p.Item(i)
+		if !it.AddedAt.IsZero() {
+			res[i].AddedAt = &it.AddedAt
+		}
+		res[i].AddedBy = it.AddedBy
+	}
+	return res
+}
+
+func toTracks(ts []track) []domain.Track {
+	var res []domain.Track
+	for _, t := range ts {
+		dt := domain.Track{
+			ID:          t.ID,
+			ISRC:        t.ISRC,
+			MBID:        t.MBID,
+			Title:       t.Title,
+			Artist:      t.Artist,
+			Album:       t.Album,
+			AlbumArtist: t.AlbumArtist,
+			UPC:         t.UPC,
+			TrackNumber: t.TrackNumber,
+			DiscNumber:  t.DiscNumber,
+			ReleaseDate: t.ReleaseDate,
+			Explicit:    t.Explicit,
+			Popularity:  t.Popularity,
+			URL:         t.URL,
+			Duration:    time.Duration(t.DurationMS) * time.Millisecond,
+		}
+		for _, a := range t.Artists {
+			dt.Artists = append(dt.Artists, domain.Artist{ID: a.ID, Name: a.Name})
+		}
+		if dt.Artist == "" && len(dt.Artists) > 0 {
+			dt.Artist = dt.Artists[0].Name
+		}
+		res = append(res, dt)
+	}
+	return res
+}
+
+// toItems returns the playlist items of the tracks, or nil when none is
+// known.
+func toItems(ts []track) []domain.PlaylistItem {
+	var res []domain.PlaylistItem
+	known := false
+	for _, t := range ts {
+		it := domain.PlaylistItem{AddedBy: t.AddedBy}
+		if t.AddedAt != nil {
+			it.AddedAt = *t.AddedAt
+		}
+		known = known || it != (domain.PlaylistItem{})
+		res = append(res, it)
+	}
+	if !known {
+		return nil
+	}
+	return res
+}
+
+func fromFilters(f domain.TrackFilters) filters {
+	return filters{
+		ID:         f.ID,
+		ISRC:       f.ISRC,
+		MBID:       f.MBID,
+		Title:      f.Title,
+		Artist:     f.Artist,
+		Album:      f.Album,
+		DurationMS: f.Duration.Milliseconds(),
+	}
+}
+
+func (f filters) toDomain() domain.TrackFilters {
+	return domain.TrackFilters{
+		ID:       f.ID,
+		ISRC:     f.ISRC,
+		MBID:     f.MBID,
+		Title:    f.Title,
+		Artist:   f.Artist,
+		Album:    f.Album,
+		Duration: time.Duration(f.DurationMS) * time.Millisecond,
+	}
+}
@@ -0,0 +1,201 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+)
+
+// Plugin is implemented by plugin authors and served with Serve or Main.
+type Plugin struct {
+	// New builds the connector from the JSON encoded config section and the
+	// argument of the connector spec.
+	New func(ctx context.Context, config json.RawMessage, arg string) (domain.Connector, error)
+	// Login runs the authentication flow; it is optional.
+	Login func(ctx context.Context, config json.RawMessage) error
+}
+
+// Main serves the plugin over stdin and stdout and exits.
+func Main(p Plugin) {
+	if err := Serve(context.Background(), p, os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// Serve answers requests read from r until shutdown is requested or r is
+// closed.
+func Serve(ctx context.Context, p Plugin, r io.Reader, w io.Writer) error {
+	s := &server{plugin: p}
+
+	in := bufio.NewReader(r)
+	for {
+		line, err := in.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read request: %w", err)
+		}
+
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			return fmt.Errorf("failed to decode request: %w", err)
+		}
+
+		resp := response{JSONRPC: "2.0", ID: req.ID}
+		result, err := s.handle(ctx, req)
+		if err != nil {
+			resp.Error = toError(err)
+		} else {
+			b, err := json.Marshal(result)
+			if err != nil {
+				return fmt.Errorf("failed to encode %s result: %w", req.Method, err)
+			}
+			resp.Result = b
+		}
+
+		b, err := json.Marshal(resp)
+		if err != nil {
+			return fmt.Errorf("failed to encode response: %w", err)
+		}
+		if _, err := w.Write(append(b, '\n')); err != nil {
+			return fmt.Errorf("failed to write response: %w", err)
+		}
+
+		if req.Method == MethodShutdown {
+			return nil
+		}
+	}
+}
+
+type server struct {
+	plugin    Plugin
+	connector domain.Connector
+}
+
+func (s *server) handle(ctx context.Context, req request) (any, error) {
+	switch req.Method {
+	case MethodInitialize:
+		var params initializeParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		if params.Version != ProtocolVersion {
+			return nil, fmt.Errorf("unsupported protocol version: %d", params.Version)
+		}
+
+		c, err := s.plugin.New(ctx, params.Config, params.Arg)
+		if err != nil {
+			return nil, err
+		}
+		s.connector = c
+		return initializeResult{Capabilities: c.Capabilities()}, nil
+	case MethodLogin:
+		if s.plugin.Login == nil {
+			return nil, &Error{Code: CodeMethodNotFound, Message: "method not found: " + req.Method}
+		}
+
+		var params loginParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.plugin.Login(ctx, params.Config)
+	case MethodShutdown:
+		return nil, nil
+	}
+
+	if s.connector == nil {
+		return nil, fmt.Errorf("plugin is not initialized")
+	}
+
+	switch req.Method {
+	case MethodCreatePlaylist:
+		var params nameParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		pl, err := s.connector.CreatePlaylist(ctx, params.Name)
+		if err != nil {
+			return nil, err
+		}
+		return fromPlaylist(pl), nil
+	case MethodGetPlaylists:
+		pls, err := s.connector.GetPlaylists(ctx)
+		if err != nil {
+			return nil, err
+		}
+		res := []*playlist{}
+		for _, pl := range pls {
+			res = append(res, fromPlaylist(pl))
+		}
+		return res, nil
+	case MethodGetPlaylist:
+		var params idParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		pl, err := s.connector.GetPlaylist(ctx, params.ID)
+		if err != nil {
+			return nil, err
+		}
+		return fromPlaylist(pl), nil
+	case MethodGetPlaylistByName:
+		var params nameParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		pl, err := s.connector.GetPlaylistByName(ctx, params.Name)
+		if err != nil {
+			return nil, err
+		}
+		// A missing playlist is a null result.
+		return fromPlaylist(pl), nil
+	case MethodAddTracksToPlaylist:
+		var params tracksParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.connector.AddTracksToPlaylist(ctx, params.ID, toTracks(params.Tracks))
+	case MethodDeleteTracksFromPlaylist:
+		var params tracksParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.connector.DeleteTracksFromPlaylist(ctx, params.ID, toTracks(params.Tracks))
+	case MethodUpdatePlaylist:
+		var params updateParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.connector.UpdatePlaylist(ctx, params.ID, params.toDomain())
+	case MethodSearchTrack:
+		var params filters
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		tracks, err := s.connector.SearchTrack(ctx, params.toDomain())
+		if err != nil {
+			return nil, err
+		}
+		return fromTracks(tracks), nil
+	default:
+		return nil, &Error{Code: CodeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+func decodeParams(req request, v any) error {
+	if len(req.Params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(req.Params, v); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("invalid %s params: %v", req.Method, err)}
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+// Package registry keeps track of the available connectors. Built-in
+// connectors register themselves from their package init, and external
+// plugins are registered at startup from the config file.
+package registry
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+)
+
+// DecodeFunc decodes the config section of a connector into v. It leaves v
+// untouched when the section is missing.
+type DecodeFunc func(v any) error
+
+// Connector describes a connector whose config section is decoded into C.
+type Connector[C any] struct {
+	Name string
+	// Usage shows how the connector is selected, e.g. "m3u[:<dir>]".
+	Usage        string
+	Description  string
+	Capabilities domain.Capabilities
+	// New builds the connector; arg is the text after the colon in the
+	// connector spec, if any.
+	New func(ctx context.Context, cfg C, arg string) (domain.Connector, error)
+	// Login runs the interactive authentication flow and stores the
+	// credentials, for connectors that need one.
+	Login func(ctx context.Context, cfg C) error
+}
+
+// Entry is a registered connector.
+type Entry struct {
+	Name         string
+	Usage        string
+	Description  string
+	Capabilities domain.Capabilities
+	// Config lists the keys of the config section.
+	Config []Field
+
+	newFunc   func(ctx context.Context, decode DecodeFunc, arg string) (domain.Connector, error)
+	loginFunc func(ctx context.Context, decode DecodeFunc) error
+}
+
+// Field is a key of a connector's config section.
+type Field struct {
+	Key  string `json:"key" yaml:"key"`
+	Type string `json:"type" yaml:"type"`
+}
+
+// HasLogin reports whether the connector has an authentication flow.
+func (e Entry) HasLogin() bool {
+	return e.loginFunc != nil
+}
+
+func (e Entry) New(ctx context.Context, decode DecodeFunc, arg string) (domain.Connector, error) {
+	return e.newFunc(ctx, decode, arg)
+}
+
+func (e Entry) Login(ctx context.Context, decode DecodeFunc) error {
+	if e.loginFunc == nil {
+		return fmt.Errorf("connector %s has no login: %w", e.Name, domain.ErrUnsupported)
+	}
+	return e.loginFunc(ctx, decode)
+}
+
+var (
+	mu      sync.RWMutex
+	entries = make(map[string]Entry)
+)
+
+// Register adds a connector to the registry. It panics when the name is
+// already taken, as that is a programming error.
+func Register[C any](c Connector[C]) {
+	if err := register(c); err != nil {
+		panic(err)
+	}
+}
+
+// TryRegister is like Register but returns an error when the name is taken,
+// for connectors registered at runtime such as plugins.
+func TryRegister[C any](c Connector[C]) error {
+	return register(c)
+}
+
+func register[C any](c Connector[C]) error {
+	if c.Name == "" || strings.Contains(c.Name, ":") {
+		return fmt.Errorf("invalid connector name: %q", c.Name)
+	}
+	if c.New == nil {
+		return fmt.Errorf("connector %s has no factory", c.Name)
+	}
+
+	e := Entry{
+		Name:         c.Name,
+		Usage:        c.Usage,
+		Description:  c.Description,
+		Capabilities: c.Capabilities,
+		Config:       fields(reflect.TypeFor[C]()),
+		newFunc: func(ctx context.Context, decode DecodeFunc, arg string) (domain.Connector, error) {
+			cfg, err := decodeConfig[C](c.Name, decode)
+			if err != nil {
+				return nil, err
+			}
+			return c.New(ctx, cfg, arg)
+		},
+	}
+	if e.Usage == "" {
+		e.Usage = c.Name
+	}
+	if c.Login != nil {
+		e.loginFunc = func(ctx context.Context, decode DecodeFunc) error {
+			cfg, err := decodeConfig[C](c.Name, decode)
+			if err != nil {
+				return err
+			}
+			return c.Login(ctx, cfg)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, found := entries[c.Name]; found {
+		return fmt.Errorf("connector %s is already registered", c.Name)
+	}
+	entries[c.Name] = e
+	return nil
+}
+
+func decodeConfig[C any](name string, decode DecodeFunc) (C, error) {
+	var cfg C
+	if decode == nil {
+		return cfg, nil
+	}
+	if err := decode(&cfg); err != nil {
+		return cfg, fmt.Errorf("failed to decode %s config: %w", name, err)
+	}
+	return cfg, nil
+}
+
+// Lookup returns the connector registered under name.
+func Lookup(name string) (Entry, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	e, found := entries[name]
+	return e, found
+}
+
+// All returns the registered connectors sorted by name.
+func All() []Entry {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var all []Entry
+	for _, e := range entries {
+		all = append(all, e)
+	}
+	slices.SortFunc(all, func(a, b Entry) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return all
+}
+
+// ParseSpec splits a connector spec such as `m3u:./playlists` into the
+// connector name and its argument.
+func ParseSpec(spec string) (name, arg string) {
+	name, arg, _ = strings.Cut(spec, ":")
+	return name, arg
+}
+
+// New builds the connector for spec, decoding its config with decode, which
+// receives the connector name.
+func New(ctx context.Context, spec string, decode func(name string, v any) error) (domain.Connector, error) {
+	name, arg := ParseSpec(spec)
+	e, found := Lookup(name)
+	if !found {
+		return nil, fmt.Errorf("unknown connector: %s", name)
+	}
+
+	return e.New(ctx, func(v any) error {
+		if decode == nil {
+			return nil
+		}
+		return decode(name, v)
+	}, arg)
+}
+
+// fields lists the config keys of a struct from its yaml tags.
+func fields(t reflect.Type) []Field {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fs []Field
+	for i := range t.NumField() {
+		f := t.Field(i)
+		key, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if !f.IsExported() || key == "-" {
+			continue
+		}
+		if key == "" {
+			key = strings.ToLower(f.Name)
+		}
+		fs = append(fs, Field{Key: key, Type: f.Type.String()})
+	}
+	return fs
+}
@@ -0,0 +1,129 @@
+package registry_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testConfig struct {
+	Dir     string   `yaml:"dir"`
+	Retries int      `yaml:"retries,omitempty"`
+	Tags    []string `yaml:"tags"`
+	Ignored string   `yaml:"-"`
+}
+
+type testConnector struct {
+	domain.Connector
+	cfg testConfig
+	arg string
+}
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+
+	var logins []testConfig
+	registry.Register(registry.Connector[testConfig]{
+		Name:         "test-dir",
+		Usage:        "test-dir[:<dir>]",
+		Capabilities: domain.Capabilities{CreatePlaylist: true},
+		New: func(ctx context.Context, cfg testConfig, arg string) (domain.Connector, error) {
+			return &testConnector{cfg: cfg, arg: arg}, nil
+		},
+		Login: func(ctx context.Context, cfg testConfig) error {
+			logins = append(logins, cfg)
+			return nil
+		},
+	})
+	registry.Register(registry.Connector[testConfig]{
+		Name: "test-bare",
+		New: func(ctx context.Context, cfg testConfig, arg string) (domain.Connector, error) {
+			return &testConnector{cfg: cfg, arg: arg}, nil
+		},
+	})
+
+	decode := func(name string, v any) error {
+		if name != "test-dir" {
+			return nil
+		}
+		v.(*testConfig).Dir = "/music"
+		return nil
+	}
+
+	t.Run("lookup", func(t *testing.T) {
+		assert := assert.New(t)
+
+		e, found := registry.Lookup("test-dir")
+		assert.True(found)
+		assert.Equal("test-dir[:<dir>]", e.Usage)
+		assert.True(e.Capabilities.CreatePlaylist)
+		assert.True(e.HasLogin())
+		assert.Equal([]registry.Field{
+			{Key: "dir", Type: "string"},
+			{Key: "retries", Type: "int"},
+			{Key: "tags", Type: "[]string"},
+		}, e.Config)
+
+		e, found = registry.Lookup("test-bare")
+		assert.True(found)
+		assert.Equal("test-bare", e.Usage)
+		assert.False(e.HasLogin())
+		assert.ErrorIs(e.Login(ctx, nil), domain.ErrUnsupported)
+
+		_, found = registry.Lookup("missing")
+		assert.False(found)
+
+		var names []string
+		for _, e := range registry.All() {
+			names = append(names, e.Name)
+		}
+		assert.IsIncreasing(names)
+		assert.Subset(names, []string{"test-bare", "test-dir"})
+	})
+
+	t.Run("new", func(t *testing.T) {
+		assert := assert.New(t)
+
+		c, err := registry.New(ctx, "test-dir:./playlists", decode)
+		require.NoError(t, err)
+		assert.Equal("/music", c.(*testConnector).cfg.Dir)
+		assert.Equal("./playlists", c.(*testConnector).arg)
+
+		c, err = registry.New(ctx, "test-bare", nil)
+		require.NoError(t, err)
+		assert.Equal(testConfig{}, c.(*testConnector).cfg)
+
+		_, err = registry.New(ctx, "missing:arg", decode)
+		assert.EqualError(err, "unknown connector: missing")
+
+		_, err = registry.New(ctx, "test-dir", func(name string, v any) error {
+			return fmt.Errorf("bad yaml")
+		})
+		assert.EqualError(err, "failed to decode test-dir config: bad yaml")
+	})
+
+	t.Run("login", func(t *testing.T) {
+		e, _ := registry.Lookup("test-dir")
+		assert.NoError(t, e.Login(ctx, func(v any) error { return decode("test-dir", v) }))
+		assert.Equal(t, []testConfig{{Dir: "/music"}}, logins)
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		c := registry.Connector[testConfig]{
+			Name: "test-dir",
+			New: func(ctx context.Context, cfg testConfig, arg string) (domain.Connector, error) {
+				return nil, nil
+			},
+		}
+		assert.Panics(t, func() { registry.Register(c) })
+		assert.EqualError(t, registry.TryRegister(c), "connector test-dir is already registered")
+
+		c.Name = "bad:name"
+		assert.EqualError(t, registry.TryRegister(c), `invalid connector name: "bad:name"`)
+	})
+}
@@ -0,0 +1,138 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/registry"
+	"gopkg.in/yaml.v3"
+)
+
+var connectorsFormats = []Format{FormatTable, FormatJSON, FormatYAML}
+
+func ConnectorsFormats() []string {
+	var names []string
+	for _, f := range connectorsFormats {
+		names = append(names, string(f))
+	}
+	return names
+}
+
+func ParseConnectorsFormat(s string) (Format, error) {
+	f := Format(strings.ToLower(s))
+	if f == "yml" {
+		f = FormatYAML
+	}
+	if !slices.Contains(connectorsFormats, f) {
+		return "", fmt.Errorf("unknown output format %q: expected one of %s", s, strings.Join(ConnectorsFormats(), ", "))
+	}
+	return f, nil
+}
+
+type ConnectorInfo struct {
+	Name         string              `json:"name" yaml:"name"`
+	Usage        string              `json:"usage" yaml:"usage"`
+	Description  string              `json:"description,omitempty" yaml:"description,omitempty"`
+	Capabilities domain.Capabilities `json:"capabilities" yaml:"capabilities"`
+	// Login is set when the connector has an interactive login.
+	Login  bool             `json:"login" yaml:"login"`
+	Config []registry.Field `json:"config,omitempty" yaml:"config,omitempty"`
+}
+
+// ConnectorInfos describes the registered connectors.
+func ConnectorInfos(es []registry.Entry) []ConnectorInfo {
+	var cs []ConnectorInfo
+	for _, e := range es {
+		cs = append(cs, ConnectorInfo{
+			Name:         e.Name,
+			Usage:        e.Usage,
+			Description:  e.Description,
+			Capabilities: e.Capabilities,
+			Login:        e.HasLogin(),
+			Config:       e.Config,
+		})
+	}
+	return cs
+}
+
+// Connectors renders the available connectors and what they support.
+func Connectors(w io.Writer, format Format, cs []ConnectorInfo) error {
+	switch format {
+	case FormatTable:
+		return connectorsTable(w, cs)
+	case FormatJSON:
+		return writeJSON(w, cs)
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(cs); err != nil {
+			return fmt.Errorf("failed to encode yaml: %w", err)
+		}
+		return enc.Close()
+	default:
+		return fmt.Errorf("unsupported connectors format: %s", format)
+	}
+}
+
+func connectorsTable(w io.Writer, cs []ConnectorInfo) error {
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return headerStyle
+			}
+			return cellStyle
+		})
+
+	t.Headers("Connector", "Create", "Delete", "ISRC", "Batch", "Descriptions", "Visibility", "Collaborative", "Images", "Folders", "Login", "Usage")
+	for _, c := range cs {
+		batch := "-"
+		if c.Capabilities.MaxBatchSize > 0 {
+			batch = strconv.Itoa(c.Capabilities.MaxBatchSize)
+		}
+
+		t.Row(
+			c.Name,
+			yesNo(c.Capabilities.CreatePlaylist),
+			yesNo(c.Capabilities.DeleteTracks),
+			yesNo(c.Capabilities.ISRCLookup),
+			batch,
+			yesNo(c.Capabilities.Descriptions),
+			yesNo(c.Capabilities.Visibility),
+			yesNo(c.Capabilities.Collaborative),
+			yesNo(c.Capabilities.Images),
+			folderSupport(c.Capabilities),
+			yesNo(c.Login),
+			c.Usage,
+		)
+	}
+
+	fmt.Fprintln(w, t.Render())
+	return nil
+}
+
+// folderSupport tells connectors that only read folders apart from those
+// that also create them.
+func folderSupport(caps domain.Capabilities) string {
+	switch {
+	case caps.CreateFolders:
+		return "yes"
+	case caps.Folders:
+		return "read"
+	default:
+		return "no"
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,78 @@
+package render
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+var changelogTemplate = template.Must(template.New("changelog").Funcs(template.FuncMap{"dict": dict, "join": strings.Join, "added": formatAdded}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Sync changelog{{if or .From .To}}: {{.From}} → {{.To}}{{end}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem auto; max-width: 60rem; color: #222; }
+h1 { font-size: 1.6rem; }
+section { border-top: 1px solid #ddd; margin-top: 1.5rem; }
+table { border-collapse: collapse; }
+th, td { padding: .25rem .75rem; text-align: left; }
+.summary td { font-size: 1.4rem; font-weight: bold; }
+.added { color: #1a7f37; }
+.removed { color: #cf222e; }
+.missing { color: #9a6700; }
+.skipped { color: #666; }
+.new { font-size: .8rem; background: #ddf4ff; border-radius: .5rem; padding: .1rem .5rem; }
+.album { color: #666; }
+</style>
+</head>
+<body>
+<h1>Sync changelog{{if or .From .To}}: {{.From}} → {{.To}}{{end}}</h1>
+<table class="summary">
+<tr><th>Created</th><th class="added">Added</th><th class="removed">Removed</th><th class="missing">Missing</th></tr>
+<tr><td>{{.Summary.Created}}</td><td class="added">{{.Summary.Added}}</td><td class="removed">{{.Summary.Removed}}</td><td class="missing">{{.Summary.Missing}}</td></tr>
+</table>
+{{range .Playlists}}
+<section>
+<h2>{{.Name}}{{if .Created}} <span class="new">new</span>{{end}}{{if .Skipped}} <span class="new">skipped</span>{{end}}</h2>
+{{if .Updated}}<p class="updated">Updated: {{join .Updated ", "}}</p>{{end}}
+{{template "tracks" dict "Title" "Added" "Class" "added" "Tracks" .Added}}
+{{template "tracks" dict "Title" "Removed" "Class" "removed" "Tracks" .Removed}}
+{{template "tracks" dict "Title" "Missing" "Class" "missing" "Tracks" .Missing}}
+{{template "tracks" dict "Title" "Kept (destination cannot remove tracks)" "Class" "missing" "Tracks" .Kept}}
+{{template "tracks" dict "Title" "Skipped" "Class" "skipped" "Tracks" .SkippedTracks}}
+</section>
+{{end}}
+</body>
+</html>
+{{define "tracks"}}{{if .Tracks}}
+<h3 class="{{.Class}}">{{.Title}} ({{len .Tracks}})</h3>
+<ul>
+{{range .Tracks}}<li>{{if .URL}}<a href="{{.URL}}">{{.Title}}</a>{{else}}{{.Title}}{{end}} — {{.Artist}}{{if .Album}} <span class="album">· {{.Album}}</span>{{end}}{{with added .}} <span class="album">· {{.}}</span>{{end}}{{if .Reason}} <span class="skipped">({{.Reason}})</span>{{end}}</li>
+{{end}}</ul>
+{{end}}{{end}}
+`))
+
+func dict(kv ...any) (map[string]any, error) {
+	if len(kv)%2 != 0 {
+		return nil, fmt.Errorf("dict expects key/value pairs")
+	}
+
+	m := make(map[string]any, len(kv)/2)
+	for i := 0; i < len(kv); i += 2 {
+		k, ok := kv[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict keys must be strings")
+		}
+		m[k] = kv[i+1]
+	}
+	return m, nil
+}
+
+func changelogHTML(w io.Writer, v changelogView) error {
+	if err := changelogTemplate.Execute(w, v); err != nil {
+		return fmt.Errorf("failed to render html: %w", err)
+	}
+	return nil
+}
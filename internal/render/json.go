@@ -0,0 +1,20 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+func changelogJSON(w io.Writer, v changelogView) error {
+	return writeJSON(w, v)
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode json: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+func changelogMarkdown(w io.Writer, v changelogView) error {
+	var b strings.Builder
+
+	b.WriteString("# Sync changelog")
+	if v.From != "" || v.To != "" {
+		fmt.Fprintf(&b, ": %s → %s", v.From, v.To)
+	}
+	b.WriteString("\n\n")
+
+	b.WriteString("| Created | Added | Removed | Missing |\n")
+	b.WriteString("| ---: | ---: | ---: | ---: |\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d |\n", v.Summary.Created, v.Summary.Added, v.Summary.Removed, v.Summary.Missing)
+
+	for _, pl := range v.Playlists {
+		fmt.Fprintf(&b, "\n## %s", escapeMarkdown(pl.Name))
+		if pl.Created {
+			b.WriteString(" (new)")
+		}
+		if pl.Skipped {
+			b.WriteString(" (skipped: destination cannot create playlists)")
+		}
+		b.WriteString("\n")
+
+		if len(pl.Updated) > 0 {
+			fmt.Fprintf(&b, "\nUpdated: %s\n", strings.Join(pl.Updated, ", "))
+		}
+
+		writeMarkdownTracks(&b, "Added", pl.Added)
+		writeMarkdownTracks(&b, "Removed", pl.Removed)
+		writeMarkdownTracks(&b, "Missing", pl.Missing)
+		writeMarkdownTracks(&b, "Kept (destination cannot remove tracks)", pl.Kept)
+		writeMarkdownTracks(&b, "Skipped", pl.SkippedTracks)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdownTracks(b *strings.Builder, title string, tracks []trackView) {
+	if len(tracks) == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, "\n### %s (%d)\n\n", title, len(tracks))
+	for _, tr := range tracks {
+		name := escapeMarkdown(tr.Title)
+		if tr.URL != "" {
+			name = fmt.Sprintf("[%s](%s)", name, tr.URL)
+		}
+
+		fmt.Fprintf(b, "- %s — %s", name, escapeMarkdown(tr.Artist))
+		if tr.Album != "" {
+			fmt.Fprintf(b, " · _%s_", escapeMarkdown(tr.Album))
+		}
+		if added := formatAdded(tr); added != "" {
+			fmt.Fprintf(b, " · %s", escapeMarkdown(added))
+		}
+		if tr.Reason != "" {
+			fmt.Fprintf(b, " (%s)", tr.Reason)
+		}
+		b.WriteString("\n")
+	}
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"[", `\[`,
+	"]", `\]`,
+	"|", `\|`,
+	"#", `\#`,
+)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
@@ -0,0 +1,213 @@
+package render
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatYAML Format = "yaml"
+	FormatCSV  Format = "csv"
+	FormatTSV  Format = "tsv"
+)
+
+var playlistsFormats = []Format{FormatTable, FormatJSON, FormatYAML, FormatCSV, FormatTSV}
+
+func PlaylistsFormats() []string {
+	var names []string
+	for _, f := range playlistsFormats {
+		names = append(names, string(f))
+	}
+	return names
+}
+
+func ParsePlaylistsFormat(s string) (Format, error) {
+	f := Format(strings.ToLower(s))
+	if f == "yml" {
+		f = FormatYAML
+	}
+	if !slices.Contains(playlistsFormats, f) {
+		return "", fmt.Errorf("unknown output format %q: expected one of %s", s, strings.Join(PlaylistsFormats(), ", "))
+	}
+	return f, nil
+}
+
+// Playlists renders playlist metadata and, when withTracks is set, the full
+// track listing of each playlist.
+func Playlists(w io.Writer, format Format, pls []*domain.Playlist, withTracks bool) error {
+	views := make([]playlistInfoView, 0, len(pls))
+	for _, pl := range pls {
+		views = append(views, newPlaylistInfoView(pl, withTracks))
+	}
+
+	switch format {
+	case FormatTable:
+		return playlistsTable(w, views, withTracks)
+	case FormatJSON:
+		return writeJSON(w, views)
+	case FormatYAML:
+		return playlistsYAML(w, views)
+	case FormatCSV:
+		return playlistsDelimited(w, views, withTracks, ',')
+	case FormatTSV:
+		return playlistsDelimited(w, views, withTracks, '\t')
+	default:
+		return fmt.Errorf("unsupported playlists format: %s", format)
+	}
+}
+
+type playlistInfoView struct {
+	ID            string      `json:"id" yaml:"id"`
+	Name          string      `json:"name" yaml:"name"`
+	Description   string      `json:"description" yaml:"description"`
+	Owner         string      `json:"owner" yaml:"owner"`
+	Owned         bool        `json:"owned" yaml:"owned"`
+	Visibility    string      `json:"visibility" yaml:"visibility"`
+	Collaborative bool        `json:"collaborative" yaml:"collaborative"`
+	Folder        string      `json:"folder,omitempty" yaml:"folder,omitempty"`
+	TrackCount    int         `json:"track_count" yaml:"track_count"`
+	URL           string      `json:"url,omitempty" yaml:"url,omitempty"`
+	Tracks        []trackView `json:"tracks,omitempty" yaml:"tracks,omitempty"`
+}
+
+func newPlaylistInfoView(pl *domain.Playlist, withTracks bool) playlistInfoView {
+	v := playlistInfoView{
+		ID:            pl.ID,
+		Name:          pl.Name,
+		Description:   pl.Description,
+		Owner:         pl.Owner,
+		Owned:         pl.Owned,
+		Visibility:    pl.Visibility(),
+		Collaborative: pl.Collaborative,
+		Folder:        pl.FolderPath(),
+		TrackCount:    pl.Size(),
+		URL:           pl.URL,
+	}
+
+	if withTracks {
+		v.Tracks = newItemViews(pl)
+	}
+
+	return v
+}
+
+// newItemViews returns the tracks of the playlist with when and by whom they
+// were added.
+func newItemViews(pl *domain.Playlist) []trackView {
+	views := newTrackViews(pl.Tracks)
+	for i := range views {
+		views[i].setItem(pl.Item(i))
+	}
+	return views
+}
+
+func playlistsTable(w io.Writer, views []playlistInfoView, withTracks bool) error {
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return headerStyle
+			}
+			return cellStyle
+		})
+
+	t.Headers("ID", "Name", "Folder", "Owner", "Visibility", "# Tracks")
+	for _, v := range views {
+		t.Row(v.ID, v.Name, v.Folder, v.Owner, v.Visibility, strconv.Itoa(v.TrackCount))
+	}
+
+	fmt.Fprintln(w, t.Render())
+
+	if !withTracks {
+		return nil
+	}
+
+	for _, v := range views {
+		fmt.Fprintln(w, titleStyle.Render(v.Name))
+
+		tt := table.New().
+			Border(lipgloss.NormalBorder()).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				if row == table.HeaderRow {
+					return headerStyle
+				}
+				return cellStyle
+			})
+
+		tt.Headers("#", "Title", "Artist", "Album", "Added", "Added By", "ID")
+		for i, tr := range v.Tracks {
+			tt.Row(strconv.Itoa(i+1), tr.Title, tr.Artist, tr.Album, formatAddedAt(tr.AddedAt, time.DateOnly), tr.AddedBy, tr.ID)
+		}
+
+		fmt.Fprintln(w, tt.Render())
+	}
+
+	return nil
+}
+
+func playlistsYAML(w io.Writer, views []playlistInfoView) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(views); err != nil {
+		return fmt.Errorf("failed to encode yaml: %w", err)
+	}
+	return enc.Close()
+}
+
+func playlistsDelimited(w io.Writer, views []playlistInfoView, withTracks bool, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+
+	if withTracks {
+		cw.Write([]string{"playlist_id", "playlist_name", "position", "track_id", "isrc", "title", "artist", "album", "url", "added_at", "added_by"})
+		for _, v := range views {
+			for i, tr := range v.Tracks {
+				cw.Write([]string{v.ID, v.Name, strconv.Itoa(i + 1), tr.ID, tr.ISRC, tr.Title, tr.Artist, tr.Album, tr.URL, formatAddedAt(tr.AddedAt, time.RFC3339), tr.AddedBy})
+			}
+		}
+	} else {
+		cw.Write([]string{"id", "name", "owner", "visibility", "collaborative", "description", "track_count", "url", "folder"})
+		for _, v := range views {
+			cw.Write([]string{v.ID, v.Name, v.Owner, v.Visibility, strconv.FormatBool(v.Collaborative), v.Description, strconv.Itoa(v.TrackCount), v.URL, v.Folder})
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write delimited output: %w", err)
+	}
+	return nil
+}
+
+// formatAdded describes when and by whom the track was added, such as
+// "added 2024-05-01 by alice".
+func formatAdded(tr trackView) string {
+	var parts []string
+	if tr.AddedAt != nil {
+		parts = append(parts, tr.AddedAt.Format(time.DateOnly))
+	}
+	if tr.AddedBy != "" {
+		parts = append(parts, "by "+tr.AddedBy)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "added " + strings.Join(parts, " ")
+}
+
+func formatAddedAt(t *time.Time, layout string) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(layout)
+}
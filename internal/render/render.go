@@ -0,0 +1,235 @@
+package render
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+)
+
+type Format string
+
+const (
+	FormatTable    Format = "table"
+	FormatJSON     Format = "json"
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+)
+
+var changelogFormats = []Format{FormatTable, FormatJSON, FormatMarkdown, FormatHTML}
+
+func ChangelogFormats() []string {
+	var names []string
+	for _, f := range changelogFormats {
+		names = append(names, string(f))
+	}
+	return names
+}
+
+func ParseChangelogFormat(s string) (Format, error) {
+	f := Format(strings.ToLower(s))
+	if f == "md" {
+		f = FormatMarkdown
+	}
+	if !slices.Contains(changelogFormats, f) {
+		return "", fmt.Errorf("unknown output format %q: expected one of %s", s, strings.Join(ChangelogFormats(), ", "))
+	}
+	return f, nil
+}
+
+type ChangelogReport struct {
+	From      string
+	To        string
+	Changelog *domain.Changelog
+}
+
+func Changelog(w io.Writer, format Format, r ChangelogReport) error {
+	v := newChangelogView(r)
+	switch format {
+	case FormatTable:
+		return changelogTable(w, v)
+	case FormatJSON:
+		return changelogJSON(w, v)
+	case FormatMarkdown:
+		return changelogMarkdown(w, v)
+	case FormatHTML:
+		return changelogHTML(w, v)
+	default:
+		return fmt.Errorf("unsupported changelog format: %s", format)
+	}
+}
+
+type changelogView struct {
+	From      string         `json:"from"`
+	To        string         `json:"to"`
+	Summary   summaryView    `json:"summary"`
+	Playlists []playlistView `json:"playlists"`
+}
+
+type summaryView struct {
+	Created int `json:"created"`
+	Skipped int `json:"skipped"`
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	Missing int `json:"missing"`
+	Kept    int `json:"kept"`
+	// SkippedTracks counts source items that cannot be synced.
+	SkippedTracks int `json:"skipped_tracks"`
+	// Updated counts playlists whose details change.
+	Updated int `json:"updated"`
+}
+
+type playlistView struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Created bool   `json:"created"`
+	// Skipped is set for playlists the destination cannot create.
+	Skipped bool        `json:"skipped"`
+	Added   []trackView `json:"added"`
+	Removed []trackView `json:"removed"`
+	Missing []trackView `json:"missing"`
+	Kept    []trackView `json:"kept"`
+	// SkippedTracks are source items that cannot be synced, with the reason.
+	SkippedTracks []trackView `json:"skipped_tracks"`
+	// Updated lists the details that change, such as the description.
+	Updated []string `json:"updated"`
+}
+
+type trackView struct {
+	ID     string `json:"id" yaml:"id"`
+	ISRC   string `json:"isrc,omitempty" yaml:"isrc,omitempty"`
+	Title  string `json:"title" yaml:"title"`
+	Artist string `json:"artist" yaml:"artist"`
+	Album  string `json:"album" yaml:"album"`
+	URL    string `json:"url,omitempty" yaml:"url,omitempty"`
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	// AddedAt and AddedBy tell when and by whom the track was added, if
+	// known.
+	AddedAt *time.Time `json:"added_at,omitempty" yaml:"added_at,omitempty"`
+	AddedBy string     `json:"added_by,omitempty" yaml:"added_by,omitempty"`
+}
+
+func newChangelogView(r ChangelogReport) changelogView {
+	v := changelogView{
+		From:      r.From,
+		To:        r.To,
+		Playlists: []playlistView{},
+	}
+
+	created := make(map[domain.PlaylistRef]struct{})
+	for _, ref := range r.Changelog.Playlists.Added {
+		created[ref] = struct{}{}
+		if _, found := r.Changelog.TracksByPlaylist[ref]; !found {
+			v.Playlists = append(v.Playlists, newPlaylistView(ref, true, domain.PlaylistTracksChangelog{}))
+		}
+	}
+
+	for _, ref := range r.Changelog.Playlists.Skipped {
+		pl := newPlaylistView(ref, false, domain.PlaylistTracksChangelog{})
+		pl.Skipped = true
+		v.Playlists = append(v.Playlists, pl)
+	}
+
+	for ref, cl := range r.Changelog.TracksByPlaylist {
+		_, isNew := created[ref]
+		v.Playlists = append(v.Playlists, newPlaylistView(ref, isNew, cl))
+	}
+
+	for ref, cl := range r.Changelog.MetadataByPlaylist {
+		i := slices.IndexFunc(v.Playlists, func(pl playlistView) bool {
+			return pl.ID == ref.ID && pl.Name == ref.Name && !pl.Skipped
+		})
+		if i < 0 {
+			_, isNew := created[ref]
+			v.Playlists = append(v.Playlists, newPlaylistView(ref, isNew, domain.PlaylistTracksChangelog{}))
+			i = len(v.Playlists) - 1
+		}
+		v.Playlists[i].Updated = cl.Changes()
+	}
+
+	slices.SortFunc(v.Playlists, func(a, b playlistView) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+
+	v.Summary.Created = len(created)
+	v.Summary.Skipped = len(r.Changelog.Playlists.Skipped)
+	for _, pl := range v.Playlists {
+		v.Summary.Added += len(pl.Added)
+		v.Summary.Removed += len(pl.Removed)
+		v.Summary.Missing += len(pl.Missing)
+		v.Summary.Kept += len(pl.Kept)
+		v.Summary.SkippedTracks += len(pl.SkippedTracks)
+		if len(pl.Updated) > 0 {
+			v.Summary.Updated++
+		}
+	}
+
+	return v
+}
+
+func newPlaylistView(ref domain.PlaylistRef, created bool, cl domain.PlaylistTracksChangelog) playlistView {
+	return playlistView{
+		ID:      ref.ID,
+		Name:    ref.Name,
+		Created: created,
+		Added:   newChangedTrackViews(cl.Added, cl),
+		Removed: newChangedTrackViews(cl.Removed, cl),
+		Missing: newChangedTrackViews(cl.Missing, cl),
+		Kept:    newChangedTrackViews(cl.Kept, cl),
+
+		SkippedTracks: newSkippedTrackViews(cl),
+		Updated:       []string{},
+	}
+}
+
+func newTrackViews(tracks []domain.Track) []trackView {
+	views := make([]trackView, 0, len(tracks))
+	for _, tr := range tracks {
+		views = append(views, newTrackView(tr))
+	}
+	return views
+}
+
+// newChangedTrackViews returns the tracks of a changelog with when and by
+// whom they were added.
+func newChangedTrackViews(tracks []domain.Track, cl domain.PlaylistTracksChangelog) []trackView {
+	views := newTrackViews(tracks)
+	for i := range views {
+		views[i].setItem(cl.Item(views[i].ID))
+	}
+	return views
+}
+
+func newSkippedTrackViews(cl domain.PlaylistTracksChangelog) []trackView {
+	views := make([]trackView, 0, len(cl.Skipped))
+	for _, s := range cl.Skipped {
+		tv := newTrackView(s.Track)
+		tv.Reason = s.Reason
+		tv.setItem(cl.Item(s.Track.ID))
+		views = append(views, tv)
+	}
+	return views
+}
+
+func (v *trackView) setItem(it domain.PlaylistItem) {
+	if !it.AddedAt.IsZero() {
+		addedAt := it.AddedAt.UTC()
+		v.AddedAt = &addedAt
+	}
+	v.AddedBy = it.AddedBy
+}
+
+func newTrackView(tr domain.Track) trackView {
+	return trackView{
+		ID:     tr.ID,
+		ISRC:   tr.ISRC,
+		Title:  tr.Title,
+		Artist: strings.Join(tr.ArtistNames(), ", "),
+		Album:  tr.Album,
+		URL:    tr.URL,
+	}
+}
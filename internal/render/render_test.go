@@ -0,0 +1,216 @@
+package render_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/render"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangelog(t *testing.T) {
+	assert := assert.New(t)
+
+	ref1 := domain.PlaylistRef{ID: "pl1", Name: "Road Trip"}
+	ref2 := domain.PlaylistRef{ID: "pl2", Name: "Empty"}
+	ref3 := domain.PlaylistRef{ID: "pl3", Name: "Skipped"}
+	ref4 := domain.PlaylistRef{ID: "pl4", Name: "Party"}
+	report := render.ChangelogReport{
+		From: "spotify",
+		To:   "tidal",
+		Changelog: &domain.Changelog{
+			Playlists: domain.PlaylistChangelog{
+				Added:   []domain.PlaylistRef{ref1, ref2},
+				Skipped: []domain.PlaylistRef{ref3},
+			},
+			TracksByPlaylist: map[domain.PlaylistRef]domain.PlaylistTracksChangelog{
+				ref1: {
+					Added: []domain.Track{
+						{ID: "t1", Title: "Drive <Fast>", Artist: "Band", Album: "Album", URL: "https://example.com/t1"},
+					},
+					Missing: []domain.Track{
+						{ID: "t2", Title: "Rare_Track", Artist: "Unknown"},
+					},
+					Kept: []domain.Track{
+						{ID: "t3", Title: "Old", Artist: "Band"},
+					},
+					Skipped: []domain.SkippedTrack{
+						{Track: domain.Track{ID: "e1", Kind: domain.TrackKindEpisode, Title: "Episode 1", Artist: "The Show"}, Reason: "podcast episode"},
+					},
+					Items: map[string]domain.PlaylistItem{
+						"t1": {AddedAt: time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC), AddedBy: "alice"},
+						"t3": {AddedBy: "bob"},
+					},
+				},
+			},
+			MetadataByPlaylist: map[domain.PlaylistRef]domain.PlaylistMetadataChangelog{
+				ref4: {
+					From: domain.PlaylistMetadata{Description: "Old"},
+					To:   domain.PlaylistMetadata{Description: "New", Public: true},
+				},
+			},
+		},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(render.Changelog(&buf, render.FormatJSON, report))
+
+		var v struct {
+			From    string `json:"from"`
+			Summary struct {
+				Created int `json:"created"`
+				Skipped int `json:"skipped"`
+				Added   int `json:"added"`
+				Missing int `json:"missing"`
+				Kept    int `json:"kept"`
+
+				SkippedTracks int `json:"skipped_tracks"`
+				Updated       int `json:"updated"`
+			} `json:"summary"`
+			Playlists []struct {
+				Name    string `json:"name"`
+				Created bool   `json:"created"`
+				Skipped bool   `json:"skipped"`
+				Added   []struct {
+					URL     string     `json:"url"`
+					AddedAt *time.Time `json:"added_at"`
+					AddedBy string     `json:"added_by"`
+				} `json:"added"`
+				SkippedTracks []struct {
+					Reason string `json:"reason"`
+				} `json:"skipped_tracks"`
+				Updated []string `json:"updated"`
+			} `json:"playlists"`
+		}
+		assert.NoError(json.Unmarshal(buf.Bytes(), &v))
+		assert.Equal("spotify", v.From)
+		assert.Equal(2, v.Summary.Created)
+		assert.Equal(1, v.Summary.Added)
+		assert.Equal(1, v.Summary.Missing)
+		assert.Equal(1, v.Summary.Kept)
+		assert.Equal(1, v.Summary.Skipped)
+		assert.Equal(1, v.Summary.SkippedTracks)
+		assert.Equal(1, v.Summary.Updated)
+		assert.Len(v.Playlists, 4)
+		assert.Equal("Empty", v.Playlists[0].Name)
+		assert.Equal("Party", v.Playlists[1].Name)
+		assert.Equal([]string{"description", "visibility"}, v.Playlists[1].Updated)
+		assert.True(v.Playlists[2].Created)
+		assert.Equal("https://example.com/t1", v.Playlists[2].Added[0].URL)
+		assert.Equal(time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC), *v.Playlists[2].Added[0].AddedAt)
+		assert.Equal("alice", v.Playlists[2].Added[0].AddedBy)
+		assert.Equal("podcast episode", v.Playlists[2].SkippedTracks[0].Reason)
+		assert.Empty(v.Playlists[2].Updated)
+		assert.True(v.Playlists[3].Skipped)
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(render.Changelog(&buf, render.FormatMarkdown, report))
+		assert.Contains(buf.String(), "# Sync changelog: spotify → tidal")
+		assert.Contains(buf.String(), "## Road Trip (new)")
+		assert.Contains(buf.String(), "- [Drive <Fast>](https://example.com/t1) — Band · _Album_ · added 2024-05-01 by alice\n")
+		assert.Contains(buf.String(), "- Old — Band · added by bob\n")
+		assert.Contains(buf.String(), `- Rare\_Track — Unknown`)
+		assert.Contains(buf.String(), "### Kept (destination cannot remove tracks) (1)")
+		assert.Contains(buf.String(), "## Skipped (skipped: destination cannot create playlists)")
+		assert.Contains(buf.String(), "### Skipped (1)\n\n- Episode 1 — The Show (podcast episode)")
+		assert.Contains(buf.String(), "## Party\n\nUpdated: description, visibility\n")
+	})
+
+	t.Run("html", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(render.Changelog(&buf, render.FormatHTML, report))
+		assert.Contains(buf.String(), `<a href="https://example.com/t1">Drive &lt;Fast&gt;</a>`)
+		assert.Contains(buf.String(), `<span class="album">· Album</span> <span class="album">· added 2024-05-01 by alice</span>`)
+		assert.Contains(buf.String(), "Missing (1)")
+		assert.Contains(buf.String(), `Episode 1 — The Show <span class="skipped">(podcast episode)</span>`)
+		assert.Contains(buf.String(), `<p class="updated">Updated: description, visibility</p>`)
+	})
+
+	t.Run("table", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(render.Changelog(&buf, render.FormatTable, report))
+		assert.Contains(buf.String(), "Road Trip")
+		assert.Contains(buf.String(), "Added:   1 tracks")
+		assert.Contains(buf.String(), "Kept:    1 tracks")
+		assert.Contains(buf.String(), "Skipped: 1 tracks")
+		assert.Contains(buf.String(), "Updated: 1 playlists")
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := render.ParseChangelogFormat("xml")
+		assert.Error(err)
+
+		f, err := render.ParseChangelogFormat("md")
+		assert.NoError(err)
+		assert.Equal(render.FormatMarkdown, f)
+	})
+}
+
+func TestPlaylists(t *testing.T) {
+	assert := assert.New(t)
+
+	pls := []*domain.Playlist{
+		{
+			ID:          "pl1",
+			Name:        "Road Trip",
+			Description: "Songs, for the road",
+			Owner:       "alice",
+			Public:      true,
+			Folder:      []string{"Moods", "Travel"},
+			TrackCount:  2,
+			URL:         "https://example.com/pl1",
+			Tracks: []domain.Track{
+				{ID: "t1", Title: "Drive", Artist: "Band"},
+				{ID: "t2", Title: "Ride", Artist: "Band", Artists: []domain.Artist{{Name: "Band"}, {Name: "Guest"}}},
+			},
+			Items: []domain.PlaylistItem{{}, {AddedAt: time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC), AddedBy: "bob"}},
+		},
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(render.Playlists(&buf, render.FormatCSV, pls, false))
+		assert.Equal("id,name,owner,visibility,collaborative,description,track_count,url,folder\n"+
+			"pl1,Road Trip,alice,public,false,\"Songs, for the road\",2,https://example.com/pl1,Moods / Travel\n", buf.String())
+	})
+
+	t.Run("tsv with tracks", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(render.Playlists(&buf, render.FormatTSV, pls, true))
+		assert.Contains(buf.String(), "pl1\tRoad Trip\t1\tt1\t\tDrive\tBand\t\t\t\t\n")
+		assert.Contains(buf.String(), "pl1\tRoad Trip\t2\tt2\t\tRide\tBand, Guest\t\t\t2024-05-01T10:30:00Z\tbob\n")
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(render.Playlists(&buf, render.FormatYAML, pls, false))
+		assert.Contains(buf.String(), "- id: pl1\n  name: Road Trip\n")
+		assert.Contains(buf.String(), "  folder: Moods / Travel\n")
+		assert.Contains(buf.String(), "  track_count: 2\n")
+		assert.NotContains(buf.String(), "tracks:")
+	})
+
+	t.Run("json with tracks", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(render.Playlists(&buf, render.FormatJSON, pls, true))
+
+		var v []struct {
+			Visibility string `json:"visibility"`
+			Tracks     []struct {
+				ID      string `json:"id"`
+				AddedBy string `json:"added_by"`
+			} `json:"tracks"`
+		}
+		assert.NoError(json.Unmarshal(buf.Bytes(), &v))
+		assert.Equal("public", v[0].Visibility)
+		assert.Len(v[0].Tracks, 2)
+		assert.Equal("bob", v[0].Tracks[1].AddedBy)
+		assert.NotContains(buf.String(), `"added_at": null`)
+	})
+}
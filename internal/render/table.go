@@ -0,0 +1,80 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+)
+
+var (
+	cellStyle    = lipgloss.NewStyle().Padding(0, 1)
+	headerStyle  = cellStyle.Bold(true)
+	titleStyle   = lipgloss.NewStyle().Bold(true)
+	addedStyle   = cellStyle.Foreground(lipgloss.Color("2"))
+	removedStyle = cellStyle.Foreground(lipgloss.Color("1"))
+	missingStyle = cellStyle.Foreground(lipgloss.Color("3"))
+)
+
+func changelogTable(w io.Writer, v changelogView) error {
+	if v.From != "" || v.To != "" {
+		fmt.Fprintln(w, titleStyle.Render(fmt.Sprintf("%s → %s", v.From, v.To)))
+	}
+
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			switch {
+			case row == table.HeaderRow:
+				return headerStyle
+			case col == 2:
+				return addedStyle
+			case col == 3:
+				return removedStyle
+			case col == 4:
+				return missingStyle
+			default:
+				return cellStyle
+			}
+		})
+
+	t.Headers("Playlist", "New", "Added", "Removed", "Missing")
+	for _, pl := range v.Playlists {
+		var created string
+		switch {
+		case pl.Created:
+			created = "yes"
+		case pl.Skipped:
+			created = "skipped"
+		}
+
+		t.Row(
+			pl.Name,
+			created,
+			strconv.Itoa(len(pl.Added)),
+			strconv.Itoa(len(pl.Removed)),
+			strconv.Itoa(len(pl.Missing)),
+		)
+	}
+
+	fmt.Fprintln(w, t.Render())
+	fmt.Fprintln(w, addedStyle.UnsetPadding().Render(fmt.Sprintf("Added:   %d tracks", v.Summary.Added)))
+	fmt.Fprintln(w, removedStyle.UnsetPadding().Render(fmt.Sprintf("Removed: %d tracks", v.Summary.Removed)))
+	fmt.Fprintln(w, missingStyle.UnsetPadding().Render(fmt.Sprintf("Missing: %d tracks", v.Summary.Missing)))
+	if v.Summary.Updated > 0 {
+		fmt.Fprintln(w, addedStyle.UnsetPadding().Render(fmt.Sprintf("Updated: %d playlists (description, visibility, cover image or folder)", v.Summary.Updated)))
+	}
+	if v.Summary.Kept > 0 {
+		fmt.Fprintln(w, missingStyle.UnsetPadding().Render(fmt.Sprintf("Kept:    %d tracks (destination cannot remove tracks)", v.Summary.Kept)))
+	}
+	if v.Summary.SkippedTracks > 0 {
+		fmt.Fprintln(w, missingStyle.UnsetPadding().Render(fmt.Sprintf("Skipped: %d tracks (episodes, local files or unavailable)", v.Summary.SkippedTracks)))
+	}
+	if v.Summary.Skipped > 0 {
+		fmt.Fprintln(w, missingStyle.UnsetPadding().Render(fmt.Sprintf("Skipped: %d playlists (destination cannot create playlists)", v.Summary.Skipped)))
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package smart
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+)
+
+var errReadOnly = fmt.Errorf("smart playlists are read-only: %w", domain.ErrUnsupported)
+
+// NewConnector exposes an evaluated smart playlist as a read-only connector,
+// so it can be used as the source of a sync.
+func NewConnector(pl *domain.Playlist) *connector {
+	return &connector{playlist: pl}
+}
+
+type connector struct {
+	playlist *domain.Playlist
+}
+
+var _ domain.Connector = (*connector)(nil)
+
+// Capabilities of a smart playlist, which is read-only but sets the
+// description and visibility of the playlist it is synced to.
+var Capabilities = domain.Capabilities{
+	Descriptions: true,
+	Visibility:   true,
+}
+
+func (c *connector) Capabilities() domain.Capabilities {
+	return Capabilities
+}
+
+func (c *connector) CreatePlaylist(ctx context.Context, name string) (*domain.Playlist, error) {
+	return nil, errReadOnly
+}
+
+func (c *connector) GetPlaylists(ctx context.Context) ([]*domain.Playlist, error) {
+	return []*domain.Playlist{c.playlist}, nil
+}
+
+func (c *connector) GetPlaylist(ctx context.Context, id string) (*domain.Playlist, error) {
+	if c.playlist.ID == id {
+		return c.playlist, nil
+	}
+	return nil, nil
+}
+
+func (c *connector) GetPlaylistByName(ctx context.Context, name string) (*domain.Playlist, error) {
+	if c.playlist.Name == name {
+		return c.playlist, nil
+	}
+	return nil, nil
+}
+
+func (c *connector) AddTracksToPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	return errReadOnly
+}
+
+func (c *connector) DeleteTracksFromPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	return errReadOnly
+}
+
+func (c *connector) UpdatePlaylist(ctx context.Context, id string, meta domain.PlaylistMetadata) error {
+	return errReadOnly
+}
+
+func (c *connector) SearchTrack(ctx context.Context, filters domain.TrackFilters) ([]domain.Track, error) {
+	return nil, errReadOnly
+}
@@ -0,0 +1,484 @@
+// Package smart generates playlists from rules instead of copying them.
+//
+// A smart playlist is evaluated against the library of one or more
+// connectors, made of the tracks in their playlists or the tracks the user
+// saved, and the result is synced to a destination like any other playlist, so
+// each run only applies what changed since the last one.
+package smart
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+)
+
+// Definition is a smart playlist as written in the config file.
+type Definition struct {
+	// Name of the generated playlist.
+	Name        string `yaml:"name,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	Public      bool   `yaml:"public,omitempty"`
+	// From are the connectors whose library is searched and To the one the
+	// playlist is written to.
+	From []string `yaml:"from"`
+	To   string   `yaml:"to"`
+	// Library lists the Sources making up the library; it defaults to the
+	// playlists.
+	Library []string `yaml:"library,omitempty"`
+	// Include, Exclude and OwnedOnly pick the playlists making up the
+	// library; every playlist is used when none are given.
+	Include   []string `yaml:"include,omitempty"`
+	Exclude   []string `yaml:"exclude,omitempty"`
+	OwnedOnly bool     `yaml:"owned_only,omitempty"`
+	Rules     Rules    `yaml:"rules,omitempty"`
+	// Sort orders the tracks by one of SortKeys, the most popular first for
+	// popularity and ascending for the others, before Limit keeps the first
+	// ones. Reverse flips the order.
+	Sort    string `yaml:"sort,omitempty"`
+	Reverse bool   `yaml:"reverse,omitempty"`
+	Limit   int    `yaml:"limit,omitempty"`
+}
+
+// Rules select the tracks of a smart playlist. A track is selected when it
+// passes every rule that is set.
+type Rules struct {
+	// Artists and Albums are name patterns, as used to select playlists, at
+	// least one of which must match; Artists are matched against every
+	// artist credited on the track.
+	Artists        []string `yaml:"artists,omitempty"`
+	ExcludeArtists []string `yaml:"exclude_artists,omitempty"`
+	Albums         []string `yaml:"albums,omitempty"`
+	// Genres are patterns matched against the genres of the track's
+	// artists, which only some connectors know.
+	Genres []string `yaml:"genres,omitempty"`
+	// AddedWithin keeps the tracks added to a library playlist in the given
+	// period, such as "30d", "2w" or "12h".
+	AddedWithin Period `yaml:"added_within,omitempty"`
+	// MinYear and MaxYear bound the release year, inclusive.
+	MinYear       int   `yaml:"min_year,omitempty"`
+	MaxYear       int   `yaml:"max_year,omitempty"`
+	MinPopularity int   `yaml:"min_popularity,omitempty"`
+	Explicit      *bool `yaml:"explicit,omitempty"`
+}
+
+// Period is a duration that can also be written in days or weeks.
+type Period time.Duration
+
+func (p *Period) UnmarshalText(text []byte) error {
+	s := string(text)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, found := strings.CutSuffix(s, suffix); found {
+			v, err := strconv.Atoi(n)
+			if err != nil {
+				return fmt.Errorf("invalid period %q: %w", s, err)
+			}
+			*p = Period(time.Duration(v) * unit)
+			return nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid period %q: %w", s, err)
+	}
+	*p = Period(d)
+	return nil
+}
+
+const (
+	// SourcePlaylists is the tracks in the selected playlists.
+	SourcePlaylists = "playlists"
+	// SourceSaved is the tracks the user liked or saved, on connectors
+	// implementing domain.SavedTracksReader.
+	SourceSaved = "saved"
+)
+
+func Sources() []string {
+	return []string{SourcePlaylists, SourceSaved}
+}
+
+func SortKeys() []string {
+	return []string{"popularity", "added", "released", "title", "artist"}
+}
+
+// candidate is a track of the library with the last time it was added to a
+// library playlist or saved, and the index of the library it was found in.
+type candidate struct {
+	track  domain.Track
+	item   domain.PlaylistItem
+	lib    int
+	genres []string
+}
+
+type rules struct {
+	artists        []domain.NamePattern
+	excludeArtists []domain.NamePattern
+	albums         []domain.NamePattern
+	genres         []domain.NamePattern
+	Rules
+}
+
+// Evaluate builds the smart playlist from the libraries, with now as the
+// time periods are counted back from.
+func (d Definition) Evaluate(ctx context.Context, now time.Time, libraries ...domain.Connector) (*domain.Playlist, error) {
+	r, err := d.Rules.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	compare, err := sortFunc(d.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	selector, err := d.selector()
+	if err != nil {
+		return nil, err
+	}
+
+	sources, err := d.sources()
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := collect(ctx, selector, sources, libraries)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates = slices.DeleteFunc(candidates, func(c candidate) bool {
+		return !r.match(c, now)
+	})
+
+	// Genres are looked up last, for the tracks passing the other rules.
+	if len(r.genres) > 0 {
+		if err := withGenres(ctx, candidates, libraries); err != nil {
+			return nil, err
+		}
+		candidates = slices.DeleteFunc(candidates, func(c candidate) bool {
+			return !matchAny(r.genres, c.genres...)
+		})
+	}
+
+	if compare != nil {
+		slices.SortStableFunc(candidates, func(a, b candidate) int {
+			if d.Reverse {
+				return compare(b, a)
+			}
+			return compare(a, b)
+		})
+	}
+
+	if d.Limit > 0 && len(candidates) > d.Limit {
+		candidates = candidates[:d.Limit]
+	}
+
+	pl := &domain.Playlist{
+		ID:          d.Name,
+		Name:        d.Name,
+		Description: d.Description,
+		Public:      d.Public,
+		Owned:       true,
+	}
+	for _, c := range candidates {
+		pl.Tracks = append(pl.Tracks, c.track)
+		pl.Items = append(pl.Items, c.item)
+	}
+	pl.TrackCount = len(pl.Tracks)
+	return pl, nil
+}
+
+// selector picks the library playlists, leaving out the smart playlist itself
+// so that it does not feed on its own tracks when written to a library.
+func (d Definition) selector() (domain.PlaylistSelector, error) {
+	include, err := domain.ParseNamePatterns(d.Include)
+	if err != nil {
+		return domain.PlaylistSelector{}, err
+	}
+
+	exclude, err := domain.ParseNamePatterns(d.Exclude)
+	if err != nil {
+		return domain.PlaylistSelector{}, err
+	}
+
+	self, err := domain.ParseNamePattern("re:^" + regexp.QuoteMeta(d.Name) + "$")
+	if err != nil {
+		return domain.PlaylistSelector{}, err
+	}
+
+	return domain.PlaylistSelector{
+		Include:   include,
+		Exclude:   append(exclude, self),
+		OwnedOnly: d.OwnedOnly,
+	}, nil
+}
+
+func (d Definition) sources() ([]string, error) {
+	if len(d.Library) == 0 {
+		return []string{SourcePlaylists}, nil
+	}
+
+	for _, s := range d.Library {
+		if !slices.Contains(Sources(), s) {
+			return nil, fmt.Errorf("unknown library source %q: expected %s", s, strings.Join(Sources(), ", "))
+		}
+	}
+	return d.Library, nil
+}
+
+// collect gathers the tracks of the sources of every library. A recording
+// found more than once is kept once, at its first position, with the last
+// time it was added.
+func collect(ctx context.Context, selector domain.PlaylistSelector, sources []string, libraries []domain.Connector) ([]candidate, error) {
+	var candidates []candidate
+	seen := make(map[string]int)
+
+	add := func(lib int, tracks []domain.Track, item func(int) domain.PlaylistItem) {
+		for i, t := range tracks {
+			if t.SkipReason() != "" {
+				continue
+			}
+
+			c := candidate{track: t, item: item(i), lib: lib}
+			key := recordingKey(t)
+			if j, found := seen[key]; found {
+				if c.item.AddedAt.After(candidates[j].item.AddedAt) {
+					candidates[j].item = c.item
+				}
+				continue
+			}
+			seen[key] = len(candidates)
+			candidates = append(candidates, c)
+		}
+	}
+
+	for i, lib := range libraries {
+		if slices.Contains(sources, SourcePlaylists) {
+			pls, err := lib.GetPlaylists(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get playlists: %w", err)
+			}
+
+			for _, pl := range selector.Select(pls) {
+				if !pl.HasTracks() {
+					full, err := lib.GetPlaylist(ctx, pl.ID)
+					if err != nil {
+						return nil, fmt.Errorf("failed to get playlist %s: %w", pl.Name, err)
+					}
+					if full == nil {
+						continue
+					}
+					pl = full
+				}
+				add(i, pl.Tracks, pl.Item)
+			}
+		}
+
+		if slices.Contains(sources, SourceSaved) {
+			reader, ok := lib.(domain.SavedTracksReader)
+			if !ok {
+				return nil, fmt.Errorf("failed to get saved tracks: %w", domain.ErrUnsupported)
+			}
+
+			tracks, items, err := reader.GetSavedTracks(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get saved tracks: %w", err)
+			}
+			add(i, tracks, func(j int) domain.PlaylistItem {
+				return items[j]
+			})
+		}
+	}
+
+	return candidates, nil
+}
+
+// withGenres sets the genres of the candidates' artists, as known by the
+// library each was found in. Candidates from libraries that do not know
+// genres have none, but at least one library must know them.
+func withGenres(ctx context.Context, candidates []candidate, libraries []domain.Connector) error {
+	supported := false
+	for i, lib := range libraries {
+		reader, ok := lib.(domain.GenreReader)
+		if !ok {
+			continue
+		}
+		supported = true
+
+		var ids []string
+		for _, c := range candidates {
+			if c.lib != i {
+				continue
+			}
+			for _, a := range c.track.Artists {
+				if a.ID != "" && !slices.Contains(ids, a.ID) {
+					ids = append(ids, a.ID)
+				}
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		genres, err := reader.GetArtistGenres(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("failed to get artist genres: %w", err)
+		}
+
+		for j := range candidates {
+			if candidates[j].lib != i {
+				continue
+			}
+			for _, a := range candidates[j].track.Artists {
+				candidates[j].genres = append(candidates[j].genres, genres[a.ID]...)
+			}
+		}
+	}
+
+	if !supported {
+		return fmt.Errorf("failed to get artist genres: %w", domain.ErrUnsupported)
+	}
+	return nil
+}
+
+// recordingKey identifies the same recording across playlists and services.
+func recordingKey(t domain.Track) string {
+	if t.ISRC != "" {
+		return "isrc:" + strings.ToUpper(t.ISRC)
+	}
+	if key := t.RecordingKey(); key != "" {
+		return key
+	}
+	return "id:" + t.ID
+}
+
+func (r Rules) parse() (rules, error) {
+	artists, err := domain.ParseNamePatterns(r.Artists)
+	if err != nil {
+		return rules{}, err
+	}
+
+	excludeArtists, err := domain.ParseNamePatterns(r.ExcludeArtists)
+	if err != nil {
+		return rules{}, err
+	}
+
+	albums, err := domain.ParseNamePatterns(r.Albums)
+	if err != nil {
+		return rules{}, err
+	}
+
+	genres, err := domain.ParseNamePatterns(r.Genres)
+	if err != nil {
+		return rules{}, err
+	}
+
+	return rules{
+		artists:        artists,
+		excludeArtists: excludeArtists,
+		albums:         albums,
+		genres:         genres,
+		Rules:          r,
+	}, nil
+}
+
+// match checks every rule but the genres, which are looked up separately.
+func (r rules) match(c candidate, now time.Time) bool {
+	t := c.track
+
+	if len(r.artists) > 0 && !matchAny(r.artists, t.ArtistNames()...) {
+		return false
+	}
+
+	if matchAny(r.excludeArtists, t.ArtistNames()...) {
+		return false
+	}
+
+	if len(r.albums) > 0 && !matchAny(r.albums, t.Album) {
+		return false
+	}
+
+	if r.AddedWithin > 0 {
+		if c.item.AddedAt.IsZero() || c.item.AddedAt.Before(now.Add(-time.Duration(r.AddedWithin))) {
+			return false
+		}
+	}
+
+	if r.MinYear > 0 || r.MaxYear > 0 {
+		year := releaseYear(t)
+		if year == 0 || (r.MinYear > 0 && year < r.MinYear) || (r.MaxYear > 0 && year > r.MaxYear) {
+			return false
+		}
+	}
+
+	if r.MinPopularity > 0 && t.Popularity < r.MinPopularity {
+		return false
+	}
+
+	if r.Explicit != nil && *r.Explicit != t.Explicit {
+		return false
+	}
+
+	return true
+}
+
+func matchAny(patterns []domain.NamePattern, names ...string) bool {
+	for _, p := range patterns {
+		for _, name := range names {
+			if p.Match(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// releaseYear returns the year of the track's release date, or zero when
+// unknown.
+func releaseYear(t domain.Track) int {
+	year, _, _ := strings.Cut(t.ReleaseDate, "-")
+	y, err := strconv.Atoi(year)
+	if err != nil {
+		return 0
+	}
+	return y
+}
+
+func sortFunc(by string) (func(a, b candidate) int, error) {
+	switch by {
+	case "":
+		return nil, nil
+	case "popularity":
+		return func(a, b candidate) int {
+			return cmp.Compare(b.track.Popularity, a.track.Popularity)
+		}, nil
+	case "added":
+		return func(a, b candidate) int {
+			return a.item.AddedAt.Compare(b.item.AddedAt)
+		}, nil
+	case "released":
+		return func(a, b candidate) int {
+			return cmp.Compare(a.track.ReleaseDate, b.track.ReleaseDate)
+		}, nil
+	case "title":
+		return func(a, b candidate) int {
+			return cmp.Compare(domain.NormalizeText(a.track.Title), domain.NormalizeText(b.track.Title))
+		}, nil
+	case "artist":
+		return func(a, b candidate) int {
+			return cmp.Or(
+				cmp.Compare(domain.NormalizeText(a.track.Artist), domain.NormalizeText(b.track.Artist)),
+				cmp.Compare(domain.NormalizeText(a.track.Title), domain.NormalizeText(b.track.Title)),
+			)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown sort key %q: expected %s", by, strings.Join(SortKeys(), ", "))
+	}
+}
@@ -0,0 +1,214 @@
+package smart_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/backup"
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/smart"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var now = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func daysAgo(d int) domain.PlaylistItem {
+	return domain.PlaylistItem{AddedAt: now.AddDate(0, 0, -d)}
+}
+
+func track(id, isrc, title, artist, released string, popularity int) domain.Track {
+	return domain.Track{
+		ID: id, ISRC: isrc, Title: title, Artist: artist,
+		Artists:     []domain.Artist{{ID: artist, Name: artist}},
+		ReleaseDate: released, Popularity: popularity,
+	}
+}
+
+var (
+	song   = track("t1", "USRC17607839", "Song", "Band", "1999-03-22", 40)
+	hit    = track("t2", "GBUM71029604", "Hit", "Band", "2021", 90)
+	ballad = track("t3", "", "Ballad", "Other Band", "2023-01", 70)
+	remix  = track("t4", "", "Remix", "Band", "", 60)
+)
+
+func libraries() []domain.Connector {
+	spotify := backup.NewConnector(backup.New("spotify", []*domain.Playlist{
+		{
+			ID: "pl1", Name: "Road Trip",
+			Tracks: []domain.Track{song, hit},
+			Items:  []domain.PlaylistItem{daysAgo(90), daysAgo(40)},
+		},
+		{
+			ID: "pl2", Name: "Fresh Band",
+			Tracks: []domain.Track{song, ballad},
+			Items:  []domain.PlaylistItem{daysAgo(60), daysAgo(10)},
+		},
+	}))
+
+	tidal := backup.NewConnector(backup.New("tidal", []*domain.Playlist{
+		{
+			ID: "pl3", Name: "Favorites",
+			Tracks: []domain.Track{{ID: "99", ISRC: "GBUM71029604", Title: "Hit", Artist: "Band"}, remix},
+			Items:  []domain.PlaylistItem{daysAgo(5), daysAgo(2)},
+		},
+	}))
+
+	return []domain.Connector{spotify, tidal}
+}
+
+// savedLibrary adds saved tracks and artist genres to a library.
+type savedLibrary struct {
+	domain.Connector
+	tracks []domain.Track
+	items  []domain.PlaylistItem
+	genres map[string][]string
+}
+
+func (l *savedLibrary) GetSavedTracks(ctx context.Context) ([]domain.Track, []domain.PlaylistItem, error) {
+	return l.tracks, l.items, nil
+}
+
+func (l *savedLibrary) GetArtistGenres(ctx context.Context, ids []string) (map[string][]string, error) {
+	genres := make(map[string][]string)
+	for _, id := range ids {
+		genres[id] = l.genres[id]
+	}
+	return genres, nil
+}
+
+func TestEvaluate(t *testing.T) {
+	ctx := context.Background()
+
+	titles := func(pl *domain.Playlist) []string {
+		var res []string
+		for _, t := range pl.Tracks {
+			res = append(res, t.Title)
+		}
+		return res
+	}
+
+	t.Run("whole library", func(t *testing.T) {
+		assert := assert.New(t)
+
+		pl, err := smart.Definition{Name: "All"}.Evaluate(ctx, now, libraries()...)
+		assert.NoError(err)
+		assert.Equal("All", pl.Name)
+		assert.Equal([]string{"Song", "Hit", "Ballad", "Remix"}, titles(pl))
+		// Tracks found more than once keep the last time they were added.
+		assert.Equal(daysAgo(60), pl.Item(0))
+		assert.Equal(daysAgo(5), pl.Item(1))
+	})
+
+	t.Run("rules, sort and limit", func(t *testing.T) {
+		assert := assert.New(t)
+
+		def := smart.Definition{
+			Name: "Fresh Band",
+			Rules: smart.Rules{
+				Artists:     []string{"band"},
+				AddedWithin: smart.Period(30 * 24 * time.Hour),
+			},
+			Sort:  "popularity",
+			Limit: 2,
+		}
+
+		pl, err := def.Evaluate(ctx, now, libraries()...)
+		assert.NoError(err)
+		// The Fresh Band playlist itself is not part of the library.
+		assert.Equal([]string{"Hit", "Remix"}, titles(pl))
+	})
+
+	t.Run("saved tracks", func(t *testing.T) {
+		assert := assert.New(t)
+
+		libs := libraries()
+		libs[0] = &savedLibrary{
+			Connector: libs[0],
+			tracks:    []domain.Track{ballad, remix},
+			items:     []domain.PlaylistItem{daysAgo(3), daysAgo(1)},
+		}
+
+		def := smart.Definition{
+			Name:    "Liked",
+			Library: []string{smart.SourceSaved},
+			Rules:   smart.Rules{AddedWithin: smart.Period(2 * 24 * time.Hour)},
+		}
+		pl, err := def.Evaluate(ctx, now, libs[0])
+		assert.NoError(err)
+		assert.Equal([]string{"Remix"}, titles(pl))
+
+		_, err = def.Evaluate(ctx, now, libs...)
+		assert.ErrorIs(err, domain.ErrUnsupported)
+
+		_, err = smart.Definition{Name: "All", Library: []string{"albums"}}.Evaluate(ctx, now, libs...)
+		assert.ErrorContains(err, `unknown library source "albums"`)
+	})
+
+	t.Run("genres", func(t *testing.T) {
+		assert := assert.New(t)
+
+		def := smart.Definition{
+			Name:  "Rock",
+			Rules: smart.Rules{Genres: []string{"*rock*"}},
+		}
+		_, err := def.Evaluate(ctx, now, libraries()...)
+		assert.ErrorIs(err, domain.ErrUnsupported)
+
+		lib := &savedLibrary{
+			Connector: libraries()[0],
+			tracks:    []domain.Track{song, hit, ballad},
+			items:     []domain.PlaylistItem{daysAgo(3), daysAgo(2), daysAgo(1)},
+			genres:    map[string][]string{"Band": {"Indie Rock"}, "Other Band": {"pop"}},
+		}
+		def.Library = []string{smart.SourceSaved}
+		pl, err := def.Evaluate(ctx, now, lib)
+		assert.NoError(err)
+		assert.Equal([]string{"Song", "Hit"}, titles(pl))
+	})
+
+	t.Run("release years", func(t *testing.T) {
+		assert := assert.New(t)
+
+		def := smart.Definition{
+			Name:  "Recent",
+			Rules: smart.Rules{MinYear: 2020, MaxYear: 2022},
+		}
+
+		pl, err := def.Evaluate(ctx, now, libraries()...)
+		assert.NoError(err)
+		assert.Equal([]string{"Hit"}, titles(pl))
+	})
+
+	t.Run("unknown sort key", func(t *testing.T) {
+		_, err := smart.Definition{Name: "All", Sort: "mood"}.Evaluate(ctx, now, libraries()...)
+		assert.ErrorContains(t, err, `unknown sort key "mood"`)
+	})
+}
+
+func TestDefinition(t *testing.T) {
+	assert := assert.New(t)
+
+	var def smart.Definition
+	err := yaml.Unmarshal([]byte(`
+from: [spotify, tidal]
+to: tidal
+rules:
+  artists: ["Band"]
+  added_within: 30d
+  explicit: false
+sort: popularity
+reverse: true
+limit: 100
+`), &def)
+	require.NoError(t, err)
+	assert.Equal([]string{"spotify", "tidal"}, def.From)
+	assert.Equal(smart.Period(30*24*time.Hour), def.Rules.AddedWithin)
+	assert.NotNil(def.Rules.Explicit)
+	assert.Equal(100, def.Limit)
+
+	err = yaml.Unmarshal([]byte(`rules: {added_within: soon}`), &def)
+	assert.ErrorContains(err, `invalid period "soon"`)
+}
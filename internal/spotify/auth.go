@@ -1,12 +1,15 @@
 package spotify
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"path"
 
+	"github.com/toqueteos/webbrowser"
 	spotifyauth "github.com/zmb3/spotify/v2/auth"
 	"golang.org/x/oauth2"
 	"gopkg.in/yaml.v2"
@@ -26,6 +29,52 @@ const (
 	`
 )
 
+func newAuthenticator(clientID, clientSecret string) *spotifyauth.Authenticator {
+	return spotifyauth.New(
+		spotifyauth.WithClientID(clientID),
+		spotifyauth.WithClientSecret(clientSecret),
+		spotifyauth.WithRedirectURL(authRedirectURI),
+		spotifyauth.WithScopes(
+			spotifyauth.ScopeUserReadPrivate,
+			spotifyauth.ScopePlaylistReadPrivate,
+			spotifyauth.ScopePlaylistReadCollaborative,
+			spotifyauth.ScopePlaylistModifyPrivate,
+			spotifyauth.ScopePlaylistModifyPublic,
+			spotifyauth.ScopeImageUpload,
+			spotifyauth.ScopeUserLibraryRead,
+		),
+	)
+}
+
+// Login opens the browser to sign in to Spotify and stores the token once the
+// callback is received.
+func Login(ctx context.Context, clientID, clientSecret string) (*oauth2.Token, error) {
+	auth := newAuthenticator(clientID, clientSecret)
+
+	ch := make(chan *oauth2.Token)
+	server := NewAuthServer(auth, ch)
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("failed to start server: %v", err)
+		}
+	}()
+	defer func() {
+		if err := server.Close(); err != nil {
+			log.Fatalf("failed to close server: %v", err)
+		}
+	}()
+
+	webbrowser.Open(auth.AuthURL(authState))
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case token := <-ch:
+		return token, nil
+	}
+}
+
 func NewAuthServer(auth *spotifyauth.Authenticator, ch chan<- *oauth2.Token) *http.Server {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
@@ -121,6 +170,8 @@ func GetAuthToken() (*oauth2.Token, error) {
 	return cfg.Token, nil
 }
 
+// IsInvalidAuthToken reports whether the token can no longer be used, i.e. it
+// expired and cannot be refreshed.
 func IsInvalidAuthToken(token *oauth2.Token) bool {
-	return token == nil || !token.Valid()
+	return token == nil || (!token.Valid() && token.RefreshToken == "")
 }
@@ -0,0 +1,41 @@
+package spotify
+
+import (
+	"context"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/registry"
+)
+
+type Config struct {
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	BaseURL      string `yaml:"base_url,omitempty"`
+	TokenURL     string `yaml:"token_url,omitempty"`
+	Rootlist     string `yaml:"rootlist,omitempty"`
+}
+
+func init() {
+	registry.Register(registry.Connector[Config]{
+		Name:         "spotify",
+		Description:  "Spotify account",
+		Capabilities: Capabilities,
+		New: func(ctx context.Context, cfg Config, arg string) (domain.Connector, error) {
+			var opts []Option
+			if cfg.BaseURL != "" {
+				opts = append(opts, WithBaseURL(cfg.BaseURL))
+			}
+			if cfg.TokenURL != "" {
+				opts = append(opts, WithTokenURL(cfg.TokenURL))
+			}
+			if cfg.Rootlist != "" {
+				opts = append(opts, WithRootlist(cfg.Rootlist))
+			}
+			return NewConnector(cfg.ClientID, cfg.ClientSecret, opts...)
+		},
+		Login: func(ctx context.Context, cfg Config) error {
+			_, err := Login(ctx, cfg.ClientID, cfg.ClientSecret)
+			return err
+		},
+	})
+}
@@ -0,0 +1,55 @@
+package spotify
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+)
+
+// The Web API does not expose playlist folders, but the desktop client caches
+// the user's rootlist, the ordered list of playlists and folders shown in its
+// sidebar. Folders are delimited by start-group and end-group URIs, with the
+// folder name URL-encoded in the start one.
+var rootlistURI = regexp.MustCompile(
+	`spotify:(?:start-group:([0-9a-f]+):([0-9A-Za-z%+._*~-]*)|end-group:[0-9a-f]+|(?:user:[^:\s]+:)?playlist:([0-9A-Za-z]{22}))`,
+)
+
+// readRootlist maps the IDs of the playlists in the rootlist cache file to
+// the path of folders holding them. Playlists outside any folder are left
+// out.
+func readRootlist(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rootlist: %w", err)
+	}
+	return parseRootlist(data)
+}
+
+func parseRootlist(data []byte) (map[string][]string, error) {
+	folders := make(map[string][]string)
+
+	var stack []string
+	for _, m := range rootlistURI.FindAllSubmatch(data, -1) {
+		switch {
+		case m[1] != nil:
+			name, err := url.QueryUnescape(string(m[2]))
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode folder name %q: %w", m[2], err)
+			}
+			stack = append(stack, name)
+		case m[3] != nil:
+			if len(stack) > 0 {
+				folders[string(m[3])] = append([]string(nil), stack...)
+			}
+		default:
+			if len(stack) == 0 {
+				return nil, errors.New("failed to parse rootlist: folder closed before being opened")
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	return folders, nil
+}
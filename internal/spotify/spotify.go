@@ -1,52 +1,114 @@
 package spotify
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/pedrobarco/nomuz/internal/domain"
-	"github.com/toqueteos/webbrowser"
+	"github.com/pedrobarco/nomuz/internal/imageutil"
 	"github.com/zmb3/spotify/v2"
 	spotifyauth "github.com/zmb3/spotify/v2/auth"
 	"golang.org/x/oauth2"
 )
 
-func NewConnector(clientID, clientSecret string) (*connector, error) {
+// pageSize is the most playlists and saved tracks the API returns per page;
+// playlist items are fetched in pages of trackPageSize and artists in batches
+// of artistBatchSize.
+const (
+	baseURL         = "https://api.spotify.com/v1/"
+	pageSize        = 50
+	trackPageSize   = 100
+	artistBatchSize = 50
+)
+
+type Option func(*options)
+
+type options struct {
+	httpClient *http.Client
+	baseURL    string
+	tokenURL   string
+	rootlist   string
+}
+
+// WithHTTPClient makes the connector use an already authenticated client
+// instead of logging in.
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = c
+	}
+}
+
+// WithBaseURL points the connector at another Web API server, e.g. a fake
+// one in tests.
+func WithBaseURL(u string) Option {
+	return func(o *options) {
+		o.baseURL = u
+	}
+}
+
+// WithTokenURL sets the endpoint used to refresh the access token.
+func WithTokenURL(u string) Option {
+	return func(o *options) {
+		o.tokenURL = u
+	}
+}
+
+// WithRootlist reads playlist folders from the rootlist cache file of the
+// desktop client, as the Web API does not expose them.
+func WithRootlist(path string) Option {
+	return func(o *options) {
+		o.rootlist = path
+	}
+}
+
+func NewConnector(clientID, clientSecret string, opts ...Option) (*connector, error) {
 	ctx := context.Background()
 
-	auth := spotifyauth.New(
-		spotifyauth.WithClientID(clientID),
-		spotifyauth.WithClientSecret(clientSecret),
-		spotifyauth.WithRedirectURL(authRedirectURI),
-		spotifyauth.WithScopes(
-			spotifyauth.ScopeUserReadPrivate,
-		),
-	)
+	o := options{
+		baseURL:  baseURL,
+		tokenURL: spotifyauth.TokenURL,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
 
-	token, err := GetAuthToken()
-	if err != nil || IsInvalidAuthToken(token) {
-		ch := make(chan *oauth2.Token)
-		server := NewAuthServer(auth, ch)
+	var folders map[string][]string
+	if o.rootlist != "" {
+		var err error
+		folders, err = readRootlist(o.rootlist)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-		go func() {
-			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-				log.Fatalf("failed to start server: %v", err)
-			}
-		}()
-		defer func() {
-			if err := server.Close(); err != nil {
-				log.Fatalf("failed to close server: %v", err)
+	if o.httpClient == nil {
+		token, err := GetAuthToken()
+		if err != nil || IsInvalidAuthToken(token) {
+			token, err = Login(ctx, clientID, clientSecret)
+			if err != nil {
+				return nil, err
 			}
-		}()
+		}
 
-		webbrowser.Open(auth.AuthURL(authState))
-		token = <-ch
+		cfg := &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  spotifyauth.AuthURL,
+				TokenURL: o.tokenURL,
+			},
+		}
+		o.httpClient = cfg.Client(ctx, token)
 	}
 
-	client := spotify.New(auth.Client(ctx, token))
+	o.baseURL = strings.TrimSuffix(o.baseURL, "/") + "/"
+	client := spotify.New(o.httpClient, spotify.WithBaseURL(o.baseURL))
 
 	user, err := client.CurrentUser(ctx)
 	if err != nil {
@@ -54,17 +116,48 @@ func NewConnector(clientID, clientSecret string) (*connector, error) {
 	}
 
 	return &connector{
-		client: client,
-		user:   user,
+		client:     client,
+		httpClient: o.httpClient,
+		baseURL:    o.baseURL,
+		user:       user,
+		folders:    folders,
 	}, nil
 }
 
 type connector struct {
 	client *spotify.Client
-	user   *spotify.PrivateUser
+	// httpClient and baseURL make the requests the client has no method
+	// for.
+	httpClient *http.Client
+	baseURL    string
+	user       *spotify.PrivateUser
+	// folders maps playlist IDs to their folder; it is nil without a
+	// rootlist.
+	folders map[string][]string
 }
 
-var _ domain.Connector = (*connector)(nil)
+var (
+	_ domain.Connector         = (*connector)(nil)
+	_ domain.SavedTracksReader = (*connector)(nil)
+	_ domain.GenreReader       = (*connector)(nil)
+)
+
+var Capabilities = domain.Capabilities{
+	CreatePlaylist: true,
+	DeleteTracks:   true,
+	ISRCLookup:     true,
+	MaxBatchSize:   100,
+	Descriptions:   true,
+	Visibility:     true,
+	Collaborative:  true,
+	Images:         true,
+}
+
+func (s *connector) Capabilities() domain.Capabilities {
+	caps := Capabilities
+	caps.Folders = s.folders != nil
+	return caps
+}
 
 func (s *connector) CreatePlaylist(ctx context.Context, name string) (*domain.Playlist, error) {
 	pl, err := s.client.CreatePlaylistForUser(ctx, s.user.ID, name, "", false, false)
@@ -79,18 +172,15 @@ func (s *connector) CreatePlaylist(ctx context.Context, name string) (*domain.Pl
 }
 
 func (s *connector) GetPlaylistByName(ctx context.Context, name string) (*domain.Playlist, error) {
-	res, err := s.client.GetPlaylistsForUser(ctx, s.user.ID)
+	playlists, err := s.getPlaylists(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get playlists for user: %w", err)
+		return nil, err
 	}
 
 	var p *domain.Playlist
-	for _, pl := range res.Playlists {
+	for _, pl := range playlists {
 		if pl.Name == name {
-			p = &domain.Playlist{
-				ID:   pl.ID.String(),
-				Name: pl.Name,
-			}
+			p = s.toDomainPlaylist(pl)
 			break
 		}
 	}
@@ -99,28 +189,45 @@ func (s *connector) GetPlaylistByName(ctx context.Context, name string) (*domain
 		return nil, nil
 	}
 
-	tracks, err := s.getTracksByPlaylistID(ctx, p.ID)
+	return s.withTracks(ctx, p)
+}
+
+func (s *connector) GetPlaylist(ctx context.Context, id string) (*domain.Playlist, error) {
+	pl, err := s.client.GetPlaylist(ctx, spotify.ID(id))
+	var apiErr spotify.Error
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlist: %w", err)
+	}
+
+	p := s.toDomainPlaylist(pl.SimplePlaylist)
+	p.TrackCount = int(pl.Tracks.Total)
+	return s.withTracks(ctx, p)
+}
+
+func (s *connector) withTracks(ctx context.Context, p *domain.Playlist) (*domain.Playlist, error) {
+	tracks, items, err := s.getTracksByPlaylistID(ctx, p.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tracks for playlist %s: %w", p.Name, err)
 	}
 
 	p.Tracks = tracks
+	p.Items = items
 	return p, nil
 }
 
 func (s *connector) GetPlaylists(ctx context.Context) ([]*domain.Playlist, error) {
-	res, err := s.client.GetPlaylistsForUser(ctx, s.user.ID)
+	playlists, err := s.getPlaylists(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get playlists for user: %w", err)
+		return nil, err
 	}
 
 	var pls []*domain.Playlist
-	for _, pl := range res.Playlists {
-		p := &domain.Playlist{
-			ID:     pl.ID.String(),
-			Name:   pl.Name,
-			Tracks: make([]domain.Track, int(pl.Tracks.Total)),
-		}
+	for _, pl := range playlists {
+		p := s.toDomainPlaylist(pl)
+		p.Tracks = make([]domain.Track, p.TrackCount)
 		pls = append(pls, p)
 	}
 
@@ -128,6 +235,10 @@ func (s *connector) GetPlaylists(ctx context.Context) ([]*domain.Playlist, error
 }
 
 func (s *connector) AddTracksToPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	if len(tracks) == 0 {
+		return nil
+	}
+
 	var spotifyTracks []spotify.ID
 	for _, t := range tracks {
 		spotifyTracks = append(spotifyTracks, spotify.ID(t.ID))
@@ -142,6 +253,10 @@ func (s *connector) AddTracksToPlaylist(ctx context.Context, id string, tracks [
 }
 
 func (s *connector) DeleteTracksFromPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	if len(tracks) == 0 {
+		return nil
+	}
+
 	var spotifyTracks []spotify.ID
 	for _, t := range tracks {
 		spotifyTracks = append(spotifyTracks, spotify.ID(t.ID))
@@ -155,8 +270,146 @@ func (s *connector) DeleteTracksFromPlaylist(ctx context.Context, id string, tra
 	return nil
 }
 
+// UpdatePlaylist sets the visibility, collaboration and description, and
+// uploads the cover image, which must be a JPEG. The API leaves the
+// description as is when it is empty. Collaborative playlists cannot be
+// public, so they are made private.
+func (s *connector) UpdatePlaylist(ctx context.Context, id string, meta domain.PlaylistMetadata) error {
+	if err := s.changeDetails(ctx, id, meta); err != nil {
+		return fmt.Errorf("failed to update playlist: %w", err)
+	}
+
+	if meta.Image == "" {
+		return nil
+	}
+
+	img, err := imageutil.Fetch(ctx, meta.Image)
+	if err != nil {
+		return fmt.Errorf("failed to update playlist image: %w", err)
+	}
+	if img.ContentType != "image/jpeg" {
+		return fmt.Errorf("failed to update playlist image: %s is not a JPEG", img.ContentType)
+	}
+
+	if err := s.client.SetPlaylistImage(ctx, spotify.ID(id), bytes.NewReader(img.Data)); err != nil {
+		return fmt.Errorf("failed to update playlist image: %w", err)
+	}
+	return nil
+}
+
+// changeDetails changes the playlist details with a request of its own, as
+// the client cannot change whether a playlist is collaborative.
+func (s *connector) changeDetails(ctx context.Context, id string, meta domain.PlaylistMetadata) error {
+	body, err := json.Marshal(struct {
+		Public        bool   `json:"public"`
+		Collaborative bool   `json:"collaborative"`
+		Description   string `json:"description,omitempty"`
+	}{
+		Public:        meta.Public && !meta.Collaborative,
+		Collaborative: meta.Collaborative,
+		Description:   meta.Description,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.baseURL+"playlists/"+id, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	var e struct {
+		Error spotify.Error `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&e); err != nil || e.Error.Message == "" {
+		return fmt.Errorf("status code %d", resp.StatusCode)
+	}
+	return e.Error
+}
+
+// GetSavedTracks returns the user's liked songs.
+func (s *connector) GetSavedTracks(ctx context.Context) ([]domain.Track, []domain.PlaylistItem, error) {
+	page, err := s.client.CurrentUsersTracks(ctx,
+		spotify.Limit(pageSize),
+		spotify.Market(spotify.MarketFromToken),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get saved tracks: %w", err)
+	}
+
+	var tracks []domain.Track
+	var items []domain.PlaylistItem
+	for {
+		for _, t := range page.Tracks {
+			var it domain.PlaylistItem
+			if addedAt, err := time.Parse(time.RFC3339, t.AddedAt); err == nil {
+				it.AddedAt = addedAt
+			}
+			tracks = append(tracks, s.toDomainTrack(t.FullTrack))
+			items = append(items, it)
+		}
+
+		err := s.client.NextPage(ctx, page)
+		if errors.Is(err, spotify.ErrNoMorePages) {
+			return tracks, items, nil
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get saved tracks: %w", err)
+		}
+	}
+}
+
+func (s *connector) GetArtistGenres(ctx context.Context, ids []string) (map[string][]string, error) {
+	genres := make(map[string][]string)
+	for start := 0; start < len(ids); start += artistBatchSize {
+		var batch []spotify.ID
+		for _, id := range ids[start:min(start+artistBatchSize, len(ids))] {
+			batch = append(batch, spotify.ID(id))
+		}
+
+		artists, err := s.client.GetArtists(ctx, batch...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get artists: %w", err)
+		}
+
+		for _, a := range artists {
+			// Unknown IDs come back as null.
+			if a != nil {
+				genres[a.ID.String()] = a.Genres
+			}
+		}
+	}
+	return genres, nil
+}
+
+// SearchTrack looks the ISRC up and falls back to a search by title, artist
+// and album.
 func (s *connector) SearchTrack(ctx context.Context, filters domain.TrackFilters) ([]domain.Track, error) {
-	res, err := s.client.Search(ctx, filters.ID, spotify.SearchTypeTrack)
+	if filters.ISRC != "" {
+		tracks, err := s.search(ctx, "isrc:"+filters.ISRC)
+		if err != nil {
+			return nil, err
+		}
+		if len(tracks) > 0 || filters.Title == "" {
+			return tracks, nil
+		}
+	}
+
+	return s.search(ctx, searchQuery(filters))
+}
+
+func (s *connector) search(ctx context.Context, query string) ([]domain.Track, error) {
+	res, err := s.client.Search(ctx, query, spotify.SearchTypeTrack)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search track: %w", err)
 	}
@@ -173,28 +426,161 @@ func (s *connector) SearchTrack(ctx context.Context, filters domain.TrackFilters
 	return tracks, nil
 }
 
-func (s *connector) getTracksByPlaylistID(ctx context.Context, playlistID string) ([]domain.Track, error) {
-	res, err := s.client.GetPlaylistItems(ctx, spotify.ID(playlistID))
+func searchQuery(filters domain.TrackFilters) string {
+	if filters.Title == "" {
+		return filters.ID
+	}
+
+	q := fmt.Sprintf("track:%q", filters.Title)
+	if filters.Artist != "" {
+		q += fmt.Sprintf(" artist:%q", filters.Artist)
+	}
+	if filters.Album != "" {
+		q += fmt.Sprintf(" album:%q", filters.Album)
+	}
+	return q
+}
+
+// getPlaylists fetches every page of the user's playlists.
+func (s *connector) getPlaylists(ctx context.Context) ([]spotify.SimplePlaylist, error) {
+	page, err := s.client.GetPlaylistsForUser(ctx, s.user.ID, spotify.Limit(pageSize))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get playlist items: %w", err)
+		return nil, fmt.Errorf("failed to get playlists for user: %w", err)
+	}
+
+	var playlists []spotify.SimplePlaylist
+	for {
+		playlists = append(playlists, page.Playlists...)
+
+		err := s.client.NextPage(ctx, page)
+		if errors.Is(err, spotify.ErrNoMorePages) {
+			return playlists, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get playlists for user: %w", err)
+		}
+	}
+}
+
+func (s *connector) getTracksByPlaylistID(ctx context.Context, playlistID string) ([]domain.Track, []domain.PlaylistItem, error) {
+	// The market makes the API report tracks unavailable to the user.
+	page, err := s.client.GetPlaylistItems(ctx, spotify.ID(playlistID),
+		spotify.Limit(trackPageSize),
+		spotify.Market(spotify.MarketFromToken),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get playlist items: %w", err)
 	}
 
 	var tracks []domain.Track
-	for _, item := range res.Items {
-		if item.Track.Track == nil {
-			continue
+	var items []domain.PlaylistItem
+	for {
+		for _, item := range page.Items {
+			var tr domain.Track
+			switch {
+			case item.Track.Track != nil:
+				tr = s.toDomainTrack(*item.Track.Track)
+				// Local files have no ID, only a URI made of their tags.
+				if item.IsLocal {
+					tr.ID = string(item.Track.Track.URI)
+					tr.Kind = domain.TrackKindLocal
+				}
+			case item.Track.Episode != nil:
+				tr = toDomainEpisode(*item.Track.Episode)
+			default:
+				continue
+			}
+			it := domain.PlaylistItem{AddedBy: item.AddedBy.DisplayName}
+			if it.AddedBy == "" {
+				it.AddedBy = item.AddedBy.ID
+			}
+			if addedAt, err := time.Parse(time.RFC3339, item.AddedAt); err == nil {
+				it.AddedAt = addedAt
+			}
+			tracks = append(tracks, tr)
+			items = append(items, it)
+		}
+
+		err := s.client.NextPage(ctx, page)
+		if errors.Is(err, spotify.ErrNoMorePages) {
+			return tracks, items, nil
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get playlist items: %w", err)
 		}
-		tracks = append(tracks, s.toDomainTrack(*item.Track.Track))
 	}
+}
 
-	return tracks, nil
+func (s *connector) toDomainPlaylist(pl spotify.SimplePlaylist) *domain.Playlist {
+	owner := pl.Owner.DisplayName
+	if owner == "" {
+		owner = pl.Owner.ID
+	}
+
+	p := &domain.Playlist{
+		ID:            pl.ID.String(),
+		Name:          pl.Name,
+		Description:   pl.Description,
+		Owner:         owner,
+		Owned:         pl.Owner.ID == s.user.ID,
+		Public:        pl.IsPublic,
+		Collaborative: pl.Collaborative,
+		URL:           pl.ExternalURLs["spotify"],
+		TrackCount:    int(pl.Tracks.Total),
+	}
+	// The largest image comes first.
+	if len(pl.Images) > 0 {
+		p.Image = pl.Images[0].URL
+	}
+	p.Folder = s.folders[p.ID]
+	return p
 }
 
 func (s *connector) toDomainTrack(t spotify.FullTrack) domain.Track {
+	tr := domain.Track{
+		ID:          t.ID.String(),
+		ISRC:        t.ExternalIDs["isrc"],
+		Title:       t.Name,
+		Artists:     toDomainArtists(t.Artists),
+		Album:       t.Album.Name,
+		TrackNumber: int(t.TrackNumber),
+		DiscNumber:  int(t.DiscNumber),
+		ReleaseDate: t.Album.ReleaseDate,
+		Explicit:    t.Explicit,
+		Popularity:  int(t.Popularity),
+		URL:         t.ExternalURLs["spotify"],
+		Duration:    t.TimeDuration(),
+	}
+	if len(tr.Artists) > 0 {
+		tr.Artist = tr.Artists[0].Name
+	}
+	if len(t.Album.Artists) > 0 {
+		tr.AlbumArtist = t.Album.Artists[0].Name
+	}
+	if t.IsPlayable != nil && !*t.IsPlayable {
+		tr.Unavailable = true
+	}
+	return tr
+}
+
+func toDomainEpisode(e spotify.EpisodePage) domain.Track {
 	return domain.Track{
-		ID:     t.ID.String(),
-		Title:  t.Name,
-		Artist: t.Artists[0].Name,
-		Album:  t.Album.Name,
+		ID:          e.ID.String(),
+		Kind:        domain.TrackKindEpisode,
+		Title:       e.Name,
+		Artist:      e.Show.Name,
+		Album:       e.Show.Name,
+		ReleaseDate: e.ReleaseDate,
+		Explicit:    e.Explicit,
+		URL:         e.ExternalURLs["spotify"],
+		Duration:    time.Duration(e.Duration_ms) * time.Millisecond,
+	}
+}
+
+func toDomainArtists(artists []spotify.SimpleArtist) []domain.Artist {
+	var res []domain.Artist
+	for _, a := range artists {
+		res = append(res, domain.Artist{ID: a.ID.String(), Name: a.Name})
 	}
+	return res
 }
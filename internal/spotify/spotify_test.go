@@ -0,0 +1,348 @@
+package spotify_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/cassette"
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/domain/connectortest"
+	"github.com/pedrobarco/nomuz/internal/fakeservice"
+	"github.com/pedrobarco/nomuz/internal/spotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	spotifyapi "github.com/zmb3/spotify/v2"
+	"golang.org/x/oauth2"
+)
+
+// testUser replaces the ID of the account used to record the cassettes.
+const testUser = "nomuz-test"
+
+// newConnector replays the named cassette, or records it with the token
+// stored by `nomuz login spotify` when the tests run with -record.
+func newConnector(t *testing.T, name string) domain.Connector {
+	t.Helper()
+
+	var opts []cassette.Option
+	if cassette.Recording() {
+		token, err := spotify.GetAuthToken()
+		require.NoError(t, err, "run `nomuz login spotify` before recording")
+
+		transport := &oauth2.Transport{Source: oauth2.StaticTokenSource(token)}
+		user, err := spotifyapi.New(&http.Client{Transport: transport}).CurrentUser(context.Background())
+		require.NoError(t, err)
+
+		opts = append(opts,
+			cassette.WithTransport(transport),
+			cassette.WithScrubbers(
+				cassette.Replace(user.ID, testUser),
+				// Only the account's own name is hidden; other owners, such
+				// as Spotify, are public.
+				cassette.Replace(user.DisplayName, cassette.Redacted),
+			),
+		)
+	}
+
+	rec := cassette.ForTest(t, name, opts...)
+	c, err := spotify.NewConnector("", "", spotify.WithHTTPClient(rec.Client()))
+	require.NoError(t, err)
+	return c
+}
+
+func TestGetPlaylists(t *testing.T) {
+	c := newConnector(t, "get_playlists")
+
+	pls, err := c.GetPlaylists(context.Background())
+	require.NoError(t, err)
+	require.Len(t, pls, 52)
+
+	assert.Equal(t, "Road Trip", pls[0].Name)
+	assert.Equal(t, "Songs for the road", pls[0].Description)
+	assert.True(t, pls[0].Owned)
+	assert.True(t, pls[0].Public)
+	assert.Equal(t, 12, pls[0].TrackCount)
+	assert.Len(t, pls[0].Tracks, 12)
+	assert.Equal(t, "https://open.spotify.com/playlist/3cEYpjA9oz9GiPac4AsH00", pls[0].URL)
+
+	assert.True(t, pls[1].Collaborative)
+
+	assert.Equal(t, "Today's Top Hits", pls[2].Name)
+	assert.Equal(t, "Spotify", pls[2].Owner)
+	assert.False(t, pls[2].Owned)
+
+	assert.Equal(t, "Playlist 51", pls[51].Name)
+}
+
+func TestGetPlaylistByName(t *testing.T) {
+	c := newConnector(t, "get_playlist_by_name")
+	ctx := context.Background()
+
+	pl, err := c.GetPlaylistByName(ctx, "Road Trip")
+	require.NoError(t, err)
+	require.NotNil(t, pl)
+
+	// Items whose track was removed from Spotify are dropped.
+	require.Len(t, pl.Tracks, 100)
+	assert.Equal(t, domain.Track{
+		ID:     "4uLU6hMCjMI75M1A2tKU00",
+		ISRC:   "USRC17607800",
+		Title:  "Song 0",
+		Artist: "Band",
+		Artists: []domain.Artist{
+			{ID: "0OdUWJ0sBjDrqHygGUXeCF", Name: "Band"},
+			{ID: "6sFIWsNpZYqfjUpaCgueju", Name: "Guest"},
+		},
+		Album:       "Album",
+		AlbumArtist: "Band",
+		TrackNumber: 1,
+		DiscNumber:  1,
+		ReleaseDate: "1999-03-22",
+		Popularity:  42,
+		URL:         "https://open.spotify.com/track/4uLU6hMCjMI75M1A2tKU00",
+		Duration:    201 * time.Second,
+	}, pl.Tracks[0])
+	require.Len(t, pl.Items, 100)
+	assert.Equal(t, domain.PlaylistItem{
+		AddedAt: time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC),
+		AddedBy: testUser,
+	}, pl.Items[0])
+
+	// Tracks without artists, such as some local files, are kept.
+	assert.Equal(t, "Song 1", pl.Tracks[1].Title)
+	assert.Empty(t, pl.Tracks[1].Artist)
+
+	assert.True(t, pl.Tracks[2].Unavailable)
+	assert.False(t, pl.Tracks[0].Unavailable)
+
+	assert.Equal(t, domain.TrackKindLocal, pl.Tracks[3].Kind)
+	assert.Equal(t, "spotify:local:Band:Demo:Demo+Song:180", pl.Tracks[3].ID)
+	assert.Equal(t, "Band", pl.Tracks[3].Artist)
+
+	assert.Equal(t, domain.Track{
+		ID:          "512ojhOuo1ktJprKbVcKyQ",
+		Kind:        domain.TrackKindEpisode,
+		Title:       "Episode 1",
+		Artist:      "The Show",
+		Album:       "The Show",
+		ReleaseDate: "2024-01-01",
+		URL:         "https://open.spotify.com/episode/512ojhOuo1ktJprKbVcKyQ",
+		Duration:    30 * time.Minute,
+	}, pl.Tracks[19])
+
+	assert.Equal(t, "Song 98", pl.Tracks[99].Title)
+
+	pl, err = c.GetPlaylistByName(ctx, "Missing")
+	require.NoError(t, err)
+	assert.Nil(t, pl)
+}
+
+func TestEditPlaylist(t *testing.T) {
+	c := newConnector(t, "edit_playlist")
+	ctx := context.Background()
+
+	pl, err := c.CreatePlaylist(ctx, "nomuz test")
+	require.NoError(t, err)
+	assert.NotEmpty(t, pl.ID)
+	assert.Equal(t, "nomuz test", pl.Name)
+
+	tracks := []domain.Track{{ID: "4uLU6hMCjMI75M1A2tKU01"}, {ID: "4uLU6hMCjMI75M1A2tKU02"}}
+	require.NoError(t, c.AddTracksToPlaylist(ctx, pl.ID, tracks))
+	require.NoError(t, c.DeleteTracksFromPlaylist(ctx, pl.ID, tracks[:1]))
+
+	// Empty batches do not reach the API.
+	require.NoError(t, c.AddTracksToPlaylist(ctx, pl.ID, nil))
+	require.NoError(t, c.DeleteTracksFromPlaylist(ctx, pl.ID, nil))
+}
+
+func TestSearchTrack(t *testing.T) {
+	c := newConnector(t, "search_track")
+	ctx := context.Background()
+
+	tracks, err := c.SearchTrack(ctx, domain.TrackFilters{ISRC: "USRC17607839", Title: "Song", Artist: "Band"})
+	require.NoError(t, err)
+	require.Len(t, tracks, 1)
+	assert.Equal(t, "USRC17607839", tracks[0].ISRC)
+
+	tracks, err = c.SearchTrack(ctx, domain.TrackFilters{Title: "Song", Artist: "Band"})
+	require.NoError(t, err)
+	require.Len(t, tracks, 2)
+	assert.Equal(t, "Song", tracks[0].Title)
+	assert.Equal(t, "Live at the Venue", tracks[1].Album)
+
+	tracks, err = c.SearchTrack(ctx, domain.TrackFilters{ISRC: "XX0000000000"})
+	require.NoError(t, err)
+	assert.Empty(t, tracks)
+
+	// Unknown ISRCs fall back to a search by title and artist.
+	tracks, err = c.SearchTrack(ctx, domain.TrackFilters{ISRC: "XX0000000000", Title: "Song", Artist: "Band"})
+	require.NoError(t, err)
+	assert.Len(t, tracks, 2)
+}
+
+func TestErrors(t *testing.T) {
+	c := newConnector(t, "errors")
+	ctx := context.Background()
+
+	_, err := c.CreatePlaylist(ctx, "nomuz test")
+	assert.ErrorContains(t, err, "Insufficient client scope")
+
+	err = c.AddTracksToPlaylist(ctx, "0000000000000000000000", []domain.Track{{ID: "4uLU6hMCjMI75M1A2tKU01"}})
+	assert.ErrorContains(t, err, "Resource not found")
+
+	_, err = c.SearchTrack(ctx, domain.TrackFilters{ISRC: "USRC17607839"})
+	assert.ErrorContains(t, err, "API rate limit exceeded")
+}
+
+// newFakeConnector connects to a fake Web API server.
+func newFakeConnector(t *testing.T, srv *fakeservice.Spotify, opts ...spotify.Option) domain.Connector {
+	t.Helper()
+
+	// The connector refreshes the token stored by `nomuz login`.
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	require.NoError(t, spotify.SaveAuthToken(srv.Token()))
+
+	opts = append(opts, spotify.WithBaseURL(srv.BaseURL()), spotify.WithTokenURL(srv.TokenURL()))
+	c, err := spotify.NewConnector(srv.ClientID, srv.ClientSecret, opts...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestUpdatePlaylist(t *testing.T) {
+	ctx := context.Background()
+	srv := fakeservice.NewSpotify(t, fakeservice.Library{
+		Playlists: []fakeservice.Playlist{{ID: "pl1", Name: "Road Trip", Description: "Old"}},
+	})
+	c := newFakeConnector(t, srv)
+
+	images := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/cover.png" {
+			w.Header().Set("Content-Type", "image/png")
+		} else {
+			w.Header().Set("Content-Type", "image/jpeg")
+		}
+		w.Write([]byte("cover"))
+	}))
+	defer images.Close()
+
+	err := c.UpdatePlaylist(ctx, "pl1", domain.PlaylistMetadata{
+		Description: "Songs for the road",
+		Public:      true,
+		Image:       images.URL + "/cover.jpg",
+	})
+	require.NoError(t, err)
+
+	pl, err := c.GetPlaylistByName(ctx, "Road Trip")
+	require.NoError(t, err)
+	assert.Equal(t, "Songs for the road", pl.Description)
+	assert.True(t, pl.Public)
+	assert.Equal(t, srv.Server.URL+"/images/pl1", pl.Image)
+
+	// Collaborative playlists are always private.
+	err = c.UpdatePlaylist(ctx, "pl1", domain.PlaylistMetadata{Public: true, Collaborative: true})
+	require.NoError(t, err)
+	pl, err = c.GetPlaylistByName(ctx, "Road Trip")
+	require.NoError(t, err)
+	assert.True(t, pl.Collaborative)
+	assert.False(t, pl.Public)
+	assert.Equal(t, "Songs for the road", pl.Description)
+
+	err = c.UpdatePlaylist(ctx, "pl1", domain.PlaylistMetadata{Image: images.URL + "/cover.png"})
+	assert.ErrorContains(t, err, "image/png is not a JPEG")
+
+	err = c.UpdatePlaylist(ctx, "missing", domain.PlaylistMetadata{})
+	assert.ErrorContains(t, err, "Resource not found")
+}
+
+func TestFolders(t *testing.T) {
+	ctx := context.Background()
+	srv := fakeservice.NewSpotify(t, fakeservice.Library{
+		Playlists: []fakeservice.Playlist{
+			{ID: "37i9dQZF1DXcBWIGoYBM5M", Name: "Road Trip"},
+			{ID: "37i9dQZF1DX4WYpdgoIcn6", Name: "Chill Hits"},
+			{ID: "37i9dQZF1DWXRqgorJj26U", Name: "Rock Classics"},
+		},
+	})
+
+	assert.False(t, newFakeConnector(t, srv).Capabilities().Folders)
+
+	// The cache is binary; only the URIs matter.
+	rootlist := filepath.Join(t.TempDir(), "rootlist")
+	require.NoError(t, os.WriteFile(rootlist, []byte(
+		"\x0a\x26spotify:playlist:37i9dQZF1DWXRqgorJj26U"+
+			"\x0a\x2bspotify:start-group:1a2b:Moods+%26+Vibes"+
+			"\x0a\x20spotify:start-group:3c4d:Chill"+
+			"\x0a\x26spotify:playlist:37i9dQZF1DX4WYpdgoIcn6"+
+			"\x0a\x18spotify:end-group:3c4d"+
+			"\x0a\x33spotify:user:alice:playlist:37i9dQZF1DXcBWIGoYBM5M"+
+			"\x0a\x18spotify:end-group:1a2b",
+	), 0o600))
+
+	c := newFakeConnector(t, srv, spotify.WithRootlist(rootlist))
+	assert.True(t, c.Capabilities().Folders)
+
+	pls, err := c.GetPlaylists(ctx)
+	require.NoError(t, err)
+	folders := make(map[string]string)
+	for _, pl := range pls {
+		folders[pl.Name] = pl.FolderPath()
+	}
+	assert.Equal(t, map[string]string{
+		"Road Trip":     "Moods & Vibes",
+		"Chill Hits":    "Moods & Vibes / Chill",
+		"Rock Classics": "",
+	}, folders)
+
+	require.NoError(t, os.WriteFile(rootlist, []byte("spotify:end-group:1a2b"), 0o600))
+	_, err = spotify.NewConnector(srv.ClientID, srv.ClientSecret, spotify.WithBaseURL(srv.BaseURL()), spotify.WithRootlist(rootlist))
+	assert.ErrorContains(t, err, "failed to parse rootlist")
+}
+
+func TestLibrary(t *testing.T) {
+	ctx := context.Background()
+	srv := fakeservice.NewSpotify(t, fakeservice.Library{
+		Tracks: []fakeservice.Track{
+			{ID: "1", Title: "Song", Artist: "Band", Album: "Album"},
+			{ID: "2", Title: "Other Song", Artist: "Other Band", Album: "Other Album"},
+		},
+		Saved:  []string{"2", "1"},
+		Genres: map[string][]string{"Band": {"indie rock", "shoegaze"}},
+	})
+	c := newFakeConnector(t, srv)
+
+	tracks, items, err := c.(domain.SavedTracksReader).GetSavedTracks(ctx)
+	require.NoError(t, err)
+	require.Len(t, tracks, 2)
+	assert.Equal(t, "Other Song", tracks[0].Title)
+	assert.Equal(t, "Song", tracks[1].Title)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC), items[0].AddedAt)
+
+	genres, err := c.(domain.GenreReader).GetArtistGenres(ctx, []string{"band", "other-band", "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"band":       {"indie rock", "shoegaze"},
+		"other-band": {},
+	}, genres)
+}
+
+func TestConformance(t *testing.T) {
+	connectortest.Run(t, connectortest.Harness{
+		New: func(t *testing.T) domain.Connector {
+			srv := fakeservice.NewSpotify(t, fakeservice.Library{
+				Tracks: []fakeservice.Track{
+					{ID: "1", ISRC: "USRC17607839", Title: "Song", Artist: "Band", Album: "Album", Duration: 201 * time.Second},
+					{ID: "2", Title: "Other Song", Artist: "Other Band", Album: "Other Album", Duration: 242 * time.Second},
+				},
+			})
+			return newFakeConnector(t, srv)
+		},
+		Tracks: []domain.Track{
+			{ID: "1", ISRC: "USRC17607839", Title: "Song", Artist: "Band", Album: "Album"},
+			{ID: "2", Title: "Other Song", Artist: "Other Band", Album: "Other Album"},
+		},
+	})
+}
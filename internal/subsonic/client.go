@@ -0,0 +1,148 @@
+package subsonic
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	apiVersion = "1.16.1"
+	clientName = "nomuz"
+)
+
+type client struct {
+	baseURL  string
+	username string
+	password string
+	http     *http.Client
+}
+
+type response struct {
+	Status        string         `json:"status"`
+	Error         *apiError      `json:"error"`
+	Playlists     *playlists     `json:"playlists"`
+	Playlist      *playlist      `json:"playlist"`
+	SearchResult3 *searchResult3 `json:"searchResult3"`
+}
+
+// errNotFound is the error code for requested data that does not exist.
+const errNotFound = 70
+
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("subsonic error %d: %s", e.Code, e.Message)
+}
+
+type playlists struct {
+	Playlist []playlist `json:"playlist"`
+}
+
+type playlist struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Comment   string `json:"comment"`
+	Owner     string `json:"owner"`
+	Public    bool   `json:"public"`
+	SongCount int    `json:"songCount"`
+	Entry     []song `json:"entry"`
+}
+
+type song struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Artist   string `json:"artist"`
+	ArtistID string `json:"artistId"`
+	Album    string `json:"album"`
+	Track    int    `json:"track"`
+	Disc     int    `json:"discNumber"`
+	Year     int    `json:"year"`
+	// Duration is in seconds.
+	Duration int `json:"duration"`
+	// The fields below are OpenSubsonic extensions.
+	MusicBrainzID      string   `json:"musicBrainzId"`
+	ISRC               []string `json:"isrc"`
+	Artists            []artist `json:"artists"`
+	DisplayAlbumArtist string   `json:"displayAlbumArtist"`
+	// ExplicitStatus is "explicit", "clean" or empty.
+	ExplicitStatus string `json:"explicitStatus"`
+}
+
+type artist struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type searchResult3 struct {
+	Song []song `json:"song"`
+}
+
+// get calls a Subsonic endpoint, authenticating with a salted token so the
+// password never travels over the wire.
+func (c *client) get(ctx context.Context, endpoint string, params url.Values) (*response, error) {
+	salt, err := newSalt()
+	if err != nil {
+		return nil, err
+	}
+
+	if params == nil {
+		params = url.Values{}
+	}
+	sum := md5.Sum([]byte(c.password + salt))
+	params.Set("u", c.username)
+	params.Set("t", hex.EncodeToString(sum[:]))
+	params.Set("s", salt)
+	params.Set("v", apiVersion)
+	params.Set("c", clientName)
+	params.Set("f", "json")
+
+	u := strings.TrimSuffix(c.baseURL, "/") + "/rest/" + endpoint + ".view?" + params.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to call %s: status code %d", endpoint, resp.StatusCode)
+	}
+
+	var body struct {
+		Response response `json:"subsonic-response"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode %s response: %w", endpoint, err)
+	}
+
+	if body.Response.Status != "ok" {
+		if body.Response.Error != nil {
+			return nil, body.Response.Error
+		}
+		return nil, fmt.Errorf("failed to call %s: status %q", endpoint, body.Response.Status)
+	}
+
+	return &body.Response, nil
+}
+
+func newSalt() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
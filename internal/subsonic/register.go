@@ -0,0 +1,25 @@
+package subsonic
+
+import (
+	"context"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/registry"
+)
+
+type Config struct {
+	URL      string `yaml:"url"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+func init() {
+	registry.Register(registry.Connector[Config]{
+		Name:         "subsonic",
+		Description:  "Subsonic/OpenSubsonic server such as Navidrome",
+		Capabilities: Capabilities,
+		New: func(ctx context.Context, cfg Config, arg string) (domain.Connector, error) {
+			return NewConnector(cfg.URL, cfg.Username, cfg.Password)
+		},
+	})
+}
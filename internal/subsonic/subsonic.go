@@ -0,0 +1,265 @@
+package subsonic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+)
+
+const searchLimit = 20
+
+// NewConnector connects to a Subsonic compatible server such as Navidrome.
+// MusicBrainz IDs and ISRCs are used when the server supports the OpenSubsonic
+// extensions.
+func NewConnector(baseURL, username, password string) (*connector, error) {
+	if baseURL == "" || username == "" {
+		return nil, fmt.Errorf("subsonic url and username are required")
+	}
+
+	c := &connector{
+		client: &client{
+			baseURL:  baseURL,
+			username: username,
+			password: password,
+			http:     http.DefaultClient,
+		},
+	}
+
+	if _, err := c.client.get(context.Background(), "ping", nil); err != nil {
+		return nil, fmt.Errorf("failed to connect to subsonic server: %w", err)
+	}
+
+	return c, nil
+}
+
+type connector struct {
+	client *client
+}
+
+var _ domain.Connector = (*connector)(nil)
+
+var Capabilities = domain.Capabilities{
+	CreatePlaylist: true,
+	DeleteTracks:   true,
+	Descriptions:   true,
+	Visibility:     true,
+}
+
+func (c *connector) Capabilities() domain.Capabilities {
+	return Capabilities
+}
+
+func (c *connector) CreatePlaylist(ctx context.Context, name string) (*domain.Playlist, error) {
+	res, err := c.client.get(ctx, "createPlaylist", url.Values{"name": {name}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create playlist: %w", err)
+	}
+
+	// Servers implementing API versions before 1.14 return an empty response.
+	if res.Playlist == nil {
+		pl, err := c.GetPlaylistByName(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if pl == nil {
+			return nil, fmt.Errorf("failed to create playlist: %s not found after creation", name)
+		}
+		return pl, nil
+	}
+
+	return c.toDomainPlaylist(*res.Playlist), nil
+}
+
+func (c *connector) GetPlaylists(ctx context.Context) ([]*domain.Playlist, error) {
+	res, err := c.client.get(ctx, "getPlaylists", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlists: %w", err)
+	}
+
+	if res.Playlists == nil {
+		return nil, nil
+	}
+
+	var pls []*domain.Playlist
+	for _, p := range res.Playlists.Playlist {
+		pl := c.toDomainPlaylist(p)
+		pl.Tracks = make([]domain.Track, pl.TrackCount)
+		pls = append(pls, pl)
+	}
+	return pls, nil
+}
+
+func (c *connector) GetPlaylistByName(ctx context.Context, name string) (*domain.Playlist, error) {
+	pls, err := c.GetPlaylists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pl := range pls {
+		if pl.Name == name {
+			return c.GetPlaylist(ctx, pl.ID)
+		}
+	}
+	return nil, nil
+}
+
+func (c *connector) AddTracksToPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	params := url.Values{"playlistId": {id}}
+	for _, t := range tracks {
+		params.Add("songIdToAdd", t.ID)
+	}
+
+	if _, err := c.client.get(ctx, "updatePlaylist", params); err != nil {
+		return fmt.Errorf("failed to add tracks to playlist: %w", err)
+	}
+	return nil
+}
+
+// DeleteTracksFromPlaylist removes every entry of the given tracks. Subsonic
+// removes entries by position, so the playlist is fetched first.
+func (c *connector) DeleteTracksFromPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	pl, err := c.GetPlaylist(ctx, id)
+	if err != nil {
+		return err
+	}
+	if pl == nil {
+		return fmt.Errorf("failed to remove tracks from playlist: playlist %s not found", id)
+	}
+
+	remove := make(map[string]struct{})
+	for _, t := range tracks {
+		remove[t.ID] = struct{}{}
+	}
+
+	params := url.Values{"playlistId": {id}}
+	for i, t := range pl.Tracks {
+		if _, found := remove[t.ID]; found {
+			params.Add("songIndexToRemove", strconv.Itoa(i))
+		}
+	}
+
+	if !params.Has("songIndexToRemove") {
+		return nil
+	}
+
+	if _, err := c.client.get(ctx, "updatePlaylist", params); err != nil {
+		return fmt.Errorf("failed to remove tracks from playlist: %w", err)
+	}
+	return nil
+}
+
+// UpdatePlaylist sets the comment and visibility.
+func (c *connector) UpdatePlaylist(ctx context.Context, id string, meta domain.PlaylistMetadata) error {
+	params := url.Values{
+		"playlistId": {id},
+		"comment":    {meta.Description},
+		"public":     {strconv.FormatBool(meta.Public)},
+	}
+
+	if _, err := c.client.get(ctx, "updatePlaylist", params); err != nil {
+		return fmt.Errorf("failed to update playlist: %w", err)
+	}
+	return nil
+}
+
+// SearchTrack searches songs by title and artist and keeps the ones with the
+// same MusicBrainz ID or ISRC, falling back to the same title and artist.
+func (c *connector) SearchTrack(ctx context.Context, filters domain.TrackFilters) ([]domain.Track, error) {
+	query := strings.TrimSpace(filters.Title + " " + filters.Artist)
+	if filters.Title == "" {
+		query = filters.MBID
+	}
+	if query == "" {
+		return nil, nil
+	}
+
+	res, err := c.client.get(ctx, "search3", url.Values{
+		"query":       {query},
+		"songCount":   {strconv.Itoa(searchLimit)},
+		"artistCount": {"0"},
+		"albumCount":  {"0"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search track: %w", err)
+	}
+
+	if res.SearchResult3 == nil {
+		return nil, nil
+	}
+
+	var tracks []domain.Track
+	for _, s := range res.SearchResult3.Song {
+		tracks = append(tracks, toDomainTrack(s))
+	}
+	return domain.RankMatches(tracks, filters), nil
+}
+
+func (c *connector) GetPlaylist(ctx context.Context, id string) (*domain.Playlist, error) {
+	res, err := c.client.get(ctx, "getPlaylist", url.Values{"id": {id}})
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.Code == errNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlist %s: %w", id, err)
+	}
+
+	if res.Playlist == nil {
+		return nil, fmt.Errorf("failed to get playlist %s: empty response", id)
+	}
+
+	pl := c.toDomainPlaylist(*res.Playlist)
+	for _, s := range res.Playlist.Entry {
+		pl.Tracks = append(pl.Tracks, toDomainTrack(s))
+	}
+	return pl, nil
+}
+
+func (c *connector) toDomainPlaylist(p playlist) *domain.Playlist {
+	return &domain.Playlist{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Comment,
+		Owner:       p.Owner,
+		Owned:       p.Owner == "" || p.Owner == c.client.username,
+		Public:      p.Public,
+		TrackCount:  p.SongCount,
+	}
+}
+
+func toDomainTrack(s song) domain.Track {
+	t := domain.Track{
+		ID:          s.ID,
+		MBID:        s.MusicBrainzID,
+		Title:       s.Title,
+		Artist:      s.Artist,
+		Album:       s.Album,
+		AlbumArtist: s.DisplayAlbumArtist,
+		TrackNumber: s.Track,
+		DiscNumber:  s.Disc,
+		Explicit:    s.ExplicitStatus == "explicit",
+		Duration:    time.Duration(s.Duration) * time.Second,
+	}
+	if len(s.ISRC) > 0 {
+		t.ISRC = s.ISRC[0]
+	}
+	if s.Year > 0 {
+		t.ReleaseDate = strconv.Itoa(s.Year)
+	}
+
+	artists := s.Artists
+	if len(artists) == 0 && s.Artist != "" {
+		artists = []artist{{ID: s.ArtistID, Name: s.Artist}}
+	}
+	for _, a := range artists {
+		t.Artists = append(t.Artists, domain.Artist{ID: a.ID, Name: a.Name})
+	}
+	return t
+}
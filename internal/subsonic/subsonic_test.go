@@ -0,0 +1,180 @@
+package subsonic_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/domain/connectortest"
+	"github.com/pedrobarco/nomuz/internal/fakeservice"
+	"github.com/pedrobarco/nomuz/internal/subsonic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newConnector(t *testing.T, srv *fakeservice.Subsonic) domain.Connector {
+	c, err := subsonic.NewConnector(srv.Server.URL, "alice", srv.Password)
+	require.NoError(t, err)
+	return c
+}
+
+func TestConnector(t *testing.T) {
+	ctx := context.Background()
+
+	tracks := []fakeservice.Track{
+		{
+			ID: "s1", ISRC: "USRC17607839", MBID: "4f3d9fc1-4ad4-4d1f-9c1a-5f5bbd6c2c36", Title: "Song", Artist: "Band",
+			Featuring: []string{"Guest"}, Album: "Album", ReleaseDate: "1999", TrackNumber: 1, Duration: 201 * time.Second, Explicit: true,
+		},
+		{ID: "s2", Title: "Song", Artist: "Band", Album: "Live", Duration: 240 * time.Second},
+		{ID: "s3", Title: "Song", Artist: "Band", Album: "Album", Duration: 201 * time.Second},
+		{ID: "s4", Title: "Other Song", Artist: "Other Band", Album: "Other Album", Duration: 180 * time.Second},
+	}
+
+	t.Run("wrong password", func(t *testing.T) {
+		srv := fakeservice.NewSubsonic(t, fakeservice.Library{})
+		_, err := subsonic.NewConnector(srv.Server.URL, "alice", "wrong")
+		assert.ErrorContains(t, err, "Wrong username or password")
+	})
+
+	t.Run("playlists", func(t *testing.T) {
+		assert := assert.New(t)
+
+		srv := fakeservice.NewSubsonic(t, fakeservice.Library{
+			Tracks: tracks,
+			Playlists: []fakeservice.Playlist{
+				{ID: "10", Name: "Mix", Tracks: []string{"s1", "s4", "s1"}},
+				{ID: "11", Name: "Shared", Owner: "bob"},
+			},
+		})
+		c := newConnector(t, srv)
+
+		pls, err := c.GetPlaylists(ctx)
+		assert.NoError(err)
+		assert.Len(pls, 2)
+		assert.Equal(3, pls[0].Size())
+		assert.True(pls[0].Owned)
+		assert.False(pls[1].Owned)
+
+		pl, err := c.GetPlaylistByName(ctx, "Mix")
+		assert.NoError(err)
+		assert.Len(pl.Tracks, 3)
+		assert.Equal(domain.Track{
+			ID:          "s1",
+			ISRC:        "USRC17607839",
+			MBID:        "4f3d9fc1-4ad4-4d1f-9c1a-5f5bbd6c2c36",
+			Title:       "Song",
+			Artist:      "Band",
+			Artists:     []domain.Artist{{ID: "band", Name: "Band"}, {ID: "guest", Name: "Guest"}},
+			Album:       "Album",
+			AlbumArtist: "Band",
+			TrackNumber: 1,
+			ReleaseDate: "1999",
+			Explicit:    true,
+			Duration:    201 * time.Second,
+		}, pl.Tracks[0])
+		assert.Equal([]domain.Artist{{ID: "other-band", Name: "Other Band"}}, pl.Tracks[1].Artists)
+
+		pl, err = c.GetPlaylistByName(ctx, "Missing")
+		assert.NoError(err)
+		assert.Nil(pl)
+
+		assert.NoError(c.DeleteTracksFromPlaylist(ctx, "10", []domain.Track{{ID: "s1"}}))
+		mix, _ := srv.Playlist("Mix")
+		assert.Equal([]string{"s4"}, mix.Tracks)
+	})
+
+	t.Run("create and add", func(t *testing.T) {
+		assert := assert.New(t)
+
+		srv := fakeservice.NewSubsonic(t, fakeservice.Library{Tracks: tracks})
+		c := newConnector(t, srv)
+
+		pl, err := c.CreatePlaylist(ctx, "Road Trip")
+		assert.NoError(err)
+		assert.Equal("Road Trip", pl.Name)
+
+		assert.NoError(c.AddTracksToPlaylist(ctx, pl.ID, []domain.Track{{ID: "s4"}, {ID: "s1"}}))
+		got, _ := srv.Playlist("Road Trip")
+		assert.Equal([]string{"s4", "s1"}, got.Tracks)
+
+		assert.NoError(c.UpdatePlaylist(ctx, pl.ID, domain.PlaylistMetadata{Description: "Songs for the road", Public: true}))
+		got, _ = srv.Playlist("Road Trip")
+		assert.Equal("Songs for the road", got.Description)
+		assert.True(got.Public)
+		assert.Equal([]string{"s4", "s1"}, got.Tracks)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		assert := assert.New(t)
+
+		srv := fakeservice.NewSubsonic(t, fakeservice.Library{Tracks: tracks})
+		c := newConnector(t, srv)
+
+		res, err := c.SearchTrack(ctx, domain.TrackFilters{ID: "spotify-1", MBID: "4f3d9fc1-4ad4-4d1f-9c1a-5f5bbd6c2c36", Title: "Song", Artist: "Band"})
+		assert.NoError(err)
+		assert.Equal("s1", res[0].ID)
+
+		res, err = c.SearchTrack(ctx, domain.TrackFilters{Title: "song", Artist: "band", Album: "Album"})
+		assert.NoError(err)
+		assert.Equal([]string{"s1", "s3", "s2"}, ids(res))
+
+		res, err = c.SearchTrack(ctx, domain.TrackFilters{MBID: "4f3d9fc1-4ad4-4d1f-9c1a-5f5bbd6c2c36"})
+		assert.NoError(err)
+		assert.Equal([]string{"s1"}, ids(res))
+
+		res, err = c.SearchTrack(ctx, domain.TrackFilters{Title: "Song", Artist: "Nobody"})
+		assert.NoError(err)
+		assert.Empty(res)
+
+		assert.Equal("song band", srv.Queries()[0])
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		assert := assert.New(t)
+
+		from := newConnector(t, fakeservice.NewSubsonic(t, fakeservice.Library{
+			Tracks:    tracks,
+			Playlists: []fakeservice.Playlist{{ID: "1", Name: "Mix", Tracks: []string{"s1", "s4"}}},
+		}))
+		dst := fakeservice.NewSubsonic(t, fakeservice.Library{
+			Tracks: []fakeservice.Track{{ID: "d1", MBID: "4f3d9fc1-4ad4-4d1f-9c1a-5f5bbd6c2c36", Title: "Song", Artist: "Band"}},
+		})
+		to := newConnector(t, dst)
+
+		cl, err := domain.PlanSync(ctx, from, to)
+		assert.NoError(err)
+		_, err = domain.Sync(ctx, from, to, *cl)
+		assert.NoError(err)
+
+		pls := dst.Playlists()
+		assert.Len(pls, 1)
+		assert.Equal([]string{"d1"}, pls[0].Tracks)
+	})
+}
+
+func ids(tracks []domain.Track) []string {
+	var res []string
+	for _, t := range tracks {
+		res = append(res, t.ID)
+	}
+	return res
+}
+
+func TestConformance(t *testing.T) {
+	connectortest.Run(t, connectortest.Harness{
+		New: func(t *testing.T) domain.Connector {
+			return newConnector(t, fakeservice.NewSubsonic(t, fakeservice.Library{
+				Tracks: []fakeservice.Track{
+					{ID: "s1", Title: "Song", Artist: "Band", Album: "Album", Duration: 201 * time.Second},
+					{ID: "s2", Title: "Other Song", Artist: "Other Band", Album: "Other Album", Duration: 180 * time.Second},
+				},
+			}))
+		},
+		Tracks: []domain.Track{
+			{ID: "s1", Title: "Song", Artist: "Band", Album: "Album"},
+			{ID: "s2", Title: "Other Song", Artist: "Other Band", Album: "Other Album"},
+		},
+	})
+}
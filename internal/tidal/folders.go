@@ -0,0 +1,273 @@
+package tidal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/pkg/tidal"
+)
+
+// Playlist folders are not part of the generated client, as the
+// userCollectionFolders endpoints are newer than the API spec it is generated
+// from, so they are read and written with plain JSON:API requests. Where the
+// API answers them with 404, playlists are read without folders. TIDAL
+// folders cannot be nested: a playlist in a nested folder goes in a folder
+// named after the whole path, which is split back into the path when read.
+
+const folderType = "userCollectionFolders"
+
+type folder struct {
+	ID   string
+	Name string
+	// Playlists are the IDs of the playlists in the folder.
+	Playlists []string
+}
+
+type folderResource struct {
+	ID         string `json:"id,omitempty"`
+	Type       string `json:"type"`
+	Attributes struct {
+		Name           string `json:"name"`
+		CollectionType string `json:"collectionType"`
+	} `json:"attributes"`
+}
+
+type resourceIdentifier struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+type relationship struct {
+	Data []resourceIdentifier `json:"data"`
+}
+
+// getFolders returns the playlist folders of the user with the playlists in
+// each. They are read once, with a request per folder, and then kept up to
+// date by moveToFolder.
+func (c *connector) getFolders(ctx context.Context) ([]folder, error) {
+	if c.folders != nil {
+		return *c.folders, nil
+	}
+
+	folders, err := c.readFolders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.folders = &folders
+	return folders, nil
+}
+
+func (c *connector) readFolders(ctx context.Context) ([]folder, error) {
+	var folders []folder
+	query := url.Values{}
+	for {
+		var doc struct {
+			Data  []folderResource `json:"data"`
+			Links tidal.Links      `json:"links"`
+		}
+		err := c.do(ctx, http.MethodGet, "/"+folderType, query, nil, &doc)
+		var statusErr *statusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			return []folder{}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get folders: %w", err)
+		}
+
+		for _, f := range doc.Data {
+			if f.Attributes.CollectionType != "" && f.Attributes.CollectionType != "PLAYLISTS" {
+				continue
+			}
+			folders = append(folders, folder{ID: f.ID, Name: f.Attributes.Name})
+		}
+
+		cursor := nextCursor(doc.Links)
+		if cursor == "" {
+			break
+		}
+		query.Set("page[cursor]", cursor)
+	}
+
+	for i := range folders {
+		playlists, err := c.getFolderPlaylists(ctx, folders[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get folder %s: %w", folders[i].Name, err)
+		}
+		folders[i].Playlists = playlists
+	}
+	return folders, nil
+}
+
+func (c *connector) getFolderPlaylists(ctx context.Context, id string) ([]string, error) {
+	var ids []string
+	query := url.Values{}
+	for {
+		var doc struct {
+			Data  []resourceIdentifier `json:"data"`
+			Links tidal.Links          `json:"links"`
+		}
+		if err := c.do(ctx, http.MethodGet, folderItemsPath(id), query, nil, &doc); err != nil {
+			return nil, err
+		}
+
+		for _, it := range doc.Data {
+			if it.Type == "playlists" {
+				ids = append(ids, it.ID)
+			}
+		}
+
+		cursor := nextCursor(doc.Links)
+		if cursor == "" {
+			return ids, nil
+		}
+		query.Set("page[cursor]", cursor)
+	}
+}
+
+// playlistFolders maps the IDs of the playlists in folders to their folder
+// path.
+func playlistFolders(folders []folder) map[string][]string {
+	res := make(map[string][]string)
+	for _, f := range folders {
+		for _, id := range f.Playlists {
+			res[id] = strings.Split(f.Name, domain.FolderSeparator)
+		}
+	}
+	return res
+}
+
+// moveToFolder takes the playlist out of the folders it is in and puts it in
+// the folder for the given path, creating it if needed. An empty path leaves
+// the playlist at the top level.
+func (c *connector) moveToFolder(ctx context.Context, id string, path []string) error {
+	folders, err := c.getFolders(ctx)
+	if err != nil {
+		return err
+	}
+
+	name := domain.FolderPath(path)
+	item := relationship{Data: []resourceIdentifier{{ID: id, Type: "playlists"}}}
+
+	target := -1
+	for i, f := range folders {
+		if f.Name == name && name != "" {
+			target = i
+			continue
+		}
+		if slices.Contains(f.Playlists, id) {
+			if err := c.do(ctx, http.MethodDelete, folderItemsPath(f.ID), nil, item, nil); err != nil {
+				return fmt.Errorf("failed to remove playlist from folder %s: %w", f.Name, err)
+			}
+			folders[i].Playlists = slices.DeleteFunc(folders[i].Playlists, func(p string) bool {
+				return p == id
+			})
+		}
+	}
+
+	if name == "" || (target >= 0 && slices.Contains(folders[target].Playlists, id)) {
+		return nil
+	}
+
+	if target < 0 {
+		f, err := c.createFolder(ctx, name)
+		if err != nil {
+			return err
+		}
+		folders = append(folders, *f)
+		*c.folders = folders
+		target = len(folders) - 1
+	}
+
+	if err := c.do(ctx, http.MethodPost, folderItemsPath(folders[target].ID), nil, item, nil); err != nil {
+		return fmt.Errorf("failed to add playlist to folder %s: %w", name, err)
+	}
+	folders[target].Playlists = append(folders[target].Playlists, id)
+	return nil
+}
+
+func (c *connector) createFolder(ctx context.Context, name string) (*folder, error) {
+	var req struct {
+		Data folderResource `json:"data"`
+	}
+	req.Data.Type = folderType
+	req.Data.Attributes.Name = name
+	req.Data.Attributes.CollectionType = "PLAYLISTS"
+
+	var resp struct {
+		Data folderResource `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/"+folderType, nil, req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to create folder %s: %w", name, err)
+	}
+	return &folder{ID: resp.Data.ID, Name: name}, nil
+}
+
+func folderItemsPath(id string) string {
+	return "/" + folderType + "/" + url.PathEscape(id) + "/relationships/items"
+}
+
+// statusError is returned by do for responses outside the 2xx range.
+type statusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("status code %d: %s", e.StatusCode, e.Body)
+}
+
+// do sends a JSON:API request with the data in body, if any, and decodes the
+// response into out, if given.
+func (c *connector) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	u := strings.TrimSuffix(c.baseURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.api+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/vnd.api+json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &statusError{StatusCode: resp.StatusCode, Body: string(data)}
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
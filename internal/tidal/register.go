@@ -0,0 +1,34 @@
+package tidal
+
+import (
+	"context"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/registry"
+)
+
+type Config struct {
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	CountryCode  string `yaml:"country_code"`
+	BaseURL      string `yaml:"base_url,omitempty"`
+	TokenURL     string `yaml:"token_url,omitempty"`
+}
+
+func init() {
+	registry.Register(registry.Connector[Config]{
+		Name:         "tidal",
+		Description:  "TIDAL account",
+		Capabilities: Capabilities,
+		New: func(ctx context.Context, cfg Config, arg string) (domain.Connector, error) {
+			var opts []Option
+			if cfg.BaseURL != "" {
+				opts = append(opts, WithBaseURL(cfg.BaseURL))
+			}
+			if cfg.TokenURL != "" {
+				opts = append(opts, WithTokenURL(cfg.TokenURL))
+			}
+			return NewConnector(cfg.ClientID, cfg.ClientSecret, cfg.CountryCode, opts...)
+		},
+	})
+}
@@ -1,11 +1,20 @@
 package tidal
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/base64"
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/imageutil"
 	"github.com/pedrobarco/nomuz/pkg/tidal"
 	"golang.org/x/oauth2/clientcredentials"
 )
@@ -13,16 +22,64 @@ import (
 const serverURL = "https://openapi.tidal.com/v2"
 const tokenURL = "https://auth.tidal.com/v1/oauth2/token"
 
-func NewConnector(clientID, clientSecret, countryCode string) (*connector, error) {
-	cfg := clientcredentials.Config{
-		ClientID:     clientID,
-		ClientSecret: clientSecret,
-		TokenURL:     tokenURL,
+const (
+	searchLimit = 10
+	// filterBatchSize is the number of IDs sent in one filter[id] query.
+	filterBatchSize = 20
+)
+
+type Option func(*options)
+
+type options struct {
+	httpClient *http.Client
+	baseURL    string
+	tokenURL   string
+}
+
+// WithHTTPClient makes the connector use an already authenticated client
+// instead of requesting client credentials.
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = c
+	}
+}
+
+// WithBaseURL points the connector at another API server, e.g. a fake one in
+// tests.
+func WithBaseURL(u string) Option {
+	return func(o *options) {
+		o.baseURL = u
 	}
-	httpClient := cfg.Client(context.Background())
+}
+
+// WithTokenURL sets the endpoint the client credentials are exchanged at.
+func WithTokenURL(u string) Option {
+	return func(o *options) {
+		o.tokenURL = u
+	}
+}
+
+func NewConnector(clientID, clientSecret, countryCode string, opts ...Option) (*connector, error) {
+	o := options{
+		baseURL:  serverURL,
+		tokenURL: tokenURL,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.httpClient == nil {
+		cfg := clientcredentials.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			TokenURL:     o.tokenURL,
+		}
+		o.httpClient = cfg.Client(context.Background())
+	}
+
 	client, err := tidal.NewClientWithResponses(
-		serverURL,
-		tidal.WithHTTPClient(httpClient),
+		o.baseURL,
+		tidal.WithHTTPClient(o.httpClient),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create tidal connector: %w", err)
@@ -30,18 +87,45 @@ func NewConnector(clientID, clientSecret, countryCode string) (*connector, error
 
 	return &connector{
 		client:      client,
+		httpClient:  o.httpClient,
+		baseURL:     o.baseURL,
 		countryCode: countryCode,
 	}, nil
 }
 
 type connector struct {
-	client      tidal.ClientWithResponsesInterface
+	client tidal.ClientWithResponsesInterface
+	// httpClient and baseURL serve the requests the generated client lacks.
+	httpClient  *http.Client
+	baseURL     string
 	countryCode string
+	// folders caches the folder tree once read by getFolders.
+	folders *[]folder
 }
 
 var _ domain.Connector = (*connector)(nil)
 
+var Capabilities = domain.Capabilities{
+	CreatePlaylist: true,
+	DeleteTracks:   true,
+	ISRCLookup:     true,
+	MaxBatchSize:   20,
+	Descriptions:   true,
+	Visibility:     true,
+	Images:         true,
+	Folders:        true,
+	CreateFolders:  true,
+}
+
+func (c *connector) Capabilities() domain.Capabilities {
+	return Capabilities
+}
+
 func (c *connector) AddTracksToPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	if len(tracks) == 0 {
+		return nil
+	}
+
 	var data []tidal.PlaylistItemsRelationshipAddOperationPayloadData
 
 	for _, track := range tracks {
@@ -73,44 +157,631 @@ func (c *connector) AddTracksToPlaylist(ctx context.Context, id string, tracks [
 }
 
 func (c *connector) CreatePlaylist(ctx context.Context, name string) (*domain.Playlist, error) {
-	panic("unimplemented")
+	access := tidal.PlaylistCreateOperationPayloadDataAttributesAccessTypeUNLISTED
+	resp, err := c.client.PostPlaylistsWithApplicationVndAPIPlusJSONBodyWithResponse(
+		ctx,
+		&tidal.PostPlaylistsParams{
+			CountryCode: c.countryCode,
+		},
+		tidal.PostPlaylistsApplicationVndAPIPlusJSONRequestBody{
+			Data: tidal.PlaylistCreateOperationPayloadData{
+				Attributes: tidal.PlaylistCreateOperationPayloadDataAttributes{
+					Name:       name,
+					AccessType: &access,
+				},
+				Type: tidal.PlaylistCreateOperationPayloadDataTypePlaylists,
+			},
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create playlist: %w", err)
+	}
+
+	if resp.StatusCode() != http.StatusCreated {
+		return nil, fmt.Errorf("failed to create playlist: status code %d: %s", resp.StatusCode(), string(resp.Body))
+	}
+
+	pl := toDomainPlaylist(resp.ApplicationvndApiJSON201.Data, newIncludedResources(nil))
+	pl.Tracks = []domain.Track{}
+	return pl, nil
 }
 
 func (c *connector) DeleteTracksFromPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
-	panic("unimplemented")
+	if len(tracks) == 0 {
+		return nil
+	}
+
+	items, err := c.getPlaylistItems(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to remove tracks from playlist: %w", err)
+	}
+
+	remove := make(map[string]struct{})
+	for _, t := range tracks {
+		remove[t.ID] = struct{}{}
+	}
+
+	var data []tidal.PlaylistItemsRelationshipRemoveOperationPayloadData
+	for _, it := range items {
+		if _, found := remove[it.Id]; !found || it.Meta == nil || it.Meta.ItemId == nil {
+			continue
+		}
+
+		data = append(data, tidal.PlaylistItemsRelationshipRemoveOperationPayloadData{
+			Id:   it.Id,
+			Meta: tidal.PlaylistItemsRelationshipRemoveOperationPayloadDataMeta{ItemId: *it.Meta.ItemId},
+			Type: tidal.PlaylistItemsRelationshipRemoveOperationPayloadDataTypeTracks,
+		})
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	resp, err := c.client.DeletePlaylistsIdRelationshipsItemsWithApplicationVndAPIPlusJSONBodyWithResponse(
+		ctx,
+		id,
+		tidal.DeletePlaylistsIdRelationshipsItemsApplicationVndAPIPlusJSONRequestBody{
+			Data: data,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove tracks from playlist: %w", err)
+	}
+
+	if resp.StatusCode() != http.StatusNoContent {
+		return fmt.Errorf("failed to remove tracks from playlist: status code %d", resp.StatusCode())
+	}
+
+	return nil
 }
 
 func (c *connector) GetPlaylistByName(ctx context.Context, name string) (*domain.Playlist, error) {
-	panic("unimplemented")
+	pls, err := c.GetPlaylists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pl := range pls {
+		if pl.Name == name {
+			return c.withTracks(ctx, pl)
+		}
+	}
+
+	return nil, nil
+}
+
+func (c *connector) GetPlaylist(ctx context.Context, id string) (*domain.Playlist, error) {
+	resp, err := c.client.GetPlaylistsIdWithResponse(ctx, id, &tidal.GetPlaylistsIdParams{
+		CountryCode: c.countryCode,
+		Include:     &[]string{"coverArt"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlist: %w", err)
+	}
+
+	if resp.StatusCode() == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("failed to get playlist: status code %d: %s", resp.StatusCode(), string(resp.Body))
+	}
+
+	doc := resp.ApplicationvndApiJSON200
+	pl := toDomainPlaylist(doc.Data, newIncludedResources(doc.Included))
+
+	folders, err := c.getFolders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pl.Folder = playlistFolders(folders)[pl.ID]
+
+	return c.withTracks(ctx, pl)
+}
+
+func (c *connector) withTracks(ctx context.Context, pl *domain.Playlist) (*domain.Playlist, error) {
+	items, err := c.getPlaylistItems(ctx, pl.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracks for playlist %s: %w", pl.Name, err)
+	}
+
+	var ids []string
+	addedAt := make(map[string]time.Time)
+	for _, it := range items {
+		if it.Type != "tracks" {
+			continue
+		}
+		ids = append(ids, it.Id)
+		if it.Meta != nil && it.Meta.AddedAt != nil {
+			addedAt[it.Id] = *it.Meta.AddedAt
+		}
+	}
+
+	tracks, err := c.getTracks(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracks for playlist %s: %w", pl.Name, err)
+	}
+
+	pl.Tracks = []domain.Track{}
+	pl.Items = nil
+	for _, t := range tracks {
+		pl.Tracks = append(pl.Tracks, t)
+		pl.Items = append(pl.Items, domain.PlaylistItem{AddedAt: addedAt[t.ID]})
+	}
+	return pl, nil
 }
 
 func (c *connector) GetPlaylists(ctx context.Context) ([]*domain.Playlist, error) {
-	resp, err := c.client.GetPlaylistsWithResponse(
+	var playlists []*domain.Playlist
+	params := &tidal.GetPlaylistsParams{
+		CountryCode: c.countryCode,
+		Include:     &[]string{"coverArt"},
+	}
+	for {
+		resp, err := c.client.GetPlaylistsWithResponse(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get playlists: %w", err)
+		}
+
+		if resp.StatusCode() != http.StatusOK {
+			return nil, fmt.Errorf("failed to get playlists: status code %d: %s", resp.StatusCode(), string(resp.Body))
+		}
+
+		doc := resp.ApplicationvndApiJSON200
+		included := newIncludedResources(doc.Included)
+		for _, p := range doc.Data {
+			pl := toDomainPlaylist(p, included)
+			pl.Tracks = make([]domain.Track, pl.TrackCount)
+			playlists = append(playlists, pl)
+		}
+
+		cursor := nextCursor(doc.Links)
+		if cursor == "" {
+			break
+		}
+		params.PageCursor = &cursor
+	}
+
+	folders, err := c.getFolders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byPlaylist := playlistFolders(folders)
+	for _, pl := range playlists {
+		pl.Folder = byPlaylist[pl.ID]
+	}
+
+	return playlists, nil
+}
+
+// UpdatePlaylist sets the description and access type, and moves the playlist
+// to its folder. A cover image is uploaded as a new artwork, which then
+// becomes the playlist's cover art.
+func (c *connector) UpdatePlaylist(ctx context.Context, id string, meta domain.PlaylistMetadata) error {
+	access := tidal.PlaylistUpdateOperationPayloadDataAttributesAccessTypeUNLISTED
+	if meta.Public {
+		access = tidal.PlaylistUpdateOperationPayloadDataAttributesAccessTypePUBLIC
+	}
+
+	resp, err := c.client.PatchPlaylistsIdWithApplicationVndAPIPlusJSONBodyWithResponse(
 		ctx,
-		&tidal.GetPlaylistsParams{
+		id,
+		&tidal.PatchPlaylistsIdParams{
 			CountryCode: c.countryCode,
 		},
+		tidal.PatchPlaylistsIdApplicationVndAPIPlusJSONRequestBody{
+			Data: tidal.PlaylistUpdateOperationPayloadData{
+				Attributes: tidal.PlaylistUpdateOperationPayloadDataAttributes{
+					AccessType:  &access,
+					Description: &meta.Description,
+				},
+				Id:   id,
+				Type: tidal.PlaylistUpdateOperationPayloadDataTypePlaylists,
+			},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update playlist: %w", err)
+	}
+
+	if resp.StatusCode() != http.StatusNoContent {
+		return fmt.Errorf("failed to update playlist: status code %d: %s", resp.StatusCode(), string(resp.Body))
+	}
+
+	if err := c.moveToFolder(ctx, id, meta.Folder); err != nil {
+		return fmt.Errorf("failed to move playlist to folder: %w", err)
+	}
+
+	if meta.Image == "" {
+		return nil
+	}
+
+	artworkID, err := c.uploadArtwork(ctx, meta.Image)
+	if err != nil {
+		return fmt.Errorf("failed to update playlist image: %w", err)
+	}
+
+	coverResp, err := c.client.PatchPlaylistsIdRelationshipsCoverArtWithApplicationVndAPIPlusJSONBodyWithResponse(
+		ctx,
+		id,
+		tidal.PatchPlaylistsIdRelationshipsCoverArtApplicationVndAPIPlusJSONRequestBody{
+			Data: []tidal.PlaylistCoverArtRelationshipUpdateOperationPayloadData{{
+				Id:   artworkID,
+				Type: tidal.PlaylistCoverArtRelationshipUpdateOperationPayloadDataTypeArtworks,
+			}},
+		},
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get playlists: %w", err)
+		return fmt.Errorf("failed to update playlist image: %w", err)
+	}
+
+	if coverResp.StatusCode() != http.StatusNoContent {
+		return fmt.Errorf("failed to update playlist image: status code %d: %s", coverResp.StatusCode(), string(coverResp.Body))
+	}
+
+	return nil
+}
+
+// uploadArtwork creates an artwork for the image at the given URL and uploads
+// the image to the link the API returns for it.
+func (c *connector) uploadArtwork(ctx context.Context, imageURL string) (string, error) {
+	img, err := imageutil.Fetch(ctx, imageURL)
+	if err != nil {
+		return "", err
+	}
+
+	sum := md5.Sum(img.Data)
+	resp, err := c.client.PostArtworksWithApplicationVndAPIPlusJSONBodyWithResponse(
+		ctx,
+		tidal.PostArtworksApplicationVndAPIPlusJSONRequestBody{
+			Data: tidal.ArtworkCreateOperationPayloadData{
+				Attributes: tidal.ArtworkCreateOperationPayloadDataAttributes{
+					MediaType: tidal.ArtworkCreateOperationPayloadDataAttributesMediaTypeIMAGE,
+					SourceFile: tidal.ArtworkCreateOperationPayloadDataAttributesSourceFile{
+						Md5Hash: base64.StdEncoding.EncodeToString(sum[:]),
+						Size:    int64(len(img.Data)),
+					},
+				},
+				Type: tidal.ArtworkCreateOperationPayloadDataTypeArtworks,
+			},
+		},
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to create artwork: %w", err)
+	}
+
+	if resp.StatusCode() != http.StatusCreated {
+		return "", fmt.Errorf("failed to create artwork: status code %d: %s", resp.StatusCode(), string(resp.Body))
+	}
+
+	artwork := resp.ApplicationvndApiJSON201.Data
+	if artwork.Attributes == nil || artwork.Attributes.SourceFile == nil {
+		return "", fmt.Errorf("failed to create artwork: no upload link")
+	}
+	link := artwork.Attributes.SourceFile.UploadLink
+
+	method := link.Meta.Method
+	if method == "" {
+		method = http.MethodPut
+	}
+
+	// The upload link is signed, so the request goes out without the API's
+	// credentials.
+	req, err := http.NewRequestWithContext(ctx, method, link.Href, bytes.NewReader(img.Data))
+	if err != nil {
+		return "", fmt.Errorf("failed to upload artwork: %w", err)
+	}
+	req.Header.Set("Content-Type", img.ContentType)
+	if link.Meta.Headers != nil {
+		for k, v := range *link.Meta.Headers {
+			req.Header.Set(k, v)
+		}
+	}
+
+	uploadResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload artwork: %w", err)
+	}
+	defer uploadResp.Body.Close()
+
+	if uploadResp.StatusCode < 200 || uploadResp.StatusCode > 299 {
+		return "", fmt.Errorf("failed to upload artwork: status code %d", uploadResp.StatusCode)
+	}
+
+	return artwork.Id, nil
+}
+
+// SearchTrack looks the ISRC up in the catalog and falls back to a search by
+// title and artist.
+func (c *connector) SearchTrack(ctx context.Context, filters domain.TrackFilters) ([]domain.Track, error) {
+	if filters.ISRC != "" {
+		tracks, err := c.queryTracks(ctx, &tidal.GetTracksParams{
+			FilterIsrc: &[]string{filters.ISRC},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search track: %w", err)
+		}
+		if len(tracks) > 0 {
+			return tracks, nil
+		}
+	}
+
+	if filters.Title == "" {
+		return nil, nil
+	}
+
+	query := strings.TrimSpace(filters.Title + " " + filters.Artist)
+	resp, err := c.client.GetSearchResultsIdRelationshipsTracksWithResponse(
+		ctx,
+		query,
+		&tidal.GetSearchResultsIdRelationshipsTracksParams{
+			CountryCode: c.countryCode,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search track: %w", err)
 	}
 
 	if resp.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("failed to get playlists: status code %d: %s", resp.StatusCode(), string(resp.Body))
+		return nil, fmt.Errorf("failed to search track: status code %d: %s", resp.StatusCode(), string(resp.Body))
 	}
 
-	var playlists []*domain.Playlist
-	for _, p := range resp.ApplicationvndApiJSON200.Data {
-		playlists = append(playlists, &domain.Playlist{
-			ID:     p.Id,
-			Name:   p.Attributes.Name,
-			Tracks: make([]domain.Track, *p.Attributes.NumberOfItems),
+	var ids []string
+	if data := resp.ApplicationvndApiJSON200.Data; data != nil {
+		for _, r := range *data {
+			if r.Type == "tracks" && len(ids) < searchLimit {
+				ids = append(ids, r.Id)
+			}
+		}
+	}
+
+	tracks, err := c.getTracks(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search track: %w", err)
+	}
+
+	return domain.RankMatches(tracks, filters), nil
+}
+
+func (c *connector) getPlaylistItems(ctx context.Context, id string) ([]tidal.PlaylistsItemsResourceIdentifier, error) {
+	var items []tidal.PlaylistsItemsResourceIdentifier
+	params := &tidal.GetPlaylistsIdRelationshipsItemsParams{
+		CountryCode: c.countryCode,
+	}
+	for {
+		resp, err := c.client.GetPlaylistsIdRelationshipsItemsWithResponse(ctx, id, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get playlist items: %w", err)
+		}
+
+		if resp.StatusCode() != http.StatusOK {
+			return nil, fmt.Errorf("failed to get playlist items: status code %d: %s", resp.StatusCode(), string(resp.Body))
+		}
+
+		doc := resp.ApplicationvndApiJSON200
+		if doc.Data != nil {
+			items = append(items, *doc.Data...)
+		}
+
+		cursor := nextCursor(doc.Links)
+		if cursor == "" {
+			return items, nil
+		}
+		params.PageCursor = &cursor
+	}
+}
+
+// getTracks fetches the tracks with their artists and albums, keeping the
+// order of ids.
+func (c *connector) getTracks(ctx context.Context, ids []string) ([]domain.Track, error) {
+	byID := make(map[string]domain.Track)
+	for start := 0; start < len(ids); start += filterBatchSize {
+		batch := ids[start:min(start+filterBatchSize, len(ids))]
+		tracks, err := c.queryTracks(ctx, &tidal.GetTracksParams{
+			FilterId: &batch,
 		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range tracks {
+			byID[t.ID] = t
+		}
 	}
 
-	return playlists, nil
+	var tracks []domain.Track
+	for _, id := range ids {
+		if t, found := byID[id]; found {
+			tracks = append(tracks, t)
+		}
+	}
+	return tracks, nil
 }
 
-func (c *connector) SearchTrack(ctx context.Context, filters domain.TrackFilters) ([]domain.Track, error) {
-	panic("unimplemented")
+func (c *connector) queryTracks(ctx context.Context, params *tidal.GetTracksParams) ([]domain.Track, error) {
+	params.CountryCode = c.countryCode
+	params.Include = &[]string{"artists", "albums"}
+
+	resp, err := c.client.GetTracksWithResponse(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracks: %w", err)
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("failed to get tracks: status code %d: %s", resp.StatusCode(), string(resp.Body))
+	}
+
+	doc := resp.ApplicationvndApiJSON200
+	included := newIncludedResources(doc.Included)
+
+	var tracks []domain.Track
+	for _, t := range doc.Data {
+		tracks = append(tracks, toDomainTrack(t, included))
+	}
+	return tracks, nil
+}
+
+// includedResources indexes the artists and albums included with tracks, and
+// the artworks included with playlists, by their IDs.
+type includedResources struct {
+	artists map[string]string
+	albums  map[string]tidal.AlbumsResourceObject
+	// artworks maps artwork IDs to the URL of their largest file.
+	artworks map[string]string
+}
+
+func newIncludedResources(included *tidal.Included) includedResources {
+	res := includedResources{
+		artists:  make(map[string]string),
+		albums:   make(map[string]tidal.AlbumsResourceObject),
+		artworks: make(map[string]string),
+	}
+	if included == nil {
+		return res
+	}
+
+	for _, item := range *included {
+		if artist, err := item.AsArtistsResourceObject(); err == nil && artist.Type == "artists" && artist.Attributes != nil {
+			res.artists[artist.Id] = artist.Attributes.Name
+		}
+		if album, err := item.AsAlbumsResourceObject(); err == nil && album.Type == "albums" && album.Attributes != nil {
+			res.albums[album.Id] = album
+		}
+		if artwork, err := item.AsArtworksResourceObject(); err == nil && artwork.Type == "artworks" && artwork.Attributes != nil {
+			var width int32
+			for _, f := range artwork.Attributes.Files {
+				if f.Meta != nil && f.Meta.Width > width {
+					width = f.Meta.Width
+					res.artworks[artwork.Id] = f.Href
+				}
+			}
+		}
+	}
+	return res
+}
+
+// nextCursor extracts the cursor of the next page from the links of a
+// document.
+func nextCursor(links tidal.Links) string {
+	if links.Next == nil {
+		return ""
+	}
+
+	u, err := url.Parse(*links.Next)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("page[cursor]")
+}
+
+func toDomainPlaylist(p tidal.PlaylistsResourceObject, included includedResources) *domain.Playlist {
+	pl := &domain.Playlist{
+		ID: p.Id,
+	}
+
+	if p.Relationships != nil && p.Relationships.CoverArt.Data != nil && len(*p.Relationships.CoverArt.Data) > 0 {
+		pl.Image = included.artworks[(*p.Relationships.CoverArt.Data)[0].Id]
+	}
+
+	if p.Attributes == nil {
+		return pl
+	}
+
+	pl.Name = p.Attributes.Name
+	pl.Owned = p.Attributes.PlaylistType == tidal.USER
+	pl.Public = p.Attributes.AccessType == tidal.PlaylistsAttributesAccessTypePUBLIC
+
+	if p.Attributes.Description != nil {
+		pl.Description = *p.Attributes.Description
+	}
+
+	if p.Attributes.NumberOfItems != nil {
+		pl.TrackCount = int(*p.Attributes.NumberOfItems)
+	}
+
+	for _, link := range p.Attributes.ExternalLinks {
+		if link.Meta.Type == tidal.TIDALSHARING {
+			pl.URL = link.Href
+			break
+		}
+	}
+
+	return pl
+}
+
+func toDomainTrack(t tidal.TracksResourceObject, included includedResources) domain.Track {
+	tr := domain.Track{
+		ID: t.Id,
+	}
+
+	if t.Attributes != nil {
+		tr.ISRC = t.Attributes.Isrc
+		tr.Title = t.Attributes.Title
+		tr.Explicit = t.Attributes.Explicit
+		tr.Popularity = int(math.Round(t.Attributes.Popularity * 100))
+		tr.Duration = parseDuration(t.Attributes.Duration)
+
+		if t.Attributes.ExternalLinks != nil {
+			for _, link := range *t.Attributes.ExternalLinks {
+				if link.Meta.Type == tidal.TIDALSHARING {
+					tr.URL = link.Href
+					break
+				}
+			}
+		}
+	}
+
+	if t.Relationships == nil {
+		return tr
+	}
+
+	if artists := t.Relationships.Artists.Data; artists != nil {
+		for _, a := range *artists {
+			tr.Artists = append(tr.Artists, domain.Artist{ID: a.Id, Name: included.artists[a.Id]})
+		}
+		if len(tr.Artists) > 0 {
+			tr.Artist = tr.Artists[0].Name
+		}
+	}
+
+	if albums := t.Relationships.Albums.Data; albums != nil && len(*albums) > 0 {
+		album, found := included.albums[(*albums)[0].Id]
+		if !found {
+			return tr
+		}
+
+		tr.Album = album.Attributes.Title
+		tr.UPC = album.Attributes.BarcodeId
+		if album.Attributes.ReleaseDate != nil {
+			tr.ReleaseDate = album.Attributes.ReleaseDate.String()
+		}
+	}
+
+	return tr
+}
+
+// parseDuration parses the ISO 8601 durations used by the API, e.g. PT3M21S.
+func parseDuration(s string) time.Duration {
+	rest, found := strings.CutPrefix(s, "PT")
+	if !found {
+		return 0
+	}
+
+	var d time.Duration
+	for _, unit := range []struct {
+		suffix string
+		scale  time.Duration
+	}{{"H", time.Hour}, {"M", time.Minute}, {"S", time.Second}} {
+		value, after, found := strings.Cut(rest, unit.suffix)
+		if !found {
+			continue
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0
+		}
+		d += time.Duration(f * float64(unit.scale))
+		rest = after
+	}
+	return d
 }
@@ -0,0 +1,283 @@
+package tidal_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/cassette"
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/domain/connectortest"
+	"github.com/pedrobarco/nomuz/internal/fakeservice"
+	"github.com/pedrobarco/nomuz/internal/tidal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const countryCode = "US"
+
+// newConnector replays the named cassette. The TIDAL cassettes are written by
+// hand after the OpenAPI spec in pkg/tidal and cannot be recorded: the
+// connector logs in with client credentials, which cannot read or edit user
+// playlists.
+func newConnector(t *testing.T, name string) domain.Connector {
+	t.Helper()
+
+	if cassette.Recording() {
+		t.Skip("the TIDAL cassettes are hand-written")
+	}
+
+	rec := cassette.ForTest(t, name)
+	c, err := tidal.NewConnector("", "", countryCode, tidal.WithHTTPClient(rec.Client()))
+	require.NoError(t, err)
+	return c
+}
+
+func TestGetPlaylists(t *testing.T) {
+	c := newConnector(t, "get_playlists")
+
+	pls, err := c.GetPlaylists(context.Background())
+	require.NoError(t, err)
+	require.Len(t, pls, 3)
+
+	assert.Equal(t, &domain.Playlist{
+		ID:          "2a2c3f5e-0e67-4d6b-a3c4-4a3a0c6f7e01",
+		Name:        "Road Trip",
+		Description: "Songs for the road",
+		Owned:       true,
+		Public:      true,
+		Folder:      []string{"Travel"},
+		URL:         "https://tidal.com/browse/playlist/2a2c3f5e-0e67-4d6b-a3c4-4a3a0c6f7e01",
+		Image:       "https://resources.tidal.com/images/a1b2c3d4/5e6f/4a7b/8c9d/0e1f2a3b4c01/1080x1080.jpg",
+		TrackCount:  2,
+		Tracks:      make([]domain.Track, 2),
+	}, pls[0])
+
+	assert.False(t, pls[1].Public)
+	assert.Empty(t, pls[1].Image)
+	assert.Empty(t, pls[1].Folder)
+	assert.False(t, pls[2].Owned)
+	assert.Equal(t, "Editorial Picks", pls[2].Name)
+}
+
+func TestGetPlaylistByName(t *testing.T) {
+	c := newConnector(t, "get_playlist_by_name")
+	ctx := context.Background()
+
+	pl, err := c.GetPlaylistByName(ctx, "Road Trip")
+	require.NoError(t, err)
+	require.NotNil(t, pl)
+
+	// Videos are skipped and tracks keep the order of the playlist.
+	require.Len(t, pl.Tracks, 2)
+	assert.Equal(t, domain.Track{
+		ID:     "77640617",
+		ISRC:   "USRC17607839",
+		Title:  "Song",
+		Artist: "Band",
+		Artists: []domain.Artist{
+			{ID: "3346", Name: "Band"},
+			{ID: "5214", Name: "Guest"},
+		},
+		Album:       "Album",
+		UPC:         "00602537518357",
+		ReleaseDate: "1999-03-22",
+		Popularity:  42,
+		URL:         "https://tidal.com/browse/track/77640617",
+		Duration:    3*time.Minute + 21*time.Second,
+	}, pl.Tracks[0])
+	assert.Equal(t, []domain.PlaylistItem{
+		{AddedAt: time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)},
+		{AddedAt: time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC)},
+	}, pl.Items)
+	assert.Equal(t, "Other Song", pl.Tracks[1].Title)
+	assert.Equal(t, "Other Band", pl.Tracks[1].Artist)
+
+	pl, err = c.GetPlaylistByName(ctx, "Missing")
+	require.NoError(t, err)
+	assert.Nil(t, pl)
+}
+
+func TestEditPlaylist(t *testing.T) {
+	c := newConnector(t, "edit_playlist")
+	ctx := context.Background()
+
+	pl, err := c.CreatePlaylist(ctx, "nomuz test")
+	require.NoError(t, err)
+	assert.NotEmpty(t, pl.ID)
+	assert.Equal(t, "nomuz test", pl.Name)
+
+	tracks := []domain.Track{{ID: "77640617"}, {ID: "251380837"}}
+	require.NoError(t, c.AddTracksToPlaylist(ctx, pl.ID, tracks))
+	require.NoError(t, c.DeleteTracksFromPlaylist(ctx, pl.ID, tracks[:1]))
+	require.NoError(t, c.UpdatePlaylist(ctx, pl.ID, domain.PlaylistMetadata{
+		Description: "Songs for the road",
+		Public:      true,
+		Folder:      []string{"Road"},
+	}))
+
+	// Empty batches do not reach the API.
+	require.NoError(t, c.AddTracksToPlaylist(ctx, pl.ID, nil))
+	require.NoError(t, c.DeleteTracksFromPlaylist(ctx, pl.ID, nil))
+}
+
+func TestSearchTrack(t *testing.T) {
+	c := newConnector(t, "search_track")
+	ctx := context.Background()
+
+	tracks, err := c.SearchTrack(ctx, domain.TrackFilters{ISRC: "USRC17607839", Title: "Song", Artist: "Band"})
+	require.NoError(t, err)
+	require.Len(t, tracks, 1)
+	assert.Equal(t, "77640617", tracks[0].ID)
+
+	// Unknown ISRCs fall back to a search by title and artist, preferring
+	// the same album.
+	tracks, err = c.SearchTrack(ctx, domain.TrackFilters{ISRC: "XX0000000000", Title: "Song", Artist: "Band", Album: "Album"})
+	require.NoError(t, err)
+	require.Len(t, tracks, 2)
+	assert.Equal(t, "77640617", tracks[0].ID)
+	assert.Equal(t, "Greatest Hits", tracks[1].Album)
+}
+
+func TestErrors(t *testing.T) {
+	c := newConnector(t, "errors")
+	ctx := context.Background()
+
+	_, err := c.GetPlaylists(ctx)
+	assert.ErrorContains(t, err, "status code 401")
+	assert.ErrorContains(t, err, "Missing user scope")
+
+	_, err = c.SearchTrack(ctx, domain.TrackFilters{ISRC: "USRC17607839"})
+	assert.ErrorContains(t, err, "status code 429")
+
+	err = c.AddTracksToPlaylist(ctx, "00000000-0000-0000-0000-000000000000", []domain.Track{{ID: "77640617"}})
+	assert.ErrorContains(t, err, "status code 404")
+}
+
+// newFakeConnector connects to a fake API server.
+func newFakeConnector(t *testing.T, srv *fakeservice.Tidal) domain.Connector {
+	t.Helper()
+
+	c, err := tidal.NewConnector(srv.ClientID, srv.ClientSecret, countryCode,
+		tidal.WithBaseURL(srv.BaseURL()),
+		tidal.WithTokenURL(srv.TokenURL()),
+	)
+	require.NoError(t, err)
+	return c
+}
+
+func TestUpdatePlaylist(t *testing.T) {
+	ctx := context.Background()
+	srv := fakeservice.NewTidal(t, fakeservice.Library{
+		Playlists: []fakeservice.Playlist{{ID: "pl1", Name: "Road Trip"}, {ID: "pl2", Name: "Drafts"}},
+	})
+	srv.PageSize = 1
+	c := newFakeConnector(t, srv)
+
+	images := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("cover"))
+	}))
+	defer images.Close()
+
+	err := c.UpdatePlaylist(ctx, "pl2", domain.PlaylistMetadata{
+		Description: "Songs for the road",
+		Public:      true,
+		Image:       images.URL + "/cover.jpg",
+	})
+	require.NoError(t, err)
+
+	pls, err := c.GetPlaylists(ctx)
+	require.NoError(t, err)
+	require.Len(t, pls, 2)
+	assert.Empty(t, pls[0].Image)
+	assert.Equal(t, "Songs for the road", pls[1].Description)
+	assert.True(t, pls[1].Public)
+	require.NotEmpty(t, pls[1].Image)
+
+	// The cover is served from the uploaded artwork.
+	resp, err := http.Get(pls[1].Image)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "cover", string(body))
+
+	err = c.UpdatePlaylist(ctx, "missing", domain.PlaylistMetadata{})
+	assert.ErrorContains(t, err, "status code 404")
+}
+
+func TestFolders(t *testing.T) {
+	ctx := context.Background()
+	srv := fakeservice.NewTidal(t, fakeservice.Library{
+		Playlists: []fakeservice.Playlist{
+			{ID: "pl1", Name: "Road Trip", Folder: []string{"Moods", "Chill"}},
+			{ID: "pl2", Name: "Drafts"},
+		},
+	})
+	srv.PageSize = 1
+	c := newFakeConnector(t, srv)
+
+	pls, err := c.GetPlaylists(ctx)
+	require.NoError(t, err)
+	require.Len(t, pls, 2)
+	assert.Equal(t, []string{"Moods", "Chill"}, pls[0].Folder)
+	assert.Empty(t, pls[1].Folder)
+	reads := srv.FolderReads()
+
+	// Nested folders are flattened into one named after the path.
+	require.NoError(t, c.UpdatePlaylist(ctx, "pl2", domain.PlaylistMetadata{Folder: []string{"Moods", "Chill"}}))
+	require.NoError(t, c.UpdatePlaylist(ctx, "pl1", domain.PlaylistMetadata{Folder: []string{"Travel"}}))
+
+	pl, found := srv.Playlist("Drafts")
+	require.True(t, found)
+	assert.Equal(t, []string{"Moods", "Chill"}, pl.Folder)
+	pl, found = srv.Playlist("Road Trip")
+	require.True(t, found)
+	assert.Equal(t, []string{"Travel"}, pl.Folder)
+
+	require.NoError(t, c.UpdatePlaylist(ctx, "pl1", domain.PlaylistMetadata{}))
+	pls, err = c.GetPlaylists(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pls[0].Folder)
+	assert.Equal(t, "Moods / Chill", pls[1].FolderPath())
+
+	// The folder tree is read once and then kept up to date.
+	assert.Equal(t, reads, srv.FolderReads())
+}
+
+func TestFoldersUnavailable(t *testing.T) {
+	srv := fakeservice.NewTidal(t, fakeservice.Library{
+		Playlists: []fakeservice.Playlist{{ID: "pl1", Name: "Road Trip", Folder: []string{"Moods"}}},
+	})
+	srv.NoFolders = true
+	c := newFakeConnector(t, srv)
+
+	pls, err := c.GetPlaylists(context.Background())
+	require.NoError(t, err)
+	require.Len(t, pls, 1)
+	assert.Empty(t, pls[0].Folder)
+}
+
+func TestConformance(t *testing.T) {
+	connectortest.Run(t, connectortest.Harness{
+		New: func(t *testing.T) domain.Connector {
+			srv := fakeservice.NewTidal(t, fakeservice.Library{
+				Tracks: []fakeservice.Track{
+					{ID: "1", ISRC: "USRC17607839", Title: "Song", Artist: "Band", Album: "Album", Duration: 201 * time.Second},
+					{ID: "2", Title: "Other Song", Artist: "Other Band", Album: "Other Album", Duration: 242 * time.Second},
+				},
+			})
+			// Exercise pagination with the few playlists and tracks of the suite.
+			srv.PageSize = 1
+			return newFakeConnector(t, srv)
+		},
+		Tracks: []domain.Track{
+			{ID: "1", ISRC: "USRC17607839", Title: "Song", Artist: "Band", Album: "Album"},
+			{ID: "2", Title: "Other Song", Artist: "Other Band", Album: "Other Album"},
+		},
+	})
+}
@@ -0,0 +1,232 @@
+package xspf
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+type Format string
+
+const (
+	FormatXSPF Format = "xspf"
+	FormatJSPF Format = "jspf"
+)
+
+func (f Format) ext() string {
+	return "." + string(f)
+}
+
+// document is the playlist model shared by XSPF and JSPF.
+type document struct {
+	Title      string
+	Creator    string
+	Annotation string
+	Info       string
+	Image      string
+	Tracks     []docTrack
+}
+
+type docTrack struct {
+	Locations   []string
+	Identifiers []string
+	Title       string
+	Creator     string
+	Album       string
+	TrackNum    int
+	Info        string
+	// Duration is in milliseconds, as in both specs.
+	Duration int
+}
+
+func decode(f Format, r io.Reader) (*document, error) {
+	switch f {
+	case FormatXSPF:
+		return decodeXSPF(r)
+	case FormatJSPF:
+		return decodeJSPF(r)
+	default:
+		return nil, fmt.Errorf("unknown playlist format: %s", f)
+	}
+}
+
+func encode(f Format, doc *document) ([]byte, error) {
+	switch f {
+	case FormatXSPF:
+		return encodeXSPF(doc)
+	case FormatJSPF:
+		return encodeJSPF(doc)
+	default:
+		return nil, fmt.Errorf("unknown playlist format: %s", f)
+	}
+}
+
+type xspfPlaylist struct {
+	XMLName    xml.Name    `xml:"http://xspf.org/ns/0/ playlist"`
+	Version    string      `xml:"version,attr"`
+	Title      string      `xml:"title,omitempty"`
+	Creator    string      `xml:"creator,omitempty"`
+	Annotation string      `xml:"annotation,omitempty"`
+	Info       string      `xml:"info,omitempty"`
+	Image      string      `xml:"image,omitempty"`
+	Tracks     []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Locations   []string `xml:"location"`
+	Identifiers []string `xml:"identifier"`
+	Title       string   `xml:"title,omitempty"`
+	Creator     string   `xml:"creator,omitempty"`
+	Album       string   `xml:"album,omitempty"`
+	TrackNum    int      `xml:"trackNum,omitempty"`
+	Info        string   `xml:"info,omitempty"`
+	Duration    int      `xml:"duration,omitempty"`
+}
+
+func decodeXSPF(r io.Reader) (*document, error) {
+	var pl xspfPlaylist
+	if err := xml.NewDecoder(r).Decode(&pl); err != nil {
+		return nil, fmt.Errorf("failed to decode xspf: %w", err)
+	}
+
+	doc := &document{
+		Title:      pl.Title,
+		Creator:    pl.Creator,
+		Annotation: pl.Annotation,
+		Info:       pl.Info,
+		Image:      pl.Image,
+	}
+	for _, t := range pl.Tracks {
+		doc.Tracks = append(doc.Tracks, docTrack(t))
+	}
+	return doc, nil
+}
+
+func encodeXSPF(doc *document) ([]byte, error) {
+	pl := xspfPlaylist{
+		Version:    "1",
+		Title:      doc.Title,
+		Creator:    doc.Creator,
+		Annotation: doc.Annotation,
+		Info:       doc.Info,
+		Image:      doc.Image,
+		Tracks:     []xspfTrack{},
+	}
+	for _, t := range doc.Tracks {
+		pl.Tracks = append(pl.Tracks, xspfTrack(t))
+	}
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	enc := xml.NewEncoder(&b)
+	enc.Indent("", "  ")
+	if err := enc.Encode(pl); err != nil {
+		return nil, fmt.Errorf("failed to encode xspf: %w", err)
+	}
+	b.WriteString("\n")
+	return b.Bytes(), nil
+}
+
+type jspfDocument struct {
+	Playlist jspfPlaylist `json:"playlist"`
+}
+
+type jspfPlaylist struct {
+	Title      string      `json:"title,omitempty"`
+	Creator    string      `json:"creator,omitempty"`
+	Annotation string      `json:"annotation,omitempty"`
+	Info       string      `json:"info,omitempty"`
+	Image      string      `json:"image,omitempty"`
+	Tracks     []jspfTrack `json:"track"`
+}
+
+type jspfTrack struct {
+	Locations   stringList `json:"location,omitempty"`
+	Identifiers stringList `json:"identifier,omitempty"`
+	Title       string     `json:"title,omitempty"`
+	Creator     string     `json:"creator,omitempty"`
+	Album       string     `json:"album,omitempty"`
+	TrackNum    int        `json:"trackNum,omitempty"`
+	Info        string     `json:"info,omitempty"`
+	Duration    int        `json:"duration,omitempty"`
+}
+
+// stringList accepts both a single string and an array of strings, since
+// JSPF producers disagree on whether location and identifier are lists.
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*l = stringList{s}
+		return nil
+	}
+
+	var ss []string
+	if err := json.Unmarshal(b, &ss); err != nil {
+		return err
+	}
+	*l = ss
+	return nil
+}
+
+func decodeJSPF(r io.Reader) (*document, error) {
+	var d jspfDocument
+	if err := json.NewDecoder(r).Decode(&d); err != nil {
+		return nil, fmt.Errorf("failed to decode jspf: %w", err)
+	}
+
+	doc := &document{
+		Title:      d.Playlist.Title,
+		Creator:    d.Playlist.Creator,
+		Annotation: d.Playlist.Annotation,
+		Info:       d.Playlist.Info,
+		Image:      d.Playlist.Image,
+	}
+	for _, t := range d.Playlist.Tracks {
+		doc.Tracks = append(doc.Tracks, docTrack{
+			Locations:   t.Locations,
+			Identifiers: t.Identifiers,
+			Title:       t.Title,
+			Creator:     t.Creator,
+			Album:       t.Album,
+			TrackNum:    t.TrackNum,
+			Info:        t.Info,
+			Duration:    t.Duration,
+		})
+	}
+	return doc, nil
+}
+
+func encodeJSPF(doc *document) ([]byte, error) {
+	d := jspfDocument{
+		Playlist: jspfPlaylist{
+			Title:      doc.Title,
+			Creator:    doc.Creator,
+			Annotation: doc.Annotation,
+			Info:       doc.Info,
+			Image:      doc.Image,
+			Tracks:     []jspfTrack{},
+		},
+	}
+	for _, t := range doc.Tracks {
+		d.Playlist.Tracks = append(d.Playlist.Tracks, jspfTrack{
+			Locations:   t.Locations,
+			Identifiers: t.Identifiers,
+			Title:       t.Title,
+			Creator:     t.Creator,
+			Album:       t.Album,
+			TrackNum:    t.TrackNum,
+			Info:        t.Info,
+			Duration:    t.Duration,
+		})
+	}
+
+	b, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode jspf: %w", err)
+	}
+	return append(b, '\n'), nil
+}
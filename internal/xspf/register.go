@@ -0,0 +1,31 @@
+package xspf
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/registry"
+)
+
+type Config struct {
+	Dir string `yaml:"dir"`
+}
+
+func init() {
+	for _, format := range []Format{FormatXSPF, FormatJSPF} {
+		name := string(format)
+		registry.Register(registry.Connector[Config]{
+			Name:         name,
+			Usage:        name + "[:<dir>]",
+			Description:  "Directory of " + strings.ToUpper(name) + " playlists",
+			Capabilities: Capabilities,
+			New: func(ctx context.Context, cfg Config, arg string) (domain.Connector, error) {
+				if arg != "" {
+					cfg.Dir = arg
+				}
+				return NewConnector(cfg.Dir, format)
+			},
+		})
+	}
+}
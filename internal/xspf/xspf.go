@@ -0,0 +1,398 @@
+package xspf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/fsutil"
+)
+
+const (
+	isrcPrefix = "urn:isrc:"
+	mbidPrefix = "https://musicbrainz.org/recording/"
+)
+
+// NewConnector treats every file of the given format in dir as a playlist.
+// The files are self-describing, so any track can be written to them: tracks
+// are identified by ISRC, MusicBrainz ID or title and artist.
+func NewConnector(dir string, format Format) (*connector, error) {
+	if format != FormatXSPF && format != FormatJSPF {
+		return nil, fmt.Errorf("unknown playlist format: %s", format)
+	}
+
+	if dir == "" {
+		return nil, fmt.Errorf("%s playlists dir is required", format)
+	}
+
+	if err := os.MkdirAll(dir, 00755); err != nil {
+		return nil, fmt.Errorf("failed to create %s playlists dir: %w", format, err)
+	}
+
+	return &connector{
+		dir:    dir,
+		format: format,
+	}, nil
+}
+
+type connector struct {
+	mu     sync.Mutex
+	dir    string
+	format Format
+}
+
+var _ domain.Connector = (*connector)(nil)
+
+var Capabilities = domain.Capabilities{
+	CreatePlaylist: true,
+	DeleteTracks:   true,
+	ISRCLookup:     true,
+	Descriptions:   true,
+	Images:         true,
+}
+
+func (c *connector) Capabilities() domain.Capabilities {
+	return Capabilities
+}
+
+type playlistFile struct {
+	path string
+	doc  *document
+}
+
+func (c *connector) CreatePlaylist(ctx context.Context, name string) (*domain.Playlist, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if pf, err := c.findPlaylist(name); err != nil {
+		return nil, err
+	} else if pf != nil {
+		return nil, fmt.Errorf("playlist %s already exists", name)
+	}
+
+	pf := &playlistFile{
+		path: filepath.Join(c.dir, fsutil.SafeFileName(name)+c.format.ext()),
+		doc:  &document{Title: name},
+	}
+
+	if _, err := os.Stat(pf.path); err == nil {
+		return nil, fmt.Errorf("playlist file %s already exists", pf.path)
+	}
+
+	if err := c.write(pf); err != nil {
+		return nil, fmt.Errorf("failed to create playlist: %w", err)
+	}
+
+	return toDomainPlaylist(pf), nil
+}
+
+func (c *connector) GetPlaylists(ctx context.Context) ([]*domain.Playlist, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pfs, err := c.readPlaylists()
+	if err != nil {
+		return nil, err
+	}
+
+	var pls []*domain.Playlist
+	for _, pf := range pfs {
+		pls = append(pls, toDomainPlaylist(pf))
+	}
+	return pls, nil
+}
+
+func (c *connector) GetPlaylistByName(ctx context.Context, name string) (*domain.Playlist, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pf, err := c.findPlaylist(name)
+	if err != nil || pf == nil {
+		return nil, err
+	}
+	return toDomainPlaylist(pf), nil
+}
+
+func (c *connector) GetPlaylist(ctx context.Context, id string) (*domain.Playlist, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pf, err := c.readPlaylist(id)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toDomainPlaylist(pf), nil
+}
+
+func (c *connector) AddTracksToPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pf, err := c.readPlaylist(id)
+	if err != nil {
+		return err
+	}
+
+	for _, tr := range tracks {
+		pf.doc.Tracks = append(pf.doc.Tracks, fromDomainTrack(tr))
+	}
+
+	if err := c.write(pf); err != nil {
+		return fmt.Errorf("failed to add tracks to playlist: %w", err)
+	}
+	return nil
+}
+
+func (c *connector) DeleteTracksFromPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pf, err := c.readPlaylist(id)
+	if err != nil {
+		return err
+	}
+
+	remove := make(map[string]struct{})
+	for _, tr := range tracks {
+		remove[tr.ID] = struct{}{}
+	}
+
+	var kept []docTrack
+	for _, t := range pf.doc.Tracks {
+		if _, found := remove[toDomainTrack(t).ID]; !found {
+			kept = append(kept, t)
+		}
+	}
+	pf.doc.Tracks = kept
+
+	if err := c.write(pf); err != nil {
+		return fmt.Errorf("failed to remove tracks from playlist: %w", err)
+	}
+	return nil
+}
+
+// UpdatePlaylist writes the annotation and, when given, the image URL.
+func (c *connector) UpdatePlaylist(ctx context.Context, id string, meta domain.PlaylistMetadata) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pf, err := c.readPlaylist(id)
+	if err != nil {
+		return err
+	}
+
+	pf.doc.Annotation = meta.Description
+	if meta.Image != "" {
+		pf.doc.Image = meta.Image
+	}
+
+	if err := c.write(pf); err != nil {
+		return fmt.Errorf("failed to update playlist: %w", err)
+	}
+	return nil
+}
+
+// SearchTrack echoes the searched track back, since a playlist file can hold
+// any track that carries enough metadata to be identified.
+func (c *connector) SearchTrack(ctx context.Context, filters domain.TrackFilters) ([]domain.Track, error) {
+	tr := domain.Track{
+		ISRC:     filters.ISRC,
+		MBID:     filters.MBID,
+		Title:    filters.Title,
+		Artist:   filters.Artist,
+		Album:    filters.Album,
+		Duration: filters.Duration,
+	}
+
+	tr.ID = trackID(tr)
+	if tr.ID == "" {
+		return nil, nil
+	}
+	return []domain.Track{tr}, nil
+}
+
+func (c *connector) readPlaylists() ([]*playlistFile, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s playlists dir: %w", c.format, err)
+	}
+
+	var pfs []*playlistFile
+	for _, e := range entries {
+		if e.IsDir() || !strings.EqualFold(filepath.Ext(e.Name()), c.format.ext()) {
+			continue
+		}
+
+		pf, err := c.readPlaylist(e.Name())
+		if err != nil {
+			return nil, err
+		}
+		pfs = append(pfs, pf)
+	}
+
+	sort.Slice(pfs, func(i, j int) bool {
+		return pfs[i].path < pfs[j].path
+	})
+	return pfs, nil
+}
+
+func (c *connector) findPlaylist(name string) (*playlistFile, error) {
+	pfs, err := c.readPlaylists()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pf := range pfs {
+		if playlistName(pf) == name {
+			return pf, nil
+		}
+	}
+	return nil, nil
+}
+
+// readPlaylist loads a playlist by ID, which is the file name relative to the
+// playlists dir.
+func (c *connector) readPlaylist(id string) (*playlistFile, error) {
+	if id != filepath.Base(id) || !strings.EqualFold(filepath.Ext(id), c.format.ext()) {
+		return nil, fmt.Errorf("invalid %s playlist id: %s", c.format, id)
+	}
+
+	p := filepath.Join(c.dir, id)
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read playlist %s: %w", id, err)
+	}
+
+	doc, err := decode(c.format, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read playlist %s: %w", id, err)
+	}
+
+	return &playlistFile{
+		path: p,
+		doc:  doc,
+	}, nil
+}
+
+func (c *connector) write(pf *playlistFile) error {
+	data, err := encode(c.format, pf.doc)
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(pf.path, data)
+}
+
+func playlistName(pf *playlistFile) string {
+	if pf.doc.Title != "" {
+		return pf.doc.Title
+	}
+	base := filepath.Base(pf.path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+func toDomainPlaylist(pf *playlistFile) *domain.Playlist {
+	tracks := make([]domain.Track, 0, len(pf.doc.Tracks))
+	for _, t := range pf.doc.Tracks {
+		tracks = append(tracks, toDomainTrack(t))
+	}
+
+	return &domain.Playlist{
+		ID:          filepath.Base(pf.path),
+		Name:        playlistName(pf),
+		Description: pf.doc.Annotation,
+		Owner:       pf.doc.Creator,
+		Owned:       true,
+		URL:         pf.doc.Info,
+		Image:       pf.doc.Image,
+		TrackCount:  len(tracks),
+		Tracks:      tracks,
+	}
+}
+
+func toDomainTrack(t docTrack) domain.Track {
+	tr := domain.Track{
+		Title:       t.Title,
+		Artist:      t.Creator,
+		Album:       t.Album,
+		TrackNumber: t.TrackNum,
+		URL:         t.Info,
+		Duration:    time.Duration(t.Duration) * time.Millisecond,
+	}
+
+	for _, id := range t.Identifiers {
+		if isrc, found := cutPrefixFold(id, isrcPrefix, "isrc:"); found && tr.ISRC == "" {
+			tr.ISRC = isrc
+		}
+		if mbid, found := cutPrefixFold(id, mbidPrefix, "http://musicbrainz.org/recording/", "mbid:"); found && tr.MBID == "" {
+			tr.MBID = mbid
+		}
+	}
+
+	if tr.URL == "" {
+		for _, loc := range t.Locations {
+			if strings.HasPrefix(loc, "http://") || strings.HasPrefix(loc, "https://") {
+				tr.URL = loc
+				break
+			}
+		}
+	}
+
+	tr.ID = trackID(tr)
+	return tr
+}
+
+func fromDomainTrack(tr domain.Track) docTrack {
+	t := docTrack{
+		Title:    tr.Title,
+		Creator:  tr.Artist,
+		Album:    tr.Album,
+		TrackNum: tr.TrackNumber,
+		Info:     tr.URL,
+		Duration: int(tr.Duration / time.Millisecond),
+	}
+
+	if tr.ISRC != "" {
+		t.Identifiers = append(t.Identifiers, isrcPrefix+tr.ISRC)
+	}
+	if tr.MBID != "" {
+		t.Identifiers = append(t.Identifiers, mbidPrefix+tr.MBID)
+	}
+
+	return t
+}
+
+// trackID derives a stable ID from the best identifier available, so tracks
+// written to a file can be recognized when the file is read back.
+func trackID(tr domain.Track) string {
+	switch {
+	case tr.ISRC != "":
+		return "isrc:" + strings.ToUpper(tr.ISRC)
+	case tr.MBID != "":
+		return "mbid:" + strings.ToLower(tr.MBID)
+	case tr.Title != "":
+		return "meta:" + domain.NormalizeText(tr.Artist) + " - " + domain.NormalizeText(tr.Title)
+	default:
+		return ""
+	}
+}
+
+func cutPrefixFold(s string, prefixes ...string) (string, bool) {
+	for _, prefix := range prefixes {
+		if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+			return s[len(prefix):], true
+		}
+	}
+	return "", false
+}
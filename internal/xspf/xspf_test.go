@@ -0,0 +1,208 @@
+package xspf_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/domain/connectortest"
+	"github.com/pedrobarco/nomuz/internal/m3u"
+	"github.com/pedrobarco/nomuz/internal/xspf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnector(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("read xspf", func(t *testing.T) {
+		assert := assert.New(t)
+
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "mix.xspf"), []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<playlist version="1" xmlns="http://xspf.org/ns/0/">
+  <title>Mix</title>
+  <creator>alice</creator>
+  <annotation>Songs for the road</annotation>
+  <image>https://example.com/mix.jpg</image>
+  <trackList>
+    <track>
+      <location>https://example.com/song</location>
+      <identifier>urn:isrc:USRC17607839</identifier>
+      <identifier>https://musicbrainz.org/recording/4f3d9fc1-4ad4-4d1f-9c1a-5f5bbd6c2c36</identifier>
+      <title>Song</title>
+      <creator>Band</creator>
+      <album>Album</album>
+      <trackNum>3</trackNum>
+      <duration>201000</duration>
+    </track>
+    <track>
+      <title>Other Song</title>
+      <creator>Other Band</creator>
+    </track>
+  </trackList>
+</playlist>
+`), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644))
+
+		c, err := xspf.NewConnector(dir, xspf.FormatXSPF)
+		require.NoError(t, err)
+
+		pls, err := c.GetPlaylists(ctx)
+		assert.NoError(err)
+		assert.Len(pls, 1)
+		assert.Equal("mix.xspf", pls[0].ID)
+		assert.Equal("Mix", pls[0].Name)
+		assert.Equal("alice", pls[0].Owner)
+		assert.Equal("Songs for the road", pls[0].Description)
+		assert.Equal("https://example.com/mix.jpg", pls[0].Image)
+
+		tracks := pls[0].Tracks
+		assert.Len(tracks, 2)
+		assert.Equal("isrc:USRC17607839", tracks[0].ID)
+		assert.Equal("USRC17607839", tracks[0].ISRC)
+		assert.Equal("4f3d9fc1-4ad4-4d1f-9c1a-5f5bbd6c2c36", tracks[0].MBID)
+		assert.Equal("Song", tracks[0].Title)
+		assert.Equal("Band", tracks[0].Artist)
+		assert.Equal("Album", tracks[0].Album)
+		assert.Equal(3, tracks[0].TrackNumber)
+		assert.Equal("https://example.com/song", tracks[0].URL)
+		assert.Equal("meta:other band - other song", tracks[1].ID)
+	})
+
+	t.Run("read jspf with single values", func(t *testing.T) {
+		assert := assert.New(t)
+
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "mix.jspf"), []byte(`{
+  "playlist": {
+    "title": "Mix",
+    "track": [
+      {
+        "identifier": "https://musicbrainz.org/recording/4f3d9fc1-4ad4-4d1f-9c1a-5f5bbd6c2c36",
+        "title": "Song",
+        "creator": "Band"
+      }
+    ]
+  }
+}`), 0o644))
+
+		c, err := xspf.NewConnector(dir, xspf.FormatJSPF)
+		require.NoError(t, err)
+
+		pl, err := c.GetPlaylistByName(ctx, "Mix")
+		assert.NoError(err)
+		assert.Len(pl.Tracks, 1)
+		assert.Equal("mbid:4f3d9fc1-4ad4-4d1f-9c1a-5f5bbd6c2c36", pl.Tracks[0].ID)
+		assert.Equal("4f3d9fc1-4ad4-4d1f-9c1a-5f5bbd6c2c36", pl.Tracks[0].MBID)
+
+		pl, err = c.GetPlaylistByName(ctx, "Missing")
+		assert.NoError(err)
+		assert.Nil(pl)
+	})
+
+	for _, format := range []xspf.Format{xspf.FormatXSPF, xspf.FormatJSPF} {
+		t.Run("create, add and delete "+string(format), func(t *testing.T) {
+			assert := assert.New(t)
+
+			dir := t.TempDir()
+			c, err := xspf.NewConnector(dir, format)
+			require.NoError(t, err)
+
+			pl, err := c.CreatePlaylist(ctx, "Road: Trip")
+			assert.NoError(err)
+			assert.Equal("Road_ Trip."+string(format), pl.ID)
+
+			_, err = c.CreatePlaylist(ctx, "Road: Trip")
+			assert.Error(err)
+
+			song, err := c.SearchTrack(ctx, domain.TrackFilters{ID: "spotify-1", ISRC: "USRC17607839", Title: "Song", Artist: "Band"})
+			assert.NoError(err)
+			assert.Len(song, 1)
+			other, err := c.SearchTrack(ctx, domain.TrackFilters{ID: "spotify-2", Title: "Other Song", Artist: "Other Band", Album: "Other Album"})
+			assert.NoError(err)
+			assert.Len(other, 1)
+			none, err := c.SearchTrack(ctx, domain.TrackFilters{ID: "spotify-3"})
+			assert.NoError(err)
+			assert.Empty(none)
+
+			assert.NoError(c.AddTracksToPlaylist(ctx, pl.ID, append(song, other...)))
+
+			got, err := c.GetPlaylistByName(ctx, "Road: Trip")
+			assert.NoError(err)
+			assert.Equal(append(song, other...), got.Tracks)
+
+			assert.NoError(c.DeleteTracksFromPlaylist(ctx, pl.ID, song))
+
+			got, err = c.GetPlaylistByName(ctx, "Road: Trip")
+			assert.NoError(err)
+			assert.Equal(other, got.Tracks)
+
+			assert.NoError(c.UpdatePlaylist(ctx, pl.ID, domain.PlaylistMetadata{
+				Description: "Songs for the road",
+				Image:       "https://example.com/road.jpg",
+			}))
+
+			got, err = c.GetPlaylistByName(ctx, "Road: Trip")
+			assert.NoError(err)
+			assert.Equal("Songs for the road", got.Description)
+			assert.Equal("https://example.com/road.jpg", got.Image)
+			assert.Equal(other, got.Tracks)
+		})
+	}
+
+	t.Run("back up and restore", func(t *testing.T) {
+		assert := assert.New(t)
+
+		root := t.TempDir()
+		srcDir := filepath.Join(root, "playlists")
+		require.NoError(t, os.MkdirAll(srcDir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(srcDir, "Favorites.m3u"), []byte(
+			"#EXTM3U\n#EXTINF:-1,Band - Song\nhttps://example.com/1\n#EXTINF:-1,Other Band - Other Song\nhttps://example.com/2\n",
+		), 0o644))
+
+		src, err := m3u.NewConnector(srcDir, "")
+		require.NoError(t, err)
+		backup, err := xspf.NewConnector(filepath.Join(root, "backup"), xspf.FormatXSPF)
+		require.NoError(t, err)
+
+		cl, err := domain.PlanSync(ctx, src, backup)
+		assert.NoError(err)
+		_, err = domain.Sync(ctx, src, backup, *cl)
+		assert.NoError(err)
+
+		pl, err := backup.GetPlaylistByName(ctx, "Favorites")
+		assert.NoError(err)
+		assert.Len(pl.Tracks, 2)
+		assert.Equal("Song", pl.Tracks[0].Title)
+		assert.Equal("Other Band", pl.Tracks[1].Artist)
+
+		cl, err = domain.PlanSync(ctx, src, backup)
+		assert.NoError(err)
+		for _, changes := range cl.TracksByPlaylist {
+			assert.False(changes.HasChanges())
+		}
+	})
+}
+
+func TestConformance(t *testing.T) {
+	tracks := []domain.Track{
+		{ID: "isrc:USRC17607839", ISRC: "USRC17607839", Title: "Song", Artist: "Band"},
+		{ID: "isrc:GBAYE0601498", ISRC: "GBAYE0601498", Title: "Other Song", Artist: "Other Band"},
+	}
+
+	for _, format := range []xspf.Format{xspf.FormatXSPF, xspf.FormatJSPF} {
+		t.Run(string(format), func(t *testing.T) {
+			connectortest.Run(t, connectortest.Harness{
+				New: func(t *testing.T) domain.Connector {
+					c, err := xspf.NewConnector(t.TempDir(), format)
+					require.NoError(t, err)
+					return c
+				},
+				Tracks: tracks,
+			})
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package ytmusic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	deviceCodeURL = "https://oauth2.googleapis.com/device/code"
+	tokenURL      = "https://oauth2.googleapis.com/token"
+	authScope     = "https://www.googleapis.com/auth/youtube"
+	deviceGrant   = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+type deviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURL string `json:"verification_url"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	Error        string `json:"error"`
+}
+
+// deviceLogin runs the OAuth device authorization flow: the user opens the
+// verification URL on any device and enters the code while we poll for the
+// token.
+func deviceLogin(ctx context.Context, cfg *oauth2.Config, deviceURL string) (*oauth2.Token, error) {
+	var code deviceCode
+	err := postForm(ctx, deviceURL, url.Values{
+		"client_id": {cfg.ClientID},
+		"scope":     {strings.Join(cfg.Scopes, " ")},
+	}, &code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request device code: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "To sign in to YouTube Music, open %s and enter the code %s\n", code.VerificationURL, code.UserCode)
+
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		var res tokenResponse
+		err := postForm(ctx, cfg.Endpoint.TokenURL, url.Values{
+			"client_id":     {cfg.ClientID},
+			"client_secret": {cfg.ClientSecret},
+			"device_code":   {code.DeviceCode},
+			"grant_type":    {deviceGrant},
+		}, &res)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get token: %w", err)
+		}
+
+		switch res.Error {
+		case "":
+			token := &oauth2.Token{
+				AccessToken:  res.AccessToken,
+				RefreshToken: res.RefreshToken,
+				TokenType:    res.TokenType,
+			}
+			if res.ExpiresIn > 0 {
+				token.Expiry = time.Now().Add(time.Duration(res.ExpiresIn) * time.Second)
+			}
+			return token, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		default:
+			return nil, fmt.Errorf("failed to get token: %s", res.Error)
+		}
+	}
+
+	return nil, errors.New("failed to get token: device code expired")
+}
+
+// postForm posts a form and decodes the JSON response, including the error
+// responses of the token endpoint.
+func postForm(ctx context.Context, u string, form url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("status code %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func getAuthConfigPath() (string, error) {
+	cfg, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home dir: %v", err)
+	}
+	return path.Join(cfg, "nomuz", "ytmusic_auth.yaml"), nil
+}
+
+type authConfig struct {
+	Token *oauth2.Token `yaml:"token"`
+}
+
+func SaveAuthToken(token *oauth2.Token) error {
+	filePath, err := getAuthConfigPath()
+	if err != nil {
+		return fmt.Errorf("failed to get ytmusic auth config path: %v", err)
+	}
+
+	if err := os.MkdirAll(path.Dir(filePath), 00755); err != nil {
+		return fmt.Errorf("failed to create ytmusic auth config dir: %v", err)
+	}
+
+	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 00600)
+	if err != nil {
+		return fmt.Errorf("failed to create ytmusic auth config file: %v", err)
+	}
+	defer f.Close()
+
+	cfg := &authConfig{Token: token}
+	if err := yaml.NewEncoder(f).Encode(cfg); err != nil {
+		return fmt.Errorf("failed to write ytmusic auth config file: %v", err)
+	}
+
+	return nil
+}
+
+func GetAuthToken() (*oauth2.Token, error) {
+	path, err := getAuthConfigPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ytmusic auth config path: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ytmusic auth config file: %v", err)
+	}
+	defer f.Close()
+
+	var cfg authConfig
+	if err := yaml.NewDecoder(f).Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse ytmusic auth config file: %v", err)
+	}
+
+	return cfg.Token, nil
+}
+
+// IsInvalidAuthToken reports whether a new login is needed. Expired tokens are
+// still usable when they can be refreshed.
+func IsInvalidAuthToken(token *oauth2.Token) bool {
+	return token == nil || (!token.Valid() && token.RefreshToken == "")
+}
@@ -0,0 +1,132 @@
+package ytmusic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	apiURL   = "https://www.googleapis.com/youtube/v3"
+	pageSize = 50
+)
+
+type client struct {
+	baseURL string
+	http    *http.Client
+}
+
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("youtube error %d: %s", e.Code, e.Message)
+}
+
+type playlistsResponse struct {
+	Items         []playlist `json:"items"`
+	NextPageToken string     `json:"nextPageToken"`
+}
+
+type playlist struct {
+	ID      string `json:"id"`
+	Snippet struct {
+		Title        string `json:"title"`
+		Description  string `json:"description"`
+		ChannelTitle string `json:"channelTitle"`
+	} `json:"snippet"`
+	Status struct {
+		PrivacyStatus string `json:"privacyStatus"`
+	} `json:"status"`
+	ContentDetails struct {
+		ItemCount int `json:"itemCount"`
+	} `json:"contentDetails"`
+}
+
+type playlistItemsResponse struct {
+	Items         []playlistItem `json:"items"`
+	NextPageToken string         `json:"nextPageToken"`
+}
+
+type playlistItem struct {
+	ID      string `json:"id"`
+	Snippet struct {
+		Title string `json:"title"`
+		// VideoOwnerChannelTitle is empty for deleted and private videos.
+		VideoOwnerChannelTitle string    `json:"videoOwnerChannelTitle"`
+		PublishedAt            time.Time `json:"publishedAt"`
+		ResourceID             struct {
+			VideoID string `json:"videoId"`
+		} `json:"resourceId"`
+	} `json:"snippet"`
+}
+
+type searchResponse struct {
+	Items []struct {
+		ID struct {
+			VideoID string `json:"videoId"`
+		} `json:"id"`
+		Snippet struct {
+			Title        string `json:"title"`
+			ChannelTitle string `json:"channelTitle"`
+		} `json:"snippet"`
+	} `json:"items"`
+}
+
+// do calls the YouTube Data API and decodes the response into out, if set.
+func (c *client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	u := strings.TrimSuffix(c.baseURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e struct {
+			Error *apiError `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Error != nil {
+			return e.Error
+		}
+		return fmt.Errorf("failed to call %s %s: status code %d", method, path, resp.StatusCode)
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package ytmusic
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+)
+
+const topicSuffix = " - Topic"
+
+// videoNoise matches the decorations uploaders add to video titles.
+var videoNoise = regexp.MustCompile(`(?i)\s*[(\[](official\s+)?(music\s+)?(audio|video|lyric video|lyrics?|visualizer|hd|hq|4k)[)\]]`)
+
+// variants are versions of a recording that should not replace the original
+// unless asked for.
+var variants = []string{"live", "cover", "remix", "karaoke", "instrumental", "sped up", "slowed", "nightcore", "8d"}
+
+type video struct {
+	ID      string
+	Title   string
+	Channel string
+}
+
+// videoInfo is the artist and title guessed from a video.
+type videoInfo struct {
+	Artist string
+	Title  string
+	// Topic is set for the auto-generated channels of a release.
+	Topic bool
+	// Uploader is set when the artist is only the name of the channel.
+	Uploader bool
+}
+
+// parseVideo guesses the artist and title of a video. Auto-generated "Topic"
+// channels carry the exact metadata of the release; other uploads usually
+// follow the "Artist - Title (Official Audio)" convention.
+func parseVideo(v video) videoInfo {
+	if artist, found := strings.CutSuffix(v.Channel, topicSuffix); found {
+		return videoInfo{Artist: artist, Title: v.Title, Topic: true}
+	}
+
+	title := strings.TrimSpace(videoNoise.ReplaceAllString(v.Title, ""))
+	if a, t, found := strings.Cut(title, " - "); found {
+		return videoInfo{Artist: strings.TrimSpace(a), Title: strings.TrimSpace(t)}
+	}
+	return videoInfo{Artist: strings.TrimSuffix(v.Channel, "VEVO"), Title: title, Uploader: true}
+}
+
+func toDomainTrack(v video) domain.Track {
+	info := parseVideo(v)
+	return domain.Track{
+		ID:     v.ID,
+		Title:  info.Title,
+		Artist: info.Artist,
+		URL:    "https://music.youtube.com/watch?v=" + v.ID,
+	}
+}
+
+// rankVideos keeps the videos whose title and artist match the filters and
+// orders them so that "Topic" uploads and official audio come first, and
+// live versions, covers and remixes last.
+func rankVideos(videos []video, filters domain.TrackFilters) []domain.Track {
+	wantTitle := words(filters.Title)
+	wantArtist := words(filters.Artist)
+
+	var tracks []domain.Track
+	scores := make(map[string]int)
+	for _, v := range videos {
+		info := parseVideo(v)
+		raw := words(v.Title)
+		title := words(info.Title)
+
+		if !hasPrefixWords(title, wantTitle) {
+			continue
+		}
+		if wantArtist != "" && !hasPrefixWords(words(info.Artist), wantArtist) && !(info.Uploader && containsWords(raw, wantArtist)) {
+			continue
+		}
+
+		var score int
+		if info.Topic {
+			score += 4
+		}
+		if containsWords(raw, "official audio") {
+			score += 2
+		}
+		if containsWords(raw, "official video") || containsWords(raw, "official music video") || strings.HasSuffix(v.Channel, "VEVO") {
+			score++
+		}
+		if title == wantTitle {
+			score++
+		}
+		for _, variant := range variants {
+			if containsWords(raw, variant) && !containsWords(wantTitle, variant) {
+				score -= 5
+				break
+			}
+		}
+
+		scores[v.ID] = score
+		tracks = append(tracks, toDomainTrack(v))
+	}
+
+	slices.SortStableFunc(tracks, func(a, b domain.Track) int {
+		return cmp.Compare(scores[b.ID], scores[a.ID])
+	})
+	return tracks
+}
+
+// words lowercases s and reduces it to words separated by single spaces.
+func words(s string) string {
+	return domain.NormalizeText(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, s))
+}
+
+func hasPrefixWords(s, prefix string) bool {
+	return s == prefix || strings.HasPrefix(s, prefix+" ")
+}
+
+func containsWords(s, sub string) bool {
+	return strings.Contains(" "+s+" ", " "+sub+" ")
+}
@@ -0,0 +1,28 @@
+package ytmusic
+
+import (
+	"context"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/registry"
+)
+
+type Config struct {
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+}
+
+func init() {
+	registry.Register(registry.Connector[Config]{
+		Name:         "ytmusic",
+		Description:  "YouTube Music account",
+		Capabilities: Capabilities,
+		New: func(ctx context.Context, cfg Config, arg string) (domain.Connector, error) {
+			return NewConnector(cfg.ClientID, cfg.ClientSecret)
+		},
+		Login: func(ctx context.Context, cfg Config) error {
+			_, err := Login(ctx, cfg.ClientID, cfg.ClientSecret)
+			return err
+		},
+	})
+}
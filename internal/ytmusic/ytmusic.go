@@ -0,0 +1,362 @@
+package ytmusic
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"golang.org/x/oauth2"
+)
+
+const searchLimit = 10
+
+type Option func(*options)
+
+type options struct {
+	baseURL   string
+	deviceURL string
+	tokenURL  string
+}
+
+// WithBaseURL points the connector at another API server.
+func WithBaseURL(u string) Option {
+	return func(o *options) {
+		o.baseURL = u
+	}
+}
+
+// WithOAuthURLs overrides the device authorization and token endpoints.
+func WithOAuthURLs(deviceURL, tokenURL string) Option {
+	return func(o *options) {
+		o.deviceURL = deviceURL
+		o.tokenURL = tokenURL
+	}
+}
+
+// NewConnector manages YouTube Music playlists through the YouTube Data API.
+// The first run signs in with the OAuth device flow, which works on machines
+// without a browser; the token is stored and refreshed afterwards.
+func NewConnector(clientID, clientSecret string, opts ...Option) (*connector, error) {
+	ctx := context.Background()
+
+	o := newOptions(opts)
+	cfg := o.oauthConfig(clientID, clientSecret)
+
+	token, err := GetAuthToken()
+	if err != nil || IsInvalidAuthToken(token) {
+		token, err = Login(ctx, clientID, clientSecret, opts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &connector{
+		client: &client{
+			baseURL: o.baseURL,
+			http:    cfg.Client(ctx, token),
+		},
+	}, nil
+}
+
+// Login signs in to YouTube Music with the device flow and stores the token.
+func Login(ctx context.Context, clientID, clientSecret string, opts ...Option) (*oauth2.Token, error) {
+	o := newOptions(opts)
+
+	token, err := deviceLogin(ctx, o.oauthConfig(clientID, clientSecret), o.deviceURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign in to youtube music: %w", err)
+	}
+
+	if err := SaveAuthToken(token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		baseURL:   apiURL,
+		deviceURL: deviceCodeURL,
+		tokenURL:  tokenURL,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o options) oauthConfig(clientID, clientSecret string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Endpoint:     oauth2.Endpoint{TokenURL: o.tokenURL},
+		Scopes:       []string{authScope},
+	}
+}
+
+type connector struct {
+	client *client
+}
+
+var _ domain.Connector = (*connector)(nil)
+
+var Capabilities = domain.Capabilities{
+	CreatePlaylist: true,
+	DeleteTracks:   true,
+	Descriptions:   true,
+	Visibility:     true,
+}
+
+func (c *connector) Capabilities() domain.Capabilities {
+	return Capabilities
+}
+
+func (c *connector) CreatePlaylist(ctx context.Context, name string) (*domain.Playlist, error) {
+	var res playlist
+	err := c.client.do(ctx, http.MethodPost, "/playlists", url.Values{"part": {"snippet,status"}}, map[string]any{
+		"snippet": map[string]string{"title": name},
+		"status":  map[string]string{"privacyStatus": "private"},
+	}, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create playlist: %w", err)
+	}
+
+	pl := toDomainPlaylist(res)
+	pl.Tracks = []domain.Track{}
+	return pl, nil
+}
+
+func (c *connector) GetPlaylists(ctx context.Context) ([]*domain.Playlist, error) {
+	var pls []*domain.Playlist
+	var pageToken string
+	for {
+		var res playlistsResponse
+		err := c.client.do(ctx, http.MethodGet, "/playlists", url.Values{
+			"part":       {"snippet,status,contentDetails"},
+			"mine":       {"true"},
+			"maxResults": {strconv.Itoa(pageSize)},
+			"pageToken":  {pageToken},
+		}, nil, &res)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get playlists: %w", err)
+		}
+
+		for _, p := range res.Items {
+			pl := toDomainPlaylist(p)
+			pl.Tracks = make([]domain.Track, pl.TrackCount)
+			pls = append(pls, pl)
+		}
+
+		if res.NextPageToken == "" {
+			return pls, nil
+		}
+		pageToken = res.NextPageToken
+	}
+}
+
+func (c *connector) GetPlaylistByName(ctx context.Context, name string) (*domain.Playlist, error) {
+	pls, err := c.GetPlaylists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pl := range pls {
+		if pl.Name == name {
+			return c.withTracks(ctx, pl)
+		}
+	}
+	return nil, nil
+}
+
+func (c *connector) GetPlaylist(ctx context.Context, id string) (*domain.Playlist, error) {
+	var res playlistsResponse
+	err := c.client.do(ctx, http.MethodGet, "/playlists", url.Values{
+		"part": {"snippet,status,contentDetails"},
+		"id":   {id},
+	}, nil, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlist: %w", err)
+	}
+
+	if len(res.Items) == 0 {
+		return nil, nil
+	}
+	return c.withTracks(ctx, toDomainPlaylist(res.Items[0]))
+}
+
+func (c *connector) withTracks(ctx context.Context, pl *domain.Playlist) (*domain.Playlist, error) {
+	items, err := c.getPlaylistItems(ctx, pl.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracks for playlist %s: %w", pl.Name, err)
+	}
+
+	pl.Tracks = []domain.Track{}
+	pl.Items = nil
+	for _, it := range items {
+		// Deleted and private videos have no owner and cannot be played.
+		if it.Snippet.VideoOwnerChannelTitle == "" {
+			continue
+		}
+
+		t := toDomainTrack(video{
+			ID:      it.Snippet.ResourceID.VideoID,
+			Title:   it.Snippet.Title,
+			Channel: it.Snippet.VideoOwnerChannelTitle,
+		})
+		pl.Tracks = append(pl.Tracks, t)
+		pl.Items = append(pl.Items, domain.PlaylistItem{AddedAt: it.Snippet.PublishedAt})
+	}
+	return pl, nil
+}
+
+// AddTracksToPlaylist inserts the videos one at a time, as the API has no
+// batch insert.
+func (c *connector) AddTracksToPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	for _, t := range tracks {
+		err := c.client.do(ctx, http.MethodPost, "/playlistItems", url.Values{"part": {"snippet"}}, map[string]any{
+			"snippet": map[string]any{
+				"playlistId": id,
+				"resourceId": map[string]string{
+					"kind":    "youtube#video",
+					"videoId": t.ID,
+				},
+			},
+		}, nil)
+		if err != nil {
+			return fmt.Errorf("failed to add tracks to playlist: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *connector) DeleteTracksFromPlaylist(ctx context.Context, id string, tracks []domain.Track) error {
+	items, err := c.getPlaylistItems(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to remove tracks from playlist: %w", err)
+	}
+
+	remove := make(map[string]struct{})
+	for _, t := range tracks {
+		remove[t.ID] = struct{}{}
+	}
+
+	for _, it := range items {
+		if _, found := remove[it.Snippet.ResourceID.VideoID]; !found {
+			continue
+		}
+
+		if err := c.client.do(ctx, http.MethodDelete, "/playlistItems", url.Values{"id": {it.ID}}, nil, nil); err != nil {
+			return fmt.Errorf("failed to remove tracks from playlist: %w", err)
+		}
+	}
+	return nil
+}
+
+// UpdatePlaylist sets the description and privacy status. The API replaces
+// the whole snippet, so the current title is sent along, and unlisted
+// playlists stay unlisted unless made public.
+func (c *connector) UpdatePlaylist(ctx context.Context, id string, meta domain.PlaylistMetadata) error {
+	var res playlistsResponse
+	err := c.client.do(ctx, http.MethodGet, "/playlists", url.Values{
+		"part": {"snippet,status"},
+		"id":   {id},
+	}, nil, &res)
+	if err != nil {
+		return fmt.Errorf("failed to update playlist: %w", err)
+	}
+	if len(res.Items) == 0 {
+		return fmt.Errorf("failed to update playlist: playlist %s not found", id)
+	}
+	pl := res.Items[0]
+
+	privacy := pl.Status.PrivacyStatus
+	switch {
+	case meta.Public:
+		privacy = "public"
+	case privacy == "public":
+		privacy = "private"
+	}
+
+	err = c.client.do(ctx, http.MethodPut, "/playlists", url.Values{"part": {"snippet,status"}}, map[string]any{
+		"id": id,
+		"snippet": map[string]string{
+			"title":       pl.Snippet.Title,
+			"description": meta.Description,
+		},
+		"status": map[string]string{"privacyStatus": privacy},
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update playlist: %w", err)
+	}
+	return nil
+}
+
+// SearchTrack searches music videos by artist and title. YouTube exposes no
+// ISRCs, so the results are matched on metadata only.
+func (c *connector) SearchTrack(ctx context.Context, filters domain.TrackFilters) ([]domain.Track, error) {
+	if filters.Title == "" {
+		return nil, nil
+	}
+
+	var res searchResponse
+	err := c.client.do(ctx, http.MethodGet, "/search", url.Values{
+		"part":            {"snippet"},
+		"type":            {"video"},
+		"videoCategoryId": {"10"},
+		"maxResults":      {strconv.Itoa(searchLimit)},
+		"q":               {strings.TrimSpace(filters.Artist + " " + filters.Title)},
+	}, nil, &res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search track: %w", err)
+	}
+
+	var videos []video
+	for _, it := range res.Items {
+		videos = append(videos, video{
+			ID:      it.ID.VideoID,
+			Title:   it.Snippet.Title,
+			Channel: it.Snippet.ChannelTitle,
+		})
+	}
+	return rankVideos(videos, filters), nil
+}
+
+func (c *connector) getPlaylistItems(ctx context.Context, id string) ([]playlistItem, error) {
+	var items []playlistItem
+	var pageToken string
+	for {
+		var res playlistItemsResponse
+		err := c.client.do(ctx, http.MethodGet, "/playlistItems", url.Values{
+			"part":       {"snippet"},
+			"playlistId": {id},
+			"maxResults": {strconv.Itoa(pageSize)},
+			"pageToken":  {pageToken},
+		}, nil, &res)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, res.Items...)
+		if res.NextPageToken == "" {
+			return items, nil
+		}
+		pageToken = res.NextPageToken
+	}
+}
+
+func toDomainPlaylist(p playlist) *domain.Playlist {
+	return &domain.Playlist{
+		ID:          p.ID,
+		Name:        p.Snippet.Title,
+		Description: p.Snippet.Description,
+		Owner:       p.Snippet.ChannelTitle,
+		Owned:       true,
+		Public:      p.Status.PrivacyStatus == "public",
+		URL:         "https://music.youtube.com/playlist?list=" + p.ID,
+		TrackCount:  p.ContentDetails.ItemCount,
+	}
+}
@@ -0,0 +1,175 @@
+package ytmusic_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pedrobarco/nomuz/internal/domain"
+	"github.com/pedrobarco/nomuz/internal/domain/connectortest"
+	"github.com/pedrobarco/nomuz/internal/fakeservice"
+	"github.com/pedrobarco/nomuz/internal/ytmusic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// videos are tracks titled and credited as YouTube shows them.
+var videos = []fakeservice.Track{
+	{ID: "live", Title: "Band - Song (Live at Wembley)", Artist: "Band"},
+	{ID: "cover", Title: "Song (Band cover)", Artist: "Someone"},
+	{ID: "video", Title: "Band - Song (Official Video)", Artist: "BandVEVO"},
+	{ID: "topic", Title: "Song", Artist: "Band - Topic"},
+	{ID: "other", Title: "Other Band - Other Song (Official Audio)", Artist: "Label"},
+}
+
+func newConnector(t *testing.T, srv *fakeservice.YTMusic) domain.Connector {
+	c, err := ytmusic.NewConnector(srv.ClientID, srv.ClientSecret,
+		ytmusic.WithBaseURL(srv.Server.URL),
+		ytmusic.WithOAuthURLs(srv.DeviceURL(), srv.TokenURL()),
+	)
+	require.NoError(t, err)
+	return c
+}
+
+func TestConnector(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("device login", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+		srv := fakeservice.NewYTMusic(t, fakeservice.Library{})
+
+		newConnector(t, srv)
+		newConnector(t, srv)
+		assert.Equal(t, 1, srv.Logins())
+
+		token, err := ytmusic.GetAuthToken()
+		require.NoError(t, err)
+		assert.Equal(t, srv.AccessToken, token.AccessToken)
+		assert.Equal(t, "yt-refresh", token.RefreshToken)
+	})
+
+	t.Run("playlists", func(t *testing.T) {
+		assert := assert.New(t)
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+		srv := fakeservice.NewYTMusic(t, fakeservice.Library{
+			Tracks: videos,
+			Playlists: []fakeservice.Playlist{
+				{ID: "PLa", Name: "Road Trip", Tracks: []string{"topic", "gone", "other"}},
+				{ID: "PLb", Name: "Party"},
+			},
+		})
+		// Small pages make the connector follow the page tokens.
+		srv.PageSize = 2
+		srv.Unlisted["PLb"] = true
+		c := newConnector(t, srv)
+
+		pl, err := c.CreatePlaylist(ctx, "Mix")
+		assert.NoError(err)
+		mix, _ := srv.Playlist("Mix")
+		assert.Equal(mix.ID, pl.ID)
+		assert.Equal("private", srv.Privacy(pl.ID))
+
+		assert.NoError(c.AddTracksToPlaylist(ctx, pl.ID, []domain.Track{{ID: "topic"}, {ID: "other"}, {ID: "video"}}))
+		assert.Error(c.AddTracksToPlaylist(ctx, pl.ID, []domain.Track{{ID: "gone"}}))
+
+		pls, err := c.GetPlaylists(ctx)
+		assert.NoError(err)
+		assert.Len(pls, 3)
+		assert.Equal(3, pls[0].Size())
+		assert.Equal(3, pls[2].Size())
+
+		// Deleted videos are skipped.
+		got, err := c.GetPlaylistByName(ctx, "Road Trip")
+		assert.NoError(err)
+		assert.Equal([]domain.Track{
+			{
+				ID:     "topic",
+				Title:  "Song",
+				Artist: "Band",
+				URL:    "https://music.youtube.com/watch?v=topic",
+			},
+			{
+				ID:     "other",
+				Title:  "Other Song",
+				Artist: "Other Band",
+				URL:    "https://music.youtube.com/watch?v=other",
+			},
+		}, got.Tracks)
+		assert.Equal([]domain.PlaylistItem{
+			{AddedAt: time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)},
+			{AddedAt: time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)},
+		}, got.Items)
+
+		got, err = c.GetPlaylistByName(ctx, "Mix")
+		assert.NoError(err)
+		assert.Len(got.Tracks, 3)
+		assert.Equal(domain.Track{
+			ID:     "video",
+			Title:  "Song",
+			Artist: "Band",
+			URL:    "https://music.youtube.com/watch?v=video",
+		}, got.Tracks[2])
+		assert.WithinDuration(time.Now(), got.Items[2].AddedAt, time.Minute)
+
+		assert.NoError(c.DeleteTracksFromPlaylist(ctx, pl.ID, []domain.Track{{ID: "topic"}, {ID: "video"}}))
+		mix, _ = srv.Playlist("Mix")
+		assert.Equal([]string{"other"}, mix.Tracks)
+
+		assert.NoError(c.UpdatePlaylist(ctx, pl.ID, domain.PlaylistMetadata{Description: "Songs for the road", Public: true}))
+		mix, _ = srv.Playlist("Mix")
+		assert.Equal("Songs for the road", mix.Description)
+		assert.Equal("public", srv.Privacy(pl.ID))
+
+		// Unlisted playlists are not made private.
+		assert.NoError(c.UpdatePlaylist(ctx, "PLb", domain.PlaylistMetadata{}))
+		assert.Equal("unlisted", srv.Privacy("PLb"))
+
+		got, err = c.GetPlaylistByName(ctx, "Missing")
+		assert.NoError(err)
+		assert.Nil(got)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		assert := assert.New(t)
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+		srv := fakeservice.NewYTMusic(t, fakeservice.Library{Tracks: videos})
+		c := newConnector(t, srv)
+
+		res, err := c.SearchTrack(ctx, domain.TrackFilters{Title: "Song", Artist: "Band"})
+		assert.NoError(err)
+
+		var ids []string
+		for _, tr := range res {
+			ids = append(ids, tr.ID)
+		}
+		assert.Equal([]string{"topic", "video", "live", "cover"}, ids)
+
+		res, err = c.SearchTrack(ctx, domain.TrackFilters{Title: "Song (Live at Wembley)", Artist: "Band"})
+		assert.NoError(err)
+		assert.Equal("live", res[0].ID)
+
+		res, err = c.SearchTrack(ctx, domain.TrackFilters{ID: "spotify-id"})
+		assert.NoError(err)
+		assert.Empty(res)
+	})
+}
+
+func TestConformance(t *testing.T) {
+	// The token stored by the first login is reused by the other cases.
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	connectortest.Run(t, connectortest.Harness{
+		New: func(t *testing.T) domain.Connector {
+			return newConnector(t, fakeservice.NewYTMusic(t, fakeservice.Library{Tracks: []fakeservice.Track{
+				{ID: "song", Title: "Song", Artist: "Band - Topic"},
+				{ID: "other", Title: "Other Song", Artist: "Other Band - Topic"},
+			}}))
+		},
+		Tracks: []domain.Track{
+			{ID: "song", Title: "Song", Artist: "Band"},
+			{ID: "other", Title: "Other Song", Artist: "Other Band"},
+		},
+	})
+}